@@ -0,0 +1,189 @@
+// Package apperr defines the domain errors returned by the repository and
+// service layers. Each error carries a Kind, which the API maps to a
+// transport status, and a stable machine-readable Code clients can switch on.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type Kind string
+
+const (
+	KindInvalidArgument  Kind = "invalid_argument"
+	KindUnauthenticated  Kind = "unauthenticated"
+	KindPermissionDenied Kind = "permission_denied"
+	KindNotFound         Kind = "not_found"
+	KindConflict         Kind = "conflict"
+	KindRejected         Kind = "rejected"
+	KindRateLimited      Kind = "rate_limited"
+	KindUnavailable      Kind = "unavailable"
+	KindInternal         Kind = "internal"
+)
+
+// Stable error codes. Codes are part of the API contract: add new ones
+// freely, but never change the meaning of an existing one.
+const (
+	CodeInvalidRequest      = "invalid_request"
+	CodeValidationFailed    = "validation_failed"
+	CodeInvalidOrderID      = "invalid_order_id"
+	CodeInvalidOrderSide    = "invalid_order_side"
+	CodeSymbolRequired      = "symbol_required"
+	CodeFilterRequired      = "filter_required"
+	CodeConstraintViolation = "constraint_violation"
+	CodeOrderNotFound       = "order_not_found"
+	CodeOrderNotCancelable  = "order_not_cancelable"
+	CodeAccountNotFound     = "account_not_found"
+	CodeAPIKeyNotFound      = "api_key_not_found"
+	CodeAPIKeyRevoked       = "api_key_revoked"
+	CodeTransactionConflict = "transaction_conflict"
+	CodeQueryTimeout        = "query_timeout"
+	CodeRateLimited         = "rate_limited"
+	CodeOrderToTradeRatio   = "order_to_trade_ratio_exceeded"
+	CodeWebhookNotFound     = "webhook_not_found"
+	CodeDeliveryNotFound    = "delivery_not_found"
+	CodeEventNotFound       = "event_not_found"
+	CodeFIXSessionNotFound  = "fix_session_not_found"
+	CodeStreamEnded         = "stream_ended"
+	CodeBatchTooLarge       = "batch_too_large"
+	CodeBatchAborted        = "batch_aborted"
+	CodeShuttingDown        = "shutting_down"
+	CodePhaseNotFound       = "trading_phase_not_found"
+	CodeBookCrossed         = "book_crossed"
+	CodeInternal            = "internal_error"
+)
+
+// Authentication failures. They are deliberately coarse so a client cannot
+// probe which keys exist.
+const (
+	CodeUnauthenticated   = "unauthenticated"    // missing or unknown credentials
+	CodeInvalidSignature  = "invalid_signature"  // signature does not match the request
+	CodeStaleTimestamp    = "stale_timestamp"    // timestamp outside the replay window
+	CodeReplayedRequest   = "replayed_request"   // nonce already used
+	CodeInsufficientScope = "insufficient_scope" // key lacks the scope the endpoint needs
+	CodeInsufficientRole  = "insufficient_role"  // account's role does not allow the endpoint
+)
+
+// Rejection reasons for orders that break an instrument's trading rules.
+const (
+	ReasonInvalidTickSize    = "invalid_tick_size"
+	ReasonInvalidLotSize     = "invalid_lot_size"
+	ReasonQuantityOutOfRange = "quantity_out_of_range"
+	ReasonInvalidPrecision   = "invalid_quantity_precision"
+)
+
+// Rejection reasons for orders the trading phase of their symbol does not
+// accept.
+const (
+	ReasonTradingHalted = "trading_halted"
+	ReasonMarketClosed  = "market_closed"
+	ReasonNotContinuous = "no_continuous_trading" // market orders outside continuous trading
+)
+
+// Rejection reason for orders that would trade beyond the price band of
+// their symbol.
+const ReasonPriceOutOfBand = "price_out_of_band"
+
+// Rejection reason for market buys sized in the quote currency that cannot
+// afford a single lot.
+const ReasonQuoteTooSmall = "quote_quantity_too_small"
+
+type Error struct {
+	Kind       Kind
+	Code       string
+	Message    string
+	RetryAfter time.Duration // when a retry may succeed; set on rate limited errors
+	Err        error         // underlying cause, never shown to clients
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap returns a copy of e with cause attached.
+func (e *Error) Wrap(cause error) *Error {
+	c := *e
+	c.Err = cause
+	return &c
+}
+
+func newError(kind Kind, code, format string, args ...any) *Error {
+	return &Error{Kind: kind, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func InvalidArgument(code, format string, args ...any) *Error {
+	return newError(KindInvalidArgument, code, format, args...)
+}
+
+func Unauthenticated(code, format string, args ...any) *Error {
+	return newError(KindUnauthenticated, code, format, args...)
+}
+
+func PermissionDenied(code, format string, args ...any) *Error {
+	return newError(KindPermissionDenied, code, format, args...)
+}
+
+func NotFound(code, format string, args ...any) *Error {
+	return newError(KindNotFound, code, format, args...)
+}
+
+func Conflict(code, format string, args ...any) *Error {
+	return newError(KindConflict, code, format, args...)
+}
+
+// Rejected reports a well-formed request the engine refused to accept;
+// reason is the machine-readable reason code.
+func Rejected(reason, format string, args ...any) *Error {
+	return newError(KindRejected, reason, format, args...)
+}
+
+// RateLimited reports a request refused for exceeding a limit; it may be
+// retried after retryAfter.
+func RateLimited(code string, retryAfter time.Duration, format string, args ...any) *Error {
+	e := newError(KindRateLimited, code, format, args...)
+	e.RetryAfter = retryAfter
+	return e
+}
+
+func Unavailable(code, format string, args ...any) *Error {
+	return newError(KindUnavailable, code, format, args...)
+}
+
+// Internal wraps an unexpected failure. Its message is never shown to clients.
+func Internal(err error) *Error {
+	return &Error{Kind: KindInternal, Code: CodeInternal, Message: "internal server error", Err: err}
+}
+
+// As returns the first *Error in err's chain.
+func As(err error) (*Error, bool) {
+	var e *Error
+	ok := errors.As(err, &e)
+	return e, ok
+}
+
+// KindOf returns the Kind of err, or KindInternal for errors that are not
+// domain errors.
+func KindOf(err error) Kind {
+	if e, ok := As(err); ok {
+		return e.Kind
+	}
+	return KindInternal
+}
+
+// CodeOf returns the Code of err, or CodeInternal for errors that are not
+// domain errors.
+func CodeOf(err error) string {
+	if e, ok := As(err); ok {
+		return e.Code
+	}
+	return CodeInternal
+}
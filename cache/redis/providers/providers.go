@@ -0,0 +1,18 @@
+package providers
+
+import (
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type RedisHelper struct {
+	RedisClient *redis.Client
+}
+
+func NewRedisProvider(redisClient *redis.Client) (*RedisHelper, error) {
+	if redisClient == nil {
+		return nil, fmt.Errorf("invalid redis client: nil pointer provided")
+	}
+	return &RedisHelper{RedisClient: redisClient}, nil
+}
@@ -0,0 +1,56 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+	"github.com/go-redis/redis/v8"
+)
+
+type Redis struct {
+	RedisClient *redis.Client
+}
+
+// ConnectRedis establishes a connection to Redis
+func ConnectRedis(cfg config.RedisConfig) *Redis {
+	client := redis.NewClient(&redis.Options{
+		Addr:         cfg.Addr,
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		DialTimeout:  cfg.DialTimeout,
+		ReadTimeout:  cfg.OperationTimeout,
+		WriteTimeout: cfg.OperationTimeout,
+	})
+
+	var err error
+	for i := 0; i < cfg.ConnectAttempts; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.DialTimeout+cfg.OperationTimeout)
+		err = client.Ping(ctx).Err()
+		cancel()
+		if err == nil {
+			fmt.Println("Connected to Redis successfully!")
+			return &Redis{RedisClient: client}
+		}
+
+		log.Printf("Attempt %d: failed to ping Redis: %v", i+1, err)
+		time.Sleep(cfg.ConnectRetryDelay)
+	}
+
+	client.Close()
+	log.Fatalf("Exceeded max retries. Could not connect to Redis: %v", err)
+	return nil
+}
+
+// Stop gracefully closes the Redis connection
+func (r *Redis) Stop() {
+	if r.RedisClient != nil {
+		if err := r.RedisClient.Close(); err != nil {
+			log.Printf("Error closing Redis connection: %v", err)
+		} else {
+			fmt.Println("Redis connection closed successfully!")
+		}
+	}
+}
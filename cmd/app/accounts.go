@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+)
+
+const accountsUsage = `usage: app [flags] accounts <command>
+
+commands:
+  create <name> [role]              create an account; role is one of viewer,
+                                    trader (default), market_maker, operator, admin
+  set-role <account-id> <role>      change an account's role
+  issue-key <account-id> <scopes>   issue an API key; scopes are comma separated
+                                    (read, trade, cancel, admin)
+  revoke-key <key-id>               revoke an API key
+
+Use it to bootstrap the first admin account and key; afterwards accounts
+and keys can be managed through the /admin endpoints. Every command is
+recorded in the admin audit log.`
+
+// runAccounts implements the `accounts` subcommand.
+func runAccounts(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(accountsUsage)
+	}
+
+	key, err := cfg.Auth.Key()
+	if err != nil {
+		return err
+	}
+	secrets, err := service.NewSecretBox(key)
+	if err != nil {
+		return err
+	}
+
+	store := openStorage(cfg)
+	defer store.Close()
+	auth := service.NewAuthService(repository.NewTxRunner(store.TxManager), store.Accounts, secrets, cfg.Auth.ReplayWindow)
+	audit := service.NewAuditService(store.Audit)
+	ctx := service.WithActor(context.Background(), "cli")
+	action := "cli accounts " + args[0]
+	params := map[string][]string{"args": args[1:]}
+
+	switch {
+	case args[0] == "create" && (len(args) == 2 || len(args) == 3):
+		role := ""
+		if len(args) == 3 {
+			role = args[2]
+		}
+		var account *models.Account
+		err := audit.Run(ctx, action, params, func() (err error) {
+			account, err = auth.CreateAccount(ctx, args[1], role)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created account %d (%s, %s)\n", account.ID, account.Name, account.Role)
+
+	case args[0] == "set-role" && len(args) == 3:
+		accountID, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid account ID %q", args[1])
+		}
+		err = audit.Run(ctx, action, params, func() error {
+			_, err := auth.SetRole(ctx, accountID, args[2])
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("account %d is now %s\n", accountID, args[2])
+
+	case args[0] == "issue-key" && len(args) == 3:
+		accountID, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid account ID %q", args[1])
+		}
+		var key *models.IssuedAPIKeyResponse
+		err = audit.Run(ctx, action, params, func() (err error) {
+			key, err = auth.IssueKey(ctx, accountID, strings.Split(args[2], ","))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("key id: %s\nsecret: %s\nscopes: %s\n\nThe secret is shown only once.\n",
+			key.KeyID, key.Secret, strings.Join(key.Scopes, ","))
+
+	case args[0] == "revoke-key" && len(args) == 2:
+		err := audit.Run(ctx, action, params, func() error {
+			_, err := auth.RevokeKey(ctx, args[1])
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("revoked %s\n", args[1])
+
+	default:
+		return errors.New(accountsUsage)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+)
+
+const configUsage = `usage: app [flags] config <command>
+
+commands:
+  print       show the effective configuration with secrets redacted`
+
+// runConfig implements the `config` subcommand.
+func runConfig(cfg *config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		return errors.New(configUsage)
+	}
+	return cfg.Print(os.Stdout)
+}
@@ -4,73 +4,182 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
-	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
-
-	// "github.com/Puneet-Vishnoi/order-matching-engine/cache/redis"
-	// redisProvider "github.com/Puneet-Vishnoi/order-matching-engine/cache/redis/providers"
-	"github.com/Puneet-Vishnoi/order-matching-engine/db/postgres"
-	providers "github.com/Puneet-Vishnoi/order-matching-engine/db/postgres/providers"
-	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/Puneet-Vishnoi/order-matching-engine/cache/redis"
+	redisProvider "github.com/Puneet-Vishnoi/order-matching-engine/cache/redis/providers"
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+	"github.com/Puneet-Vishnoi/order-matching-engine/fix"
+	"github.com/Puneet-Vishnoi/order-matching-engine/grpcapi"
+	"github.com/Puneet-Vishnoi/order-matching-engine/health"
+	"github.com/Puneet-Vishnoi/order-matching-engine/marketdata"
+	"github.com/Puneet-Vishnoi/order-matching-engine/ratelimit"
 	"github.com/Puneet-Vishnoi/order-matching-engine/routes"
 	orderService "github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tracing"
 )
 
 func main() {
-	err := godotenv.Load(".env")
+	// 0. Configuration: defaults < config file < env (.env optional) < flags
+	cfg, args, err := config.Load(os.Args[1:])
 	if err != nil {
-		log.Fatal("Failed to load env file: ", err)
+		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// // 1. Connect Redis
-	// redisClient := redis.ConnectRedis()
-	// redisHelper := redisProvider.NewRedisProvider(redisClient.RedisClient)
-	// defer redisClient.Stop()
-
-	// 2. Connect PostgreSQL
-	postgresClient := postgres.ConnectDB()
-	defer postgresClient.Stop()
+	if len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			err = runMigrate(cfg, args[1:])
+		case "config":
+			err = runConfig(cfg, args[1:])
+		case "accounts":
+			err = runAccounts(cfg, args[1:])
+		default:
+			err = fmt.Errorf("unknown command %q", args[0])
+		}
+		if err != nil {
+			log.Fatalf("%s: %v", args[0], err)
+		}
+		return
+	}
 
-	// 2.1 Init Schema (optional — only for development)
-	if err := postgresClient.InitSchema(); err != nil {
-		log.Fatalf("Failed to initialize database schema: %v", err)
+	// 1. Connect Redis, when a feature needs it
+	var redisClient *redis.Redis
+	if cfg.UsesRedis() {
+		redisClient = redis.ConnectRedis(cfg.Redis)
+		defer redisClient.Stop()
 	}
 
-	// 3. DB Helper
-	dbHelper, err := providers.NewDbProvider(postgresClient.PostgresClient)
+	// 1.1 Tracing (tracing.exporter=otlp|stdout|file, off by default)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
 	if err != nil {
-		log.Fatalf("Failed to initialize DB helper: %v", err)
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+
+	// 2. Storage backend (PostgreSQL unless storage.backend=memory)
+	store := openStorage(cfg)
+	defer store.Close()
+
+	// 2.1 Readiness checks
+	checker := health.NewChecker()
+	for name, check := range store.Checks {
+		checker.AddCheck(name, check)
+	}
+
+	// 3. Service
+	orderSrv := orderService.NewOrderService(store.TxManager, store.Orders, store.Trades, store.Events)
+	orderSrv.Engine = cfg.Engine
+	orderSrv.Phases = store.Phases
+	if store.DB != nil {
+		orderSrv.Metrics.RegisterDBStats(store.DB, cfg.Postgres.Database)
 	}
 
-	// 4. Repo & Service
-	orderRepo := repository.NewOrderRepository(dbHelper)
-	tradeRepo := repository.NewTradeRepository(dbHelper)
-	orderSrv := orderService.NewOrderService(orderRepo, tradeRepo)
+	// 3.1 Rate limits (features.rate_limit; rate_limit.backend=redis shares them across instances)
+	if cfg.Features.RateLimit {
+		var limitStore ratelimit.Store = ratelimit.NewMemoryStore()
+		if cfg.RateLimit.Backend == config.RateLimitRedis {
+			limitStore = ratelimit.NewRedisStore(redisClient.RedisClient, "ratelimit:")
+		}
+		orderSrv.RateLimiter = orderService.NewRateLimiter(limitStore, cfg.RateLimit, orderSrv.Metrics)
+	}
 
-	// 5. Gin Router & Handlers
-	router := gin.Default()
-	routes.RegisterRoutes(router, orderSrv)
+	// 3.2 Market data (features.market_data): publish book changes and trades
+	// through Redis, stream them, and optionally serve the book from there
+	var feed *marketdata.Feed
+	feedCtx, stopFeed := context.WithCancel(context.Background())
+	defer stopFeed()
+	if cfg.Features.MarketData {
+		redisHelper, err := redisProvider.NewRedisProvider(redisClient.RedisClient)
+		if err != nil {
+			log.Fatalf("Failed to get redisHelper: %v", err)
+		}
+		feed = marketdata.NewFeed(redisHelper, orderSrv.LoadOrderBook)
+		feed.SubscriberBuffer = cfg.MarketData.SubscriberBuffer
+		if err := feed.Start(feedCtx); err != nil {
+			log.Fatalf("Failed to start market data: %v", err)
+		}
+		orderSrv.MarketData = feed
+		orderSrv.ServeBookFromCache = cfg.MarketData.ServeFromCache
+		if cfg.MarketData.ServeFromCache {
+			checker.AddCheck("redis", func(ctx context.Context) error {
+				return redisClient.RedisClient.Ping(ctx).Err()
+			})
+		}
+	}
+
+	// 3.3 Webhooks (features.webhooks): fills are recorded in the outbox with
+	// the trades and delivered by a dispatcher loop
+	var webhookSrv *orderService.WebhookService
+	dispatcherDone := make(chan struct{})
+	dispatchCtx, stopDispatcher := context.WithCancel(context.Background())
+	defer stopDispatcher()
+	if cfg.Features.Webhooks {
+		orderSrv.Outbox = store.Outbox
+		webhookSrv = orderService.NewWebhookService(orderSrv.TxRunner, store.Webhooks, store.Outbox, cfg.Webhooks, orderSrv.Metrics)
+		go func() {
+			defer close(dispatcherDone)
+			webhookSrv.Run(dispatchCtx)
+		}()
+		if !cfg.Features.Auth {
+			log.Println("Webhooks are enabled without auth; they can only be managed with /admin, which needs features.auth")
+		}
+	}
+
+	// 3.4 API key authentication (features.auth)
+	var authSrv *orderService.AuthService
+	var auditSrv *orderService.AuditService
+	if cfg.Features.Auth {
+		key, err := cfg.Auth.Key()
+		if err != nil {
+			log.Fatalf("Failed to read the secret key: %v", err)
+		}
+		secrets, err := orderService.NewSecretBox(key)
+		if err != nil {
+			log.Fatalf("Failed to read the secret key: %v", err)
+		}
+		authSrv = orderService.NewAuthService(orderSrv.TxRunner, store.Accounts, secrets, cfg.Auth.ReplayWindow)
+		auditSrv = orderService.NewAuditService(store.Audit)
+	} else {
+		log.Println("Authentication is disabled; /api is open to anyone who can reach it")
+	}
 
-	// 6. Run REST API
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// 3.5 FIX 4.4 order entry (features.fix), next to the REST API; logons are
+	// signed with API keys when auth is on
+	var fixAcceptor *fix.Acceptor
+	if cfg.Features.FIX {
+		fixAcceptor = fix.NewAcceptor(cfg.FIX, orderSrv, authSrv, store.FIX)
+		orderSrv.Executions = fixAcceptor
+		if err := fixAcceptor.Start(); err != nil {
+			log.Fatalf("Failed to start FIX acceptor: %v", err)
+		}
+		fmt.Printf("FIX acceptor running on %d\n", cfg.FIX.Port)
 	}
 
-	addr := ":" + port
+	// 4. Gin Router & Handlers
+	router, err := routes.NewRouter(cfg.Server.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Failed to configure trusted proxies: %v", err)
+	}
+	routes.RegisterRoutes(router, orderSrv, authSrv, auditSrv, webhookSrv)
+	routes.RegisterHealthRoutes(router, checker)
 
+	// 5. Run REST API
+	port := strconv.Itoa(cfg.Server.Port)
 	srv := &http.Server{
-		Addr:    addr,
-		Handler: router,
+		Addr:         ":" + port,
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
-	//7. run server in GO rutine so main thread become non blocking
+	//6. run server in GO rutine so main thread become non blocking
 	go func() {
 		fmt.Printf("Order REST API running on %s\n", port)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -78,19 +187,88 @@ func main() {
 		}
 	}()
 
-	// 8. wait for OS Signal to shutdown gracefully
+	// 6.1 Run the gRPC API (features.grpc) next to the REST API, on the same
+	// service, auth and rate limits
+	var grpcSrv *grpcapi.Server
+	if cfg.Features.GRPC {
+		lis, err := net.Listen("tcp", ":"+strconv.Itoa(cfg.GRPC.Port))
+		if err != nil {
+			log.Fatalf("Failed to listen for gRPC: %v", err)
+		}
+		grpcSrv = grpcapi.NewServer(orderSrv, authSrv)
+		go func() {
+			fmt.Printf("Order gRPC API running on %d\n", cfg.GRPC.Port)
+			if err := grpcSrv.Serve(lis); err != nil {
+				log.Fatalf("Failed to start gRPC server: %v", err)
+			}
+		}()
+	}
+
+	// 6.2 Nothing to warm up yet: the book is read from storage on every request
+	checker.MarkWarm()
+
+	// 7. wait for OS Signal to shutdown gracefully
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	sig := <-quit
 	log.Printf("Received signal %s. Hence Gracefully Shutdown.", sig)
 
-	//9.1 gracefully shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	//7.1 fail readiness first so load balancers stop sending new orders,
+	// then give them time to notice before in-flight requests are drained
+	checker.StartDraining()
+	time.Sleep(cfg.Server.DrainDelay)
+
+	//8. gracefully shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	//8.1 disarm dead man's switches; their clients re-arm them on another
+	// instance
+	orderSrv.DeadMansSwitch.Stop()
+
+	//8.2 end market data streams and let gRPC calls finish
+	if grpcSrv != nil {
+		if err := grpcSrv.Shutdown(ctx); err != nil {
+			log.Printf("gRPC server forced to shutdown: %v", err)
+		}
+	}
+
+	//8.3 log out FIX sessions; reports not yet sent are kept for their resend
+	// requests
+	if fixAcceptor != nil {
+		if err := fixAcceptor.Close(ctx); err != nil {
+			log.Printf("FIX acceptor forced to shutdown: %v", err)
+		}
+	}
+
+	//8.4 close market data streams and publish what is still pending; hijacked
+	// WebSocket connections are not tracked by srv.Shutdown
+	if feed != nil {
+		stopFeed()
+		select {
+		case <-feed.Done():
+		case <-ctx.Done():
+		}
+	}
+
+	//8.5 stop webhook deliveries; interrupted attempts are retried by the
+	// next instance once their lease expires
+	if webhookSrv != nil {
+		stopDispatcher()
+		select {
+		case <-dispatcherDone:
+		case <-ctx.Done():
+		}
+	}
+
+	//8.6 flush buffered spans
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("Failed to flush traces: %v", err)
+	}
+
 	log.Println("gracefully shutdown")
 }
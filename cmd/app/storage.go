@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+	"github.com/Puneet-Vishnoi/order-matching-engine/db/postgres"
+	"github.com/Puneet-Vishnoi/order-matching-engine/db/postgres/migrate"
+	providers "github.com/Puneet-Vishnoi/order-matching-engine/db/postgres/providers"
+	"github.com/Puneet-Vishnoi/order-matching-engine/health"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository/memory"
+)
+
+// storage bundles the transaction manager and repositories of one backend.
+type storage struct {
+	TxManager repository.TxManager
+	Orders    repository.OrderRepository
+	Trades    repository.TradeRepository
+	Events    repository.OrderEventRepository
+	Accounts  repository.AccountRepository
+	Audit     repository.AuditRepository
+	Outbox    repository.OutboxRepository
+	Webhooks  repository.WebhookRepository
+	FIX       repository.FIXRepository
+	Phases    repository.TradingPhaseRepository
+	DB        *sql.DB                 // connection pool; nil for the memory backend
+	Checks    map[string]health.Check // readiness checks of the backend's dependencies
+	Close     func()
+}
+
+// traced wraps every repository in a tracing decorator.
+func (s *storage) traced() *storage {
+	s.Orders = repository.NewTracedOrderRepository(s.Orders)
+	s.Trades = repository.NewTracedTradeRepository(s.Trades)
+	s.Events = repository.NewTracedOrderEventRepository(s.Events)
+	s.Accounts = repository.NewTracedAccountRepository(s.Accounts)
+	s.Audit = repository.NewTracedAuditRepository(s.Audit)
+	s.Outbox = repository.NewTracedOutboxRepository(s.Outbox)
+	s.Webhooks = repository.NewTracedWebhookRepository(s.Webhooks)
+	s.FIX = repository.NewTracedFIXRepository(s.FIX)
+	s.Phases = repository.NewTracedTradingPhaseRepository(s.Phases)
+	return s
+}
+
+// openStorage opens the configured backend ("postgres" by default, or
+// "memory" for a throwaway, database-free instance).
+func openStorage(cfg *config.Config) *storage {
+	if cfg.Storage.Backend == config.BackendMemory {
+		log.Println("Using in-memory storage; data is lost on restart")
+		store := memory.NewStore()
+		return (&storage{
+			TxManager: store,
+			Orders:    memory.NewOrderRepository(store),
+			Trades:    memory.NewTradeRepository(store),
+			Events:    memory.NewOrderEventRepository(store),
+			Accounts:  memory.NewAccountRepository(store),
+			Audit:     memory.NewAuditRepository(store),
+			Outbox:    memory.NewOutboxRepository(store),
+			Webhooks:  memory.NewWebhookRepository(store),
+			FIX:       memory.NewFIXRepository(store),
+			Phases:    memory.NewTradingPhaseRepository(store),
+			Close:     func() {},
+		}).traced()
+	}
+
+	// 1. Connect PostgreSQL
+	postgresClient := postgres.ConnectDB(cfg.Postgres)
+
+	// 1.1 Apply pending migrations (disable features.auto_migrate and run `migrate up` instead)
+	migrator, err := migrate.NewMigrator(postgresClient.PostgresClient)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	if cfg.Features.AutoMigrate {
+		if _, err := migrator.Up(context.Background()); err != nil {
+			log.Fatalf("Failed to apply migrations: %v", err)
+		}
+	}
+
+	// 2. DB Helper
+	dbHelper, err := providers.NewDbProvider(postgresClient.PostgresClient)
+	if err != nil {
+		log.Fatalf("Failed to initialize DB helper: %v", err)
+	}
+	dbHelper.QueryTimeout = cfg.Postgres.QueryTimeout
+
+	return (&storage{
+		TxManager: repository.NewPostgresTxManager(dbHelper),
+		Orders:    repository.NewPostgresOrderRepository(dbHelper),
+		Trades:    repository.NewPostgresTradeRepository(dbHelper),
+		Events:    repository.NewPostgresOrderEventRepository(dbHelper),
+		Accounts:  repository.NewPostgresAccountRepository(dbHelper),
+		Audit:     repository.NewPostgresAuditRepository(dbHelper),
+		Outbox:    repository.NewPostgresOutboxRepository(dbHelper),
+		Webhooks:  repository.NewPostgresWebhookRepository(dbHelper),
+		FIX:       repository.NewPostgresFIXRepository(dbHelper),
+		Phases:    repository.NewPostgresTradingPhaseRepository(dbHelper),
+		DB:        postgresClient.PostgresClient,
+		Checks: map[string]health.Check{
+			"postgres":   health.PostgresCheck(postgresClient.PostgresClient),
+			"migrations": health.MigrationsCheck(migrator),
+		},
+		Close: postgresClient.Stop,
+	}).traced()
+}
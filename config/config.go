@@ -0,0 +1,460 @@
+// Package config loads the application configuration. Values come from, in
+// increasing order of precedence: built-in defaults, a YAML file (-config or
+// CONFIG_FILE), environment variables (including a .env file when present)
+// and command-line flags.
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/ratelimit"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tracing"
+)
+
+// Storage backends accepted by StorageConfig.Backend
+const (
+	BackendPostgres = "postgres"
+	BackendMemory   = "memory" // no database; data is lost on restart
+)
+
+type Config struct {
+	Server     ServerConfig     `yaml:"server"`
+	Storage    StorageConfig    `yaml:"storage"`
+	Postgres   PostgresConfig   `yaml:"postgres"`
+	Redis      RedisConfig      `yaml:"redis"`
+	Tracing    tracing.Config   `yaml:"tracing"`
+	Auth       AuthConfig       `yaml:"auth"`
+	RateLimit  RateLimitConfig  `yaml:"rate_limit"`
+	MarketData MarketDataConfig `yaml:"market_data"`
+	Webhooks   WebhookConfig    `yaml:"webhooks"`
+	FIX        FIXConfig        `yaml:"fix"`
+	GRPC       GRPCConfig       `yaml:"grpc"`
+	Engine     EngineConfig     `yaml:"engine"`
+	Features   Features         `yaml:"features"`
+}
+
+type ServerConfig struct {
+	Port            int           `yaml:"port"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"` // for in-flight requests to finish
+	DrainDelay      time.Duration `yaml:"drain_delay"`      // /readyz fails this long before shutdown starts
+	// TrustedProxies are the IPs or CIDRs whose X-Forwarded-For is believed
+	// when resolving the client IP; none by default.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+type StorageConfig struct {
+	Backend string `yaml:"backend"`
+}
+
+type PostgresConfig struct {
+	Host              string        `yaml:"host"`
+	Port              int           `yaml:"port"`
+	User              string        `yaml:"user"`
+	Password          string        `yaml:"password"`
+	Database          string        `yaml:"database"`
+	SSLMode           string        `yaml:"sslmode"`
+	SSLRootCert       string        `yaml:"sslrootcert"` // CA bundle used by verify-ca and verify-full
+	SSLCert           string        `yaml:"sslcert"`     // client certificate
+	SSLKey            string        `yaml:"sslkey"`      // client key; must not be group or world readable
+	ConnectAttempts   int           `yaml:"connect_attempts"`
+	ConnectRetryDelay time.Duration `yaml:"connect_retry_delay"`
+	ConnectTimeout    time.Duration `yaml:"connect_timeout"`
+	MaxOpenConns      int           `yaml:"max_open_conns"` // 0 means unlimited
+	MaxIdleConns      int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime   time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime   time.Duration `yaml:"conn_max_idle_time"`
+	QueryTimeout      time.Duration `yaml:"query_timeout"`     // client-side deadline of each repository call
+	StatementTimeout  time.Duration `yaml:"statement_timeout"` // server-side backstop (statement_timeout)
+}
+
+type RedisConfig struct {
+	Addr              string        `yaml:"addr"` // host:port
+	Password          string        `yaml:"password"`
+	DB                int           `yaml:"db"`
+	ConnectAttempts   int           `yaml:"connect_attempts"`
+	ConnectRetryDelay time.Duration `yaml:"connect_retry_delay"`
+	DialTimeout       time.Duration `yaml:"dial_timeout"`
+	OperationTimeout  time.Duration `yaml:"operation_timeout"` // read and write deadline of each command
+}
+
+type AuthConfig struct {
+	ReplayWindow time.Duration `yaml:"replay_window"` // accepted clock skew of signed requests
+
+	// SecretKey encrypts API key secrets at rest: 32 random bytes, base64
+	// encoded, e.g. from `openssl rand -base64 32`. Changing it invalidates
+	// every key.
+	SecretKey string `yaml:"secret_key"`
+}
+
+// Key decodes SecretKey.
+func (a AuthConfig) Key() ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(a.SecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("auth.secret_key: not base64: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("auth.secret_key: %d bytes, not 32", len(key))
+	}
+	return key, nil
+}
+
+// Rate limiter backends accepted by RateLimitConfig.Backend
+const (
+	RateLimitMemory = "memory" // limits apply per instance
+	RateLimitRedis  = "redis"  // limits are shared by every instance on the same Redis
+)
+
+// RateLimitConfig sets the token buckets each account (or, without
+// authentication, each client IP) gets per endpoint class, and the
+// order-to-trade ratio guard.
+type RateLimitConfig struct {
+	Backend        string             `yaml:"backend"`
+	Read           ratelimit.Limit    `yaml:"read"`             // market data and order queries
+	Place          ratelimit.Limit    `yaml:"place"`            // order entry
+	Cancel         ratelimit.Limit    `yaml:"cancel"`           // cancels
+	PlacePerSymbol ratelimit.Limit    `yaml:"place_per_symbol"` // order entry into one symbol
+	OrderToTrade   OrderToTradeConfig `yaml:"order_to_trade"`
+}
+
+// OrderToTradeConfig throttles accounts that send many orders and cancels
+// for each fill they get. Messages and fills are counted per account in
+// fixed windows; once an account has sent MinMessages in a window, new
+// orders are refused while it has sent more than MaxRatio messages per
+// fill, until the window ends. Cancels are never refused.
+type OrderToTradeConfig struct {
+	MaxRatio    float64       `yaml:"max_ratio"` // 0 disables the guard
+	MinMessages int           `yaml:"min_messages"`
+	Window      time.Duration `yaml:"window"`
+}
+
+// MarketDataConfig tunes the distribution of the book and trades through
+// Redis (features.market_data). Every instance with the feature on publishes
+// the changes it commits and serves /api/stream.
+type MarketDataConfig struct {
+	ServeFromCache   bool `yaml:"serve_from_cache"`  // serve GET /api/orderbook from the Redis snapshot
+	SubscriberBuffer int  `yaml:"subscriber_buffer"` // events queued per stream before a slow client is dropped
+}
+
+// WebhookConfig tunes the delivery of outbox events to webhooks
+// (features.webhooks). A failed attempt is retried after InitialBackoff,
+// doubling up to MaxBackoff; after MaxAttempts the delivery is dead until
+// replayed.
+type WebhookConfig struct {
+	PollInterval   time.Duration `yaml:"poll_interval"`   // how often the outbox and due deliveries are checked
+	BatchSize      int           `yaml:"batch_size"`      // events fanned out and deliveries claimed per poll
+	Concurrency    int           `yaml:"concurrency"`     // attempts in flight per instance
+	Timeout        time.Duration `yaml:"timeout"`         // of one attempt
+	MaxAttempts    int           `yaml:"max_attempts"`    // before a delivery is dead
+	InitialBackoff time.Duration `yaml:"initial_backoff"` // after the first failed attempt
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+}
+
+// FIXConfig sets up the FIX 4.4 order entry gateway (features.fix).
+type FIXConfig struct {
+	Port         int           `yaml:"port"`
+	SenderCompID string        `yaml:"sender_comp_id"` // ours; clients send it as TargetCompID
+	LogonTimeout time.Duration `yaml:"logon_timeout"`  // for a new connection to send its Logon
+}
+
+// GRPCConfig sets up the gRPC API (features.grpc).
+type GRPCConfig struct {
+	Port int `yaml:"port"`
+}
+
+// Features switches optional behavior on or off.
+type Features struct {
+	Auth        bool `yaml:"auth"`         // require signed API key requests
+	AutoMigrate bool `yaml:"auto_migrate"` // apply pending migrations on startup
+	RateLimit   bool `yaml:"rate_limit"`   // enforce rate_limit
+	MarketData  bool `yaml:"market_data"`  // publish and stream market data through Redis
+	Webhooks    bool `yaml:"webhooks"`     // record fills in the outbox and deliver them to webhooks
+	FIX         bool `yaml:"fix"`          // accept FIX 4.4 order entry sessions
+	GRPC        bool `yaml:"grpc"`         // serve the trading and market data API over gRPC
+}
+
+// Default returns the configuration used when nothing overrides it.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:            8080,
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    10 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 5 * time.Second,
+			DrainDelay:      5 * time.Second,
+		},
+		Storage: StorageConfig{Backend: BackendPostgres},
+		Postgres: PostgresConfig{
+			Host:              "localhost",
+			Port:              5432,
+			SSLMode:           "disable",
+			ConnectAttempts:   10,
+			ConnectRetryDelay: 2 * time.Second,
+			ConnectTimeout:    5 * time.Second,
+			MaxOpenConns:      20,
+			MaxIdleConns:      10,
+			ConnMaxLifetime:   30 * time.Minute,
+			ConnMaxIdleTime:   5 * time.Minute,
+			QueryTimeout:      5 * time.Second,
+			StatementTimeout:  30 * time.Second,
+		},
+		Tracing: tracing.Config{
+			Exporter:    tracing.ExporterNone,
+			File:        "traces.json",
+			ServiceName: "order-matching-engine",
+			SampleRatio: 1,
+		},
+		Redis: RedisConfig{
+			Addr:              "localhost:6379",
+			ConnectAttempts:   10,
+			ConnectRetryDelay: 2 * time.Second,
+			DialTimeout:       5 * time.Second,
+			OperationTimeout:  time.Second,
+		},
+		Auth: AuthConfig{ReplayWindow: 30 * time.Second},
+		RateLimit: RateLimitConfig{
+			Backend:        RateLimitMemory,
+			Read:           ratelimit.Limit{Rate: 50, Burst: 100},
+			Place:          ratelimit.Limit{Rate: 20, Burst: 40},
+			Cancel:         ratelimit.Limit{Rate: 50, Burst: 100},
+			PlacePerSymbol: ratelimit.Limit{Rate: 10, Burst: 20},
+			OrderToTrade:   OrderToTradeConfig{MaxRatio: 50, MinMessages: 200, Window: time.Minute},
+		},
+		MarketData: MarketDataConfig{SubscriberBuffer: 256},
+		Webhooks: WebhookConfig{
+			PollInterval:   time.Second,
+			BatchSize:      100,
+			Concurrency:    8,
+			Timeout:        5 * time.Second,
+			MaxAttempts:    12,
+			InitialBackoff: time.Second,
+			MaxBackoff:     time.Hour,
+		},
+		FIX: FIXConfig{
+			Port:         9878,
+			SenderCompID: "ENGINE",
+			LogonTimeout: 10 * time.Second,
+		},
+		GRPC:     GRPCConfig{Port: 9090},
+		Engine:   EngineConfig{MaxBatchSize: 100, HaltPolicy: HaltReject, BandPolicy: BandReject},
+		Features: Features{AutoMigrate: true, RateLimit: true},
+	}
+}
+
+func validProxy(proxy string) bool {
+	if _, _, err := net.ParseCIDR(proxy); err == nil {
+		return true
+	}
+	return net.ParseIP(proxy) != nil
+}
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	s := c.Server
+	check(s.Port > 0 && s.Port < 65536, "server.port: %d is not a valid port", s.Port)
+	check(s.ReadTimeout >= 0 && s.WriteTimeout >= 0 && s.IdleTimeout >= 0, "server: timeouts must not be negative")
+	check(s.ShutdownTimeout > 0, "server.shutdown_timeout: must be positive")
+	check(s.DrainDelay >= 0, "server.drain_delay: must not be negative")
+	for _, proxy := range s.TrustedProxies {
+		check(validProxy(proxy), "server.trusted_proxies: %q is not an IP or CIDR", proxy)
+	}
+
+	check(c.Storage.Backend == BackendPostgres || c.Storage.Backend == BackendMemory,
+		"storage.backend: %q is not one of %q, %q", c.Storage.Backend, BackendPostgres, BackendMemory)
+
+	if c.Storage.Backend == BackendPostgres {
+		p := c.Postgres
+		check(p.Host != "", "postgres.host: required")
+		check(p.Port > 0 && p.Port < 65536, "postgres.port: %d is not a valid port", p.Port)
+		check(p.User != "", "postgres.user: required")
+		check(p.Database != "", "postgres.database: required")
+		check(slices.Contains(sslModes, p.SSLMode), "postgres.sslmode: %q is not one of %v", p.SSLMode, sslModes)
+		check(p.ConnectAttempts >= 1, "postgres.connect_attempts: must be at least 1")
+		check(p.MaxOpenConns >= 0 && p.MaxIdleConns >= 0, "postgres: pool sizes must not be negative")
+		check(p.MaxOpenConns == 0 || p.MaxIdleConns <= p.MaxOpenConns,
+			"postgres.max_idle_conns: %d exceeds max_open_conns %d", p.MaxIdleConns, p.MaxOpenConns)
+		check(p.ConnMaxLifetime >= 0 && p.ConnMaxIdleTime >= 0, "postgres: connection lifetimes must not be negative")
+		check(p.ConnectTimeout >= 0 && p.QueryTimeout >= 0 && p.StatementTimeout >= 0, "postgres: timeouts must not be negative")
+		errs = append(errs, p.validateTLS()...)
+	}
+
+	t := c.Tracing
+	check(slices.Contains([]string{tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterStdout, tracing.ExporterFile}, t.Exporter),
+		"tracing.exporter: unknown exporter %q", t.Exporter)
+	check(t.SampleRatio >= 0 && t.SampleRatio <= 1, "tracing.sample_ratio: %v is not within [0, 1]", t.SampleRatio)
+
+	check(c.Auth.ReplayWindow > 0, "auth.replay_window: must be positive")
+	if c.Features.Auth || c.Auth.SecretKey != "" {
+		if _, err := c.Auth.Key(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if c.Features.RateLimit {
+		errs = append(errs, c.RateLimit.validate()...)
+	}
+	if c.Features.MarketData {
+		check(c.MarketData.SubscriberBuffer >= 1, "market_data.subscriber_buffer: must be at least 1")
+	} else {
+		check(!c.MarketData.ServeFromCache, "market_data.serve_from_cache: requires features.market_data")
+	}
+	if c.Features.Webhooks {
+		errs = append(errs, c.Webhooks.validate()...)
+	}
+	if c.Features.FIX {
+		f := c.FIX
+		check(f.Port > 0 && f.Port < 65536 && f.Port != s.Port, "fix.port: %d is not a valid port apart from server.port", f.Port)
+		check(f.SenderCompID != "", "fix.sender_comp_id: required")
+		check(f.LogonTimeout > 0, "fix.logon_timeout: must be positive")
+	}
+	if c.Features.GRPC {
+		g := c.GRPC
+		check(g.Port > 0 && g.Port < 65536 && g.Port != s.Port && !(c.Features.FIX && g.Port == c.FIX.Port),
+			"grpc.port: %d is not a valid port apart from server.port and fix.port", g.Port)
+	}
+	if c.UsesRedis() {
+		r := c.Redis
+		check(r.Addr != "", "redis.addr: required")
+		check(r.ConnectAttempts >= 1, "redis.connect_attempts: must be at least 1")
+		check(r.DialTimeout > 0, "redis.dial_timeout: must be positive")
+		check(r.OperationTimeout >= 0, "redis.operation_timeout: must not be negative")
+	}
+
+	errs = append(errs, c.Engine.validate()...)
+	return errors.Join(errs...)
+}
+
+// UsesRedis reports whether any enabled feature needs Redis.
+func (c *Config) UsesRedis() bool {
+	return c.Features.RateLimit && c.RateLimit.Backend == RateLimitRedis || c.Features.MarketData
+}
+
+func (r RateLimitConfig) validate() []error {
+	var errs []error
+	if r.Backend != RateLimitMemory && r.Backend != RateLimitRedis {
+		errs = append(errs, fmt.Errorf("rate_limit.backend: %q is not one of %q, %q", r.Backend, RateLimitMemory, RateLimitRedis))
+	}
+	for _, l := range []struct {
+		key   string
+		limit ratelimit.Limit
+	}{{"read", r.Read}, {"place", r.Place}, {"cancel", r.Cancel}, {"place_per_symbol", r.PlacePerSymbol}} {
+		if l.limit.Rate < 0 || l.limit.Burst < 0 {
+			errs = append(errs, fmt.Errorf("rate_limit.%s: rate and burst must not be negative", l.key))
+		}
+	}
+	o := r.OrderToTrade
+	if o.MaxRatio < 0 || o.MinMessages < 0 {
+		errs = append(errs, errors.New("rate_limit.order_to_trade: max_ratio and min_messages must not be negative"))
+	}
+	if o.MaxRatio > 0 && o.Window <= 0 {
+		errs = append(errs, errors.New("rate_limit.order_to_trade.window: must be positive"))
+	}
+	return errs
+}
+
+func (w WebhookConfig) validate() []error {
+	var errs []error
+	if w.PollInterval <= 0 || w.Timeout <= 0 || w.InitialBackoff <= 0 {
+		errs = append(errs, errors.New("webhooks: poll_interval, timeout and initial_backoff must be positive"))
+	}
+	if w.MaxBackoff < w.InitialBackoff {
+		errs = append(errs, fmt.Errorf("webhooks.max_backoff: %v is less than initial_backoff %v", w.MaxBackoff, w.InitialBackoff))
+	}
+	if w.BatchSize < 1 || w.Concurrency < 1 || w.MaxAttempts < 1 {
+		errs = append(errs, errors.New("webhooks: batch_size, concurrency and max_attempts must be at least 1"))
+	}
+	return errs
+}
+
+const redacted = "REDACTED"
+
+// Redacted returns a copy with secrets masked, safe to print or log.
+func (c *Config) Redacted() *Config {
+	out := *c
+	if out.Postgres.Password != "" {
+		out.Postgres.Password = redacted
+	}
+	if out.Redis.Password != "" {
+		out.Redis.Password = redacted
+	}
+	if out.Auth.SecretKey != "" {
+		out.Auth.SecretKey = redacted
+	}
+	return &out
+}
+
+// DSN returns the lib/pq connection string.
+func (p PostgresConfig) DSN() string {
+	params := []string{
+		"host=" + quoteDSN(p.Host),
+		"port=" + strconv.Itoa(p.Port),
+		"user=" + quoteDSN(p.User),
+		"password=" + quoteDSN(p.Password),
+		"dbname=" + quoteDSN(p.Database),
+		"sslmode=" + quoteDSN(p.SSLMode),
+	}
+	for _, f := range p.certFiles() {
+		if f.path != "" {
+			params = append(params, f.key+"="+quoteDSN(f.path))
+		}
+	}
+	if p.ConnectTimeout > 0 {
+		// lib/pq takes whole seconds; round up so a sub-second value is not "no timeout"
+		params = append(params, "connect_timeout="+strconv.Itoa(int((p.ConnectTimeout+time.Second-1)/time.Second)))
+	}
+	if p.StatementTimeout > 0 {
+		// Unknown keys are sent to the server as session parameters
+		params = append(params, "statement_timeout="+strconv.FormatInt(p.StatementTimeout.Milliseconds(), 10))
+	}
+	return strings.Join(params, " ")
+}
+
+// quoteDSN quotes a connection string value so spaces and quotes survive.
+func quoteDSN(v string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
+func (p PostgresConfig) validateTLS() []error {
+	var errs []error
+	if p.SSLMode == "disable" && (p.SSLRootCert != "" || p.SSLCert != "" || p.SSLKey != "") {
+		errs = append(errs, errors.New("postgres: certificates are set but sslmode is disable"))
+	}
+	if (p.SSLCert == "") != (p.SSLKey == "") {
+		errs = append(errs, errors.New("postgres: sslcert and sslkey must be set together"))
+	}
+	for _, f := range p.certFiles() {
+		if f.path == "" {
+			continue
+		}
+		if _, err := os.Stat(f.path); err != nil {
+			errs = append(errs, fmt.Errorf("postgres.%s: %w", f.key, err))
+		}
+	}
+	return errs
+}
+
+type certFile struct{ key, path string }
+
+func (p PostgresConfig) certFiles() []certFile {
+	return []certFile{{"sslrootcert", p.SSLRootCert}, {"sslcert", p.SSLCert}, {"sslkey", p.SSLKey}}
+}
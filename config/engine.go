@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// EngineConfig holds per-instrument trading rules. Symbols not listed in
+// Symbols use Defaults; listed symbols override Defaults field by field.
+type EngineConfig struct {
+	Defaults     SymbolConfig            `yaml:"defaults"`
+	Symbols      map[string]SymbolConfig `yaml:"symbols"`
+	MaxBatchSize int                     `yaml:"max_batch_size"` // orders or IDs per batch request; 0 is unlimited
+	HaltPolicy   string                  `yaml:"halt_policy"`    // what becomes of orders sent to a halted symbol
+	BandPolicy   string                  `yaml:"band_policy"`    // what becomes of orders that would trade outside the price band
+}
+
+// Halt policies accepted by EngineConfig.HaltPolicy
+const (
+	HaltReject = "reject" // new orders are rejected until trading resumes
+	HaltQueue  = "queue"  // new limit orders rest unmatched until trading resumes
+)
+
+// Band policies accepted by EngineConfig.BandPolicy
+const (
+	BandReject = "reject" // the order is rejected
+	BandCap    = "cap"    // the order trades up to the band; limit prices beyond it are moved to its edge
+)
+
+// SymbolConfig constrains the orders accepted for one instrument. Zero
+// values disable the corresponding check.
+type SymbolConfig struct {
+	TickSize    float64         `yaml:"tick_size"`    // limit prices must be a multiple of it
+	LotSize     decimal.Decimal `yaml:"lot_size"`     // quantities must be a multiple of it
+	MinQuantity decimal.Decimal `yaml:"min_quantity"` // per order
+	MaxQuantity decimal.Decimal `yaml:"max_quantity"` // per order
+
+	// QuantityPrecision is the number of decimals quantities may have; 0
+	// keeps them whole. It is a pointer so that a symbol can override the
+	// default with 0; unset everywhere, quantities are whole.
+	QuantityPrecision *int `yaml:"quantity_precision"`
+
+	// Price bands: trades may not move further than these percentages from
+	// the reference price (static) or from the last trade (dynamic).
+	// ReferencePrice is the reference until an auction or an operator sets
+	// one.
+	ReferencePrice float64 `yaml:"reference_price"`
+	StaticBandPct  float64 `yaml:"static_band_pct"`
+	DynamicBandPct float64 `yaml:"dynamic_band_pct"`
+
+	// Circuit breaker: trading halts when trade prices range over more than
+	// CircuitBreakerPct within CircuitBreakerWindow
+	CircuitBreakerPct    float64       `yaml:"circuit_breaker_pct"`
+	CircuitBreakerWindow time.Duration `yaml:"circuit_breaker_window"`
+
+	// MarketProtectionPct stops market orders trading further than this
+	// percentage from the best opposite price at arrival, unless they carry
+	// their own max slippage.
+	MarketProtectionPct float64 `yaml:"market_protection_pct"`
+}
+
+// Symbol returns the effective rules for symbol.
+func (e EngineConfig) Symbol(symbol string) SymbolConfig {
+	cfg := e.Defaults
+	o, ok := e.Symbols[symbol]
+	if !ok {
+		return cfg
+	}
+	if o.TickSize != 0 {
+		cfg.TickSize = o.TickSize
+	}
+	if !o.LotSize.IsZero() {
+		cfg.LotSize = o.LotSize
+	}
+	if !o.MinQuantity.IsZero() {
+		cfg.MinQuantity = o.MinQuantity
+	}
+	if !o.MaxQuantity.IsZero() {
+		cfg.MaxQuantity = o.MaxQuantity
+	}
+	if o.QuantityPrecision != nil {
+		cfg.QuantityPrecision = o.QuantityPrecision
+	}
+	if o.ReferencePrice != 0 {
+		cfg.ReferencePrice = o.ReferencePrice
+	}
+	if o.StaticBandPct != 0 {
+		cfg.StaticBandPct = o.StaticBandPct
+	}
+	if o.DynamicBandPct != 0 {
+		cfg.DynamicBandPct = o.DynamicBandPct
+	}
+	if o.CircuitBreakerPct != 0 {
+		cfg.CircuitBreakerPct = o.CircuitBreakerPct
+	}
+	if o.CircuitBreakerWindow != 0 {
+		cfg.CircuitBreakerWindow = o.CircuitBreakerWindow
+	}
+	if o.MarketProtectionPct != 0 {
+		cfg.MarketProtectionPct = o.MarketProtectionPct
+	}
+	return cfg
+}
+
+// Precision returns the number of decimals quantities may have.
+func (s SymbolConfig) Precision() int32 {
+	if s.QuantityPrecision == nil {
+		return 0
+	}
+	return int32(*s.QuantityPrecision)
+}
+
+func (e EngineConfig) validate() []error {
+	errs := validateSymbol("engine.defaults", e.Defaults)
+	if e.MaxBatchSize < 0 {
+		errs = append(errs, fmt.Errorf("engine.max_batch_size: must not be negative"))
+	}
+	if e.HaltPolicy != HaltReject && e.HaltPolicy != HaltQueue {
+		errs = append(errs, fmt.Errorf("engine.halt_policy: %q is not one of %q, %q", e.HaltPolicy, HaltReject, HaltQueue))
+	}
+	if e.BandPolicy != BandReject && e.BandPolicy != BandCap {
+		errs = append(errs, fmt.Errorf("engine.band_policy: %q is not one of %q, %q", e.BandPolicy, BandReject, BandCap))
+	}
+	for _, symbol := range slices.Sorted(maps.Keys(e.Symbols)) {
+		errs = append(errs, validateSymbol("engine.symbols."+symbol, e.Symbol(symbol))...)
+	}
+	return errs
+}
+
+func validateSymbol(path string, s SymbolConfig) []error {
+	var errs []error
+	if s.TickSize < 0 || s.LotSize.IsNegative() || s.MinQuantity.IsNegative() || s.MaxQuantity.IsNegative() {
+		errs = append(errs, fmt.Errorf("%s: sizes must not be negative", path))
+	}
+	if s.MaxQuantity.IsPositive() && s.MinQuantity.GreaterThan(s.MaxQuantity) {
+		errs = append(errs, fmt.Errorf("%s: min_quantity %s exceeds max_quantity %s", path, s.MinQuantity, s.MaxQuantity))
+	}
+	// Quantities are stored with 8 decimals
+	if s.Precision() < 0 || s.Precision() > 8 {
+		errs = append(errs, fmt.Errorf("%s: quantity_precision must be between 0 and 8", path))
+	}
+	for _, size := range []struct {
+		name  string
+		value decimal.Decimal
+	}{{"lot_size", s.LotSize}, {"min_quantity", s.MinQuantity}, {"max_quantity", s.MaxQuantity}} {
+		if !size.value.Equal(size.value.Truncate(s.Precision())) {
+			errs = append(errs, fmt.Errorf("%s: %s %s has more decimals than quantity_precision %d", path, size.name, size.value, s.Precision()))
+		}
+	}
+	if s.ReferencePrice < 0 {
+		errs = append(errs, fmt.Errorf("%s: reference_price must not be negative", path))
+	}
+	for _, pct := range []struct {
+		name  string
+		value float64
+	}{{"static_band_pct", s.StaticBandPct}, {"dynamic_band_pct", s.DynamicBandPct}, {"circuit_breaker_pct", s.CircuitBreakerPct}, {"market_protection_pct", s.MarketProtectionPct}} {
+		if pct.value < 0 || pct.value >= 100 {
+			errs = append(errs, fmt.Errorf("%s: %s must be between 0 and 100", path, pct.name))
+		}
+	}
+	if s.CircuitBreakerPct > 0 && s.CircuitBreakerWindow <= 0 {
+		errs = append(errs, fmt.Errorf("%s: circuit_breaker_window must be positive with circuit_breaker_pct set", path))
+	}
+	return errs
+}
@@ -0,0 +1,241 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+// setting binds one scalar field to an environment variable and a flag. The
+// flag name is the field's dotted path in the YAML file.
+type setting struct {
+	key    string
+	env    string
+	target any // *string, *int, **int, *bool, *float64, *time.Duration, *decimal.Decimal or *[]string
+	usage  string
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{"server.port", "PORT", &c.Server.Port, "HTTP listen port"},
+		{"server.read_timeout", "SERVER_READ_TIMEOUT", &c.Server.ReadTimeout, "max duration for reading a request"},
+		{"server.write_timeout", "SERVER_WRITE_TIMEOUT", &c.Server.WriteTimeout, "max duration for writing a response"},
+		{"server.idle_timeout", "SERVER_IDLE_TIMEOUT", &c.Server.IdleTimeout, "keep-alive idle timeout"},
+		{"server.shutdown_timeout", "SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout, "time allowed for in-flight requests on shutdown"},
+		{"server.drain_delay", "SHUTDOWN_DRAIN_DELAY", &c.Server.DrainDelay, "time /readyz fails before shutdown starts"},
+		{"server.trusted_proxies", "TRUSTED_PROXIES", &c.Server.TrustedProxies, "comma-separated proxy IPs or CIDRs trusted for X-Forwarded-For"},
+
+		{"storage.backend", "STORAGE_BACKEND", &c.Storage.Backend, "storage backend: postgres or memory"},
+
+		{"postgres.host", "POSTGRES_HOST", &c.Postgres.Host, "PostgreSQL host"},
+		{"postgres.port", "POSTGRES_PORT", &c.Postgres.Port, "PostgreSQL port"},
+		{"postgres.user", "POSTGRES_USER", &c.Postgres.User, "PostgreSQL user"},
+		{"postgres.password", "POSTGRES_PASSWORD", &c.Postgres.Password, "PostgreSQL password"},
+		{"postgres.database", "POSTGRES_DB", &c.Postgres.Database, "PostgreSQL database"},
+		{"postgres.sslmode", "POSTGRES_SSLMODE", &c.Postgres.SSLMode, "PostgreSQL sslmode: disable, require, verify-ca, verify-full, ..."},
+		{"postgres.sslrootcert", "POSTGRES_SSLROOTCERT", &c.Postgres.SSLRootCert, "CA certificate file"},
+		{"postgres.sslcert", "POSTGRES_SSLCERT", &c.Postgres.SSLCert, "client certificate file"},
+		{"postgres.sslkey", "POSTGRES_SSLKEY", &c.Postgres.SSLKey, "client key file"},
+		{"postgres.connect_attempts", "MAX_DB_ATTEMPTS", &c.Postgres.ConnectAttempts, "connection attempts on startup"},
+		{"postgres.connect_retry_delay", "POSTGRES_CONNECT_RETRY_DELAY", &c.Postgres.ConnectRetryDelay, "delay between connection attempts"},
+		{"postgres.connect_timeout", "POSTGRES_CONNECT_TIMEOUT", &c.Postgres.ConnectTimeout, "timeout of one connection attempt"},
+		{"postgres.max_open_conns", "POSTGRES_MAX_OPEN_CONNS", &c.Postgres.MaxOpenConns, "max open connections (0 = unlimited)"},
+		{"postgres.max_idle_conns", "POSTGRES_MAX_IDLE_CONNS", &c.Postgres.MaxIdleConns, "max idle connections"},
+		{"postgres.conn_max_lifetime", "POSTGRES_CONN_MAX_LIFETIME", &c.Postgres.ConnMaxLifetime, "max lifetime of a connection"},
+		{"postgres.conn_max_idle_time", "POSTGRES_CONN_MAX_IDLE_TIME", &c.Postgres.ConnMaxIdleTime, "max idle time of a connection"},
+		{"postgres.query_timeout", "POSTGRES_QUERY_TIMEOUT", &c.Postgres.QueryTimeout, "deadline of each repository call (0 = none)"},
+		{"postgres.statement_timeout", "POSTGRES_STATEMENT_TIMEOUT", &c.Postgres.StatementTimeout, "server-side statement_timeout (0 = none)"},
+
+		{"redis.addr", "REDIS_ADDR", &c.Redis.Addr, "Redis address (host:port)"},
+		{"redis.password", "REDIS_PASSWORD", &c.Redis.Password, "Redis password"},
+		{"redis.db", "REDIS_DB", &c.Redis.DB, "Redis database number"},
+		{"redis.connect_attempts", "REDIS_CONNECT_ATTEMPTS", &c.Redis.ConnectAttempts, "Redis connection attempts on startup"},
+		{"redis.connect_retry_delay", "REDIS_CONNECT_RETRY_DELAY", &c.Redis.ConnectRetryDelay, "delay between Redis connection attempts"},
+		{"redis.dial_timeout", "REDIS_DIAL_TIMEOUT", &c.Redis.DialTimeout, "timeout of one Redis connection attempt"},
+		{"redis.operation_timeout", "REDIS_OPERATION_TIMEOUT", &c.Redis.OperationTimeout, "read/write deadline of each Redis command"},
+
+		{"tracing.exporter", "TRACING_EXPORTER", &c.Tracing.Exporter, "span exporter: none, otlp, stdout or file"},
+		{"tracing.file", "TRACING_FILE", &c.Tracing.File, "output file of the file exporter"},
+		{"tracing.sample_ratio", "TRACING_SAMPLE_RATIO", &c.Tracing.SampleRatio, "fraction of new traces sampled"},
+
+		{"engine.defaults.tick_size", "ENGINE_TICK_SIZE", &c.Engine.Defaults.TickSize, "default price increment"},
+		{"engine.defaults.lot_size", "ENGINE_LOT_SIZE", &c.Engine.Defaults.LotSize, "default quantity increment"},
+		{"engine.defaults.min_quantity", "ENGINE_MIN_QUANTITY", &c.Engine.Defaults.MinQuantity, "default minimum order quantity"},
+		{"engine.defaults.max_quantity", "ENGINE_MAX_QUANTITY", &c.Engine.Defaults.MaxQuantity, "default maximum order quantity"},
+		{"engine.defaults.quantity_precision", "ENGINE_QUANTITY_PRECISION", &c.Engine.Defaults.QuantityPrecision, "default number of decimals of quantities"},
+		{"engine.defaults.static_band_pct", "ENGINE_STATIC_BAND_PCT", &c.Engine.Defaults.StaticBandPct, "default max % a trade may move from the reference price"},
+		{"engine.defaults.dynamic_band_pct", "ENGINE_DYNAMIC_BAND_PCT", &c.Engine.Defaults.DynamicBandPct, "default max % a trade may move from the last trade"},
+		{"engine.defaults.circuit_breaker_pct", "ENGINE_CIRCUIT_BREAKER_PCT", &c.Engine.Defaults.CircuitBreakerPct, "default % move within the window that halts trading"},
+		{"engine.defaults.circuit_breaker_window", "ENGINE_CIRCUIT_BREAKER_WINDOW", &c.Engine.Defaults.CircuitBreakerWindow, "default circuit breaker window"},
+		{"engine.defaults.market_protection_pct", "ENGINE_MARKET_PROTECTION_PCT", &c.Engine.Defaults.MarketProtectionPct, "default max % market orders may trade from the best price at arrival"},
+		{"engine.max_batch_size", "ENGINE_MAX_BATCH_SIZE", &c.Engine.MaxBatchSize, "orders or IDs per batch request"},
+		{"engine.halt_policy", "ENGINE_HALT_POLICY", &c.Engine.HaltPolicy, "orders sent to a halted symbol: reject or queue"},
+		{"engine.band_policy", "ENGINE_BAND_POLICY", &c.Engine.BandPolicy, "orders that would trade outside the price band: reject or cap"},
+
+		{"auth.replay_window", "AUTH_REPLAY_WINDOW", &c.Auth.ReplayWindow, "accepted clock skew of signed requests"},
+		{"auth.secret_key", "AUTH_SECRET_KEY", &c.Auth.SecretKey, "base64 encoded 32-byte key encrypting API key secrets"},
+
+		{"rate_limit.backend", "RATE_LIMIT_BACKEND", &c.RateLimit.Backend, "rate limiter backend: memory or redis"},
+		{"rate_limit.read.rate", "RATE_LIMIT_READ_RATE", &c.RateLimit.Read.Rate, "read requests per second per account (0 = unlimited)"},
+		{"rate_limit.read.burst", "RATE_LIMIT_READ_BURST", &c.RateLimit.Read.Burst, "read request burst per account"},
+		{"rate_limit.place.rate", "RATE_LIMIT_PLACE_RATE", &c.RateLimit.Place.Rate, "orders per second per account (0 = unlimited)"},
+		{"rate_limit.place.burst", "RATE_LIMIT_PLACE_BURST", &c.RateLimit.Place.Burst, "order burst per account"},
+		{"rate_limit.cancel.rate", "RATE_LIMIT_CANCEL_RATE", &c.RateLimit.Cancel.Rate, "cancels per second per account (0 = unlimited)"},
+		{"rate_limit.cancel.burst", "RATE_LIMIT_CANCEL_BURST", &c.RateLimit.Cancel.Burst, "cancel burst per account"},
+		{"rate_limit.place_per_symbol.rate", "RATE_LIMIT_PLACE_PER_SYMBOL_RATE", &c.RateLimit.PlacePerSymbol.Rate, "orders per second per account and symbol (0 = unlimited)"},
+		{"rate_limit.place_per_symbol.burst", "RATE_LIMIT_PLACE_PER_SYMBOL_BURST", &c.RateLimit.PlacePerSymbol.Burst, "order burst per account and symbol"},
+		{"rate_limit.order_to_trade.max_ratio", "RATE_LIMIT_OTR_MAX_RATIO", &c.RateLimit.OrderToTrade.MaxRatio, "max orders and cancels per fill (0 = no guard)"},
+		{"rate_limit.order_to_trade.min_messages", "RATE_LIMIT_OTR_MIN_MESSAGES", &c.RateLimit.OrderToTrade.MinMessages, "messages per window before the ratio is enforced"},
+		{"rate_limit.order_to_trade.window", "RATE_LIMIT_OTR_WINDOW", &c.RateLimit.OrderToTrade.Window, "order-to-trade ratio window"},
+
+		{"market_data.serve_from_cache", "MARKET_DATA_SERVE_FROM_CACHE", &c.MarketData.ServeFromCache, "serve GET /api/orderbook from the Redis snapshot"},
+		{"market_data.subscriber_buffer", "MARKET_DATA_SUBSCRIBER_BUFFER", &c.MarketData.SubscriberBuffer, "events queued per stream before a slow client is dropped"},
+
+		{"webhooks.poll_interval", "WEBHOOK_POLL_INTERVAL", &c.Webhooks.PollInterval, "how often the outbox and due webhook deliveries are checked"},
+		{"webhooks.batch_size", "WEBHOOK_BATCH_SIZE", &c.Webhooks.BatchSize, "events fanned out and deliveries claimed per poll"},
+		{"webhooks.concurrency", "WEBHOOK_CONCURRENCY", &c.Webhooks.Concurrency, "webhook delivery attempts in flight per instance"},
+		{"webhooks.timeout", "WEBHOOK_TIMEOUT", &c.Webhooks.Timeout, "timeout of one webhook delivery attempt"},
+		{"webhooks.max_attempts", "WEBHOOK_MAX_ATTEMPTS", &c.Webhooks.MaxAttempts, "attempts before a webhook delivery is dead"},
+		{"webhooks.initial_backoff", "WEBHOOK_INITIAL_BACKOFF", &c.Webhooks.InitialBackoff, "delay after the first failed delivery attempt"},
+		{"webhooks.max_backoff", "WEBHOOK_MAX_BACKOFF", &c.Webhooks.MaxBackoff, "max delay between delivery attempts"},
+
+		{"fix.port", "FIX_PORT", &c.FIX.Port, "FIX gateway port"},
+		{"fix.sender_comp_id", "FIX_SENDER_COMP_ID", &c.FIX.SenderCompID, "CompID of the engine in FIX sessions"},
+		{"fix.logon_timeout", "FIX_LOGON_TIMEOUT", &c.FIX.LogonTimeout, "time a FIX connection has to log on"},
+
+		{"grpc.port", "GRPC_PORT", &c.GRPC.Port, "gRPC API port"},
+
+		{"features.auth", "ENABLE_AUTH", &c.Features.Auth, "require signed API key requests"},
+		{"features.auto_migrate", "AUTO_MIGRATE", &c.Features.AutoMigrate, "apply pending migrations on startup"},
+		{"features.rate_limit", "ENABLE_RATE_LIMIT", &c.Features.RateLimit, "enforce rate_limit"},
+		{"features.market_data", "ENABLE_MARKET_DATA", &c.Features.MarketData, "publish and stream market data through Redis"},
+		{"features.webhooks", "ENABLE_WEBHOOKS", &c.Features.Webhooks, "record fills in the outbox and deliver them to webhooks"},
+		{"features.fix", "ENABLE_FIX", &c.Features.FIX, "accept FIX 4.4 order entry sessions"},
+		{"features.grpc", "ENABLE_GRPC", &c.Features.GRPC, "serve the trading and market data API over gRPC"},
+	}
+}
+
+func (s setting) set(value string) error {
+	var err error
+	switch t := s.target.(type) {
+	case *string:
+		*t = value
+	case *int:
+		*t, err = strconv.Atoi(value)
+	case **int:
+		var n int
+		n, err = strconv.Atoi(value)
+		*t = &n
+	case *bool:
+		*t, err = strconv.ParseBool(value)
+	case *float64:
+		*t, err = strconv.ParseFloat(value, 64)
+	case *time.Duration:
+		*t, err = time.ParseDuration(value)
+	case *decimal.Decimal:
+		*t, err = decimal.NewFromString(value)
+	case *[]string:
+		*t = nil
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				*t = append(*t, v)
+			}
+		}
+	default:
+		panic(fmt.Sprintf("config: unsupported type %T for %s", s.target, s.key))
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value %q", value)
+	}
+	return nil
+}
+
+// Load builds the configuration from defaults, the config file, environment
+// and args, and validates it. It returns the arguments left after the flags,
+// i.e. the subcommand. A missing .env file is not an error.
+func Load(args []string) (*Config, []string, error) {
+	if err := godotenv.Load(".env"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf("failed to load .env: %w", err)
+	}
+
+	cfg := Default()
+	settings := cfg.settings()
+
+	flags := flag.NewFlagSet("app", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML config file (env CONFIG_FILE)")
+	flagValues := map[string]string{}
+	for _, s := range settings {
+		flags.Func(s.key, fmt.Sprintf("%s (env %s)", s.usage, s.env), func(v string) error {
+			flagValues[s.key] = v
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *configFile != "" {
+		if err := cfg.loadFile(*configFile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var errs []error
+	for _, s := range settings {
+		if v := os.Getenv(s.env); v != "" {
+			if err := s.set(v); err != nil {
+				errs = append(errs, fmt.Errorf("env %s: %w", s.env, err))
+			}
+		}
+	}
+	for _, s := range settings {
+		if v, ok := flagValues[s.key]; ok {
+			if err := s.set(v); err != nil {
+				errs = append(errs, fmt.Errorf("flag -%s: %w", s.key, err))
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, flags.Args(), nil
+}
+
+// loadFile overlays a YAML file; unknown keys are rejected so typos surface.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Print writes the configuration as YAML with secrets redacted.
+func (c *Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return enc.Close()
+}
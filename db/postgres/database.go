@@ -4,11 +4,9 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"os"
-	"path/filepath"
-	"strconv"
 	"time"
 
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
 	_ "github.com/lib/pq"
 )
 
@@ -17,39 +15,30 @@ type Db struct {
 }
 
 // ConnectDB establishes a connection to the PostgreSQL database
-func ConnectDB() *Db {
-	connStr := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		os.Getenv("POSTGRES_HOST"),
-		os.Getenv("POSTGRES_PORT"),
-		os.Getenv("POSTGRES_USER"),
-		os.Getenv("POSTGRES_PASSWORD"),
-		os.Getenv("POSTGRES_DB"),
-	)
-
+func ConnectDB(cfg config.PostgresConfig) *Db {
 	var db *sql.DB
 	var err error
-	maxRetries, _ := strconv.Atoi(os.Getenv("MAX_DB_ATTEMPTS"))
-	if maxRetries == 0 {
-		maxRetries = 10
-	}
-
-	for i := 0; i < maxRetries; i++ {
-		db, err = sql.Open("postgres", connStr)
+	for i := 0; i < cfg.ConnectAttempts; i++ {
+		db, err = sql.Open("postgres", cfg.DSN())
 		if err != nil {
 			log.Printf("Attempt %d: failed to open database connection: %v", i+1, err)
-			time.Sleep(2 * time.Second)
+			time.Sleep(cfg.ConnectRetryDelay)
 			continue
 		}
 
 		err = db.Ping()
 		if err == nil {
+			db.SetMaxOpenConns(cfg.MaxOpenConns)
+			db.SetMaxIdleConns(cfg.MaxIdleConns)
+			db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+			db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 			fmt.Println("Connected to PostgreSQL database successfully!")
 			return &Db{PostgresClient: db}
 		}
 
+		db.Close()
 		log.Printf("Attempt %d: failed to ping PostgreSQL: %v", i+1, err)
-		time.Sleep(2 * time.Second)
+		time.Sleep(cfg.ConnectRetryDelay)
 	}
 
 	log.Fatalf("Exceeded max retries. Could not connect to PostgreSQL: %v", err)
@@ -68,24 +57,6 @@ func (db *Db) Stop() {
 	}
 }
 
-func (db *Db) InitSchema() error {
-	
-	schemaPath := filepath.Join("db", "postgres", "schema.sql")
-	content, err := os.ReadFile(schemaPath)
-	if err != nil {
-		return fmt.Errorf("failed to read schema file: %w", err)
-	}
-
-	_, err = db.PostgresClient.Exec(string(content))
-	if err != nil {
-		return fmt.Errorf("failed to execute schema: %w", err)
-	}
-
-	fmt.Println("Database schema initialized successfully from file.")
-	return nil
-}
-
-
 // InitSchema creates the necessary tables in the PostgreSQL database
 // func (db *Db) InitSchema() error {
 // 	schema := fmt.Sprintf(`
@@ -135,4 +106,3 @@ func (db *Db) InitSchema() error {
 // 	fmt.Println("Database schema initialized successfully.")
 // 	return nil
 // }
-
@@ -1,11 +1,9 @@
--- DROP TABLES IF THEY EXIST
-DROP TABLE IF EXISTS trades;
-DROP TABLE IF EXISTS orders;
-
 -- ==============================
 -- ORDERS TABLE
 -- ==============================
-CREATE TABLE orders (
+-- IF NOT EXISTS lets databases created by the old schema.sql adopt this
+-- migration without losing data.
+CREATE TABLE IF NOT EXISTS orders (
     id BIGSERIAL PRIMARY KEY,
     symbol VARCHAR(20) NOT NULL,
     side VARCHAR(10) CHECK (side IN ('buy', 'sell')) NOT NULL,
@@ -18,12 +16,12 @@ CREATE TABLE orders (
 );
 
 -- INDEX for matching efficiency
-CREATE INDEX idx_orders_symbol_side_price_time ON orders (symbol, side, price, created_at);
+CREATE INDEX IF NOT EXISTS idx_orders_symbol_side_price_time ON orders (symbol, side, price, created_at);
 
 -- ==============================
 -- TRADES TABLE
 -- ==============================
-CREATE TABLE trades (
+CREATE TABLE IF NOT EXISTS trades (
     id BIGSERIAL PRIMARY KEY,
     buy_order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
     sell_order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
@@ -33,4 +31,4 @@ CREATE TABLE trades (
 );
 
 -- INDEX for symbol lookup via JOIN
-CREATE INDEX idx_trades_symbol_lookup ON trades (created_at);
+CREATE INDEX IF NOT EXISTS idx_trades_symbol_lookup ON trades (created_at);
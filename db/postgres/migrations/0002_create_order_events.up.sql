@@ -0,0 +1,21 @@
+-- ==============================
+-- ORDER EVENTS TABLE
+-- ==============================
+-- One row per order state transition, written in the same transaction as the
+-- change to orders, so the history always agrees with the order row.
+CREATE TABLE order_events (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    event_type VARCHAR(20) CHECK (event_type IN ('created', 'fill', 'canceled')) NOT NULL,
+    prev_status VARCHAR(10),
+    status VARCHAR(10) NOT NULL,
+    prev_remaining_quantity INTEGER,
+    remaining_quantity INTEGER NOT NULL CHECK (remaining_quantity >= 0),
+    reason TEXT NOT NULL DEFAULT '',
+    trade_id BIGINT REFERENCES trades(id) ON DELETE SET NULL,
+    actor VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- INDEX for per-order history lookups
+CREATE INDEX idx_order_events_order_id ON order_events (order_id, id);
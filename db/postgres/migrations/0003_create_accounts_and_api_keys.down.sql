@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_orders_account_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS account_id;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS accounts;
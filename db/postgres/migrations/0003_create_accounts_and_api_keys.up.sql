@@ -0,0 +1,29 @@
+-- ==============================
+-- ACCOUNTS TABLE
+-- ==============================
+CREATE TABLE accounts (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ==============================
+-- API KEYS TABLE
+-- ==============================
+-- secret_hash is the SHA-256 digest of the secret; the secret is never stored.
+CREATE TABLE api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    key_id VARCHAR(40) NOT NULL UNIQUE,
+    account_id BIGINT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    secret_hash BYTEA NOT NULL,
+    scopes TEXT[] NOT NULL CHECK (scopes <@ ARRAY['read', 'trade', 'cancel', 'admin']::TEXT[]),
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    rotated_at TIMESTAMP WITHOUT TIME ZONE,
+    revoked_at TIMESTAMP WITHOUT TIME ZONE
+);
+
+CREATE INDEX idx_api_keys_account_id ON api_keys (account_id);
+
+-- Orders placed through an API key belong to its account
+ALTER TABLE orders ADD COLUMN account_id BIGINT REFERENCES accounts(id);
+CREATE INDEX idx_orders_account_id ON orders (account_id) WHERE account_id IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS admin_audit_log;
+ALTER TABLE accounts DROP COLUMN IF EXISTS role;
@@ -0,0 +1,23 @@
+-- ==============================
+-- ACCOUNT ROLES
+-- ==============================
+ALTER TABLE accounts ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'trader'
+    CHECK (role IN ('viewer', 'trader', 'market_maker', 'operator', 'admin'));
+
+-- Accounts that already administer keys keep doing so
+UPDATE accounts SET role = 'admin'
+WHERE id IN (SELECT account_id FROM api_keys WHERE 'admin' = ANY (scopes));
+
+-- ==============================
+-- ADMIN AUDIT LOG
+-- ==============================
+-- One row per privileged action, successful or not. result is 'ok' or the
+-- error code the action failed with.
+CREATE TABLE admin_audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor VARCHAR(100) NOT NULL,
+    action VARCHAR(100) NOT NULL,
+    params JSONB NOT NULL DEFAULT '{}',
+    result VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
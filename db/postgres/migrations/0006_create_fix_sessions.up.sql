@@ -0,0 +1,30 @@
+-- ==============================
+-- FIX SESSIONS
+-- ==============================
+-- Sequence numbers of each FIX counterparty, kept across restarts.
+CREATE TABLE fix_sessions (
+    id VARCHAR(64) PRIMARY KEY,
+    account_id BIGINT REFERENCES accounts(id),
+    next_sender_seq INTEGER NOT NULL,
+    next_target_seq INTEGER NOT NULL,
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Messages sent to each counterparty, resent on request.
+CREATE TABLE fix_messages (
+    session_id VARCHAR(64) NOT NULL REFERENCES fix_sessions(id),
+    seq INTEGER NOT NULL,
+    body BYTEA NOT NULL,
+    PRIMARY KEY (session_id, seq)
+);
+
+-- The ClOrdID each order placed over FIX was sent with. Clients may reuse
+-- ClOrdIDs from one day to the next, so the latest order wins.
+CREATE TABLE fix_orders (
+    order_id BIGINT PRIMARY KEY REFERENCES orders(id),
+    session_id VARCHAR(64) NOT NULL REFERENCES fix_sessions(id),
+    cl_ord_id VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_fix_orders_cl_ord_id ON fix_orders (session_id, cl_ord_id, order_id DESC);
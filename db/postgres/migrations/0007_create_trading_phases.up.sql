@@ -0,0 +1,12 @@
+-- ==============================
+-- TRADING PHASES
+-- ==============================
+-- The phase each symbol trades in, as an operator last set it. Symbols
+-- without a row trade continuously.
+CREATE TABLE trading_phases (
+    symbol VARCHAR(20) PRIMARY KEY,
+    phase VARCHAR(12) CHECK (phase IN ('pre_open', 'continuous', 'halted', 'closed')) NOT NULL,
+    reason VARCHAR(256) NOT NULL DEFAULT '',
+    updated_by VARCHAR(100) NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
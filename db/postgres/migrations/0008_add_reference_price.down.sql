@@ -0,0 +1 @@
+ALTER TABLE trading_phases DROP COLUMN IF EXISTS reference_price;
@@ -0,0 +1,3 @@
+-- The centre of a symbol's static price band, set by the auction it last
+-- reopened through or by an operator. NULL falls back to the configured one.
+ALTER TABLE trading_phases ADD COLUMN reference_price NUMERIC(12, 2);
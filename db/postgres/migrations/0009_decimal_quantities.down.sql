@@ -0,0 +1,21 @@
+-- Whole quantities cannot hold fractional ones: rounding them would lose
+-- quantity, or turn positive ones into 0, so the downgrade is refused while
+-- any exist.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM orders WHERE quantity <> TRUNC(quantity) OR remaining_quantity <> TRUNC(remaining_quantity))
+        OR EXISTS (SELECT 1 FROM trades WHERE quantity <> TRUNC(quantity))
+        OR EXISTS (SELECT 1 FROM order_events WHERE prev_remaining_quantity <> TRUNC(prev_remaining_quantity) OR remaining_quantity <> TRUNC(remaining_quantity))
+    THEN
+        RAISE EXCEPTION 'fractional quantities exist; they cannot be converted to whole ones';
+    END IF;
+END
+$$;
+
+ALTER TABLE order_events
+    ALTER COLUMN prev_remaining_quantity TYPE INTEGER USING prev_remaining_quantity::INTEGER,
+    ALTER COLUMN remaining_quantity TYPE INTEGER USING remaining_quantity::INTEGER;
+ALTER TABLE trades ALTER COLUMN quantity TYPE INTEGER USING quantity::INTEGER;
+ALTER TABLE orders
+    ALTER COLUMN quantity TYPE INTEGER USING quantity::INTEGER,
+    ALTER COLUMN remaining_quantity TYPE INTEGER USING remaining_quantity::INTEGER;
@@ -0,0 +1,9 @@
+-- Quantities are fixed-point decimals, to the precision each instrument
+-- configures (at most eight places).
+ALTER TABLE orders
+    ALTER COLUMN quantity TYPE NUMERIC(20, 8) USING quantity::NUMERIC,
+    ALTER COLUMN remaining_quantity TYPE NUMERIC(20, 8) USING remaining_quantity::NUMERIC;
+ALTER TABLE trades ALTER COLUMN quantity TYPE NUMERIC(20, 8) USING quantity::NUMERIC;
+ALTER TABLE order_events
+    ALTER COLUMN prev_remaining_quantity TYPE NUMERIC(20, 8) USING prev_remaining_quantity::NUMERIC,
+    ALTER COLUMN remaining_quantity TYPE NUMERIC(20, 8) USING remaining_quantity::NUMERIC;
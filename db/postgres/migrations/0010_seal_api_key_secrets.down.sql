@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS api_key_nonces;
+-- The sealed secrets cannot be hashed in SQL: every key must be rotated.
+ALTER TABLE api_keys ADD COLUMN secret_hash BYTEA NOT NULL DEFAULT ''::BYTEA;
+ALTER TABLE api_keys ALTER COLUMN secret_hash DROP DEFAULT;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS sealed_secret;
@@ -0,0 +1,15 @@
+-- API key secrets are stored encrypted with the server's key
+-- (auth.secret_key) instead of as SHA-256 digests, which were enough to sign
+-- requests. The digests cannot be turned into secrets: keys issued before
+-- have no sealed secret and must be rotated.
+ALTER TABLE api_keys ADD COLUMN sealed_secret BYTEA;
+ALTER TABLE api_keys DROP COLUMN secret_hash;
+
+-- Nonces of signed requests, remembered for the replay window so that no
+-- instance accepts a request twice.
+CREATE TABLE api_key_nonces (
+    key_id VARCHAR(40) NOT NULL,
+    nonce VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    PRIMARY KEY (key_id, nonce)
+);
@@ -0,0 +1,10 @@
+// Package migrations holds the versioned SQL migrations for the PostgreSQL
+// schema. Files are named <version>_<name>.up.sql / <version>_<name>.down.sql
+// and are embedded into the binary so the app never depends on its working
+// directory to find them.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
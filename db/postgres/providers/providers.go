@@ -1,12 +1,15 @@
 package providers
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"time"
 )
 
 type DBHelper struct {
 	PostgresClient *sql.DB
+	QueryTimeout   time.Duration // per repository call; 0 disables
 }
 
 func NewDbProvider(postgresDBClient *sql.DB) (*DBHelper, error) {
@@ -15,4 +18,13 @@ func NewDbProvider(postgresDBClient *sql.DB) (*DBHelper, error) {
 	}
 	return &DBHelper{PostgresClient: postgresDBClient}, nil
 }
- 
+
+// WithQueryTimeout bounds a repository call by QueryTimeout, so a slow scan
+// cannot hold a pooled connection indefinitely. An earlier deadline already
+// on ctx still wins.
+func (h *DBHelper) WithQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if h.QueryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, h.QueryTimeout)
+}
@@ -0,0 +1,353 @@
+// Package fix is a FIX 4.4 order entry gateway. Counterparties log on over
+// TCP, enter orders with NewOrderSingle, OrderCancelRequest and
+// OrderCancelReplaceRequest, and receive an ExecutionReport for every change
+// to them. A session can opt into having its resting orders canceled when
+// its connection ends. Sequence numbers and sent messages are kept in a
+// repository.FIXRepository, so a session resumes where it left off after a
+// restart.
+package fix
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/shopspring/decimal"
+)
+
+// writeTimeout bounds every write to a counterparty.
+const writeTimeout = 10 * time.Second
+
+// Acceptor accepts FIX sessions and maps their messages onto an
+// OrderService. It must be set as the service's ExecutionListener to send
+// execution reports.
+type Acceptor struct {
+	Config config.FIXConfig
+	Orders *service.OrderService
+	Auth   *service.AuthService // nil logs on any counterparty without a signature
+	Store  repository.FIXRepository
+	Now    func() time.Time // for tests
+
+	listener net.Listener
+	conns    sync.WaitGroup
+
+	mu       sync.Mutex
+	sessions map[string]*session // by counterparty CompID, logged on or not
+	orders   map[int64]*orderRef // open orders entered over FIX
+	closing  bool
+
+	queueMu    sync.Mutex
+	queue      []reportBatch
+	wake       chan struct{}
+	stop       chan struct{}
+	workerDone chan struct{}
+}
+
+func NewAcceptor(cfg config.FIXConfig, orders *service.OrderService, auth *service.AuthService, store repository.FIXRepository) *Acceptor {
+	return &Acceptor{
+		Config:     cfg,
+		Orders:     orders,
+		Auth:       auth,
+		Store:      store,
+		Now:        time.Now,
+		sessions:   make(map[string]*session),
+		orders:     make(map[int64]*orderRef),
+		wake:       make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		workerDone: make(chan struct{}),
+	}
+}
+
+// LogonStringToSign is what a client signs with its API key secret to log
+// on: the Logon's SendingTime, MsgSeqNum, SenderCompID and TargetCompID
+// joined by "\n". The key ID goes in Username (553) and the hex signature in
+// RawData (96).
+func LogonStringToSign(sendingTime, seqNum, senderCompID, targetCompID string) string {
+	return strings.Join([]string{sendingTime, seqNum, senderCompID, targetCompID}, "\n")
+}
+
+// Start loads the open orders entered over FIX and starts accepting
+// connections on Config.Port.
+func (a *Acceptor) Start() error {
+	ctx := context.Background()
+	links, err := a.Store.ListOpenFIXOrders(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("load FIX orders: %w", err)
+	}
+	for _, link := range links {
+		order, err := a.Orders.OrderRepo.GetOrderByID(ctx, nil, link.OrderID)
+		if err != nil {
+			return fmt.Errorf("load FIX order %d: %w", link.OrderID, err)
+		}
+		// Prices of earlier fills are not kept per order, so AvgPx
+		// assumes they were at the limit price
+		filled := order.Quantity.Sub(order.RemainingQty)
+		a.orders[order.ID] = &orderRef{
+			sessionID: link.SessionID,
+			clOrdID:   link.ClOrdID,
+			cumQty:    filled,
+			notional:  filled.Mul(decimal.NewFromFloat(order.Price)),
+		}
+	}
+
+	ln, err := net.Listen("tcp", ":"+strconv.Itoa(a.Config.Port))
+	if err != nil {
+		return err
+	}
+	a.listener = ln
+	go a.reportLoop()
+	go a.acceptLoop()
+	return nil
+}
+
+// Addr returns the address the acceptor listens on.
+func (a *Acceptor) Addr() net.Addr {
+	return a.listener.Addr()
+}
+
+// Close stops accepting connections, logs out every session and waits for
+// the counterparties to confirm, or until ctx is done. Reports still queued
+// are kept for the sessions to recover with a resend request.
+func (a *Acceptor) Close(ctx context.Context) error {
+	a.mu.Lock()
+	a.closing = true
+	sessions := make([]*session, 0, len(a.sessions))
+	for _, s := range a.sessions {
+		sessions = append(sessions, s)
+	}
+	a.mu.Unlock()
+	a.listener.Close()
+
+	for _, s := range sessions {
+		s.logout("server shutting down")
+	}
+	done := make(chan struct{})
+	go func() {
+		a.conns.Wait()
+		close(done)
+	}()
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		for _, s := range sessions {
+			s.disconnect()
+		}
+		<-done
+	}
+
+	close(a.stop)
+	<-a.workerDone
+	return err
+}
+
+func (a *Acceptor) acceptLoop() {
+	for {
+		nc, err := a.listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			log.Printf("FIX: accept: %v", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		a.mu.Lock()
+		if a.closing {
+			a.mu.Unlock()
+			nc.Close()
+			continue
+		}
+		a.conns.Add(1)
+		a.mu.Unlock()
+		go a.serve(nc)
+	}
+}
+
+// serve runs one connection: a Logon, then messages until either side logs
+// out or the connection drops.
+func (a *Acceptor) serve(nc net.Conn) {
+	defer a.conns.Done()
+	defer nc.Close()
+
+	r := bufio.NewReader(nc)
+	nc.SetReadDeadline(time.Now().Add(a.Config.LogonTimeout))
+	raw, err := ReadMessage(r)
+	if err != nil {
+		return
+	}
+	msg, err := Parse(raw)
+	if err != nil || msg.MsgType() != MsgLogon {
+		return
+	}
+	c := a.logon(nc, msg)
+	if c == nil {
+		return
+	}
+	nc.SetReadDeadline(time.Time{})
+
+	go c.monitor()
+	c.readLoop(r)
+	// The session's orders are listed before another connection can log on
+	// and enter more
+	var resting []int64
+	if c.cancelOnDisconnect {
+		resting = a.restingOrders(c.s.id)
+	}
+	c.s.detach(c)
+	if len(resting) > 0 {
+		if _, err := a.Orders.CancelResting(c.ctx, resting, models.ReasonDisconnect); err != nil {
+			log.Printf("FIX: cancel orders of %s on disconnect: %v", c.s.id, err)
+		}
+	}
+}
+
+// restingOrders returns the IDs of the open orders a session entered.
+func (a *Acceptor) restingOrders(sessionID string) []int64 {
+	links, err := a.Store.ListOpenFIXOrders(context.Background(), nil)
+	if err != nil {
+		log.Printf("FIX: list orders of %s: %v", sessionID, err)
+		return nil
+	}
+	var ids []int64
+	for _, link := range links {
+		if link.SessionID == sessionID {
+			ids = append(ids, link.OrderID)
+		}
+	}
+	return ids
+}
+
+// logon validates a Logon and attaches the connection to its session. It
+// returns nil when the logon is refused.
+func (a *Acceptor) logon(nc net.Conn, msg *Message) *conn {
+	sender, target := msg.String(TagSenderCompID), msg.String(TagTargetCompID)
+	if sender == "" || target != a.Config.SenderCompID {
+		refuse(nc, msg, "unknown SenderCompID or TargetCompID")
+		return nil
+	}
+	heartbeat, err := msg.Int(TagHeartBtInt)
+	if err != nil || heartbeat <= 0 {
+		refuse(nc, msg, "HeartBtInt must be a positive number of seconds")
+		return nil
+	}
+
+	ctx := service.WithActor(context.Background(), "fix:"+sender)
+	var accountID int64
+	if a.Auth != nil {
+		principal, err := a.authenticate(msg, sender, target)
+		if err != nil {
+			refuse(nc, msg, errorText(err))
+			return nil
+		}
+		accountID = principal.Account.ID
+		ctx = service.WithActor(context.Background(), fmt.Sprintf("account:%d/%s", accountID, principal.Key.KeyID))
+		ctx = service.WithAccount(ctx, accountID)
+	}
+
+	s, err := a.session(sender, true)
+	if err != nil {
+		log.Printf("FIX: load session %s: %v", sender, err)
+		refuse(nc, msg, "session is unavailable")
+		return nil
+	}
+	c := &conn{
+		Conn:      nc,
+		s:         s,
+		ctx:       ctx,
+		heartbeat: time.Duration(heartbeat) * time.Second,
+		done:      make(chan struct{}),
+
+		cancelOnDisconnect: msg.Bool(TagCancelOrdersOnDisconnect),
+	}
+	now := time.Now().UnixNano()
+	c.lastRecv.Store(now)
+	c.lastSent.Store(now)
+	if !s.attach(c, msg, accountID) {
+		return nil
+	}
+	return c
+}
+
+// authenticate checks the Logon's signature, and that its key may trade.
+func (a *Acceptor) authenticate(msg *Message, sender, target string) (*service.Principal, error) {
+	sendingTime := msg.String(TagSendingTime)
+	sent, err := ParseTime(sendingTime)
+	if err != nil {
+		return nil, apperr.Unauthenticated(apperr.CodeStaleTimestamp, "SendingTime is malformed")
+	}
+	principal, err := a.Auth.AuthenticateLogon(context.Background(), msg.String(TagUsername), strconv.FormatInt(sent.Unix(), 10),
+		msg.String(TagRawData), LogonStringToSign(sendingTime, msg.String(TagMsgSeqNum), sender, target))
+	if err != nil {
+		return nil, err
+	}
+	if !principal.Key.HasScope(models.ScopeTrade) {
+		return nil, apperr.PermissionDenied(apperr.CodeInsufficientScope, "API key lacks the %s scope", models.ScopeTrade)
+	}
+	if !principal.Account.HasRole(models.RoleTrader) {
+		return nil, apperr.PermissionDenied(apperr.CodeInsufficientRole, "account role %s cannot trade", principal.Account.Role)
+	}
+	return principal, nil
+}
+
+// session returns the session with a counterparty, loading it from the
+// store; create starts a new one when there is none.
+func (a *Acceptor) session(id string, create bool) (*session, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if s := a.sessions[id]; s != nil {
+		return s, nil
+	}
+
+	state, err := a.Store.GetFIXSession(context.Background(), nil, id)
+	if apperr.KindOf(err) == apperr.KindNotFound && create {
+		state, err = &models.FIXSession{ID: id, NextSenderSeq: 1, NextTargetSeq: 1}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := &session{a: a, id: id, state: *state}
+	a.sessions[id] = s
+	return s, nil
+}
+
+func (a *Acceptor) now() time.Time {
+	return a.Now().UTC()
+}
+
+// refuse answers a Logon that is not accepted. No session is attached, so
+// the Logout is numbered 1 and is not kept: refused attempts must not move a
+// session's sequence numbers.
+func refuse(nc net.Conn, logon *Message, text string) {
+	m := NewMessage(MsgLogout).
+		Set(TagSenderCompID, logon.String(TagTargetCompID)).
+		Set(TagTargetCompID, logon.String(TagSenderCompID)).
+		SetInt(TagMsgSeqNum, 1).
+		Set(TagSendingTime, time.Now().UTC().Format(TimeFormat)).
+		Set(TagText, text)
+	nc.SetWriteDeadline(time.Now().Add(writeTimeout))
+	nc.Write(m.Bytes())
+}
+
+// errorText is the Text sent for a failed request: the message of domain
+// errors, nothing more of internal ones.
+func errorText(err error) string {
+	if appErr, ok := apperr.As(err); ok && appErr.Kind != apperr.KindInternal {
+		return appErr.Message
+	}
+	return "internal error"
+}
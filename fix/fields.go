@@ -0,0 +1,153 @@
+package fix
+
+// BeginString is the only protocol version spoken.
+const BeginString = "FIX.4.4"
+
+// Tags used by the gateway
+const (
+	TagAccount              = 1
+	TagAvgPx                = 6
+	TagBeginSeqNo           = 7
+	TagBeginString          = 8
+	TagBodyLength           = 9
+	TagCheckSum             = 10
+	TagClOrdID              = 11
+	TagCumQty               = 14
+	TagEndSeqNo             = 16
+	TagExecID               = 17
+	TagLastPx               = 31
+	TagLastQty              = 32
+	TagMsgSeqNum            = 34
+	TagMsgType              = 35
+	TagNewSeqNo             = 36
+	TagOrderID              = 37
+	TagOrderQty             = 38
+	TagOrdStatus            = 39
+	TagOrdType              = 40
+	TagOrigClOrdID          = 41
+	TagPossDupFlag          = 43
+	TagPrice                = 44
+	TagRefSeqNum            = 45
+	TagSenderCompID         = 49
+	TagSendingTime          = 52
+	TagSide                 = 54
+	TagSymbol               = 55
+	TagTargetCompID         = 56
+	TagText                 = 58
+	TagTransactTime         = 60
+	TagRawDataLength        = 95
+	TagRawData              = 96
+	TagEncryptMethod        = 98
+	TagCxlRejReason         = 102
+	TagOrdRejReason         = 103
+	TagHeartBtInt           = 108
+	TagTestReqID            = 112
+	TagOrigSendingTime      = 122
+	TagGapFillFlag          = 123
+	TagResetSeqNumFlag      = 141
+	TagExecType             = 150
+	TagLeavesQty            = 151
+	TagRefTagID             = 371
+	TagRefMsgType           = 372
+	TagSessionRejectReason  = 373
+	TagBusinessRejectReason = 380
+	TagCxlRejResponseTo     = 434
+	TagUsername             = 553
+	TagTradeID              = 1003
+
+	// TagCancelOrdersOnDisconnect is a user defined Logon tag: Y opts the
+	// session into cancel-on-disconnect, and is echoed on the reply.
+	TagCancelOrdersOnDisconnect = 8013
+)
+
+// Message types
+const (
+	MsgHeartbeat                 = "0"
+	MsgTestRequest               = "1"
+	MsgResendRequest             = "2"
+	MsgReject                    = "3"
+	MsgSequenceReset             = "4"
+	MsgLogout                    = "5"
+	MsgExecutionReport           = "8"
+	MsgOrderCancelReject         = "9"
+	MsgLogon                     = "A"
+	MsgNewOrderSingle            = "D"
+	MsgOrderCancelRequest        = "F"
+	MsgOrderCancelReplaceRequest = "G"
+	MsgOrderStatusRequest        = "H"
+	MsgBusinessMessageReject     = "j"
+)
+
+// isAdmin reports whether a message type belongs to the session layer.
+// Admin messages are never resent; a resend request gap fills them.
+func isAdmin(msgType string) bool {
+	switch msgType {
+	case MsgHeartbeat, MsgTestRequest, MsgResendRequest, MsgReject, MsgSequenceReset, MsgLogout, MsgLogon:
+		return true
+	}
+	return false
+}
+
+// Side values
+const (
+	SideBuy  = "1"
+	SideSell = "2"
+)
+
+// OrdType values
+const (
+	OrdTypeMarket = "1"
+	OrdTypeLimit  = "2"
+)
+
+// OrdStatus values
+const (
+	OrdStatusNew             = "0"
+	OrdStatusPartiallyFilled = "1"
+	OrdStatusFilled          = "2"
+	OrdStatusCanceled        = "4"
+	OrdStatusRejected        = "8"
+)
+
+// ExecType values
+const (
+	ExecTypeNew         = "0"
+	ExecTypeCanceled    = "4"
+	ExecTypeReplaced    = "5"
+	ExecTypeRejected    = "8"
+	ExecTypeTrade       = "F"
+	ExecTypeOrderStatus = "I"
+)
+
+// OrdRejReason values
+const (
+	OrdRejUnknownSymbol = "1"
+	OrdRejUnknownOrder  = "5"
+	OrdRejDuplicate     = "6"
+	OrdRejOther         = "99"
+)
+
+// CxlRejReason values
+const (
+	CxlRejTooLate      = "0"
+	CxlRejUnknownOrder = "1"
+	CxlRejOther        = "99"
+)
+
+// CxlRejResponseTo values
+const (
+	CxlRejResponseToCancel  = "1"
+	CxlRejResponseToReplace = "2"
+)
+
+// SessionRejectReason values
+const (
+	SessionRejectRequiredTagMissing = "1"
+	SessionRejectValueIncorrect     = "5"
+	SessionRejectInvalidMsgType     = "11"
+)
+
+// BusinessRejectReason values
+const (
+	BusinessRejectUnsupportedMsgType = "3"
+)
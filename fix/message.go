@@ -0,0 +1,286 @@
+package fix
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const soh = '\x01'
+
+// maxBodyLength bounds the messages read from a connection.
+const maxBodyLength = 64 << 10
+
+// TimeFormat is the format of UTCTimestamp fields such as SendingTime.
+const TimeFormat = "20060102-15:04:05.000"
+
+// ParseTime reads a UTCTimestamp, with or without milliseconds.
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(TimeFormat, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("20060102-15:04:05", s)
+}
+
+// Field is one tag=value pair.
+type Field struct {
+	Tag   int
+	Value string
+}
+
+// Message is a FIX message without its framing: BeginString, BodyLength and
+// CheckSum are added by Bytes and checked by Parse. Fields keep the order
+// they were set in, except that the standard header is always written
+// first.
+type Message struct {
+	Fields []Field
+}
+
+// NewMessage returns an empty message of msgType.
+func NewMessage(msgType string) *Message {
+	return (&Message{}).Set(TagMsgType, msgType)
+}
+
+// Set replaces the value of tag, or appends it.
+func (m *Message) Set(tag int, value string) *Message {
+	for i := range m.Fields {
+		if m.Fields[i].Tag == tag {
+			m.Fields[i].Value = value
+			return m
+		}
+	}
+	m.Fields = append(m.Fields, Field{Tag: tag, Value: value})
+	return m
+}
+
+// SetInt sets tag to an integer.
+func (m *Message) SetInt(tag, value int) *Message {
+	return m.Set(tag, strconv.Itoa(value))
+}
+
+// SetFloat sets tag to a decimal, without trailing zeros.
+func (m *Message) SetFloat(tag int, value float64) *Message {
+	return m.Set(tag, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// SetDecimal sets tag to a fixed-point decimal, without trailing zeros.
+func (m *Message) SetDecimal(tag int, value decimal.Decimal) *Message {
+	return m.Set(tag, value.String())
+}
+
+// Get returns the value of tag and whether it is present.
+func (m *Message) Get(tag int) (string, bool) {
+	for _, f := range m.Fields {
+		if f.Tag == tag {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// String returns the value of tag, or "" when absent.
+func (m *Message) String(tag int) string {
+	v, _ := m.Get(tag)
+	return v
+}
+
+// Int returns the value of tag as an integer.
+func (m *Message) Int(tag int) (int, error) {
+	v, ok := m.Get(tag)
+	if !ok {
+		return 0, fmt.Errorf("tag %d is missing", tag)
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("tag %d: %q is not an integer", tag, v)
+	}
+	return n, nil
+}
+
+// Float returns the value of tag as a decimal.
+func (m *Message) Float(tag int) (float64, error) {
+	v, ok := m.Get(tag)
+	if !ok {
+		return 0, fmt.Errorf("tag %d is missing", tag)
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("tag %d: %q is not a number", tag, v)
+	}
+	return f, nil
+}
+
+// Decimal returns the value of tag as a fixed-point decimal.
+func (m *Message) Decimal(tag int) (decimal.Decimal, error) {
+	v, ok := m.Get(tag)
+	if !ok {
+		return decimal.Zero, fmt.Errorf("tag %d is missing", tag)
+	}
+	d, err := decimal.NewFromString(v)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("tag %d: %q is not a number", tag, v)
+	}
+	return d, nil
+}
+
+// Bool reports whether tag is "Y".
+func (m *Message) Bool(tag int) bool {
+	return m.String(tag) == "Y"
+}
+
+// MsgType returns the message type.
+func (m *Message) MsgType() string {
+	return m.String(TagMsgType)
+}
+
+// SeqNum returns MsgSeqNum, or 0 when it is missing or malformed.
+func (m *Message) SeqNum() int {
+	n, _ := m.Int(TagMsgSeqNum)
+	return n
+}
+
+// headerTags are written first, in this order, when present.
+var headerTags = []int{TagMsgType, TagSenderCompID, TagTargetCompID, TagMsgSeqNum, TagPossDupFlag, TagSendingTime, TagOrigSendingTime}
+
+func isHeader(tag int) bool {
+	for _, t := range headerTags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Bytes encodes the message with its framing.
+func (m *Message) Bytes() []byte {
+	var body bytes.Buffer
+	write := func(tag int, value string) {
+		body.WriteString(strconv.Itoa(tag))
+		body.WriteByte('=')
+		body.WriteString(value)
+		body.WriteByte(soh)
+	}
+	for _, tag := range headerTags {
+		if v, ok := m.Get(tag); ok {
+			write(tag, v)
+		}
+	}
+	for _, f := range m.Fields {
+		if !isHeader(f.Tag) {
+			write(f.Tag, f.Value)
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "8=%s\x019=%d\x01", BeginString, body.Len())
+	out.Write(body.Bytes())
+	fmt.Fprintf(&out, "10=%03d\x01", checksum(out.Bytes()))
+	return out.Bytes()
+}
+
+func checksum(b []byte) int {
+	var sum int
+	for _, c := range b {
+		sum += int(c)
+	}
+	return sum % 256
+}
+
+// Errors of malformed input
+var (
+	ErrGarbled        = errors.New("fix: garbled message")
+	ErrBadChecksum    = errors.New("fix: checksum mismatch")
+	ErrBadBeginString = errors.New("fix: unsupported BeginString")
+	ErrBodyTooLarge   = errors.New("fix: body length exceeds the limit")
+	ErrNoMsgType      = errors.New("fix: MsgType missing")
+)
+
+// ReadMessage reads the next whole message from r, framing included.
+func ReadMessage(r *bufio.Reader) ([]byte, error) {
+	begin, err := r.ReadSlice(soh)
+	if err != nil {
+		return nil, err
+	}
+	raw := append([]byte(nil), begin...)
+	if !bytes.HasPrefix(raw, []byte("8=")) {
+		return nil, ErrGarbled
+	}
+
+	lengthField, err := r.ReadSlice(soh)
+	if err != nil {
+		return nil, noEOF(err)
+	}
+	raw = append(raw, lengthField...)
+	if !bytes.HasPrefix(lengthField, []byte("9=")) {
+		return nil, ErrGarbled
+	}
+	n, err := strconv.Atoi(string(lengthField[2 : len(lengthField)-1]))
+	if err != nil || n < 0 {
+		return nil, ErrGarbled
+	}
+	if n > maxBodyLength {
+		return nil, ErrBodyTooLarge
+	}
+
+	// The body, then "10=NNN\x01"
+	rest := make([]byte, n+7)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return nil, noEOF(err)
+	}
+	return append(raw, rest...), nil
+}
+
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Parse decodes a whole message read by ReadMessage, checking its framing.
+func Parse(raw []byte) (*Message, error) {
+	if len(raw) == 0 || raw[len(raw)-1] != soh {
+		return nil, ErrGarbled
+	}
+	var fields []Field
+	for _, part := range bytes.Split(raw[:len(raw)-1], []byte{soh}) {
+		tag, value, ok := bytes.Cut(part, []byte{'='})
+		if !ok {
+			return nil, ErrGarbled
+		}
+		n, err := strconv.Atoi(string(tag))
+		if err != nil || n <= 0 {
+			return nil, ErrGarbled
+		}
+		fields = append(fields, Field{Tag: n, Value: string(value)})
+	}
+	if len(fields) < 4 || fields[0].Tag != TagBeginString || fields[1].Tag != TagBodyLength || fields[len(fields)-1].Tag != TagCheckSum {
+		return nil, ErrGarbled
+	}
+	if fields[0].Value != BeginString {
+		return nil, ErrBadBeginString
+	}
+
+	// BodyLength counts from after its own field up to the CheckSum field
+	first := bytes.IndexByte(raw, soh)
+	bodyStart := first + 1 + bytes.IndexByte(raw[first+1:], soh) + 1
+	trailer := bytes.LastIndexByte(raw[:len(raw)-1], soh) + 1
+	if length, _ := strconv.Atoi(fields[1].Value); length != trailer-bodyStart {
+		return nil, ErrGarbled
+	}
+	if sum, err := strconv.Atoi(fields[len(fields)-1].Value); err != nil || sum != checksum(raw[:trailer]) {
+		return nil, ErrBadChecksum
+	}
+
+	m := &Message{Fields: fields[2 : len(fields)-1]}
+	if m.MsgType() == "" {
+		return nil, ErrNoMsgType
+	}
+	return m, nil
+}
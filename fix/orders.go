@@ -0,0 +1,433 @@
+package fix
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/Puneet-Vishnoi/order-matching-engine/utils"
+	"github.com/shopspring/decimal"
+)
+
+var sides = map[string]string{SideBuy: "buy", SideSell: "sell"}
+
+var ordTypes = map[string]string{OrdTypeMarket: "market", OrdTypeLimit: "limit"}
+
+var ordStatuses = map[string]string{
+	"open":     OrdStatusNew,
+	"partial":  OrdStatusPartiallyFilled,
+	"filled":   OrdStatusFilled,
+	"canceled": OrdStatusCanceled,
+}
+
+// orderRef is what the gateway tracks of an open order entered over FIX.
+type orderRef struct {
+	sessionID string
+	clOrdID   string
+	cumQty    decimal.Decimal
+	notional  decimal.Decimal // of the fills, for AvgPx
+}
+
+func (r *orderRef) avgPx() float64 {
+	if r.cumQty.IsZero() {
+		return 0
+	}
+	return r.notional.Div(r.cumQty).InexactFloat64()
+}
+
+// origin identifies the FIX request an order change was made for, so its
+// reports carry the request's ClOrdID.
+type origin struct {
+	sessionID   string
+	clOrdID     string
+	origClOrdID string // of the order canceled or replaced
+}
+
+type originKey struct{}
+
+func withOrigin(ctx context.Context, o *origin) context.Context {
+	return context.WithValue(ctx, originKey{}, o)
+}
+
+func (c *conn) newOrderSingle(msg *Message) {
+	if !c.require(msg, TagClOrdID, TagSymbol, TagSide, TagOrderQty, TagOrdType) {
+		return
+	}
+	clOrdID := msg.String(TagClOrdID)
+	reject := func(reason, text string) {
+		c.send(c.rejected(msg, reason, text))
+	}
+
+	req := &models.PlaceOrderRequest{Symbol: msg.String(TagSymbol)}
+	var ok bool
+	if req.Side, ok = sides[msg.String(TagSide)]; !ok {
+		reject(OrdRejOther, "unsupported Side")
+		return
+	}
+	if req.Type, ok = ordTypes[msg.String(TagOrdType)]; !ok {
+		reject(OrdRejOther, "unsupported OrdType")
+		return
+	}
+	var err error
+	if req.Quantity, err = msg.Decimal(TagOrderQty); err != nil {
+		reject(OrdRejOther, err.Error())
+		return
+	}
+	if req.Type == "limit" {
+		if req.Price, err = msg.Float(TagPrice); err != nil {
+			reject(OrdRejOther, err.Error())
+			return
+		}
+	}
+	if err := utils.GetValidator().Struct(req); err != nil {
+		reject(OrdRejOther, "order validation failed")
+		return
+	}
+	if c.openClOrdID(clOrdID) {
+		reject(OrdRejDuplicate, "ClOrdID is used by an open order")
+		return
+	}
+
+	resp, err := c.s.a.Orders.PlaceOrder(withOrigin(c.ctx, &origin{sessionID: c.s.id, clOrdID: clOrdID}), req)
+	if err != nil {
+		reject(OrdRejOther, errorText(err))
+		return
+	}
+	c.link(resp.OrderID, clOrdID)
+}
+
+func (c *conn) cancelRequest(msg *Message) {
+	if !c.require(msg, TagOrigClOrdID, TagClOrdID, TagSymbol, TagSide) {
+		return
+	}
+	link, err := c.lookup(msg.String(TagOrigClOrdID))
+	if err != nil {
+		c.cancelReject(msg, CxlRejResponseToCancel, 0, err)
+		return
+	}
+
+	ctx := withOrigin(c.ctx, &origin{sessionID: c.s.id, clOrdID: msg.String(TagClOrdID), origClOrdID: link.ClOrdID})
+	if _, err := c.s.a.Orders.CancelOrder(ctx, strconv.FormatInt(link.OrderID, 10)); err != nil {
+		c.cancelReject(msg, CxlRejResponseToCancel, link.OrderID, err)
+	}
+}
+
+func (c *conn) replaceRequest(msg *Message) {
+	if !c.require(msg, TagOrigClOrdID, TagClOrdID, TagSymbol, TagSide, TagOrderQty, TagOrdType, TagPrice) {
+		return
+	}
+	link, err := c.lookup(msg.String(TagOrigClOrdID))
+	if err != nil {
+		c.cancelReject(msg, CxlRejResponseToReplace, 0, err)
+		return
+	}
+	if msg.String(TagOrdType) != OrdTypeLimit {
+		c.cancelReject(msg, CxlRejResponseToReplace, link.OrderID, apperr.InvalidArgument(apperr.CodeInvalidRequest, "only limit orders can be replaced"))
+		return
+	}
+	req := &models.ReplaceOrderRequest{}
+	if req.Quantity, err = msg.Decimal(TagOrderQty); err == nil {
+		req.Price, err = msg.Float(TagPrice)
+	}
+	if err == nil {
+		err = utils.GetValidator().Struct(req)
+	}
+	if err != nil {
+		c.cancelReject(msg, CxlRejResponseToReplace, link.OrderID, apperr.InvalidArgument(apperr.CodeValidationFailed, "replacement validation failed"))
+		return
+	}
+
+	clOrdID := msg.String(TagClOrdID)
+	ctx := withOrigin(c.ctx, &origin{sessionID: c.s.id, clOrdID: clOrdID, origClOrdID: link.ClOrdID})
+	resp, err := c.s.a.Orders.ReplaceOrder(ctx, strconv.FormatInt(link.OrderID, 10), req)
+	if err != nil {
+		c.cancelReject(msg, CxlRejResponseToReplace, link.OrderID, err)
+		return
+	}
+	c.link(resp.OrderID, clOrdID)
+}
+
+func (c *conn) statusRequest(msg *Message) {
+	if !c.require(msg, TagClOrdID) {
+		return
+	}
+	a := c.s.a
+	clOrdID := msg.String(TagClOrdID)
+	report := NewMessage(MsgExecutionReport).
+		Set(TagClOrdID, clOrdID).
+		Set(TagExecID, fmt.Sprintf("S%d", a.now().UnixNano())).
+		Set(TagExecType, ExecTypeOrderStatus).
+		Set(TagSymbol, msg.String(TagSymbol)).
+		Set(TagSide, msg.String(TagSide))
+
+	var status *models.OrderStatusResponse
+	link, err := c.lookup(clOrdID)
+	if err == nil {
+		status, err = a.Orders.GetOrderStatus(c.ctx, strconv.FormatInt(link.OrderID, 10))
+	}
+	if err != nil {
+		c.send(report.Set(TagOrderID, "NONE").
+			Set(TagOrdStatus, OrdStatusRejected).
+			Set(TagOrdRejReason, OrdRejUnknownOrder).
+			SetInt(TagCumQty, 0).
+			SetInt(TagLeavesQty, 0).
+			SetInt(TagAvgPx, 0).
+			Set(TagText, errorText(err)))
+		return
+	}
+
+	var avgPx float64
+	a.mu.Lock()
+	if ref := a.orders[status.OrderID]; ref != nil {
+		avgPx = ref.avgPx()
+	}
+	a.mu.Unlock()
+	c.send(report.Set(TagOrderID, strconv.FormatInt(status.OrderID, 10)).
+		Set(TagOrdStatus, ordStatuses[status.Status]).
+		SetDecimal(TagCumQty, status.ExecutedQuantity).
+		SetDecimal(TagLeavesQty, status.RemainingQuantity).
+		SetFloat(TagAvgPx, avgPx).
+		Set(TagTransactTime, a.now().Format(TimeFormat)))
+}
+
+// rejected is the ExecutionReport of a NewOrderSingle that was not
+// accepted.
+func (c *conn) rejected(msg *Message, reason, text string) *Message {
+	a := c.s.a
+	return NewMessage(MsgExecutionReport).
+		Set(TagOrderID, "NONE").
+		Set(TagClOrdID, msg.String(TagClOrdID)).
+		Set(TagExecID, fmt.Sprintf("X%d", a.now().UnixNano())).
+		Set(TagExecType, ExecTypeRejected).
+		Set(TagOrdStatus, OrdStatusRejected).
+		Set(TagOrdRejReason, reason).
+		Set(TagSymbol, msg.String(TagSymbol)).
+		Set(TagSide, msg.String(TagSide)).
+		Set(TagOrderQty, msg.String(TagOrderQty)).
+		SetInt(TagCumQty, 0).
+		SetInt(TagLeavesQty, 0).
+		SetInt(TagAvgPx, 0).
+		Set(TagText, text).
+		Set(TagTransactTime, a.now().Format(TimeFormat))
+}
+
+// cancelReject answers a cancel or replace request that failed; orderID is
+// 0 when the order is unknown.
+func (c *conn) cancelReject(msg *Message, responseTo string, orderID int64, err error) {
+	reason := CxlRejOther
+	switch apperr.KindOf(err) {
+	case apperr.KindNotFound:
+		reason = CxlRejUnknownOrder
+	case apperr.KindConflict:
+		reason = CxlRejTooLate
+	}
+	reply := NewMessage(MsgOrderCancelReject).
+		Set(TagOrderID, "NONE").
+		Set(TagClOrdID, msg.String(TagClOrdID)).
+		Set(TagOrigClOrdID, msg.String(TagOrigClOrdID)).
+		Set(TagOrdStatus, OrdStatusRejected).
+		Set(TagCxlRejResponseTo, responseTo).
+		Set(TagCxlRejReason, reason).
+		Set(TagText, errorText(err))
+	if orderID != 0 {
+		id := strconv.FormatInt(orderID, 10)
+		reply.Set(TagOrderID, id)
+		if status, err := c.s.a.Orders.GetOrderStatus(c.ctx, id); err == nil {
+			reply.Set(TagOrdStatus, ordStatuses[status.Status])
+		}
+	}
+	c.send(reply)
+}
+
+// lookup finds the latest order the session sent with clOrdID.
+func (c *conn) lookup(clOrdID string) (*models.FIXOrder, error) {
+	return c.s.a.Store.GetFIXOrderByClOrdID(context.Background(), nil, c.s.id, clOrdID)
+}
+
+// openClOrdID reports whether clOrdID belongs to an order that is still
+// open.
+func (c *conn) openClOrdID(clOrdID string) bool {
+	link, err := c.lookup(clOrdID)
+	if err != nil {
+		return false
+	}
+	status, err := c.s.a.Orders.GetOrderStatus(c.ctx, strconv.FormatInt(link.OrderID, 10))
+	return err == nil && (status.Status == "open" || status.Status == "partial")
+}
+
+// link records the ClOrdID an order was entered with. It is written once
+// the order has committed, not with it: if it fails, the order still trades
+// and is reported, but cannot be canceled or replaced by ClOrdID.
+func (c *conn) link(orderID int64, clOrdID string) {
+	a := c.s.a
+	ctx := context.Background()
+	err := a.Orders.TxRunner.Run(ctx, nil, func(tx repository.Tx) error {
+		return a.Store.CreateFIXOrder(ctx, tx, &models.FIXOrder{OrderID: orderID, SessionID: c.s.id, ClOrdID: clOrdID, CreatedAt: a.now()})
+	})
+	if err != nil {
+		log.Printf("FIX: link order %d to ClOrdID %s: %v", orderID, clOrdID, err)
+	}
+}
+
+// reportBatch is the executions of one order entry call.
+type reportBatch struct {
+	origin     *origin
+	executions []models.Execution
+}
+
+// OrdersExecuted queues execution reports for the orders entered over FIX;
+// they are sent in order by a worker, so order entry never waits on a
+// counterparty.
+func (a *Acceptor) OrdersExecuted(ctx context.Context, executions []models.Execution) {
+	o, _ := ctx.Value(originKey{}).(*origin)
+	a.queueMu.Lock()
+	a.queue = append(a.queue, reportBatch{origin: o, executions: executions})
+	a.queueMu.Unlock()
+
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (a *Acceptor) reportLoop() {
+	defer close(a.workerDone)
+	for {
+		select {
+		case <-a.wake:
+			a.drainReports()
+		case <-a.stop:
+			a.drainReports()
+			return
+		}
+	}
+}
+
+func (a *Acceptor) drainReports() {
+	for {
+		a.queueMu.Lock()
+		batches := a.queue
+		a.queue = nil
+		a.queueMu.Unlock()
+		if len(batches) == 0 {
+			return
+		}
+		for _, b := range batches {
+			a.report(b)
+		}
+	}
+}
+
+func (a *Acceptor) report(b reportBatch) {
+	for i := range b.executions {
+		e := &b.executions[i]
+		a.mu.Lock()
+		ref := a.track(b.origin, e)
+		if ref == nil {
+			a.mu.Unlock()
+			continue
+		}
+		msg := a.executionReport(ref, b.origin, e)
+		sessionID := ref.sessionID
+		a.mu.Unlock()
+
+		s, err := a.session(sessionID, false)
+		if err == nil {
+			err = s.send(msg)
+		}
+		if err != nil {
+			log.Printf("FIX: report order %d to %s: %v", e.Order.ID, sessionID, err)
+		}
+	}
+}
+
+// track updates the order an execution is about, starting to track orders
+// entered over FIX and forgetting those that are done. It returns nil for
+// orders the gateway does not report on. a.mu must be held.
+func (a *Acceptor) track(o *origin, e *models.Execution) *orderRef {
+	ref := a.orders[e.Order.ID]
+	if ref == nil && o != nil && (e.Type == models.ExecNew || e.Type == models.ExecReplaced) {
+		ref = &orderRef{sessionID: o.sessionID, clOrdID: o.clOrdID}
+		if prev := a.orders[e.OrigOrderID]; e.Type == models.ExecReplaced && prev != nil {
+			ref.cumQty, ref.notional = prev.cumQty, prev.notional
+			delete(a.orders, e.OrigOrderID)
+		}
+		a.orders[e.Order.ID] = ref
+	}
+	if ref == nil {
+		return nil
+	}
+
+	if e.Trade != nil {
+		ref.cumQty = ref.cumQty.Add(e.Trade.Quantity)
+		ref.notional = ref.notional.Add(e.Trade.Quantity.Mul(decimal.NewFromFloat(e.Trade.Price)))
+	}
+	if e.Order.Status == "filled" || e.Order.Status == "canceled" {
+		delete(a.orders, e.Order.ID)
+	}
+	return ref
+}
+
+// executionReport describes an execution of a tracked order. a.mu must be
+// held.
+func (a *Acceptor) executionReport(ref *orderRef, o *origin, e *models.Execution) *Message {
+	order := &e.Order
+	id := strconv.FormatInt(order.ID, 10)
+	msg := NewMessage(MsgExecutionReport).
+		Set(TagOrderID, id).
+		Set(TagClOrdID, ref.clOrdID)
+
+	switch e.Type {
+	case models.ExecNew:
+		msg.Set(TagExecID, "N"+id).Set(TagExecType, ExecTypeNew)
+	case models.ExecTrade:
+		side := "B"
+		if order.Side == "sell" {
+			side = "S"
+		}
+		msg.Set(TagExecID, fmt.Sprintf("T%d-%s", e.Trade.ID, side)).
+			Set(TagExecType, ExecTypeTrade).
+			SetDecimal(TagLastQty, e.Trade.Quantity).
+			SetFloat(TagLastPx, e.Trade.Price).
+			Set(TagTradeID, strconv.FormatInt(e.Trade.ID, 10))
+	case models.ExecCanceled:
+		msg.Set(TagExecID, "C"+id).Set(TagExecType, ExecTypeCanceled).Set(TagText, e.Reason)
+		if o != nil && o.origClOrdID != "" {
+			// Canceled on request rather than by the engine
+			msg.Set(TagClOrdID, o.clOrdID).Set(TagOrigClOrdID, o.origClOrdID)
+		}
+	case models.ExecReplaced:
+		msg.Set(TagExecID, "R"+id).Set(TagExecType, ExecTypeReplaced)
+		if o != nil {
+			msg.Set(TagOrigClOrdID, o.origClOrdID)
+		}
+	}
+
+	side := SideBuy
+	if order.Side == "sell" {
+		side = SideSell
+	}
+	ordType := OrdTypeLimit
+	if order.Type == "market" {
+		ordType = OrdTypeMarket
+	}
+	msg.Set(TagOrdStatus, ordStatuses[order.Status]).
+		Set(TagSymbol, order.Symbol).
+		Set(TagSide, side).
+		Set(TagOrdType, ordType).
+		SetDecimal(TagOrderQty, order.Quantity)
+	if order.Type == "limit" {
+		msg.SetFloat(TagPrice, order.Price)
+	}
+	if order.AccountID != 0 {
+		msg.Set(TagAccount, strconv.FormatInt(order.AccountID, 10))
+	}
+	return msg.SetDecimal(TagCumQty, ref.cumQty).
+		SetDecimal(TagLeavesQty, order.RemainingQty).
+		SetFloat(TagAvgPx, ref.avgPx()).
+		Set(TagTransactTime, a.now().Format(TimeFormat))
+}
@@ -0,0 +1,474 @@
+package fix
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+)
+
+// session is the state of a FIX session with one counterparty. It outlives
+// connections: reports for a counterparty that is logged out are numbered
+// and kept like any other, and it recovers them with a resend request.
+type session struct {
+	a  *Acceptor
+	id string // the counterparty's CompID
+
+	mu    sync.Mutex // serializes sends, and guards state and conn
+	state models.FIXSession
+	conn  *conn // nil while logged out
+}
+
+// attach makes c the session's connection after a valid Logon, answering
+// it. It returns false when the logon is refused.
+func (s *session) attach(c *conn, logon *Message, accountID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		refuse(c, logon, "session is already logged on")
+		return false
+	}
+	if accountID != 0 && s.state.AccountID != 0 && s.state.AccountID != accountID {
+		refuse(c, logon, "session belongs to another account")
+		return false
+	}
+	if accountID != 0 {
+		s.state.AccountID = accountID
+	}
+
+	reset := logon.Bool(TagResetSeqNumFlag)
+	if reset {
+		if err := s.a.Store.DeleteFIXMessages(context.Background(), nil, s.id); err != nil {
+			log.Printf("FIX: reset session %s: %v", s.id, err)
+			refuse(c, logon, "session is unavailable")
+			return false
+		}
+		s.state.NextSenderSeq, s.state.NextTargetSeq = 1, 1
+	}
+
+	s.conn = c
+	expected, seq := s.state.NextTargetSeq, logon.SeqNum()
+	if seq < expected {
+		s.sendLocked(NewMessage(MsgLogout).Set(TagText, fmt.Sprintf("MsgSeqNum too low, expecting %d but received %d", expected, seq)))
+		s.conn = nil
+		return false
+	}
+	gap := seq > expected
+	if !gap {
+		s.state.NextTargetSeq = seq + 1
+	}
+
+	reply := NewMessage(MsgLogon).SetInt(TagEncryptMethod, 0).SetInt(TagHeartBtInt, int(c.heartbeat/time.Second))
+	if reset {
+		reply.Set(TagResetSeqNumFlag, "Y")
+	}
+	if c.cancelOnDisconnect {
+		reply.Set(TagCancelOrdersOnDisconnect, "Y")
+	}
+	if err := s.sendLocked(reply); err != nil {
+		log.Printf("FIX: log on session %s: %v", s.id, err)
+		s.conn = nil
+		return false
+	}
+	if gap {
+		c.resendTo = seq
+		s.sendLocked(resendRequest(expected))
+	}
+	return true
+}
+
+// detach forgets c once its connection has ended.
+func (s *session) detach(c *conn) {
+	s.mu.Lock()
+	if s.conn == c {
+		s.conn = nil
+	}
+	s.mu.Unlock()
+	close(c.done)
+}
+
+// logout starts the logout handshake, if the session is logged on.
+func (s *session) logout(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		s.conn.logoutLocked(text)
+	}
+}
+
+// disconnect drops the connection without waiting for the counterparty.
+func (s *session) disconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		s.conn.Close()
+	}
+}
+
+// send numbers msg, keeps it, and writes it if the counterparty is logged
+// on.
+func (s *session) send(msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sendLocked(msg)
+}
+
+func (s *session) sendLocked(msg *Message) error {
+	a := s.a
+	seq := s.state.NextSenderSeq
+	s.header(msg, seq)
+	raw := msg.Bytes()
+
+	next := s.state
+	next.NextSenderSeq++
+	next.UpdatedAt = a.now()
+	ctx := context.Background()
+	err := a.Orders.TxRunner.Run(ctx, nil, func(tx repository.Tx) error {
+		if err := a.Store.SaveFIXSession(ctx, tx, &next); err != nil {
+			return err
+		}
+		if isAdmin(msg.MsgType()) {
+			return nil
+		}
+		return a.Store.SaveFIXMessage(ctx, tx, &models.FIXMessage{SessionID: s.id, Seq: seq, Body: raw})
+	})
+	if err != nil {
+		return err
+	}
+	s.state = next
+
+	if s.conn != nil {
+		s.conn.write(raw)
+	}
+	return nil
+}
+
+func (s *session) header(msg *Message, seq int) {
+	msg.Set(TagSenderCompID, s.a.Config.SenderCompID).
+		Set(TagTargetCompID, s.id).
+		SetInt(TagMsgSeqNum, seq).
+		Set(TagSendingTime, s.a.now().Format(TimeFormat))
+}
+
+// targetSeq returns the MsgSeqNum expected of the counterparty next.
+func (s *session) targetSeq() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state.NextTargetSeq
+}
+
+// setTargetSeq records that messages up to seq-1 have been received.
+func (s *session) setTargetSeq(seq int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := s.state
+	next.NextTargetSeq = seq
+	next.UpdatedAt = s.a.now()
+	if err := s.a.Store.SaveFIXSession(context.Background(), nil, &next); err != nil {
+		return err
+	}
+	s.state = next
+	return nil
+}
+
+// resend answers a ResendRequest: kept application messages go again with
+// PossDupFlag, and the gaps between them, left by session messages, are
+// filled with SequenceReset-GapFill.
+func (s *session) resend(begin, end int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	last := s.state.NextSenderSeq - 1
+	if end == 0 || end > last {
+		end = last
+	}
+	if begin > end {
+		return nil
+	}
+
+	kept, err := s.a.Store.ListFIXMessages(context.Background(), nil, s.id, begin, end)
+	if err != nil {
+		return err
+	}
+	next := begin
+	gapFill := func(upTo int) {
+		if upTo <= next {
+			return
+		}
+		m := NewMessage(MsgSequenceReset).Set(TagPossDupFlag, "Y").Set(TagGapFillFlag, "Y").SetInt(TagNewSeqNo, upTo)
+		s.header(m, next)
+		s.conn.write(m.Bytes())
+	}
+	for _, k := range kept {
+		m, err := Parse(k.Body)
+		if err != nil {
+			return fmt.Errorf("kept message %d: %w", k.Seq, err)
+		}
+		gapFill(k.Seq)
+		m.Set(TagPossDupFlag, "Y").
+			Set(TagOrigSendingTime, m.String(TagSendingTime)).
+			Set(TagSendingTime, s.a.now().Format(TimeFormat))
+		s.conn.write(m.Bytes())
+		next = k.Seq + 1
+	}
+	gapFill(end + 1)
+	return nil
+}
+
+func resendRequest(from int) *Message {
+	return NewMessage(MsgResendRequest).SetInt(TagBeginSeqNo, from).SetInt(TagEndSeqNo, 0)
+}
+
+// conn is one logged on connection of a session.
+type conn struct {
+	net.Conn
+	s         *session
+	ctx       context.Context // the account and actor orders are entered as
+	heartbeat time.Duration
+	// cancelOnDisconnect cancels the session's resting orders when the
+	// connection ends
+	cancelOnDisconnect bool
+
+	lastRecv   atomic.Int64 // Unix nanoseconds
+	lastSent   atomic.Int64
+	testReqOut atomic.Bool // a TestRequest has gone unanswered
+	logoutSent atomic.Bool
+	resendTo   int // highest MsgSeqNum seen since the last ResendRequest; read loop only
+	done       chan struct{}
+}
+
+// write sends an encoded message; a failed write ends the connection.
+func (c *conn) write(raw []byte) {
+	c.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := c.Conn.Write(raw); err != nil {
+		c.Close()
+		return
+	}
+	c.lastSent.Store(time.Now().UnixNano())
+}
+
+// send sends msg in the session, logging failures.
+func (c *conn) send(msg *Message) {
+	if err := c.s.send(msg); err != nil {
+		log.Printf("FIX: send %s to %s: %v", msg.MsgType(), c.s.id, err)
+	}
+}
+
+// logout sends a Logout; the connection ends when the counterparty confirms.
+func (c *conn) logout(text string) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	c.logoutLocked(text)
+}
+
+func (c *conn) logoutLocked(text string) {
+	if c.logoutSent.Swap(true) {
+		return
+	}
+	msg := NewMessage(MsgLogout)
+	if text != "" {
+		msg.Set(TagText, text)
+	}
+	if err := c.s.sendLocked(msg); err != nil {
+		log.Printf("FIX: log out %s: %v", c.s.id, err)
+		c.Close()
+	}
+}
+
+// reject sends a session level Reject of ref; tag is the offending tag, or
+// 0.
+func (c *conn) reject(ref *Message, reason string, tag int, text string) {
+	msg := NewMessage(MsgReject).
+		SetInt(TagRefSeqNum, ref.SeqNum()).
+		Set(TagRefMsgType, ref.MsgType()).
+		Set(TagSessionRejectReason, reason).
+		Set(TagText, text)
+	if tag != 0 {
+		msg.SetInt(TagRefTagID, tag)
+	}
+	c.send(msg)
+}
+
+// require rejects msg unless every tag is present.
+func (c *conn) require(msg *Message, tags ...int) bool {
+	for _, tag := range tags {
+		if _, ok := msg.Get(tag); !ok {
+			c.reject(msg, SessionRejectRequiredTagMissing, tag, fmt.Sprintf("required tag %d is missing", tag))
+			return false
+		}
+	}
+	return true
+}
+
+func (c *conn) readLoop(r *bufio.Reader) {
+	for {
+		raw, err := ReadMessage(r)
+		if err != nil {
+			// Framing is lost too, so there is no next message to find
+			return
+		}
+		c.lastRecv.Store(time.Now().UnixNano())
+		c.testReqOut.Store(false)
+
+		msg, err := Parse(raw)
+		if err != nil {
+			// Garbled messages are ignored, as if they were never sent
+			continue
+		}
+		if !c.handle(msg) {
+			return
+		}
+	}
+}
+
+// handle processes one message. It returns false when the connection must
+// end.
+func (c *conn) handle(msg *Message) bool {
+	s := c.s
+	if msg.String(TagSenderCompID) != s.id || msg.String(TagTargetCompID) != s.a.Config.SenderCompID {
+		c.logout("SenderCompID or TargetCompID changed")
+		return false
+	}
+	seq, err := msg.Int(TagMsgSeqNum)
+	if err != nil {
+		c.logout("MsgSeqNum is missing")
+		return false
+	}
+	msgType := msg.MsgType()
+	if msgType == MsgSequenceReset && !msg.Bool(TagGapFillFlag) {
+		// Reset mode ignores MsgSeqNum
+		c.sequenceReset(msg, false)
+		return true
+	}
+
+	expected := s.targetSeq()
+	switch {
+	case seq > expected:
+		if c.resendTo < expected {
+			c.send(resendRequest(expected))
+		}
+		c.resendTo = max(c.resendTo, seq)
+		if msgType == MsgLogout {
+			c.logout("")
+			return false
+		}
+		return true
+	case seq < expected:
+		if msg.Bool(TagPossDupFlag) {
+			return true
+		}
+		c.logout(fmt.Sprintf("MsgSeqNum too low, expecting %d but received %d", expected, seq))
+		return false
+	}
+
+	if msgType == MsgSequenceReset {
+		c.sequenceReset(msg, true)
+		return true
+	}
+	// Recorded before the message is acted on: after a crash it is not
+	// processed twice
+	if err := s.setTargetSeq(seq + 1); err != nil {
+		log.Printf("FIX: session %s: %v", s.id, err)
+		return false
+	}
+
+	switch msgType {
+	case MsgHeartbeat, MsgReject:
+		// Only liveness, recorded for every message
+	case MsgTestRequest:
+		c.send(NewMessage(MsgHeartbeat).Set(TagTestReqID, msg.String(TagTestReqID)))
+	case MsgResendRequest:
+		begin, err := msg.Int(TagBeginSeqNo)
+		if err != nil || begin < 1 {
+			c.reject(msg, SessionRejectValueIncorrect, TagBeginSeqNo, "BeginSeqNo must be positive")
+			break
+		}
+		end, _ := msg.Int(TagEndSeqNo)
+		if err := s.resend(begin, end); err != nil {
+			log.Printf("FIX: resend to %s: %v", s.id, err)
+		}
+	case MsgLogout:
+		c.logout("")
+		return false
+	case MsgLogon:
+		c.reject(msg, SessionRejectValueIncorrect, 0, "already logged on")
+	case MsgNewOrderSingle:
+		c.newOrderSingle(msg)
+	case MsgOrderCancelRequest:
+		c.cancelRequest(msg)
+	case MsgOrderCancelReplaceRequest:
+		c.replaceRequest(msg)
+	case MsgOrderStatusRequest:
+		c.statusRequest(msg)
+	default:
+		c.send(NewMessage(MsgBusinessMessageReject).
+			SetInt(TagRefSeqNum, seq).
+			Set(TagRefMsgType, msgType).
+			Set(TagBusinessRejectReason, BusinessRejectUnsupportedMsgType).
+			Set(TagText, "unsupported message type"))
+	}
+	return true
+}
+
+// sequenceReset moves the expected MsgSeqNum forward; a gap fill must move
+// it past its own MsgSeqNum.
+func (c *conn) sequenceReset(msg *Message, gapFill bool) {
+	expected := c.s.targetSeq()
+	lowest := expected
+	if gapFill {
+		lowest++
+	}
+	newSeq, err := msg.Int(TagNewSeqNo)
+	if err != nil || newSeq < lowest {
+		if gapFill {
+			c.s.setTargetSeq(expected + 1)
+		}
+		c.reject(msg, SessionRejectValueIncorrect, TagNewSeqNo, "NewSeqNo must not lower the expected MsgSeqNum")
+		return
+	}
+	if err := c.s.setTargetSeq(newSeq); err != nil {
+		log.Printf("FIX: session %s: %v", c.s.id, err)
+	}
+}
+
+// monitor keeps the connection alive and checks the counterparty is: a
+// Heartbeat goes out after HeartBtInt without sending, a TestRequest after
+// HeartBtInt plus 20% without receiving, and the connection is dropped if
+// that goes unanswered as long again.
+func (c *conn) monitor() {
+	ticker := time.NewTicker(c.heartbeat / 4)
+	defer ticker.Stop()
+	grace := c.heartbeat + c.heartbeat/5
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+		now := time.Now()
+		if now.Sub(time.Unix(0, c.lastSent.Load())) >= c.heartbeat {
+			c.send(NewMessage(MsgHeartbeat))
+		}
+		silent := now.Sub(time.Unix(0, c.lastRecv.Load()))
+		switch {
+		case silent >= 2*grace:
+			log.Printf("FIX: session %s timed out", c.s.id)
+			c.Close()
+			return
+		case silent >= grace && !c.testReqOut.Swap(true):
+			c.send(NewMessage(MsgTestRequest).Set(TagTestReqID, strconv.FormatInt(now.UnixNano(), 10)))
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	tradingv1 "github.com/Puneet-Vishnoi/order-matching-engine/api/trading/v1"
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Metadata keys of a signed call, the REST API's headers in lower case.
+const (
+	MetadataAPIKey    = "x-api-key"
+	MetadataTimestamp = "x-api-timestamp"
+	MetadataNonce     = "x-api-nonce"
+	MetadataSignature = "x-api-signature"
+)
+
+// A call is signed like a REST request: as a POST to its full method name,
+// with the deterministic protobuf encoding of the request message as body.
+const signedMethod = "POST"
+
+// policy is what a method requires of the caller.
+type policy struct {
+	scope string // of the API key
+	role  string // of the account; "" allows every role
+	limit string // rate limit class
+}
+
+var policies = map[string]policy{
+	tradingv1.Trading_PlaceOrder_FullMethodName:         {models.ScopeTrade, models.RoleTrader, models.RateLimitPlace},
+	tradingv1.Trading_CancelOrder_FullMethodName:        {models.ScopeCancel, models.RoleTrader, models.RateLimitCancel},
+	tradingv1.Trading_GetOrder_FullMethodName:           {models.ScopeRead, "", models.RateLimitRead},
+	tradingv1.Trading_ListOrders_FullMethodName:         {models.ScopeRead, "", models.RateLimitRead},
+	tradingv1.Trading_GetOrderBook_FullMethodName:       {models.ScopeRead, "", models.RateLimitRead},
+	tradingv1.Trading_ListTrades_FullMethodName:         {models.ScopeRead, "", models.RateLimitRead},
+	tradingv1.MarketData_StreamOrderBook_FullMethodName: {models.ScopeRead, "", models.RateLimitRead},
+	tradingv1.MarketData_StreamTrades_FullMethodName:    {models.ScopeRead, "", models.RateLimitRead},
+}
+
+// guard authenticates, authorizes and rate limits calls, and turns the
+// errors of handlers into statuses. Guards are no-ops while their feature
+// is off.
+type guard struct {
+	auth    *service.AuthService // nil serves everyone
+	limiter *service.RateLimiter // nil disables rate limits
+}
+
+func (g *guard) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := g.admit(ctx, info.FullMethod, req)
+	if err != nil {
+		return nil, statusError(info.FullMethod, err)
+	}
+	resp, err := handler(ctx, req)
+	return resp, statusError(info.FullMethod, err)
+}
+
+// stream admits a streaming call once its request message arrives, as the
+// signature covers it.
+func (g *guard) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	gs := &guardedStream{ServerStream: ss, g: g, method: info.FullMethod, ctx: ss.Context()}
+	return statusError(info.FullMethod, handler(srv, gs))
+}
+
+// admit checks a call and returns the context it runs with, which carries
+// the caller's account when authenticated.
+func (g *guard) admit(ctx context.Context, method string, req any) (context.Context, error) {
+	p, ok := policies[method]
+	if !ok {
+		return nil, status.Errorf(codes.Unimplemented, "method %s is not served", method)
+	}
+
+	subject := "ip:" + peerIP(ctx)
+	if g.auth != nil {
+		principal, err := g.authenticate(ctx, method, req)
+		if err != nil {
+			return nil, err
+		}
+		if !principal.Key.HasScope(p.scope) {
+			return nil, apperr.PermissionDenied(apperr.CodeInsufficientScope, "API key lacks the %q scope", p.scope)
+		}
+		if p.role != "" && !principal.Account.HasRole(p.role) {
+			return nil, apperr.PermissionDenied(apperr.CodeInsufficientRole, "account role does not allow this operation; %q required", p.role)
+		}
+		key := principal.Key
+		ctx = service.WithAccount(ctx, key.AccountID)
+		ctx = service.WithActor(ctx, fmt.Sprintf("account:%d/%s", key.AccountID, key.KeyID))
+		subject = service.AccountSubject(key.AccountID)
+	}
+
+	if g.limiter != nil {
+		if err := g.limiter.Allow(ctx, p.limit, subject); err != nil {
+			return nil, err
+		}
+	}
+	return ctx, nil
+}
+
+func (g *guard) authenticate(ctx context.Context, method string, req any) (*service.Principal, error) {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("request of %s is a %T, not a protobuf message", method, req)
+	}
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	return g.auth.Authenticate(ctx, service.SignedRequest{
+		KeyID:     first(md, MetadataAPIKey),
+		Timestamp: first(md, MetadataTimestamp),
+		Nonce:     first(md, MetadataNonce),
+		Signature: first(md, MetadataSignature),
+		Method:    signedMethod,
+		Path:      method,
+		Body:      body,
+	})
+}
+
+// guardedStream admits its call on the first message received and then
+// serves the handler the admitted context.
+type guardedStream struct {
+	grpc.ServerStream
+	g      *guard
+	method string
+
+	once sync.Once
+	ctx  context.Context
+}
+
+func (s *guardedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *guardedStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	var err error
+	s.once.Do(func() {
+		var ctx context.Context
+		if ctx, err = s.g.admit(s.ctx, s.method, m); err == nil {
+			s.ctx = ctx
+		}
+	})
+	return err
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+		return host
+	}
+	return p.Addr.String()
+}
+
+// SignContext returns ctx with the metadata that signs a call of method
+// (its full name, like tradingv1.Trading_PlaceOrder_FullMethodName) with
+// req, using an API key's ID and secret. Streaming calls are signed this
+// way; unary calls can use SigningInterceptor instead.
+func SignContext(ctx context.Context, keyID, secret, method string, req proto.Message) (context.Context, error) {
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := service.NewNonce()
+	if err != nil {
+		return nil, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	return metadata.AppendToOutgoingContext(ctx,
+		MetadataAPIKey, keyID,
+		MetadataTimestamp, timestamp,
+		MetadataNonce, nonce,
+		MetadataSignature, service.Sign(secret, timestamp, nonce, signedMethod, method, body),
+	), nil
+}
+
+// SigningInterceptor signs every unary call of a client with an API key.
+func SigningInterceptor(keyID, secret string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return fmt.Errorf("request of %s is a %T, not a protobuf message", method, req)
+		}
+		ctx, err := SignContext(ctx, keyID, secret, method, msg)
+		if err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
@@ -0,0 +1,87 @@
+package grpcapi
+
+import (
+	tradingv1 "github.com/Puneet-Vishnoi/order-matching-engine/api/trading/v1"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Enum values and the strings of the domain model. Unspecified values map
+// to "", which validation rejects where a value is required.
+var (
+	sides = map[tradingv1.Side]string{
+		tradingv1.Side_SIDE_BUY:  "buy",
+		tradingv1.Side_SIDE_SELL: "sell",
+	}
+	orderTypes = map[tradingv1.OrderType]string{
+		tradingv1.OrderType_ORDER_TYPE_LIMIT:  "limit",
+		tradingv1.OrderType_ORDER_TYPE_MARKET: "market",
+	}
+	statuses = map[tradingv1.OrderStatus]string{
+		tradingv1.OrderStatus_ORDER_STATUS_OPEN:     "open",
+		tradingv1.OrderStatus_ORDER_STATUS_PARTIAL:  "partial",
+		tradingv1.OrderStatus_ORDER_STATUS_FILLED:   "filled",
+		tradingv1.OrderStatus_ORDER_STATUS_CANCELED: "canceled",
+	}
+
+	sideToProto      = invert(sides)
+	orderTypeToProto = invert(orderTypes)
+	statusToProto    = invert(statuses)
+)
+
+func invert[E comparable](m map[E]string) map[string]E {
+	inv := make(map[string]E, len(m))
+	for k, v := range m {
+		inv[v] = k
+	}
+	return inv
+}
+
+func orderToProto(o *models.Order) *tradingv1.Order {
+	return &tradingv1.Order{
+		Id:                       o.ID,
+		AccountId:                o.AccountID,
+		Symbol:                   o.Symbol,
+		Side:                     sideToProto[o.Side],
+		Type:                     orderTypeToProto[o.Type],
+		Price:                    o.Price,
+		Quantity:                 o.Quantity.IntPart(),
+		RemainingQuantity:        o.RemainingQty.IntPart(),
+		Status:                   statusToProto[o.Status],
+		CreatedAt:                timestamppb.New(o.CreatedAt),
+		QuantityDecimal:          o.Quantity.String(),
+		RemainingQuantityDecimal: o.RemainingQty.String(),
+	}
+}
+
+func tradeToProto(symbol string, t *models.Trade) *tradingv1.Trade {
+	return &tradingv1.Trade{
+		Id:              t.ID,
+		Symbol:          symbol,
+		BuyOrderId:      t.BuyOrderID,
+		SellOrderId:     t.SellOrderID,
+		Price:           t.Price,
+		Quantity:        t.Quantity.IntPart(),
+		CreatedAt:       timestamppb.New(t.CreatedAt),
+		QuantityDecimal: t.Quantity.String(),
+	}
+}
+
+func levelsToProto(entries []models.OrderBookEntry) []*tradingv1.PriceLevel {
+	levels := make([]*tradingv1.PriceLevel, len(entries))
+	for i, e := range entries {
+		levels[i] = &tradingv1.PriceLevel{Price: e.Price, Quantity: e.Quantity.IntPart(), QuantityDecimal: e.Quantity.String()}
+	}
+	return levels
+}
+
+func bookUpdateToProto(event *models.MarketDataEvent) *tradingv1.OrderBookUpdate {
+	return &tradingv1.OrderBookUpdate{
+		Symbol:   event.Symbol,
+		Sequence: event.Sequence,
+		Snapshot: event.Type == models.MarketDataSnapshot,
+		Bids:     levelsToProto(event.Bids),
+		Asks:     levelsToProto(event.Asks),
+		Time:     timestamppb.New(event.Time),
+	}
+}
@@ -0,0 +1,69 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// errorDomain identifies the engine in the ErrorInfo of every error.
+const errorDomain = "order-matching-engine"
+
+// codeByKind maps domain error kinds to gRPC status codes.
+var codeByKind = map[apperr.Kind]codes.Code{
+	apperr.KindInvalidArgument:  codes.InvalidArgument,
+	apperr.KindUnauthenticated:  codes.Unauthenticated,
+	apperr.KindPermissionDenied: codes.PermissionDenied,
+	apperr.KindNotFound:         codes.NotFound,
+	apperr.KindConflict:         codes.FailedPrecondition,
+	apperr.KindRejected:         codes.FailedPrecondition,
+	apperr.KindRateLimited:      codes.ResourceExhausted,
+	apperr.KindUnavailable:      codes.Unavailable,
+	apperr.KindInternal:         codes.Internal,
+}
+
+// statusError is the single place errors become gRPC statuses, the
+// counterpart of the REST API's respondError. The stable error code is sent
+// as the Reason of an ErrorInfo detail, and the retry delay of rate limited
+// calls as a RetryInfo. Errors that are not domain errors are logged and
+// reported as internal errors.
+func statusError(method string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	appErr, ok := apperr.As(err)
+	if !ok {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		appErr = apperr.Internal(err)
+	}
+
+	if appErr.Kind == apperr.KindInternal || appErr.Kind == apperr.KindUnavailable {
+		log.Printf("gRPC %s failed: %v", method, err)
+	}
+	code, ok := codeByKind[appErr.Kind]
+	if !ok {
+		code = codes.Internal
+	}
+
+	st := status.New(code, appErr.Message)
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: appErr.Code, Domain: errorDomain}}
+	if appErr.RetryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(appErr.RetryAfter)})
+	}
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
@@ -0,0 +1,219 @@
+// Package grpcapi serves the trading and market data API over gRPC, as
+// defined in api/trading/v1. It is a thin layer over service.OrderService:
+// requests are validated and authorized like their REST counterparts and
+// domain errors become gRPC statuses.
+package grpcapi
+
+import (
+	"context"
+	"strconv"
+
+	tradingv1 "github.com/Puneet-Vishnoi/order-matching-engine/api/trading/v1"
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc"
+)
+
+// Server is a gRPC server with the Trading service registered, and the
+// MarketData service when the order service publishes market data.
+type Server struct {
+	*grpc.Server
+	stopping chan struct{} // closed by Shutdown to end streams
+}
+
+// NewServer returns a Server over orders. With auth set, every call must be
+// signed with an API key holding the method's scope, by an account whose
+// role allows it; a nil auth serves everyone.
+func NewServer(orders *service.OrderService, auth *service.AuthService, opts ...grpc.ServerOption) *Server {
+	g := &guard{auth: auth, limiter: orders.RateLimiter}
+	opts = append(opts, grpc.ChainUnaryInterceptor(g.unary), grpc.ChainStreamInterceptor(g.stream))
+	s := &Server{Server: grpc.NewServer(opts...), stopping: make(chan struct{})}
+
+	tradingv1.RegisterTradingServer(s, &tradingServer{orders: orders})
+	if orders.MarketData != nil {
+		tradingv1.RegisterMarketDataServer(s, &marketDataServer{orders: orders, stopping: s.stopping})
+	}
+	return s
+}
+
+// Shutdown stops accepting calls, ends market data streams and waits for
+// the calls in flight to finish, or until ctx is done, when the rest are
+// cut off.
+func (s *Server) Shutdown(ctx context.Context) error {
+	close(s.stopping)
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.Stop()
+		return ctx.Err()
+	}
+}
+
+type tradingServer struct {
+	tradingv1.UnimplementedTradingServer
+	orders *service.OrderService
+}
+
+func (s *tradingServer) PlaceOrder(ctx context.Context, in *tradingv1.PlaceOrderRequest) (*tradingv1.PlaceOrderResponse, error) {
+	// Clients built before quantities were decimal send whole units
+	quantity := decimal.NewFromInt(in.Quantity)
+	if in.QuantityDecimal != "" {
+		if in.Quantity != 0 {
+			return nil, apperr.InvalidArgument(apperr.CodeValidationFailed, "Order validation failed: set only one of Quantity and QuantityDecimal")
+		}
+		var err error
+		if quantity, err = decimal.NewFromString(in.QuantityDecimal); err != nil {
+			return nil, apperr.InvalidArgument(apperr.CodeValidationFailed, "Order validation failed: invalid QuantityDecimal")
+		}
+	}
+	req := &models.PlaceOrderRequest{
+		Symbol:   in.Symbol,
+		Side:     sides[in.Side],
+		Type:     orderTypes[in.Type],
+		Price:    in.Price,
+		Quantity: quantity,
+	}
+	if err := service.ValidateOrder(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.orders.PlaceOrder(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &tradingv1.PlaceOrderResponse{
+		OrderId:                  resp.OrderID,
+		Status:                   statusToProto[resp.Status],
+		RemainingQuantity:        resp.RemainingQuantity.IntPart(),
+		Message:                  resp.Message,
+		RemainingQuantityDecimal: resp.RemainingQuantity.String(),
+	}, nil
+}
+
+func (s *tradingServer) CancelOrder(ctx context.Context, in *tradingv1.CancelOrderRequest) (*tradingv1.CancelOrderResponse, error) {
+	resp, err := s.orders.CancelOrder(ctx, strconv.FormatInt(in.OrderId, 10))
+	if err != nil {
+		return nil, err
+	}
+	return &tradingv1.CancelOrderResponse{Message: resp.Message}, nil
+}
+
+func (s *tradingServer) GetOrder(ctx context.Context, in *tradingv1.GetOrderRequest) (*tradingv1.Order, error) {
+	order, err := s.orders.GetOrder(ctx, strconv.FormatInt(in.OrderId, 10))
+	if err != nil {
+		return nil, err
+	}
+	return orderToProto(order), nil
+}
+
+func (s *tradingServer) ListOrders(ctx context.Context, in *tradingv1.ListOrdersRequest) (*tradingv1.ListOrdersResponse, error) {
+	filter := models.OrderFilter{Symbol: in.Symbol, Status: statuses[in.Status]}
+	orders, err := s.orders.ListOrders(ctx, filter, int(in.Limit))
+	if err != nil {
+		return nil, err
+	}
+	resp := &tradingv1.ListOrdersResponse{Orders: make([]*tradingv1.Order, len(orders))}
+	for i := range orders {
+		resp.Orders[i] = orderToProto(&orders[i])
+	}
+	return resp, nil
+}
+
+func (s *tradingServer) GetOrderBook(ctx context.Context, in *tradingv1.GetOrderBookRequest) (*tradingv1.OrderBook, error) {
+	if in.Symbol == "" {
+		return nil, apperr.InvalidArgument(apperr.CodeSymbolRequired, "symbol is required")
+	}
+	book, err := s.orders.GetOrderBook(ctx, in.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	return &tradingv1.OrderBook{
+		Symbol:   book.Symbol,
+		Sequence: book.Sequence,
+		Bids:     levelsToProto(book.Bids),
+		Asks:     levelsToProto(book.Asks),
+	}, nil
+}
+
+func (s *tradingServer) ListTrades(ctx context.Context, in *tradingv1.ListTradesRequest) (*tradingv1.ListTradesResponse, error) {
+	trades, err := s.orders.ListTrades(ctx, in.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	resp := &tradingv1.ListTradesResponse{Trades: make([]*tradingv1.Trade, len(trades))}
+	for i := range trades {
+		resp.Trades[i] = tradeToProto(in.Symbol, &trades[i])
+	}
+	return resp, nil
+}
+
+type marketDataServer struct {
+	tradingv1.UnimplementedMarketDataServer
+	orders   *service.OrderService
+	stopping <-chan struct{}
+}
+
+func (s *marketDataServer) StreamOrderBook(in *tradingv1.StreamOrderBookRequest, stream grpc.ServerStreamingServer[tradingv1.OrderBookUpdate]) error {
+	return s.subscribe(stream, in.Symbol, func(event *models.MarketDataEvent) error {
+		if event.Type == models.MarketDataTrade {
+			return nil
+		}
+		return stream.Send(bookUpdateToProto(event))
+	})
+}
+
+func (s *marketDataServer) StreamTrades(in *tradingv1.StreamTradesRequest, stream grpc.ServerStreamingServer[tradingv1.Trade]) error {
+	return s.subscribe(stream, in.Symbol, func(event *models.MarketDataEvent) error {
+		if event.Type != models.MarketDataTrade {
+			return nil
+		}
+		return stream.Send(tradeToProto(event.Symbol, event.Trade))
+	})
+}
+
+// subscribe passes the market data events of symbol to send until the
+// client goes away, the feed ends the stream or the server shuts down. The
+// stream's headers are sent once it is subscribed, so a client that waits
+// for them misses no later event.
+func (s *marketDataServer) subscribe(stream grpc.ServerStream, symbol string, send func(*models.MarketDataEvent) error) error {
+	if symbol == "" {
+		return apperr.InvalidArgument(apperr.CodeSymbolRequired, "symbol is required")
+	}
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	events, err := s.orders.MarketData.Subscribe(ctx, symbol)
+	if err != nil {
+		return err
+	}
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				// The feed stopped or dropped us for falling behind
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return apperr.Unavailable(apperr.CodeStreamEnded, "stream ended; stream again for a new snapshot")
+			}
+			if err := send(&event); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.stopping:
+			return apperr.Unavailable(apperr.CodeStreamEnded, "server shutting down")
+		}
+	}
+}
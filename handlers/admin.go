@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tracing"
+	"github.com/Puneet-Vishnoi/order-matching-engine/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// AdminHandler serves account and API key management, the audit log and,
+// when Webhooks is set, webhook management.
+type AdminHandler struct {
+	Auth      *service.AuthService
+	Audit     *service.AuditService
+	Webhooks  *service.WebhookService
+	Validator *validator.Validate
+}
+
+func NewAdminHandler(auth *service.AuthService, audit *service.AuditService, webhooks *service.WebhookService) *AdminHandler {
+	return &AdminHandler{
+		Auth:      auth,
+		Audit:     audit,
+		Webhooks:  webhooks,
+		Validator: utils.GetValidator(),
+	}
+}
+
+// Page sizes of GET /admin/audit.
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// bind decodes and validates a JSON body, responding on failure.
+func (h *AdminHandler) bind(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		respondError(c, apperr.InvalidArgument(apperr.CodeInvalidRequest, "Invalid request body"))
+		return false
+	}
+	if err := h.Validator.Struct(req); err != nil {
+		respondErrorDetails(c, apperr.InvalidArgument(apperr.CodeValidationFailed, "Request validation failed"), formatValidationError(err))
+		return false
+	}
+	return true
+}
+
+// accountIDParam parses the :id path parameter.
+func accountIDParam(c *gin.Context) (int64, bool) {
+	return idParam(c, "account")
+}
+
+// POST /admin/accounts
+func (h *AdminHandler) CreateAccount(c *gin.Context) {
+	_, end := startSpan(c, "AdminHandler.CreateAccount")
+	defer end()
+
+	var req models.CreateAccountRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	account, err := h.Auth.CreateAccount(c.Request.Context(), req.Name, req.Role)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
+// PUT /admin/accounts/:id/role
+func (h *AdminHandler) SetRole(c *gin.Context) {
+	_, end := startSpan(c, "AdminHandler.SetRole")
+	defer end()
+
+	accountID, ok := accountIDParam(c)
+	if !ok {
+		return
+	}
+	var req models.UpdateAccountRoleRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	account, err := h.Auth.SetRole(c.Request.Context(), accountID, req.Role)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// GET /admin/accounts/:id/keys
+func (h *AdminHandler) ListKeys(c *gin.Context) {
+	_, end := startSpan(c, "AdminHandler.ListKeys")
+	defer end()
+
+	accountID, ok := accountIDParam(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.Auth.ListKeys(c.Request.Context(), accountID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// POST /admin/accounts/:id/keys
+func (h *AdminHandler) IssueKey(c *gin.Context) {
+	_, end := startSpan(c, "AdminHandler.IssueKey")
+	defer end()
+
+	accountID, ok := accountIDParam(c)
+	if !ok {
+		return
+	}
+	var req models.IssueAPIKeyRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	resp, err := h.Auth.IssueKey(c.Request.Context(), accountID, req.Scopes)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// POST /admin/keys/:key_id/rotate
+func (h *AdminHandler) RotateKey(c *gin.Context) {
+	_, end := startSpan(c, "AdminHandler.RotateKey", tracing.AttrAPIKeyID.String(c.Param("key_id")))
+	defer end()
+
+	resp, err := h.Auth.RotateKey(c.Request.Context(), c.Param("key_id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DELETE /admin/keys/:key_id
+func (h *AdminHandler) RevokeKey(c *gin.Context) {
+	_, end := startSpan(c, "AdminHandler.RevokeKey", tracing.AttrAPIKeyID.String(c.Param("key_id")))
+	defer end()
+
+	key, err := h.Auth.RevokeKey(c.Request.Context(), c.Param("key_id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// GET /admin/audit?limit=
+func (h *AdminHandler) ListAudit(c *gin.Context) {
+	_, end := startSpan(c, "AdminHandler.ListAudit")
+	defer end()
+
+	limit := defaultAuditLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxAuditLimit {
+			respondError(c, apperr.InvalidArgument(apperr.CodeInvalidRequest, "limit must be between 1 and %d", maxAuditLimit))
+			return
+		}
+		limit = n
+	}
+
+	resp, err := h.Audit.List(c.Request.Context(), limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
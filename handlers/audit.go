@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/middleware"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/gin-gonic/gin"
+)
+
+// auditParams is what the audit log records about a request.
+type auditParams struct {
+	Path  map[string]string   `json:"path,omitempty"`
+	Query map[string][]string `json:"query,omitempty"`
+	Body  any                 `json:"body,omitempty"`
+}
+
+// Audit writes every request that changes state to the admin audit log once
+// it has been handled, including ones later middleware rejects. The action
+// is the method and route, e.g. "POST /admin/accounts". It must run after
+// Authenticate, which buffers the body.
+func Audit(audit *service.AuditService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		c.Next()
+
+		params := auditParams{Query: c.Request.URL.Query()}
+		if len(c.Params) > 0 {
+			params.Path = make(map[string]string, len(c.Params))
+			for _, p := range c.Params {
+				params.Path[p.Key] = p.Value
+			}
+		}
+		if len(body) > 0 {
+			if json.Valid(body) {
+				params.Body = json.RawMessage(body)
+			} else {
+				params.Body = string(body)
+			}
+		}
+
+		result := models.AuditResultOK
+		if code := c.GetString(errorCodeContextKey); code != "" {
+			result = code
+		}
+
+		action := c.Request.Method + " " + c.FullPath()
+		if err := audit.Record(c.Request.Context(), action, params, result); err != nil {
+			log.Printf("request %s: failed to write audit entry for %s: %v", middleware.GetRequestID(c), action, err)
+		}
+	}
+}
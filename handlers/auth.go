@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/gin-gonic/gin"
+)
+
+// Headers of a signed request.
+const (
+	HeaderAPIKey    = "X-API-Key"
+	HeaderTimestamp = "X-API-Timestamp"
+	HeaderNonce     = "X-API-Nonce"
+	HeaderSignature = "X-API-Signature"
+)
+
+const principalContextKey = "principal"
+
+// maxSignedBody bounds how much of a request body is buffered for signing.
+const maxSignedBody = 1 << 20
+
+// Authenticate verifies the request signature, then attaches the key and
+// account to the Gin context and the account to the request context.
+func Authenticate(auth *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxSignedBody))
+		if err != nil {
+			respondError(c, apperr.InvalidArgument(apperr.CodeInvalidRequest, "request body too large or unreadable"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		principal, err := auth.Authenticate(c.Request.Context(), service.SignedRequest{
+			KeyID:     c.GetHeader(HeaderAPIKey),
+			Timestamp: c.GetHeader(HeaderTimestamp),
+			Nonce:     c.GetHeader(HeaderNonce),
+			Signature: c.GetHeader(HeaderSignature),
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.RequestURI(),
+			Body:      body,
+		})
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.Set(principalContextKey, principal)
+		key := principal.Key
+		ctx := service.WithAccount(c.Request.Context(), key.AccountID)
+		ctx = service.WithActor(ctx, fmt.Sprintf("account:%d/%s", key.AccountID, key.KeyID))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// RequireScope rejects requests whose key lacks scope. It must run after
+// Authenticate.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := GetAPIKey(c)
+		if key == nil || !key.HasScope(scope) {
+			respondError(c, apperr.PermissionDenied(apperr.CodeInsufficientScope, "API key lacks the %q scope", scope))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRole rejects requests whose account's role is below role. It must
+// run after Authenticate.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		account := GetAccount(c)
+		if account == nil || !account.HasRole(role) {
+			respondError(c, apperr.PermissionDenied(apperr.CodeInsufficientRole, "account role does not allow this operation; %q required", role))
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetAPIKey returns the key that authenticated the request, if any.
+func GetAPIKey(c *gin.Context) *models.APIKey {
+	if p := getPrincipal(c); p != nil {
+		return p.Key
+	}
+	return nil
+}
+
+// GetAccount returns the account that authenticated the request, if any.
+func GetAccount(c *gin.Context) *models.Account {
+	if p := getPrincipal(c); p != nil {
+		return p.Account
+	}
+	return nil
+}
+
+func getPrincipal(c *gin.Context) *service.Principal {
+	if v, ok := c.Get(principalContextKey); ok {
+		return v.(*service.Principal)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/middleware"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/gin-gonic/gin"
+)
+
+// statusByKind maps domain error kinds to HTTP status codes.
+var statusByKind = map[apperr.Kind]int{
+	apperr.KindInvalidArgument:  http.StatusBadRequest,
+	apperr.KindUnauthenticated:  http.StatusUnauthorized,
+	apperr.KindPermissionDenied: http.StatusForbidden,
+	apperr.KindNotFound:         http.StatusNotFound,
+	apperr.KindConflict:         http.StatusConflict,
+	apperr.KindRejected:         http.StatusUnprocessableEntity,
+	apperr.KindRateLimited:      http.StatusTooManyRequests,
+	apperr.KindUnavailable:      http.StatusServiceUnavailable,
+	apperr.KindInternal:         http.StatusInternalServerError,
+}
+
+// errorCodeContextKey holds the code of the error a request failed with.
+const errorCodeContextKey = "error_code"
+
+// respondError is the single place errors become HTTP responses. Errors that
+// are not domain errors are logged and reported as internal errors, so
+// database details never reach clients.
+func respondError(c *gin.Context, err error) {
+	respondErrorDetails(c, err, nil)
+}
+
+func respondErrorDetails(c *gin.Context, err error, details map[string]string) {
+	appErr, ok := apperr.As(err)
+	if !ok {
+		appErr = apperr.Internal(err)
+	}
+
+	requestID := middleware.GetRequestID(c)
+	if appErr.Kind == apperr.KindInternal || appErr.Kind == apperr.KindUnavailable {
+		log.Printf("request %s: %s %s failed: %v", requestID, c.Request.Method, c.FullPath(), err)
+	}
+
+	c.Set(errorCodeContextKey, appErr.Code)
+	if appErr.RetryAfter > 0 {
+		// Whole seconds, rounded up so clients never retry too early
+		c.Header("Retry-After", strconv.FormatInt(int64((appErr.RetryAfter+time.Second-1)/time.Second), 10))
+	}
+	status, ok := statusByKind[appErr.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	c.AbortWithStatusJSON(status, models.ErrorResponse{
+		Error: models.ErrorBody{
+			Code:      appErr.Code,
+			Message:   appErr.Message,
+			RequestID: requestID,
+			Details:   details,
+		},
+	})
+}
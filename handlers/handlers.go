@@ -1,15 +1,19 @@
 package handlers
 
 import (
-	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
 	"github.com/Puneet-Vishnoi/order-matching-engine/models"
 	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tracing"
 	"github.com/Puneet-Vishnoi/order-matching-engine/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type OrderHandler struct {
@@ -27,45 +31,208 @@ func NewOrderHandler(s *service.OrderService) *OrderHandler {
 func formatValidationError(err error) map[string]string {
 	errors := make(map[string]string)
 	for _, e := range err.(validator.ValidationErrors) {
-		log.Println(e.Field(),": failed on tag '" + e.Tag() + "'")
-		errors[e.Field()] = "invalid order "+ e.Field()
+		log.Println(e.Field(), ": failed on tag '"+e.Tag()+"'")
+		errors[e.Field()] = "invalid order " + e.Field()
 	}
 	return errors
 }
 
 // POST /orders
 func (h *OrderHandler) PlaceOrder(c *gin.Context) {
+	span, end := startSpan(c, "OrderHandler.PlaceOrder")
+	defer end()
+
 	var req models.PlaceOrderRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		respondError(c, apperr.InvalidArgument(apperr.CodeInvalidRequest, "Invalid request body"))
 		return
 	}
 
 	if err := h.Validator.Struct(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"validation_errors": formatValidationError(err)})
+		respondErrorDetails(c, apperr.InvalidArgument(apperr.CodeValidationFailed, "Order validation failed"), formatValidationError(err))
 		return
 	}
+	span.SetAttributes(tracing.AttrSymbol.String(req.Symbol), tracing.AttrSide.String(req.Side), tracing.AttrType.String(req.Type))
 
 	resp, err := h.Service.PlaceOrder(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, resp)
 }
 
+// DELETE /orders/:id
 func (h *OrderHandler) CancelOrder(c *gin.Context) {
+	_, end := startSpan(c, "OrderHandler.CancelOrder", orderIDAttr(c))
+	defer end()
+
 	orderID := c.Param("id")
 
 	resp, err := h.Service.CancelOrder(c.Request.Context(), orderID)
 	if err != nil {
-		if err.Error() == fmt.Sprintf("order with ID %s not found", orderID) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// POST /orders/batch
+func (h *OrderHandler) PlaceOrders(c *gin.Context) {
+	span, end := startSpan(c, "OrderHandler.PlaceOrders")
+	defer end()
+
+	var req models.BatchPlaceOrdersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperr.InvalidArgument(apperr.CodeInvalidRequest, "Invalid request body"))
+		return
+	}
+	span.SetAttributes(attribute.Int("batch.size", len(req.Orders)))
+
+	// Orders are validated one by one, so one invalid order does not hide
+	// the outcome of the others
+	resp, err := h.Service.PlaceOrders(c.Request.Context(), req.Orders, req.AllOrNone)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DELETE /orders/batch
+func (h *OrderHandler) CancelOrders(c *gin.Context) {
+	span, end := startSpan(c, "OrderHandler.CancelOrders")
+	defer end()
+
+	var req models.BatchCancelOrdersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperr.InvalidArgument(apperr.CodeInvalidRequest, "Invalid request body"))
+		return
+	}
+	span.SetAttributes(attribute.Int("batch.size", len(req.OrderIDs)))
+
+	resp, err := h.Service.CancelOrders(c.Request.Context(), req.OrderIDs, req.AllOrNone)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DELETE /orders?symbol=XYZ&side=buy
+func (h *OrderHandler) MassCancel(c *gin.Context) {
+	_, end := startSpan(c, "OrderHandler.MassCancel", tracing.AttrSymbol.String(c.Query("symbol")))
+	defer end()
+
+	filter := models.OrderFilter{Symbol: c.Query("symbol"), Side: c.Query("side")}
+	resp, err := h.Service.MassCancel(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DELETE /admin/orders?symbol=XYZ&side=buy&account_id=7
+func (h *OrderHandler) MassCancelAll(c *gin.Context) {
+	_, end := startSpan(c, "OrderHandler.MassCancelAll", tracing.AttrSymbol.String(c.Query("symbol")))
+	defer end()
+
+	filter := models.OrderFilter{Symbol: c.Query("symbol"), Side: c.Query("side")}
+	if s := c.Query("account_id"); s != "" {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || id <= 0 {
+			respondError(c, apperr.InvalidArgument(apperr.CodeInvalidRequest, "invalid account_id"))
 			return
 		}
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		filter.AccountID = id
+	}
+
+	resp, err := h.Service.MassCancelAll(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// POST /orders/cancel-all-after
+func (h *OrderHandler) ArmDeadMansSwitch(c *gin.Context) {
+	_, end := startSpan(c, "OrderHandler.ArmDeadMansSwitch")
+	defer end()
+
+	var req models.DeadMansSwitchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperr.InvalidArgument(apperr.CodeInvalidRequest, "Invalid request body"))
+		return
+	}
+	if req.TimeoutMS == nil {
+		respondError(c, apperr.InvalidArgument(apperr.CodeValidationFailed, "timeout_ms is required"))
+		return
+	}
+
+	resp, err := h.Service.DeadMansSwitch.Arm(c.Request.Context(), time.Duration(*req.TimeoutMS)*time.Millisecond)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GET /orders/cancel-all-after
+func (h *OrderHandler) GetDeadMansSwitch(c *gin.Context) {
+	_, end := startSpan(c, "OrderHandler.GetDeadMansSwitch")
+	defer end()
+
+	resp, err := h.Service.DeadMansSwitch.State(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GET /phases/:symbol
+func (h *OrderHandler) GetTradingPhase(c *gin.Context) {
+	_, end := startSpan(c, "OrderHandler.GetTradingPhase", tracing.AttrSymbol.String(c.Param("symbol")))
+	defer end()
+
+	resp, err := h.Service.GetTradingPhase(c.Request.Context(), c.Param("symbol"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// PUT /admin/phases/:symbol
+func (h *OrderHandler) SetTradingPhase(c *gin.Context) {
+	_, end := startSpan(c, "OrderHandler.SetTradingPhase", tracing.AttrSymbol.String(c.Param("symbol")))
+	defer end()
+
+	var req models.SetTradingPhaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperr.InvalidArgument(apperr.CodeInvalidRequest, "Invalid request body"))
+		return
+	}
+	if err := h.Validator.Struct(req); err != nil {
+		respondErrorDetails(c, apperr.InvalidArgument(apperr.CodeValidationFailed, "Trading phase validation failed"), formatValidationError(err))
+		return
+	}
+
+	resp, err := h.Service.SetTradingPhase(c.Request.Context(), c.Param("symbol"), &req)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -74,15 +241,18 @@ func (h *OrderHandler) CancelOrder(c *gin.Context) {
 
 // GET /orderbook?symbol=XYZ
 func (h *OrderHandler) GetOrderBook(c *gin.Context) {
+	_, end := startSpan(c, "OrderHandler.GetOrderBook", tracing.AttrSymbol.String(c.Query("symbol")))
+	defer end()
+
 	symbol := c.Query("symbol")
 	if symbol == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing symbol query parameter"})
+		respondError(c, apperr.InvalidArgument(apperr.CodeSymbolRequired, "Missing symbol query parameter"))
 		return
 	}
 
 	resp, err := h.Service.GetOrderBook(c.Request.Context(), symbol)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -91,14 +261,27 @@ func (h *OrderHandler) GetOrderBook(c *gin.Context) {
 
 // GET /orders/:id
 func (h *OrderHandler) GetOrderStatus(c *gin.Context) {
+	_, end := startSpan(c, "OrderHandler.GetOrderStatus", orderIDAttr(c))
+	defer end()
+
 	orderID := c.Param("id")
 	resp, err := h.Service.GetOrderStatus(c.Request.Context(), orderID)
 	if err != nil {
-		if err.Error() == "invalid order ID" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err})
-			return
-		}
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GET /orders/:id/events
+func (h *OrderHandler) GetOrderEvents(c *gin.Context) {
+	_, end := startSpan(c, "OrderHandler.GetOrderEvents", orderIDAttr(c))
+	defer end()
+
+	resp, err := h.Service.GetOrderEvents(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -107,15 +290,18 @@ func (h *OrderHandler) GetOrderStatus(c *gin.Context) {
 
 // GET /trades?symbol=XYZ
 func (h *OrderHandler) ListTrades(c *gin.Context) {
+	_, end := startSpan(c, "OrderHandler.ListTrades", tracing.AttrSymbol.String(c.Query("symbol")))
+	defer end()
+
 	symbol := c.Query("symbol")
 	if symbol == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing 'symbol' query parameter"})
+		respondError(c, apperr.InvalidArgument(apperr.CodeSymbolRequired, "Missing 'symbol' query parameter"))
 		return
 	}
 
 	resp, err := h.Service.ListTrades(c.Request.Context(), symbol)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit takes a token for class from the bucket of the authenticated
+// account, or of the client IP when the request is unauthenticated, and
+// responds 429 with Retry-After when it is empty.
+func RateLimit(limiter *service.RateLimiter, class string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subject := "ip:" + c.ClientIP()
+		if account := GetAccount(c); account != nil {
+			subject = service.AccountSubject(account.ID)
+		}
+		if err := limiter.Allow(c.Request.Context(), class, subject); err != nil {
+			respondError(c, err)
+			return
+		}
+		c.Next()
+	}
+}
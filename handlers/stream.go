@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tracing"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	streamWriteWait    = 10 * time.Second
+	streamPongWait     = 60 * time.Second
+	streamPingInterval = streamPongWait * 9 / 10
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// GET /stream?symbol=XYZ&cancel_on_disconnect=true (WebSocket)
+//
+// Streams market data events of a symbol as JSON text messages: a snapshot
+// of the book, then book level changes and trades. Messages from the client
+// are ignored; it must answer pings to stay connected. With
+// cancel_on_disconnect, the account's resting orders on the symbol entered
+// while the stream was open are canceled when the connection ends, for
+// whatever reason, including missed pongs, but server shutdown.
+func (h *OrderHandler) Stream(c *gin.Context) {
+	_, end := startSpan(c, "OrderHandler.Stream", tracing.AttrSymbol.String(c.Query("symbol")))
+	defer end()
+
+	symbol := c.Query("symbol")
+	if symbol == "" {
+		respondError(c, apperr.InvalidArgument(apperr.CodeSymbolRequired, "Missing symbol query parameter"))
+		return
+	}
+	cancelOnDisconnect := c.Query("cancel_on_disconnect") == "true"
+	if cancelOnDisconnect {
+		if err := canCancelOnDisconnect(c); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	events, err := h.Service.MarketData.Subscribe(ctx, symbol)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	opened := time.Now() // before the client can see the stream open
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return // the upgrader has responded
+	}
+	if cancelOnDisconnect {
+		// Once the connection is closed; the request's context may be done
+		defer func() {
+			ctx := context.WithoutCancel(c.Request.Context())
+			if _, err := h.Service.DeadMansSwitch.StreamClosed(ctx, symbol, opened); err != nil {
+				log.Printf("stream %s: cancel on disconnect failed: %v", symbol, err)
+			}
+		}()
+	}
+	defer conn.Close()
+
+	// The read loop handles pongs and close frames, and notices when the
+	// client goes away
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(streamPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(streamPongWait))
+	})
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(streamPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case event, ok := <-events:
+			conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+			if !ok {
+				// The feed stopped or dropped us for falling behind
+				if ctx.Err() == nil {
+					conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "stream ended"))
+				}
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				log.Printf("stream %s: write failed: %v", symbol, err)
+				return
+			}
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// canCancelOnDisconnect checks that a stream's orders may be canceled when it
+// ends: its key must be allowed to cancel them.
+func canCancelOnDisconnect(c *gin.Context) error {
+	key, account := GetAPIKey(c), GetAccount(c)
+	switch {
+	case key == nil:
+		return apperr.InvalidArgument(apperr.CodeInvalidRequest, "cancel_on_disconnect needs an authenticated account")
+	case !key.HasScope(models.ScopeCancel):
+		return apperr.PermissionDenied(apperr.CodeInsufficientScope, "API key lacks the %q scope", models.ScopeCancel)
+	case !account.HasRole(models.RoleTrader):
+		return apperr.PermissionDenied(apperr.CodeInsufficientRole, "account role does not allow this operation; %q required", models.RoleTrader)
+	}
+	return nil
+}
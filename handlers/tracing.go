@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"github.com/Puneet-Vishnoi/order-matching-engine/middleware"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Puneet-Vishnoi/order-matching-engine/handlers")
+
+// startSpan opens the server span of a handler, continuing any trace the
+// caller propagated in its headers, and makes it the request's context.
+// The returned function ends the span with the response status.
+func startSpan(c *gin.Context, name string, attrs ...attribute.KeyValue) (trace.Span, func()) {
+	ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+	ctx, span := tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attrs...),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(c.Request.Method),
+			semconv.HTTPRoute(c.FullPath()),
+			attribute.String("request.id", middleware.GetRequestID(c)),
+		),
+	)
+	c.Request = c.Request.WithContext(ctx)
+
+	return span, func() {
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, "")
+		}
+		span.End()
+	}
+}
+
+// orderIDAttr tags a span with the :id path parameter.
+func orderIDAttr(c *gin.Context) attribute.KeyValue {
+	return attribute.String("order.id_param", c.Param("id"))
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tracing"
+	"github.com/gin-gonic/gin"
+)
+
+// Page sizes of GET /admin/deliveries.
+const (
+	defaultDeliveryLimit = 100
+	maxDeliveryLimit     = 1000
+)
+
+// idParam parses the :id path parameter of what, for the error message.
+func idParam(c *gin.Context, what string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, apperr.InvalidArgument(apperr.CodeInvalidRequest, "invalid %s ID", what))
+		return 0, false
+	}
+	return id, true
+}
+
+// POST /admin/webhooks
+func (h *AdminHandler) CreateWebhook(c *gin.Context) {
+	_, end := startSpan(c, "AdminHandler.CreateWebhook")
+	defer end()
+
+	var req models.CreateWebhookRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	resp, err := h.Webhooks.Register(c.Request.Context(), req.URL)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GET /admin/webhooks
+func (h *AdminHandler) ListWebhooks(c *gin.Context) {
+	_, end := startSpan(c, "AdminHandler.ListWebhooks")
+	defer end()
+
+	resp, err := h.Webhooks.List(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DELETE /admin/webhooks/:id
+func (h *AdminHandler) DeactivateWebhook(c *gin.Context) {
+	_, end := startSpan(c, "AdminHandler.DeactivateWebhook", tracing.AttrWebhookID.String(c.Param("id")))
+	defer end()
+
+	id, ok := idParam(c, "webhook")
+	if !ok {
+		return
+	}
+
+	hook, err := h.Webhooks.Deactivate(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, hook)
+}
+
+// POST /admin/webhooks/:id/replay replays the webhook's dead deliveries.
+func (h *AdminHandler) ReplayDeadDeliveries(c *gin.Context) {
+	_, end := startSpan(c, "AdminHandler.ReplayDeadDeliveries", tracing.AttrWebhookID.String(c.Param("id")))
+	defer end()
+
+	id, ok := idParam(c, "webhook")
+	if !ok {
+		return
+	}
+
+	resp, err := h.Webhooks.ReplayDead(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GET /admin/deliveries?webhook_id=&status=&limit=
+func (h *AdminHandler) ListDeliveries(c *gin.Context) {
+	_, end := startSpan(c, "AdminHandler.ListDeliveries")
+	defer end()
+
+	var filter models.DeliveryFilter
+	if raw := c.Query("webhook_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			respondError(c, apperr.InvalidArgument(apperr.CodeInvalidRequest, "invalid webhook ID"))
+			return
+		}
+		filter.WebhookID = id
+	}
+	switch status := c.Query("status"); status {
+	case "", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDead:
+		filter.Status = status
+	default:
+		respondError(c, apperr.InvalidArgument(apperr.CodeInvalidRequest, "status must be one of %s, %s, %s",
+			models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDead))
+		return
+	}
+	limit := defaultDeliveryLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxDeliveryLimit {
+			respondError(c, apperr.InvalidArgument(apperr.CodeInvalidRequest, "limit must be between 1 and %d", maxDeliveryLimit))
+			return
+		}
+		limit = n
+	}
+
+	resp, err := h.Webhooks.ListDeliveries(c.Request.Context(), filter, limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// POST /admin/deliveries/:id/replay
+func (h *AdminHandler) ReplayDelivery(c *gin.Context) {
+	_, end := startSpan(c, "AdminHandler.ReplayDelivery", tracing.AttrDeliveryID.String(c.Param("id")))
+	defer end()
+
+	id, ok := idParam(c, "delivery")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.Webhooks.Replay(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
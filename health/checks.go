@@ -0,0 +1,31 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/db/postgres/migrate"
+)
+
+// PostgresCheck pings the connection pool.
+func PostgresCheck(db *sql.DB) Check {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// MigrationsCheck fails until the schema is at the latest migration this
+// binary knows about, e.g. while `migrate up` has not been run yet.
+func MigrationsCheck(m *migrate.Migrator) Check {
+	return func(ctx context.Context) error {
+		version, err := m.Version(ctx)
+		if err != nil {
+			return err
+		}
+		if latest := m.Latest(); version < latest {
+			return fmt.Errorf("schema at version %d, want %d", version, latest)
+		}
+		return nil
+	}
+}
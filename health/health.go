@@ -0,0 +1,123 @@
+// Package health serves liveness and readiness probes. Liveness only says the
+// process is up; readiness says the instance should receive traffic, which
+// requires its dependencies to be reachable, startup warm-up to be done and
+// no shutdown to be in progress.
+package health
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Check returns nil when a dependency is healthy.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+type Checker struct {
+	Timeout time.Duration // per readiness probe, across all checks
+
+	mu       sync.RWMutex
+	checks   []namedCheck
+	warm     atomic.Bool
+	draining atomic.Bool
+}
+
+// Response is the body of both probes.
+type Response struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func NewChecker() *Checker {
+	return &Checker{Timeout: 2 * time.Second}
+}
+
+// AddCheck registers a dependency check run by every readiness probe.
+func (c *Checker) AddCheck(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// MarkWarm reports that startup warm-up (book loading, replay) is complete.
+// The instance is not ready before it is called.
+func (c *Checker) MarkWarm() {
+	c.warm.Store(true)
+}
+
+// StartDraining makes readiness fail from now on, so load balancers stop
+// routing new requests here before the server shuts down.
+func (c *Checker) StartDraining() {
+	c.draining.Store(true)
+}
+
+// Ready runs every check and reports the result of each. The probe is
+// unauthenticated, so a failed check only reports "failed"; its error is
+// logged.
+func (c *Checker) Ready(ctx context.Context) (bool, map[string]string) {
+	results := map[string]string{"warmup": "ok", "shutdown": "ok"}
+	ready := true
+	if !c.warm.Load() {
+		results["warmup"] = "in progress"
+		ready = false
+	}
+	if c.draining.Load() {
+		results["shutdown"] = "draining"
+		ready = false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	c.mu.RLock()
+	checks := c.checks
+	c.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	var resMu sync.Mutex
+	for _, nc := range checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+			status := "ok"
+			if err := nc.check(ctx); err != nil {
+				log.Printf("Readiness check %s failed: %v", nc.name, err)
+				status = "failed"
+			}
+			resMu.Lock()
+			defer resMu.Unlock()
+			results[nc.name] = status
+			if status != "ok" {
+				ready = false
+			}
+		}(nc)
+	}
+	wg.Wait()
+
+	return ready, results
+}
+
+// Liveness handles GET /healthz.
+func (c *Checker) Liveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, Response{Status: "ok"})
+}
+
+// Readiness handles GET /readyz.
+func (c *Checker) Readiness(ctx *gin.Context) {
+	ready, results := c.Ready(ctx.Request.Context())
+	if !ready {
+		ctx.JSON(http.StatusServiceUnavailable, Response{Status: "not_ready", Checks: results})
+		return
+	}
+	ctx.JSON(http.StatusOK, Response{Status: "ready", Checks: results})
+}
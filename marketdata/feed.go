@@ -0,0 +1,453 @@
+// Package marketdata distributes the order book and trades through Redis, so
+// every API instance can serve them without reading the book from storage.
+//
+// Instances that change the book publish the price levels that changed, and
+// the trades, on a pub/sub channel per symbol, and keep a snapshot of the
+// book (top of book and depth) in a hash per symbol:
+//
+//	<prefix>book:<symbol>  hash: seq, bids, asks (JSON levels), best_bid,
+//	                       best_bid_qty, best_ask, best_ask_qty, updated_at
+//	<prefix>feed:<symbol>  channel of models.MarketDataEvent (JSON)
+//
+// Every instance fans the channels out to its local subscribers. Pub/sub is
+// best-effort: events published while an instance is cut off from Redis are
+// lost to its subscribers, who see a gap in the book sequence numbers and
+// should resubscribe for a new snapshot.
+package marketdata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/cache/redis/providers"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/go-redis/redis/v8"
+	"github.com/shopspring/decimal"
+)
+
+// BookLoader reads the current book of a symbol from storage.
+type BookLoader func(ctx context.Context, symbol string) (*models.OrderBookResponse, error)
+
+// maxWatchRetries bounds the optimistic transactions of one book update when
+// several instances update the same symbol at once.
+const maxWatchRetries = 5
+
+// flushTimeout bounds publishing what is still pending on shutdown.
+const flushTimeout = time.Second
+
+type Feed struct {
+	Redis            *providers.RedisHelper
+	LoadBook         BookLoader
+	Prefix           string
+	SubscriberBuffer int // events queued per subscriber before it is dropped as too slow
+	Now              func() time.Time
+
+	mu      sync.Mutex
+	dirty   map[string]bool          // symbols whose book changed since the last flush
+	trades  []models.MarketDataEvent // trades not yet published, in execution order
+	wake    chan struct{}
+	subs    map[*subscriber]struct{}
+	stopped bool
+	done    chan struct{}
+}
+
+type subscriber struct {
+	symbol string
+	events chan models.MarketDataEvent
+}
+
+func NewFeed(helper *providers.RedisHelper, loadBook BookLoader) *Feed {
+	return &Feed{
+		Redis:            helper,
+		LoadBook:         loadBook,
+		Prefix:           "md:",
+		SubscriberBuffer: 256,
+		Now:              time.Now,
+		dirty:            make(map[string]bool),
+		wake:             make(chan struct{}, 1),
+		subs:             make(map[*subscriber]struct{}),
+		done:             make(chan struct{}),
+	}
+}
+
+func (f *Feed) bookKey(symbol string) string { return f.Prefix + "book:" + symbol }
+func (f *Feed) channel(symbol string) string { return f.Prefix + "feed:" + symbol }
+
+// Start subscribes to the channels of every symbol, then publishes changes
+// and fans events out in the background until ctx is done. Done is closed
+// once pending changes are flushed and every subscription is closed.
+func (f *Feed) Start(ctx context.Context) error {
+	ps := f.Redis.RedisClient.PSubscribe(ctx, f.channel("*"))
+	if _, err := ps.Receive(ctx); err != nil {
+		ps.Close()
+		return fmt.Errorf("failed to subscribe to market data: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		f.publishLoop(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		f.fanOut(ps.Channel())
+	}()
+	go func() {
+		<-ctx.Done()
+		ps.Close()
+	}()
+	go func() {
+		wg.Wait()
+		close(f.done)
+	}()
+	return nil
+}
+
+// Done is closed once the feed started by Start has stopped.
+func (f *Feed) Done() <-chan struct{} {
+	return f.done
+}
+
+// BookChanged schedules an update of symbol's book. It does not block: a
+// burst of changes is coalesced into one update.
+func (f *Feed) BookChanged(symbol string) {
+	f.mu.Lock()
+	f.dirty[symbol] = true
+	f.mu.Unlock()
+	f.signal()
+}
+
+// TradesExecuted schedules the publication of trades. It does not block.
+func (f *Feed) TradesExecuted(symbol string, trades []models.Trade) {
+	if len(trades) == 0 {
+		return
+	}
+	now := f.Now()
+	f.mu.Lock()
+	for i := range trades {
+		trade := trades[i]
+		f.trades = append(f.trades, models.MarketDataEvent{Type: models.MarketDataTrade, Symbol: symbol, Trade: &trade, Time: now})
+	}
+	f.mu.Unlock()
+	f.signal()
+}
+
+func (f *Feed) signal() {
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (f *Feed) publishLoop(ctx context.Context) {
+	for {
+		select {
+		case <-f.wake:
+			f.flush(ctx)
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+			f.flush(flushCtx)
+			cancel()
+			return
+		}
+	}
+}
+
+// flush publishes the pending trades, then the books that changed. Failures
+// are logged and dropped: the next change of a book publishes whatever the
+// failed update missed, as it diffs against the cached snapshot.
+func (f *Feed) flush(ctx context.Context) {
+	f.mu.Lock()
+	trades, dirty := f.trades, f.dirty
+	f.trades, f.dirty = nil, make(map[string]bool)
+	f.mu.Unlock()
+
+	if len(trades) > 0 {
+		if err := f.publishTrades(ctx, trades); err != nil {
+			log.Printf("marketdata: failed to publish %d trades: %v", len(trades), err)
+		}
+	}
+	for symbol := range dirty {
+		if err := f.publishBook(ctx, symbol); err != nil {
+			log.Printf("marketdata: failed to publish the book of %s: %v", symbol, err)
+		}
+	}
+}
+
+func (f *Feed) publishTrades(ctx context.Context, trades []models.MarketDataEvent) error {
+	_, err := f.Redis.RedisClient.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for _, event := range trades {
+			payload, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			p.Publish(ctx, f.channel(event.Symbol), payload)
+		}
+		return nil
+	})
+	return err
+}
+
+// publishBook loads the book of symbol, diffs it against the cached
+// snapshot and, when levels changed, replaces the snapshot and publishes the
+// changes in one transaction. The book is loaded while the snapshot is
+// watched, so an update racing with another instance's is retried against
+// the newer snapshot instead of overwriting it with an older book.
+func (f *Feed) publishBook(ctx context.Context, symbol string) error {
+	key := f.bookKey(symbol)
+	update := func(tx *redis.Tx) error {
+		prev, err := readSnapshot(ctx, tx, key, symbol)
+		if err != nil {
+			return err
+		}
+		book, err := f.LoadBook(ctx, symbol)
+		if err != nil {
+			return err
+		}
+
+		event := models.MarketDataEvent{Type: models.MarketDataBook, Symbol: symbol, Sequence: 1, Time: f.Now()}
+		if prev != nil {
+			event.Bids = diffLevels(prev.Bids, book.Bids)
+			event.Asks = diffLevels(prev.Asks, book.Asks)
+			if len(event.Bids) == 0 && len(event.Asks) == 0 {
+				return nil
+			}
+			event.Sequence = prev.Sequence + 1
+		} else {
+			event.Bids, event.Asks = book.Bids, book.Asks
+		}
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		// Published in the same transaction, so events go out in sequence order
+		// and a subscriber that reads the snapshot can drop those it contains
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			if err := writeSnapshot(ctx, p, key, book, event.Sequence, event.Time); err != nil {
+				return err
+			}
+			p.Publish(ctx, f.channel(symbol), payload)
+			return nil
+		})
+		return err
+	}
+
+	for attempt := 0; attempt < maxWatchRetries; attempt++ {
+		err := f.Redis.RedisClient.Watch(ctx, update, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return fmt.Errorf("snapshot kept changing after %d attempts", maxWatchRetries)
+}
+
+// Book returns the cached snapshot of symbol. A symbol missing from the
+// cache, e.g. because Redis lost its data, is loaded from storage and
+// published first.
+func (f *Feed) Book(ctx context.Context, symbol string) (*models.OrderBookResponse, error) {
+	key := f.bookKey(symbol)
+	book, err := readSnapshot(ctx, f.Redis.RedisClient, key, symbol)
+	if err != nil || book != nil {
+		return book, err
+	}
+	if err := f.publishBook(ctx, symbol); err != nil {
+		return nil, fmt.Errorf("failed to cache the book of %s: %w", symbol, err)
+	}
+	return readSnapshot(ctx, f.Redis.RedisClient, key, symbol)
+}
+
+// Subscribe streams the market data of symbol until ctx is done or the feed
+// stops, when the channel is closed. The first event is a snapshot of the
+// book; book events it already contains are skipped. A subscriber that does
+// not keep up is dropped, which also closes the channel.
+func (f *Feed) Subscribe(ctx context.Context, symbol string) (<-chan models.MarketDataEvent, error) {
+	sub := &subscriber{symbol: symbol, events: make(chan models.MarketDataEvent, f.SubscriberBuffer)}
+	f.mu.Lock()
+	if f.stopped {
+		close(sub.events)
+	} else {
+		f.subs[sub] = struct{}{}
+	}
+	f.mu.Unlock()
+
+	// Subscribed first, so no change falls between the snapshot and the stream
+	book, err := f.Book(ctx, symbol)
+	if err != nil {
+		f.unsubscribe(sub)
+		return nil, err
+	}
+
+	out := make(chan models.MarketDataEvent)
+	go func() {
+		defer close(out)
+		defer f.unsubscribe(sub)
+		send := func(event models.MarketDataEvent) bool {
+			select {
+			case out <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		snapshot := models.MarketDataEvent{
+			Type: models.MarketDataSnapshot, Symbol: symbol, Sequence: book.Sequence,
+			Bids: book.Bids, Asks: book.Asks, Time: f.Now(),
+		}
+		if !send(snapshot) {
+			return
+		}
+		for {
+			select {
+			case event, ok := <-sub.events:
+				if !ok {
+					return
+				}
+				if event.Type == models.MarketDataBook && event.Sequence <= book.Sequence {
+					continue
+				}
+				if !send(event) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (f *Feed) unsubscribe(sub *subscriber) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.subs[sub]; ok {
+		delete(f.subs, sub)
+		close(sub.events)
+	}
+}
+
+func (f *Feed) fanOut(messages <-chan *redis.Message) {
+	for msg := range messages {
+		var event models.MarketDataEvent
+		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+			log.Printf("marketdata: ignoring malformed event on %s: %v", msg.Channel, err)
+			continue
+		}
+		f.dispatch(event)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stopped = true
+	for sub := range f.subs {
+		delete(f.subs, sub)
+		close(sub.events)
+	}
+}
+
+func (f *Feed) dispatch(event models.MarketDataEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for sub := range f.subs {
+		if sub.symbol != event.Symbol {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			log.Printf("marketdata: dropping a slow %s subscriber", event.Symbol)
+			delete(f.subs, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// diffLevels returns the levels of next whose quantity differs from prev,
+// followed by the levels of prev that are gone, with quantity 0.
+func diffLevels(prev, next []models.OrderBookEntry) []models.OrderBookEntry {
+	before := make(map[float64]decimal.Decimal, len(prev))
+	for _, l := range prev {
+		before[l.Price] = l.Quantity
+	}
+	var changed []models.OrderBookEntry
+	for _, l := range next {
+		if qty, ok := before[l.Price]; !ok || !qty.Equal(l.Quantity) {
+			changed = append(changed, l)
+		}
+		delete(before, l.Price)
+	}
+	for _, l := range prev {
+		if _, gone := before[l.Price]; gone {
+			changed = append(changed, models.OrderBookEntry{Price: l.Price})
+		}
+	}
+	return changed
+}
+
+// Snapshot hash fields
+const (
+	fieldSequence   = "seq"
+	fieldBids       = "bids"
+	fieldAsks       = "asks"
+	fieldBestBid    = "best_bid"
+	fieldBestBidQty = "best_bid_qty"
+	fieldBestAsk    = "best_ask"
+	fieldBestAskQty = "best_ask_qty"
+	fieldUpdatedAt  = "updated_at" // unix milliseconds
+)
+
+func readSnapshot(ctx context.Context, r redis.Cmdable, key, symbol string) (*models.OrderBookResponse, error) {
+	fields, err := r.HMGet(ctx, key, fieldSequence, fieldBids, fieldAsks).Result()
+	if err != nil {
+		return nil, err
+	}
+	if fields[0] == nil {
+		return nil, nil
+	}
+	seq, _ := fields[0].(string)
+	bids, _ := fields[1].(string)
+	asks, _ := fields[2].(string)
+
+	book := &models.OrderBookResponse{Symbol: symbol}
+	if book.Sequence, err = strconv.ParseInt(seq, 10, 64); err != nil {
+		return nil, fmt.Errorf("malformed snapshot %s: %w", key, err)
+	}
+	if err := json.Unmarshal([]byte(bids), &book.Bids); err != nil {
+		return nil, fmt.Errorf("malformed snapshot %s: %w", key, err)
+	}
+	if err := json.Unmarshal([]byte(asks), &book.Asks); err != nil {
+		return nil, fmt.Errorf("malformed snapshot %s: %w", key, err)
+	}
+	return book, nil
+}
+
+func writeSnapshot(ctx context.Context, p redis.Pipeliner, key string, book *models.OrderBookResponse, seq int64, now time.Time) error {
+	bids, err := json.Marshal(book.Bids)
+	if err != nil {
+		return err
+	}
+	asks, err := json.Marshal(book.Asks)
+	if err != nil {
+		return err
+	}
+	p.HSet(ctx, key, fieldSequence, seq, fieldBids, bids, fieldAsks, asks, fieldUpdatedAt, now.UnixMilli())
+	setBest(ctx, p, key, fieldBestBid, fieldBestBidQty, book.Bids)
+	setBest(ctx, p, key, fieldBestAsk, fieldBestAskQty, book.Asks)
+	return nil
+}
+
+func setBest(ctx context.Context, p redis.Pipeliner, key, priceField, qtyField string, levels []models.OrderBookEntry) {
+	if len(levels) == 0 {
+		p.HDel(ctx, key, priceField, qtyField)
+		return
+	}
+	p.HSet(ctx, key, priceField, strconv.FormatFloat(levels[0].Price, 'f', -1, 64), qtyField, levels[0].Quantity.String())
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// RegisterTxRunner exposes a TxRunner's transaction and retry counters.
+func (m *Metrics) RegisterTxRunner(r *repository.TxRunner) {
+	counter := func(name, help string, value func(repository.TxStats) uint64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      name,
+			Help:      help,
+		}, func() float64 { return float64(value(r.Stats())) })
+	}
+
+	m.Registry.MustRegister(
+		counter("transactions_total", "Units of work run by the transaction runner.",
+			func(s repository.TxStats) uint64 { return s.Transactions }),
+		counter("transaction_retries_total", "Transaction attempts retried after a serialization failure or deadlock.",
+			func(s repository.TxStats) uint64 { return s.Retries }),
+		counter("transaction_retries_exhausted_total", "Transactions that failed after using every retry attempt.",
+			func(s repository.TxStats) uint64 { return s.Exhausted }),
+	)
+}
+
+// RegisterDBStats exposes connection pool statistics (open, in-use and idle
+// connections, waits for a free connection, closes by limit) as go_sql_*
+// metrics labelled with dbName.
+func (m *Metrics) RegisterDBStats(db *sql.DB, dbName string) {
+	m.Registry.MustRegister(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// DepthSource returns the current resting depth of every book.
+type DepthSource func(ctx context.Context) ([]models.BookDepth, error)
+
+// RegisterBookDepth exposes book depth gauges, computed from source at
+// scrape time so the order path pays nothing for them.
+func (m *Metrics) RegisterBookDepth(source DepthSource) {
+	m.Registry.MustRegister(&bookDepthCollector{
+		source: source,
+		orders: prometheus.NewDesc(prometheus.BuildFQName(namespace, "book", "depth_orders"),
+			"Resting orders, by symbol and side.", []string{"symbol", "side"}, nil),
+		levels: prometheus.NewDesc(prometheus.BuildFQName(namespace, "book", "depth_levels"),
+			"Distinct resting price levels, by symbol and side.", []string{"symbol", "side"}, nil),
+		quantity: prometheus.NewDesc(prometheus.BuildFQName(namespace, "book", "depth_quantity"),
+			"Resting quantity, by symbol and side.", []string{"symbol", "side"}, nil),
+	})
+}
+
+type bookDepthCollector struct {
+	source                   DepthSource
+	orders, levels, quantity *prometheus.Desc
+}
+
+func (c *bookDepthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.orders
+	ch <- c.levels
+	ch <- c.quantity
+}
+
+func (c *bookDepthCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	depths, err := c.source(ctx)
+	if err != nil {
+		log.Printf("Failed to collect book depth: %v", err)
+		return
+	}
+	for _, d := range depths {
+		ch <- prometheus.MustNewConstMetric(c.orders, prometheus.GaugeValue, float64(d.Orders), d.Symbol, d.Side)
+		ch <- prometheus.MustNewConstMetric(c.levels, prometheus.GaugeValue, float64(d.Levels), d.Symbol, d.Side)
+		ch <- prometheus.MustNewConstMetric(c.quantity, prometheus.GaugeValue, d.Quantity.InexactFloat64(), d.Symbol, d.Side)
+	}
+}
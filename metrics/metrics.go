@@ -0,0 +1,123 @@
+// Package metrics holds the Prometheus collectors for the engine and the
+// HTTP layer. Collectors live on a Metrics value with its own registry
+// instead of the global default one, so every service instance (and every
+// test) gets an isolated set it can assert on.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "order_engine"
+
+// OtherSymbol labels the series of symbols without their own. Only symbols
+// listed in the engine config get one, so clients cannot add series at
+// will by trading new symbols.
+const OtherSymbol = "other"
+
+// PlaceOrder stages timed by PlaceOrderStageSeconds
+const (
+	StageDBInsert    = "db_insert"
+	StageMatch       = "match"
+	StageTradeInsert = "trade_insert"
+	StageCommit      = "commit"
+	StageTotal       = "total"
+)
+
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	PlaceOrderStageSeconds *prometheus.HistogramVec // stage
+	OrdersPlaced           *prometheus.CounterVec   // symbol, side, type
+	Trades                 *prometheus.CounterVec   // symbol
+	TradedQuantity         *prometheus.CounterVec   // symbol
+	Rejections             *prometheus.CounterVec   // reason
+	CircuitBreakerTrips    *prometheus.CounterVec   // symbol
+	RateLimited            *prometheus.CounterVec   // class
+	WebhookDeliveries      *prometheus.CounterVec   // result
+	HTTPRequests           *prometheus.CounterVec   // route, method, status
+	HTTPRequestSeconds     *prometheus.HistogramVec // route, method
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		PlaceOrderStageSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "place_order_stage_seconds",
+			Help:      "Latency of each PlaceOrder stage.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"stage"}),
+		OrdersPlaced: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_placed_total",
+			Help:      "Orders accepted, by symbol, side and type.",
+		}, []string{"symbol", "side", "type"}),
+		Trades: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "trades_total",
+			Help:      "Trades executed, by symbol.",
+		}, []string{"symbol"}),
+		TradedQuantity: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "traded_quantity_total",
+			Help:      "Quantity executed, by symbol.",
+		}, []string{"symbol"}),
+		Rejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "order_rejections_total",
+			Help:      "Orders that failed to place, by error code.",
+		}, []string{"reason"}),
+		CircuitBreakerTrips: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "circuit_breaker_trips_total",
+			Help:      "Automatic trading halts, by symbol.",
+		}, []string{"symbol"}),
+		RateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limited_total",
+			Help:      "Requests refused by the rate limiter, by limit class.",
+		}, []string{"class"}),
+		WebhookDeliveries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "webhook_delivery_attempts_total",
+			Help:      "Webhook delivery attempts, by result: delivered, failed (to be retried) or dead.",
+		}, []string{"result"}),
+		HTTPRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests, by route, method and status code.",
+		}, []string{"route", "method", "status"}),
+		HTTPRequestSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_seconds",
+			Help:      "HTTP request latency, by route and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+	}
+
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.PlaceOrderStageSeconds,
+		m.OrdersPlaced,
+		m.Trades,
+		m.TradedQuantity,
+		m.Rejections,
+		m.CircuitBreakerTrips,
+		m.RateLimited,
+		m.WebhookDeliveries,
+		m.HTTPRequests,
+		m.HTTPRequestSeconds,
+	)
+	return m
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{Registry: m.Registry})
+}
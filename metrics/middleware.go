@@ -0,0 +1,24 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GinMiddleware records the status code and latency of every request, keyed
+// by route template (e.g. /api/orders/:id) to keep label cardinality bounded.
+func (m *Metrics) GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		m.HTTPRequests.WithLabelValues(route, c.Request.Method, strconv.Itoa(c.Writer.Status())).Inc()
+		m.HTTPRequestSeconds.WithLabelValues(route, c.Request.Method).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// API key scopes. A key may only call endpoints covered by its scopes.
+const (
+	ScopeRead   = "read"   // market data and own orders
+	ScopeTrade  = "trade"  // place orders
+	ScopeCancel = "cancel" // cancel own orders
+	ScopeAdmin  = "admin"  // manage accounts and keys
+)
+
+// Scopes lists every valid scope.
+var Scopes = []string{ScopeRead, ScopeTrade, ScopeCancel, ScopeAdmin}
+
+// Account roles, from least to most privileged. Each role may do everything
+// the roles before it may: traders can view, operators can trade, and so on.
+// A key's scopes narrow further what its account's role allows.
+const (
+	RoleViewer      = "viewer"       // market data and own orders
+	RoleTrader      = "trader"       // place and cancel own orders
+	RoleMarketMaker = "market_maker" // trader with market-making privileges
+	RoleOperator    = "operator"     // operational endpoints: audit log, trading controls
+	RoleAdmin       = "admin"        // accounts, roles and API keys
+)
+
+// Roles lists every valid role in order of privilege.
+var Roles = []string{RoleViewer, RoleTrader, RoleMarketMaker, RoleOperator, RoleAdmin}
+
+type Account struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HasRole reports whether the account's role is role or a more privileged
+// one.
+func (a *Account) HasRole(role string) bool {
+	have, need := slices.Index(Roles, a.Role), slices.Index(Roles, role)
+	return have >= 0 && need >= 0 && have >= need
+}
+
+// APIKey authenticates requests on behalf of an account. The secret is
+// stored encrypted with the server's key (auth.secret_key) and shown once,
+// when the key is issued or rotated.
+type APIKey struct {
+	ID           int64      `json:"-"`
+	KeyID        string     `json:"key_id"`
+	AccountID    int64      `json:"account_id"`
+	SealedSecret []byte     `json:"-"` // nil for keys issued before secrets were encrypted; they must be rotated
+	Scopes       []string   `json:"scopes"`
+	CreatedAt    time.Time  `json:"created_at"`
+	RotatedAt    *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HasScope reports whether the key grants scope.
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type CreateAccountRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+	Role string `json:"role" validate:"omitempty,oneof=viewer trader market_maker operator admin"` // defaults to trader
+}
+
+type UpdateAccountRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=viewer trader market_maker operator admin"`
+}
+
+type IssueAPIKeyRequest struct {
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,oneof=read trade cancel admin"`
+}
+
+// IssuedAPIKeyResponse carries the only copy of a new secret.
+type IssuedAPIKeyResponse struct {
+	APIKey
+	Secret string `json:"secret"`
+}
+
+type APIKeysResponse struct {
+	AccountID int64    `json:"account_id"`
+	Keys      []APIKey `json:"keys"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditResultOK is the Result of a privileged action that succeeded; failed
+// actions record their error code instead.
+const AuditResultOK = "ok"
+
+// AuditEntry records one privileged action: who did it, with which
+// parameters, and how it ended.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Params    json.RawMessage `json:"params"`
+	Result    string          `json:"result"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type AuditLogResponse struct {
+	Entries []AuditEntry `json:"entries"`
+}
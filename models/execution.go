@@ -0,0 +1,28 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// Execution types
+const (
+	ExecNew      = "new"
+	ExecTrade    = "trade"
+	ExecCanceled = "canceled"
+	ExecReplaced = "replaced"
+)
+
+// Execution is one committed change to an order, in the order they
+// happened.
+type Execution struct {
+	Type        string
+	Order       Order  // as it stood after the change
+	Trade       *Trade // for ExecTrade
+	Reason      string // why it was canceled, for ExecCanceled
+	OrigOrderID int64  // the order a replacement replaced, for ExecReplaced
+}
+
+// ReplaceOrderRequest changes the price and total quantity of a limit
+// order. Quantity includes what has already been filled.
+type ReplaceOrderRequest struct {
+	Price    float64         `json:"price" validate:"required,gt=0"`
+	Quantity decimal.Decimal `json:"quantity" validate:"required,gt=0"`
+}
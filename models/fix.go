@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// FIXSession is the persisted state of a FIX session with one
+// counterparty, so sequence numbers survive restarts.
+type FIXSession struct {
+	ID            string // the counterparty's SenderCompID
+	AccountID     int64  // bound on the first authenticated logon; 0 without auth
+	NextSenderSeq int    // of the next message sent to the counterparty
+	NextTargetSeq int    // expected of the next message it sends
+	UpdatedAt     time.Time
+}
+
+// FIXMessage is a message sent in a session, kept to answer resend
+// requests.
+type FIXMessage struct {
+	SessionID string
+	Seq       int
+	Body      []byte // the full message as sent
+}
+
+// FIXOrder links an order placed over FIX to the ClOrdID it was sent with.
+type FIXOrder struct {
+	OrderID   int64
+	SessionID string
+	ClOrdID   string
+	CreatedAt time.Time
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Market data event types
+const (
+	MarketDataSnapshot = "snapshot" // every level of the book; first event of a stream
+	MarketDataBook     = "book"     // levels that changed; quantity 0 removes the level
+	MarketDataTrade    = "trade"
+)
+
+// MarketDataEvent is one message of a symbol's market data feed. Book events
+// are numbered per symbol: a client that sees a gap in Sequence has missed
+// updates and should resubscribe for a new snapshot.
+type MarketDataEvent struct {
+	Type     string           `json:"type"`
+	Symbol   string           `json:"symbol"`
+	Sequence int64            `json:"sequence,omitempty"`
+	Bids     []OrderBookEntry `json:"bids,omitempty"`
+	Asks     []OrderBookEntry `json:"asks,omitempty"`
+	Trade    *Trade           `json:"trade,omitempty"`
+	Time     time.Time        `json:"time"`
+}
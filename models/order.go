@@ -1,15 +1,37 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 type Order struct {
-	ID               int64     `json:"id"`
-	Symbol           string    `json:"symbol"`
-	Side             string    `json:"side"`   // "buy" or "sell"
-	Type             string    `json:"type"`   // "limit" or "market"
-	Price            float64   `json:"price"`  // Only for limit orders
-	Quantity         int       `json:"quantity"`
-	RemainingQty     int       `json:"remaining_quantity"`
-	Status           string    `json:"status"` // "open", "partial", "filled", "canceled"
-	CreatedAt        time.Time `json:"created_at"`
+	ID           int64           `json:"id"`
+	AccountID    int64           `json:"account_id,omitempty"` // 0 when placed without authentication
+	Symbol       string          `json:"symbol"`
+	Side         string          `json:"side"`  // "buy" or "sell"
+	Type         string          `json:"type"`  // "limit" or "market"
+	Price        float64         `json:"price"` // Only for limit orders
+	Quantity     decimal.Decimal `json:"quantity"`
+	RemainingQty decimal.Decimal `json:"remaining_quantity"`
+	Status       string          `json:"status"` // "open", "partial", "filled", "canceled"
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// OrderFilter selects orders; zero fields match everything.
+type OrderFilter struct {
+	AccountID int64
+	Symbol    string
+	Side      string
+	Status    string
+}
+
+// BookDepth summarizes the resting orders on one side of a book.
+type BookDepth struct {
+	Symbol   string
+	Side     string
+	Orders   int
+	Levels   int
+	Quantity decimal.Decimal
 }
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Order event types
+const (
+	OrderEventCreated  = "created"
+	OrderEventFill     = "fill"
+	OrderEventCanceled = "canceled"
+)
+
+// Reasons recorded on order events
+const (
+	ReasonNewOrder    = "new_order"
+	ReasonMatched     = "matched"
+	ReasonUserRequest = "user_request"
+	ReasonNoLiquidity = "no_liquidity" // market order remainder with nothing left to match
+	ReasonReplaced    = "replaced"     // canceled, or created, by a cancel/replace
+	ReasonMassCancel  = "mass_cancel"  // canceled with the rest of the account's matching orders
+	ReasonOperator    = "operator_request"
+	ReasonDisconnect  = "disconnect"       // canceled when the session that opted into cancel-on-disconnect ended
+	ReasonDeadMans    = "dead_mans_switch" // canceled when the account's dead man's switch fired
+	ReasonAuction     = "auction"          // filled in the auction a symbol reopened through
+	ReasonPriceBand   = "price_band"       // market order remainder that would have traded beyond the price band
+	ReasonSlippage    = "slippage"         // market order remainder that would have traded beyond its protection
+)
+
+// OrderEvent is one recorded state transition of an order.
+type OrderEvent struct {
+	ID               int64           `json:"id"`
+	OrderID          int64           `json:"order_id"`
+	Type             string          `json:"type"`
+	PrevStatus       string          `json:"prev_status,omitempty"`
+	Status           string          `json:"status"`
+	PrevRemainingQty decimal.Decimal `json:"prev_remaining_quantity"`
+	RemainingQty     decimal.Decimal `json:"remaining_quantity"`
+	Reason           string          `json:"reason"`
+	TradeID          *int64          `json:"trade_id,omitempty"`
+	Actor            string          `json:"actor"`
+	CreatedAt        time.Time       `json:"created_at"`
+}
@@ -0,0 +1,10 @@
+package models
+
+// Rate limit classes. Each has its own token bucket per account.
+const (
+	RateLimitRead           = "read"             // market data and order queries
+	RateLimitPlace          = "place"            // order entry
+	RateLimitCancel         = "cancel"           // cancels
+	RateLimitPlacePerSymbol = "place_per_symbol" // order entry into one symbol
+	RateLimitOrderToTrade   = "order_to_trade"   // the order-to-trade ratio guard
+)
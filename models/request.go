@@ -1,13 +1,43 @@
 package models
 
+import "github.com/shopspring/decimal"
+
+// PlaceOrderRequest places an order. Market orders may carry a protection:
+// they stop trading MaxSlippagePct percent beyond the best opposite price at
+// arrival, overriding the symbol's default, or beyond ProtectionPrice, and
+// their remainder is canceled. Market buys may be sized by QuoteQuantity,
+// the amount of the quote currency to spend, instead of Quantity.
 type PlaceOrderRequest struct {
-	Symbol   string  `json:"symbol" validate:"required"`
-	Side     string  `json:"side" validate:"required,oneof=buy sell"`
-	Type     string  `json:"type" validate:"required,oneof=limit market"`
-	Price    float64 `json:"price,omitempty" validate:"omitempty,gt=0"` 
-	Quantity int     `json:"quantity" validate:"required,gt=0"`
+	Symbol          string          `json:"symbol" validate:"required"`
+	Side            string          `json:"side" validate:"required,oneof=buy sell"`
+	Type            string          `json:"type" validate:"required,oneof=limit market"`
+	Price           float64         `json:"price,omitempty" validate:"omitempty,gt=0"`
+	Quantity        decimal.Decimal `json:"quantity" validate:"required_without=QuoteQuantity,excluded_with=QuoteQuantity,gte=0"`
+	QuoteQuantity   decimal.Decimal `json:"quote_quantity,omitzero" validate:"omitempty,excluded_unless=Type market,excluded_unless=Side buy,gt=0"`
+	MaxSlippagePct  float64         `json:"max_slippage_pct,omitempty" validate:"omitempty,excluded_if=Type limit,gt=0,lt=100"`
+	ProtectionPrice float64         `json:"protection_price,omitempty" validate:"omitempty,excluded_if=Type limit,gt=0"`
 }
 
 type CancelOrderRequest struct {
 	OrderID int64 `json:"order_id" validate:"required"`
 }
+
+// BatchPlaceOrdersRequest places several orders in one request. With
+// AllOrNone, no order is placed unless every one is valid.
+type BatchPlaceOrdersRequest struct {
+	Orders    []PlaceOrderRequest `json:"orders"`
+	AllOrNone bool                `json:"all_or_none"`
+}
+
+// BatchCancelOrdersRequest cancels several orders in one request. With
+// AllOrNone, they are canceled in one transaction: all of them or none.
+type BatchCancelOrdersRequest struct {
+	OrderIDs  []int64 `json:"order_ids"`
+	AllOrNone bool    `json:"all_or_none"`
+}
+
+// DeadMansSwitchRequest arms the caller's dead man's switch to cancel all
+// its orders after TimeoutMS milliseconds; 0 disarms it.
+type DeadMansSwitchRequest struct {
+	TimeoutMS *int64 `json:"timeout_ms"`
+}
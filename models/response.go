@@ -1,30 +1,118 @@
 package models
 
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PlaceOrderResponse is the outcome of placing an order: AveragePrice is
+// that of its fills, and CancelReason why its remainder was canceled, if it
+// was. A market buy sized in the quote currency reports how much of its
+// amount it spent and how much is left over.
 type PlaceOrderResponse struct {
-	OrderID          int64  `json:"order_id"`
-	Status           string `json:"status"`             
-	RemainingQuantity int    `json:"remaining_quantity"` 
-	Message          string `json:"message,omitempty"`
+	OrderID           int64           `json:"order_id"`
+	Status            string          `json:"status"`
+	RemainingQuantity decimal.Decimal `json:"remaining_quantity"`
+	ExecutedQuantity  decimal.Decimal `json:"executed_quantity,omitzero"`
+	AveragePrice      float64         `json:"average_price,omitempty"`
+	CancelReason      string          `json:"cancel_reason,omitempty"`
+	QuoteSpent        decimal.Decimal `json:"quote_spent,omitzero"`
+	QuoteLeftover     decimal.Decimal `json:"quote_leftover,omitzero"`
+	Message           string          `json:"message,omitempty"`
 }
 
 type CancelOrderResponse struct {
 	Message string `json:"message"`
 }
 
+// MassCancelResponse lists the orders a mass cancel canceled, oldest first.
+type MassCancelResponse struct {
+	Canceled int     `json:"canceled"`
+	OrderIDs []int64 `json:"order_ids"`
+}
+
+// DeadMansSwitchResponse is the state of an account's dead man's switch.
+type DeadMansSwitchResponse struct {
+	TimeoutMS int64      `json:"timeout_ms"`
+	TriggerAt *time.Time `json:"trigger_at,omitempty"` // nil while disarmed
+}
+
 type OrderStatusResponse struct {
-	OrderID           int64   `json:"order_id"`
-	Status            string  `json:"status"`
-	ExecutedQuantity  int     `json:"executed_quantity"`
-	RemainingQuantity int     `json:"remaining_quantity"`
+	OrderID           int64           `json:"order_id"`
+	Status            string          `json:"status"`
+	ExecutedQuantity  decimal.Decimal `json:"executed_quantity"`
+	RemainingQuantity decimal.Decimal `json:"remaining_quantity"`
 }
 
 type OrderBookEntry struct {
-	Price    float64 `json:"price"`
-	Quantity int     `json:"quantity"`
+	Price    float64         `json:"price"`
+	Quantity decimal.Decimal `json:"quantity"`
 }
 
 type OrderBookResponse struct {
-	Symbol string            `json:"symbol"`
-	Bids   []OrderBookEntry  `json:"bids"`
-	Asks   []OrderBookEntry  `json:"asks"`
+	Symbol   string           `json:"symbol"`
+	Sequence int64            `json:"sequence,omitempty"` // of the cached snapshot; see MarketDataEvent
+	Bids     []OrderBookEntry `json:"bids"`
+	Asks     []OrderBookEntry `json:"asks"`
+}
+
+// Outcomes of an item of a batch request
+const (
+	BatchAccepted = "accepted"
+	BatchRejected = "rejected"
+)
+
+// BatchOrderResult is the outcome of one order of a batch, at the index it
+// had in the request.
+type BatchOrderResult struct {
+	Index             int             `json:"index"`
+	Result            string          `json:"result"` // BatchAccepted or BatchRejected
+	OrderID           int64           `json:"order_id,omitempty"`
+	Status            string          `json:"status,omitempty"`
+	RemainingQuantity decimal.Decimal `json:"remaining_quantity"`
+	ExecutedQuantity  decimal.Decimal `json:"executed_quantity,omitzero"`
+	AveragePrice      float64         `json:"average_price,omitempty"`
+	CancelReason      string          `json:"cancel_reason,omitempty"`
+	QuoteSpent        decimal.Decimal `json:"quote_spent,omitzero"`
+	QuoteLeftover     decimal.Decimal `json:"quote_leftover,omitzero"`
+	Fills             []Trade         `json:"fills,omitempty"`
+	Error             *ErrorBody      `json:"error,omitempty"` // why it was rejected
+}
+
+type BatchPlaceOrdersResponse struct {
+	Accepted int                `json:"accepted"`
+	Rejected int                `json:"rejected"`
+	Results  []BatchOrderResult `json:"results"`
+}
+
+// BatchCancelResult is the outcome of one ID of a batch cancel.
+type BatchCancelResult struct {
+	Index   int        `json:"index"`
+	OrderID int64      `json:"order_id"`
+	Result  string     `json:"result"` // BatchAccepted when canceled
+	Error   *ErrorBody `json:"error,omitempty"`
+}
+
+type BatchCancelOrdersResponse struct {
+	Canceled int                 `json:"canceled"`
+	Rejected int                 `json:"rejected"`
+	Results  []BatchCancelResult `json:"results"`
+}
+
+type OrderEventsResponse struct {
+	OrderID int64        `json:"order_id"`
+	Events  []OrderEvent `json:"events"`
+}
+
+// ErrorResponse is the envelope of every API error.
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Code      string            `json:"code"` // stable, machine-readable
+	Message   string            `json:"message"`
+	RequestID string            `json:"request_id,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
 }
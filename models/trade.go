@@ -1,12 +1,24 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 type Trade struct {
-	ID          int64     `json:"id"`
-	BuyOrderID  int64     `json:"buy_order_id"`
-	SellOrderID int64     `json:"sell_order_id"`
-	Price       float64   `json:"price"`
-	Quantity    int       `json:"quantity"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID          int64           `json:"id"`
+	BuyOrderID  int64           `json:"buy_order_id"`
+	SellOrderID int64           `json:"sell_order_id"`
+	Price       float64         `json:"price"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// PriceStats summarizes the trade prices of a symbol: the last one, and
+// the range of those since some time. Prices are 0 without trades.
+type PriceStats struct {
+	Last float64
+	Low  float64
+	High float64
 }
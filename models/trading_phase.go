@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Trading phases of a symbol. Symbols whose phase was never set trade
+// continuously.
+const (
+	PhasePreOpen    = "pre_open"   // limit orders rest unmatched until the open
+	PhaseContinuous = "continuous" // orders match as they arrive
+	PhaseHalted     = "halted"     // nothing matches; new orders are rejected or queued
+	PhaseClosed     = "closed"     // new orders are rejected
+)
+
+// TradingPhase is the phase a symbol trades in, as an operator or a circuit
+// breaker last set it. ReferencePrice is the centre of the symbol's static
+// price band; 0 leaves it to the configuration.
+type TradingPhase struct {
+	Symbol         string    `json:"symbol"`
+	Phase          string    `json:"phase"`
+	Reason         string    `json:"reason,omitempty"`
+	ReferencePrice float64   `json:"reference_price,omitempty"`
+	UpdatedBy      string    `json:"updated_by,omitempty"` // actor who set it
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// SetTradingPhaseRequest moves a symbol to another phase. Auction reopens
+// it through an auction that uncrosses the orders queued while it was not
+// trading; it only applies to a move to continuous. ReferencePrice, if set,
+// replaces the reference price of the symbol's static band; an auction that
+// trades replaces it with its price.
+type SetTradingPhaseRequest struct {
+	Phase          string  `json:"phase" validate:"required,oneof=pre_open continuous halted closed"`
+	Reason         string  `json:"reason" validate:"max=256"`
+	Auction        bool    `json:"auction"`
+	ReferencePrice float64 `json:"reference_price" validate:"gte=0"`
+}
+
+// TradingPhaseResponse is a symbol's phase, with the outcome of the auction
+// it reopened through, if any.
+type TradingPhaseResponse struct {
+	TradingPhase
+	Auction *AuctionResult `json:"auction,omitempty"`
+}
+
+// AuctionResult summarizes an auction: every trade executed at Price.
+// Price is 0 when the book did not cross.
+type AuctionResult struct {
+	Price    float64         `json:"price"`
+	Quantity decimal.Decimal `json:"quantity"`
+	Trades   int             `json:"trades"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Outbox event types
+const (
+	EventTradeExecuted = "trade.executed"
+)
+
+// OutboxEvent is an event written in the transaction that produced it, and
+// delivered to every active webhook afterwards.
+type OutboxEvent struct {
+	ID           int64           `json:"id"`
+	Type         string          `json:"type"`
+	Data         json.RawMessage `json:"data"`
+	CreatedAt    time.Time       `json:"created_at"`
+	DispatchedAt *time.Time      `json:"-"` // when deliveries were created for it
+}
+
+// TradeExecutedEvent is the data of a trade.executed event. Account IDs are
+// omitted for orders placed without authentication.
+type TradeExecutedEvent struct {
+	Symbol        string `json:"symbol"`
+	Trade         Trade  `json:"trade"`
+	BuyAccountID  int64  `json:"buy_account_id,omitempty"`
+	SellAccountID int64  `json:"sell_account_id,omitempty"`
+}
+
+// Webhook is an endpoint outbox events are POSTed to. As with API keys, only
+// a SHA-256 digest of its signing secret is stored.
+type Webhook struct {
+	ID         int64     `json:"id"`
+	URL        string    `json:"url"`
+	SecretHash []byte    `json:"-"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Delivery states
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead" // out of attempts; only a replay retries it
+)
+
+// WebhookDelivery tracks one event's delivery to one webhook.
+type WebhookDelivery struct {
+	ID            int64      `json:"id"`
+	WebhookID     int64      `json:"webhook_id"`
+	EventID       int64      `json:"event_id"`
+	EventType     string     `json:"event_type"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+// DeliveryFilter selects deliveries; zero fields match everything.
+type DeliveryFilter struct {
+	WebhookID int64
+	Status    string
+}
+
+type CreateWebhookRequest struct {
+	URL string `json:"url" validate:"required,url,startswith=http"`
+}
+
+// CreatedWebhookResponse carries the only copy of a new webhook's secret.
+type CreatedWebhookResponse struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+type WebhookListResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+type DeliveryListResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
+
+type ReplayResponse struct {
+	Replayed int `json:"replayed"`
+}
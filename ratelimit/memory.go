@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many operations a MemoryStore performs between sweeps
+// of idle buckets and expired counters.
+const sweepEvery = 1024
+
+// MemoryStore keeps buckets and counters in process memory. Limits are per
+// instance.
+type MemoryStore struct {
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	counters map[string]*counterSet
+	ops      int
+}
+
+type bucket struct {
+	tokens  float64
+	last    time.Time
+	forgets time.Time // full again by then
+}
+
+type counterSet struct {
+	fields  map[string]int64
+	expires time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:  make(map[string]*bucket),
+		counters: make(map[string]*counterSet),
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maybeSweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = refill(limit, b.tokens, b.last, now)
+	if now.After(b.last) {
+		b.last = now
+	}
+	b.forgets = b.last.Add(fullAfter(limit))
+
+	if b.tokens < 1 {
+		return Result{RetryAfter: retryAfter(limit, b.tokens)}, nil
+	}
+	b.tokens--
+	return Result{Allowed: true, Remaining: int(b.tokens)}, nil
+}
+
+func (s *MemoryStore) Count(ctx context.Context, key, field string, n int64, ttl time.Duration) (map[string]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.maybeSweep(now)
+
+	c, ok := s.counters[key]
+	if !ok || now.After(c.expires) {
+		c = &counterSet{fields: make(map[string]int64)}
+		s.counters[key] = c
+	}
+	c.fields[field] += n
+	c.expires = now.Add(ttl)
+
+	out := make(map[string]int64, len(c.fields))
+	for f, v := range c.fields {
+		out[f] = v
+	}
+	return out, nil
+}
+
+// maybeSweep drops idle buckets and expired counters now and then, so
+// one-off keys do not accumulate.
+func (s *MemoryStore) maybeSweep(now time.Time) {
+	s.ops++
+	if s.ops < sweepEvery {
+		return
+	}
+	s.ops = 0
+	for key, b := range s.buckets {
+		if now.After(b.forgets) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, c := range s.counters {
+		if now.After(c.expires) {
+			delete(s.counters, key)
+		}
+	}
+}
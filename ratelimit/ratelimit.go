@@ -0,0 +1,59 @@
+// Package ratelimit stores token buckets and windowed counters for rate
+// limiting. The memory Store limits one instance; the Redis Store shares
+// limits between every instance pointed at the same Redis.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit is a token bucket: it holds up to Burst tokens and refills at Rate
+// tokens per second. Each request takes one token. The zero Limit disables
+// limiting.
+type Limit struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+// Enabled reports whether l limits anything.
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed    bool
+	Remaining  int           // whole tokens left after this request
+	RetryAfter time.Duration // until a token is available, when not Allowed
+}
+
+// Store keeps the state of buckets and counters by key.
+type Store interface {
+	// Take takes a token from the bucket at key, refilled according to limit
+	// as of now.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+	// Count adds n to field of the counter set at key, which expires ttl
+	// after it was last written, and returns every field of the set.
+	Count(ctx context.Context, key, field string, n int64, ttl time.Duration) (map[string]int64, error)
+}
+
+// refill returns the tokens in a bucket that held tokens at last, as of now.
+func refill(limit Limit, tokens float64, last, now time.Time) float64 {
+	if elapsed := now.Sub(last); elapsed > 0 {
+		tokens += elapsed.Seconds() * limit.Rate
+	}
+	return math.Min(tokens, float64(limit.Burst))
+}
+
+// retryAfter is how long a bucket holding tokens (< 1) takes to refill one.
+func retryAfter(limit Limit, tokens float64) time.Duration {
+	return time.Duration(math.Ceil((1 - tokens) / limit.Rate * float64(time.Second)))
+}
+
+// fullAfter is how long an empty bucket takes to refill completely; a
+// bucket idle that long can be forgotten.
+func fullAfter(limit Limit) time.Duration {
+	return time.Duration(float64(limit.Burst) / limit.Rate * float64(time.Second))
+}
@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// takeScript is Take as one atomic step, so instances sharing a bucket
+// never both spend its last token. The bucket is a hash of the token count
+// (t) and the time it was last updated in milliseconds (ts); it expires once
+// it would be full again anyway.
+//
+// KEYS[1] bucket; ARGV rate per second, burst, now in ms.
+// Returns {allowed, remaining tokens, retry after in ms}.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call('HMGET', KEYS[1], 't', 'ts')
+local tokens = tonumber(state[1])
+local last = tonumber(state[2])
+if tokens == nil or last == nil then
+	tokens = burst
+	last = now
+end
+if now > last then
+	tokens = math.min(burst, tokens + (now - last) * rate / 1000)
+	last = now
+end
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call('HSET', KEYS[1], 't', tostring(tokens), 'ts', tostring(last))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, math.floor(tokens), retry}
+`)
+
+// RedisStore keeps buckets and counters in Redis, shared by every instance
+// using the same Redis and Prefix. Instances should keep their clocks in
+// sync: bucket refills are computed from the caller's now.
+type RedisStore struct {
+	Client redis.UniversalClient
+	Prefix string // namespaces the keys, e.g. "ratelimit:"
+}
+
+var _ Store = (*RedisStore)(nil)
+
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	return &RedisStore{Client: client, Prefix: prefix}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	res, err := takeScript.Run(ctx, s.Client, []string{s.Prefix + key},
+		strconv.FormatFloat(limit.Rate, 'f', -1, 64), limit.Burst, now.UnixMilli(),
+	).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Allowed:    res[0] == 1,
+		Remaining:  int(res[1]),
+		RetryAfter: time.Duration(res[2]) * time.Millisecond,
+	}, nil
+}
+
+func (s *RedisStore) Count(ctx context.Context, key, field string, n int64, ttl time.Duration) (map[string]int64, error) {
+	key = s.Prefix + key
+	var all *redis.StringStringMapCmd
+	_, err := s.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, key, field, n)
+		pipe.PExpire(ctx, key, ttl)
+		all = pipe.HGetAll(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]int64, len(all.Val()))
+	for f, v := range all.Val() {
+		if out[f], err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
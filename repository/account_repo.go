@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/db/postgres/providers"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/lib/pq"
+)
+
+// PostgresAccountRepository is the production AccountRepository.
+type PostgresAccountRepository struct {
+	DBHelper *providers.DBHelper
+}
+
+var _ AccountRepository = (*PostgresAccountRepository)(nil)
+
+func NewPostgresAccountRepository(db *providers.DBHelper) *PostgresAccountRepository {
+	return &PostgresAccountRepository{DBHelper: db}
+}
+
+// CreateAccount inserts an account and retrieves its ID
+func (r *PostgresAccountRepository) CreateAccount(ctx context.Context, tx Tx, account *models.Account) error {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO accounts (name, role, created_at) VALUES ($1, $2, $3) RETURNING id`
+	q, err := sqlTx(tx)
+	if err != nil {
+		return err
+	}
+	err = q.QueryRowContext(ctx, query, account.Name, account.Role, account.CreatedAt).Scan(&account.ID)
+	return translateError(err)
+}
+
+// GetAccount fetches one account by ID
+func (r *PostgresAccountRepository) GetAccount(ctx context.Context, tx Tx, id int64) (*models.Account, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT id, name, role, created_at FROM accounts WHERE id = $1`
+	q, err := queryer(r.DBHelper, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var a models.Account
+	err = q.QueryRowContext(ctx, query, id).Scan(&a.ID, &a.Name, &a.Role, &a.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperr.NotFound(apperr.CodeAccountNotFound, "account with ID %d not found", id)
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &a, nil
+}
+
+// UpdateAccount updates the name and role of an account
+func (r *PostgresAccountRepository) UpdateAccount(ctx context.Context, tx Tx, account *models.Account) error {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE accounts SET name = $1, role = $2 WHERE id = $3`
+	q, err := sqlTx(tx)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, query, account.Name, account.Role, account.ID)
+	return translateError(err)
+}
+
+// CreateAPIKey inserts a key and retrieves its ID
+func (r *PostgresAccountRepository) CreateAPIKey(ctx context.Context, tx Tx, key *models.APIKey) error {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO api_keys (key_id, account_id, sealed_secret, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+	q, err := sqlTx(tx)
+	if err != nil {
+		return err
+	}
+	err = q.QueryRowContext(ctx, query,
+		key.KeyID, key.AccountID, key.SealedSecret, pq.Array(key.Scopes), key.CreatedAt,
+	).Scan(&key.ID)
+	return translateError(err)
+}
+
+const apiKeyColumns = `id, key_id, account_id, sealed_secret, scopes, created_at, rotated_at, revoked_at`
+
+func scanAPIKey(scan func(dest ...any) error) (*models.APIKey, error) {
+	var k models.APIKey
+	var rotatedAt, revokedAt sql.NullTime
+	if err := scan(&k.ID, &k.KeyID, &k.AccountID, &k.SealedSecret, pq.Array(&k.Scopes), &k.CreatedAt, &rotatedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	if rotatedAt.Valid {
+		k.RotatedAt = &rotatedAt.Time
+	}
+	if revokedAt.Valid {
+		k.RevokedAt = &revokedAt.Time
+	}
+	return &k, nil
+}
+
+// GetAPIKey fetches a key by its public key ID
+func (r *PostgresAccountRepository) GetAPIKey(ctx context.Context, tx Tx, keyID string) (*models.APIKey, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_id = $1`
+	q, err := queryer(r.DBHelper, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := scanAPIKey(q.QueryRowContext(ctx, query, keyID).Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperr.NotFound(apperr.CodeAPIKeyNotFound, "API key %s not found", keyID)
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return key, nil
+}
+
+// UpdateAPIKey updates the mutable fields of a key
+func (r *PostgresAccountRepository) UpdateAPIKey(ctx context.Context, tx Tx, key *models.APIKey) error {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE api_keys
+		SET sealed_secret = $1, scopes = $2, rotated_at = $3, revoked_at = $4
+		WHERE id = $5`
+	q, err := sqlTx(tx)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, query, key.SealedSecret, pq.Array(key.Scopes), key.RotatedAt, key.RevokedAt, key.ID)
+	return translateError(err)
+}
+
+// ListAPIKeys fetches the keys of one account, oldest first
+func (r *PostgresAccountRepository) ListAPIKeys(ctx context.Context, accountID int64) ([]models.APIKey, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE account_id = $1 ORDER BY id ASC`
+	rows, err := r.DBHelper.PostgresClient.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows.Scan)
+		if err != nil {
+			return nil, translateError(err)
+		}
+		keys = append(keys, *key)
+	}
+	return keys, translateError(rows.Err())
+}
+
+// UseNonce records a nonce of a key until expires and reports whether it
+// was unused. The key's expired nonces are deleted first.
+func (r *PostgresAccountRepository) UseNonce(ctx context.Context, keyID, nonce string, expires, now time.Time) (bool, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	db := r.DBHelper.PostgresClient
+	if _, err := db.ExecContext(ctx, `DELETE FROM api_key_nonces WHERE key_id = $1 AND expires_at < $2`, keyID, now); err != nil {
+		return false, translateError(err)
+	}
+	res, err := db.ExecContext(ctx, `
+		INSERT INTO api_key_nonces (key_id, nonce, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key_id, nonce) DO NOTHING`, keyID, nonce, expires)
+	if err != nil {
+		return false, translateError(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, translateError(err)
+	}
+	return n == 1, nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/db/postgres/providers"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+)
+
+// PostgresAuditRepository is the production AuditRepository.
+type PostgresAuditRepository struct {
+	DBHelper *providers.DBHelper
+}
+
+var _ AuditRepository = (*PostgresAuditRepository)(nil)
+
+func NewPostgresAuditRepository(db *providers.DBHelper) *PostgresAuditRepository {
+	return &PostgresAuditRepository{DBHelper: db}
+}
+
+// CreateAuditEntry inserts an entry and retrieves its ID
+func (r *PostgresAuditRepository) CreateAuditEntry(ctx context.Context, tx Tx, entry *models.AuditEntry) error {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO admin_audit_log (actor, action, params, result, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+	q, err := queryer(r.DBHelper, tx)
+	if err != nil {
+		return err
+	}
+	params := entry.Params
+	if len(params) == 0 {
+		params = []byte("{}")
+	}
+	err = q.QueryRowContext(ctx, query,
+		entry.Actor, entry.Action, []byte(params), entry.Result, entry.CreatedAt,
+	).Scan(&entry.ID)
+	return translateError(err)
+}
+
+// ListAuditEntries fetches the latest entries, newest first
+func (r *PostgresAuditRepository) ListAuditEntries(ctx context.Context, limit int) ([]models.AuditEntry, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, actor, action, params, result, created_at
+		FROM admin_audit_log
+		ORDER BY id DESC
+		LIMIT $1`
+	rows, err := r.DBHelper.PostgresClient.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var e models.AuditEntry
+		var params []byte
+		if err := rows.Scan(&e.ID, &e.Actor, &e.Action, &params, &e.Result, &e.CreatedAt); err != nil {
+			return nil, translateError(err)
+		}
+		e.Params = params
+		entries = append(entries, e)
+	}
+	return entries, translateError(rows.Err())
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/lib/pq"
+)
+
+const (
+	sqlStateCheckViolation = "23514"
+	sqlStateQueryCanceled  = "57014" // statement_timeout or a canceled context
+)
+
+// translateError turns constraint violations and timeouts into domain errors
+// so callers can tell bad input and overload apart from database failures.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case sqlStateCheckViolation:
+			return apperr.InvalidArgument(apperr.CodeConstraintViolation, "%s", pqErr.Message)
+		case sqlStateQueryCanceled:
+			return apperr.Unavailable(apperr.CodeQueryTimeout, "database query timed out").Wrap(err)
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return apperr.Unavailable(apperr.CodeQueryTimeout, "database query timed out").Wrap(err)
+	}
+	return err
+}
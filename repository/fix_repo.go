@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/db/postgres/providers"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+)
+
+// PostgresFIXRepository is the production FIXRepository.
+type PostgresFIXRepository struct {
+	DBHelper *providers.DBHelper
+}
+
+var _ FIXRepository = (*PostgresFIXRepository)(nil)
+
+func NewPostgresFIXRepository(db *providers.DBHelper) *PostgresFIXRepository {
+	return &PostgresFIXRepository{DBHelper: db}
+}
+
+// GetFIXSession fetches a session's state by counterparty CompID
+func (r *PostgresFIXRepository) GetFIXSession(ctx context.Context, tx Tx, id string) (*models.FIXSession, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT id, account_id, next_sender_seq, next_target_seq, updated_at FROM fix_sessions WHERE id = $1`
+	q, err := queryer(r.DBHelper, tx)
+	if err != nil {
+		return nil, err
+	}
+	var s models.FIXSession
+	var accountID sql.NullInt64
+	err = q.QueryRowContext(ctx, query, id).Scan(&s.ID, &accountID, &s.NextSenderSeq, &s.NextTargetSeq, &s.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperr.NotFound(apperr.CodeFIXSessionNotFound, "FIX session %s not found", id)
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+	s.AccountID = accountID.Int64
+	return &s, nil
+}
+
+// SaveFIXSession upserts a session's state
+func (r *PostgresFIXRepository) SaveFIXSession(ctx context.Context, tx Tx, session *models.FIXSession) error {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO fix_sessions (id, account_id, next_sender_seq, next_target_seq, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE SET
+			account_id = EXCLUDED.account_id,
+			next_sender_seq = EXCLUDED.next_sender_seq,
+			next_target_seq = EXCLUDED.next_target_seq,
+			updated_at = EXCLUDED.updated_at`
+	q, err := queryer(r.DBHelper, tx)
+	if err != nil {
+		return err
+	}
+	accountID := sql.NullInt64{Int64: session.AccountID, Valid: session.AccountID != 0}
+	_, err = q.ExecContext(ctx, query, session.ID, accountID, session.NextSenderSeq, session.NextTargetSeq, session.UpdatedAt)
+	return translateError(err)
+}
+
+// SaveFIXMessage upserts a sent message
+func (r *PostgresFIXRepository) SaveFIXMessage(ctx context.Context, tx Tx, msg *models.FIXMessage) error {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO fix_messages (session_id, seq, body) VALUES ($1, $2, $3)
+		ON CONFLICT (session_id, seq) DO UPDATE SET body = EXCLUDED.body`
+	q, err := queryer(r.DBHelper, tx)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, query, msg.SessionID, msg.Seq, msg.Body)
+	return translateError(err)
+}
+
+// ListFIXMessages fetches a range of sent messages in sequence order
+func (r *PostgresFIXRepository) ListFIXMessages(ctx context.Context, tx Tx, sessionID string, from, to int) ([]models.FIXMessage, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT session_id, seq, body FROM fix_messages
+		WHERE session_id = $1 AND seq >= $2 AND ($3 = 0 OR seq <= $3)
+		ORDER BY seq`
+	q, err := queryer(r.DBHelper, tx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.QueryContext(ctx, query, sessionID, from, to)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	var msgs []models.FIXMessage
+	for rows.Next() {
+		var m models.FIXMessage
+		if err := rows.Scan(&m.SessionID, &m.Seq, &m.Body); err != nil {
+			return nil, translateError(err)
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, translateError(rows.Err())
+}
+
+// DeleteFIXMessages drops the sent messages of a session
+func (r *PostgresFIXRepository) DeleteFIXMessages(ctx context.Context, tx Tx, sessionID string) error {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	q, err := queryer(r.DBHelper, tx)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, `DELETE FROM fix_messages WHERE session_id = $1`, sessionID)
+	return translateError(err)
+}
+
+// CreateFIXOrder links an order to its ClOrdID
+func (r *PostgresFIXRepository) CreateFIXOrder(ctx context.Context, tx Tx, order *models.FIXOrder) error {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO fix_orders (order_id, session_id, cl_ord_id, created_at) VALUES ($1, $2, $3, $4)`
+	q, err := sqlTx(tx)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, query, order.OrderID, order.SessionID, order.ClOrdID, order.CreatedAt)
+	return translateError(err)
+}
+
+// GetFIXOrderByClOrdID fetches the latest order sent with a ClOrdID
+func (r *PostgresFIXRepository) GetFIXOrderByClOrdID(ctx context.Context, tx Tx, sessionID, clOrdID string) (*models.FIXOrder, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT order_id, session_id, cl_ord_id, created_at FROM fix_orders
+		WHERE session_id = $1 AND cl_ord_id = $2
+		ORDER BY order_id DESC
+		LIMIT 1`
+	q, err := queryer(r.DBHelper, tx)
+	if err != nil {
+		return nil, err
+	}
+	var o models.FIXOrder
+	err = q.QueryRowContext(ctx, query, sessionID, clOrdID).Scan(&o.OrderID, &o.SessionID, &o.ClOrdID, &o.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperr.NotFound(apperr.CodeOrderNotFound, "order with ClOrdID %s not found", clOrdID)
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &o, nil
+}
+
+// ListOpenFIXOrders fetches the links of orders still on the book
+func (r *PostgresFIXRepository) ListOpenFIXOrders(ctx context.Context, tx Tx) ([]models.FIXOrder, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT f.order_id, f.session_id, f.cl_ord_id, f.created_at
+		FROM fix_orders f
+		JOIN orders o ON o.id = f.order_id
+		WHERE o.status IN ('open', 'partial')
+		ORDER BY f.order_id`
+	q, err := queryer(r.DBHelper, tx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	var orders []models.FIXOrder
+	for rows.Next() {
+		var o models.FIXOrder
+		if err := rows.Scan(&o.OrderID, &o.SessionID, &o.ClOrdID, &o.CreatedAt); err != nil {
+			return nil, translateError(err)
+		}
+		orders = append(orders, o)
+	}
+	return orders, translateError(rows.Err())
+}
@@ -0,0 +1,207 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+)
+
+type AccountRepository struct {
+	Store *Store
+}
+
+var _ repository.AccountRepository = (*AccountRepository)(nil)
+
+func NewAccountRepository(store *Store) *AccountRepository {
+	return &AccountRepository{Store: store}
+}
+
+// CreateAccount stores an account and assigns it the next ID.
+func (r *AccountRepository) CreateAccount(ctx context.Context, tx repository.Tx, account *models.Account) error {
+	if err := checkRole(account.Role); err != nil {
+		return err
+	}
+	return r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		s.nextAcctID++
+		account.ID = s.nextAcctID
+		s.accounts[account.ID] = *account
+		t.onRollback(func() { delete(s.accounts, account.ID) })
+		return nil
+	})
+}
+
+// GetAccount fetches one account by ID.
+func (r *AccountRepository) GetAccount(ctx context.Context, tx repository.Tx, id int64) (*models.Account, error) {
+	var account models.Account
+	err := r.Store.within(ctx, tx, false, func(t *memTx) error {
+		a, ok := r.Store.accounts[id]
+		if !ok {
+			return apperr.NotFound(apperr.CodeAccountNotFound, "account with ID %d not found", id)
+		}
+		account = a
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// UpdateAccount updates the name and role of an account.
+func (r *AccountRepository) UpdateAccount(ctx context.Context, tx repository.Tx, account *models.Account) error {
+	if err := checkRole(account.Role); err != nil {
+		return err
+	}
+	return r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		prev, ok := s.accounts[account.ID]
+		if !ok {
+			return nil // matches an UPDATE that affects no rows
+		}
+		updated := prev
+		updated.Name = account.Name
+		updated.Role = account.Role
+		s.accounts[account.ID] = updated
+		t.onRollback(func() { s.accounts[account.ID] = prev })
+		return nil
+	})
+}
+
+// CreateAPIKey stores a key and assigns it the next ID. Like the foreign key
+// and unique constraint in PostgreSQL, it requires an existing account and an
+// unused key ID.
+func (r *AccountRepository) CreateAPIKey(ctx context.Context, tx repository.Tx, key *models.APIKey) error {
+	if err := checkScopes(key.Scopes); err != nil {
+		return err
+	}
+	return r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		if _, ok := s.accounts[key.AccountID]; !ok {
+			return apperr.NotFound(apperr.CodeAccountNotFound, "account with ID %d not found", key.AccountID)
+		}
+		if _, ok := s.apiKeys[key.KeyID]; ok {
+			return apperr.Conflict(apperr.CodeConstraintViolation, "duplicate API key ID %s", key.KeyID)
+		}
+		s.nextKeyID++
+		key.ID = s.nextKeyID
+		s.apiKeys[key.KeyID] = cloneKey(*key)
+		t.onRollback(func() { delete(s.apiKeys, key.KeyID) })
+		return nil
+	})
+}
+
+// GetAPIKey fetches a key by its public key ID.
+func (r *AccountRepository) GetAPIKey(ctx context.Context, tx repository.Tx, keyID string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.Store.within(ctx, tx, false, func(t *memTx) error {
+		k, ok := r.Store.apiKeys[keyID]
+		if !ok {
+			return apperr.NotFound(apperr.CodeAPIKeyNotFound, "API key %s not found", keyID)
+		}
+		key = cloneKey(k)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// UpdateAPIKey updates the mutable fields of a key.
+func (r *AccountRepository) UpdateAPIKey(ctx context.Context, tx repository.Tx, key *models.APIKey) error {
+	if err := checkScopes(key.Scopes); err != nil {
+		return err
+	}
+	return r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		prev, ok := s.apiKeys[key.KeyID]
+		if !ok {
+			return nil // matches an UPDATE that affects no rows
+		}
+		updated := cloneKey(prev)
+		updated.SealedSecret = slices.Clone(key.SealedSecret)
+		updated.Scopes = slices.Clone(key.Scopes)
+		updated.RotatedAt = key.RotatedAt
+		updated.RevokedAt = key.RevokedAt
+		s.apiKeys[key.KeyID] = updated
+		t.onRollback(func() { s.apiKeys[key.KeyID] = prev })
+		return nil
+	})
+}
+
+// ListAPIKeys returns the keys of one account, oldest first.
+func (r *AccountRepository) ListAPIKeys(ctx context.Context, accountID int64) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.Store.within(ctx, nil, false, func(t *memTx) error {
+		for _, k := range r.Store.apiKeys {
+			if k.AccountID == accountID {
+				keys = append(keys, cloneKey(k))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys, nil
+}
+
+// UseNonce records a nonce of a key until expires and reports whether it
+// was unused. The key's expired nonces are deleted first.
+func (r *AccountRepository) UseNonce(ctx context.Context, keyID, nonce string, expires, now time.Time) (bool, error) {
+	unused := false
+	err := r.Store.within(ctx, nil, true, func(t *memTx) error {
+		nonces := r.Store.nonces[keyID]
+		for n, exp := range nonces {
+			if exp.Before(now) {
+				delete(nonces, n)
+			}
+		}
+		if _, ok := nonces[nonce]; ok {
+			return nil
+		}
+		if nonces == nil {
+			nonces = make(map[string]time.Time)
+			r.Store.nonces[keyID] = nonces
+		}
+		nonces[nonce] = expires
+		unused = true
+		return nil
+	})
+	return unused, err
+}
+
+// checkScopes mirrors the CHECK constraint on api_keys.scopes.
+func checkScopes(scopes []string) error {
+	for _, s := range scopes {
+		if !slices.Contains(models.Scopes, s) {
+			return apperr.InvalidArgument(apperr.CodeConstraintViolation,
+				`new row for relation "api_keys" violates check constraint "api_keys_scopes_check"`)
+		}
+	}
+	return nil
+}
+
+// checkRole mirrors the CHECK constraint on accounts.role.
+func checkRole(role string) error {
+	if !slices.Contains(models.Roles, role) {
+		return apperr.InvalidArgument(apperr.CodeConstraintViolation,
+			`new row for relation "accounts" violates check constraint "accounts_role_check"`)
+	}
+	return nil
+}
+
+// cloneKey copies the slices of k so callers cannot modify stored state.
+func cloneKey(k models.APIKey) models.APIKey {
+	k.SealedSecret = slices.Clone(k.SealedSecret)
+	k.Scopes = slices.Clone(k.Scopes)
+	return k
+}
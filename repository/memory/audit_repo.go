@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+)
+
+type AuditRepository struct {
+	Store *Store
+}
+
+var _ repository.AuditRepository = (*AuditRepository)(nil)
+
+func NewAuditRepository(store *Store) *AuditRepository {
+	return &AuditRepository{Store: store}
+}
+
+// CreateAuditEntry stores an entry and assigns it the next ID.
+func (r *AuditRepository) CreateAuditEntry(ctx context.Context, tx repository.Tx, entry *models.AuditEntry) error {
+	return r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		s.nextAuditID++
+		entry.ID = s.nextAuditID
+		stored := *entry
+		stored.Params = slices.Clone(entry.Params)
+		s.audit[entry.ID] = stored
+		t.onRollback(func() { delete(s.audit, entry.ID) })
+		return nil
+	})
+}
+
+// ListAuditEntries returns up to limit entries, newest first.
+func (r *AuditRepository) ListAuditEntries(ctx context.Context, limit int) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+	err := r.Store.within(ctx, nil, false, func(t *memTx) error {
+		for _, e := range r.Store.audit {
+			e.Params = slices.Clone(e.Params)
+			entries = append(entries, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID > entries[j].ID })
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
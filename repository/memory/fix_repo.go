@@ -0,0 +1,176 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+)
+
+type FIXRepository struct {
+	Store *Store
+}
+
+var _ repository.FIXRepository = (*FIXRepository)(nil)
+
+func NewFIXRepository(store *Store) *FIXRepository {
+	return &FIXRepository{Store: store}
+}
+
+// GetFIXSession fetches a session's state.
+func (r *FIXRepository) GetFIXSession(ctx context.Context, tx repository.Tx, id string) (*models.FIXSession, error) {
+	var session models.FIXSession
+	err := r.Store.within(ctx, tx, false, func(t *memTx) error {
+		s, ok := r.Store.fixSessions[id]
+		if !ok {
+			return apperr.NotFound(apperr.CodeFIXSessionNotFound, "FIX session %s not found", id)
+		}
+		session = s
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// SaveFIXSession inserts or updates a session's state.
+func (r *FIXRepository) SaveFIXSession(ctx context.Context, tx repository.Tx, session *models.FIXSession) error {
+	return r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		prev, existed := s.fixSessions[session.ID]
+		s.fixSessions[session.ID] = *session
+		t.onRollback(func() {
+			if existed {
+				s.fixSessions[session.ID] = prev
+			} else {
+				delete(s.fixSessions, session.ID)
+			}
+		})
+		return nil
+	})
+}
+
+// SaveFIXMessage keeps a sent message, replacing any with its sequence
+// number.
+func (r *FIXRepository) SaveFIXMessage(ctx context.Context, tx repository.Tx, msg *models.FIXMessage) error {
+	return r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		if _, ok := s.fixSessions[msg.SessionID]; !ok {
+			return apperr.NotFound(apperr.CodeFIXSessionNotFound, "FIX session %s not found", msg.SessionID)
+		}
+		msgs := s.fixMessages[msg.SessionID]
+		if msgs == nil {
+			msgs = make(map[int]models.FIXMessage)
+			s.fixMessages[msg.SessionID] = msgs
+		}
+		prev, existed := msgs[msg.Seq]
+		stored := *msg
+		stored.Body = slices.Clone(msg.Body)
+		msgs[msg.Seq] = stored
+		t.onRollback(func() {
+			if existed {
+				msgs[msg.Seq] = prev
+			} else {
+				delete(msgs, msg.Seq)
+			}
+		})
+		return nil
+	})
+}
+
+// ListFIXMessages returns a range of kept messages in sequence order.
+func (r *FIXRepository) ListFIXMessages(ctx context.Context, tx repository.Tx, sessionID string, from, to int) ([]models.FIXMessage, error) {
+	var msgs []models.FIXMessage
+	err := r.Store.within(ctx, tx, false, func(t *memTx) error {
+		for seq, m := range r.Store.fixMessages[sessionID] {
+			if seq >= from && (to == 0 || seq <= to) {
+				m.Body = slices.Clone(m.Body)
+				msgs = append(msgs, m)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].Seq < msgs[j].Seq })
+	return msgs, nil
+}
+
+// DeleteFIXMessages drops every kept message of a session.
+func (r *FIXRepository) DeleteFIXMessages(ctx context.Context, tx repository.Tx, sessionID string) error {
+	return r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		prev, existed := s.fixMessages[sessionID]
+		delete(s.fixMessages, sessionID)
+		t.onRollback(func() {
+			if existed {
+				s.fixMessages[sessionID] = prev
+			}
+		})
+		return nil
+	})
+}
+
+// CreateFIXOrder links an order to its ClOrdID.
+func (r *FIXRepository) CreateFIXOrder(ctx context.Context, tx repository.Tx, order *models.FIXOrder) error {
+	return r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		if _, ok := s.orders[order.OrderID]; !ok {
+			return apperr.InvalidArgument(apperr.CodeConstraintViolation, "order %d does not exist", order.OrderID)
+		}
+		if _, ok := s.fixSessions[order.SessionID]; !ok {
+			return apperr.InvalidArgument(apperr.CodeConstraintViolation, "FIX session %s does not exist", order.SessionID)
+		}
+		if _, ok := s.fixOrders[order.OrderID]; ok {
+			return apperr.Conflict(apperr.CodeConstraintViolation, "order %d is already linked", order.OrderID)
+		}
+		s.fixOrders[order.OrderID] = *order
+		t.onRollback(func() { delete(s.fixOrders, order.OrderID) })
+		return nil
+	})
+}
+
+// GetFIXOrderByClOrdID fetches the latest order a session sent with
+// clOrdID.
+func (r *FIXRepository) GetFIXOrderByClOrdID(ctx context.Context, tx repository.Tx, sessionID, clOrdID string) (*models.FIXOrder, error) {
+	var found *models.FIXOrder
+	err := r.Store.within(ctx, tx, false, func(t *memTx) error {
+		for _, o := range r.Store.fixOrders {
+			if o.SessionID == sessionID && o.ClOrdID == clOrdID && (found == nil || o.OrderID > found.OrderID) {
+				found = &o
+			}
+		}
+		if found == nil {
+			return apperr.NotFound(apperr.CodeOrderNotFound, "order with ClOrdID %s not found", clOrdID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// ListOpenFIXOrders returns the links of every open or partially filled
+// order.
+func (r *FIXRepository) ListOpenFIXOrders(ctx context.Context, tx repository.Tx) ([]models.FIXOrder, error) {
+	var orders []models.FIXOrder
+	err := r.Store.within(ctx, tx, false, func(t *memTx) error {
+		for _, o := range r.Store.fixOrders {
+			if status := r.Store.orders[o.OrderID].Status; status == "open" || status == "partial" {
+				orders = append(orders, o)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].OrderID < orders[j].OrderID })
+	return orders, nil
+}
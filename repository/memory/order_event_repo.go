@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+)
+
+type OrderEventRepository struct {
+	Store *Store
+}
+
+var _ repository.OrderEventRepository = (*OrderEventRepository)(nil)
+
+func NewOrderEventRepository(store *Store) *OrderEventRepository {
+	return &OrderEventRepository{Store: store}
+}
+
+// CreateEvent stores an event and assigns it the next ID.
+func (r *OrderEventRepository) CreateEvent(ctx context.Context, tx repository.Tx, event *models.OrderEvent) error {
+	return r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		s.nextEventID++
+		event.ID = s.nextEventID
+		s.events[event.ID] = *event
+		t.onRollback(func() { delete(s.events, event.ID) })
+		return nil
+	})
+}
+
+// ListEventsByOrder returns the history of one order, oldest first.
+func (r *OrderEventRepository) ListEventsByOrder(ctx context.Context, orderID int64) ([]models.OrderEvent, error) {
+	var events []models.OrderEvent
+	err := r.Store.within(ctx, nil, false, func(t *memTx) error {
+		for _, e := range r.Store.events {
+			if e.OrderID == orderID {
+				events = append(events, e)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, nil
+}
@@ -0,0 +1,215 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+)
+
+type OrderRepository struct {
+	Store *Store
+}
+
+var _ repository.OrderRepository = (*OrderRepository)(nil)
+
+func NewOrderRepository(store *Store) *OrderRepository {
+	return &OrderRepository{Store: store}
+}
+
+// CreateOrder stores a new order and assigns it the next ID.
+func (r *OrderRepository) CreateOrder(ctx context.Context, tx repository.Tx, order *models.Order) (int64, error) {
+	if err := checkOrder(order); err != nil {
+		return 0, err
+	}
+	err := r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		s.nextOrderID++
+		order.ID = s.nextOrderID
+		s.orders[order.ID] = *order
+		t.onRollback(func() { delete(s.orders, order.ID) })
+		return nil
+	})
+	return order.ID, err
+}
+
+// UpdateOrder updates status and remaining quantity
+func (r *OrderRepository) UpdateOrder(ctx context.Context, tx repository.Tx, order *models.Order) error {
+	if err := checkOrder(order); err != nil {
+		return err
+	}
+	return r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		prev, ok := s.orders[order.ID]
+		if !ok {
+			return nil // matches an UPDATE that affects no rows
+		}
+		updated := prev
+		updated.RemainingQty = order.RemainingQty
+		updated.Status = order.Status
+		s.orders[order.ID] = updated
+		t.onRollback(func() { s.orders[order.ID] = prev })
+		return nil
+	})
+}
+
+// FetchOpenSellOrders returns open SELL orders, ordered by price ASC, time ASC.
+func (r *OrderRepository) FetchOpenSellOrders(ctx context.Context, tx repository.Tx, symbol string) ([]models.Order, error) {
+	return r.fetchOpen(ctx, tx, symbol, "sell", func(a, b float64) bool { return a < b })
+}
+
+// FetchOpenBuyOrders returns open BUY orders, ordered by price DESC, time ASC.
+func (r *OrderRepository) FetchOpenBuyOrders(ctx context.Context, tx repository.Tx, symbol string) ([]models.Order, error) {
+	return r.fetchOpen(ctx, tx, symbol, "buy", func(a, b float64) bool { return a > b })
+}
+
+func (r *OrderRepository) fetchOpen(ctx context.Context, tx repository.Tx, symbol, side string, better func(a, b float64) bool) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.Store.within(ctx, tx, false, func(t *memTx) error {
+		for _, o := range r.Store.orders {
+			if o.Symbol == symbol && o.Side == side && (o.Status == "open" || o.Status == "partial") {
+				orders = append(orders, o)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		a, b := orders[i], orders[j]
+		if a.Price != b.Price {
+			return better(a.Price, b.Price)
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+	return orders, nil
+}
+
+// GetOrderByID fetches one order by ID
+func (r *OrderRepository) GetOrderByID(ctx context.Context, tx repository.Tx, id int64) (*models.Order, error) {
+	var order models.Order
+	var found bool
+	err := r.Store.within(ctx, tx, false, func(t *memTx) error {
+		order, found = r.Store.orders[id]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, apperr.NotFound(apperr.CodeOrderNotFound, "order with ID %d not found", id)
+	}
+	return &order, nil
+}
+
+// FetchBookDepth summarizes resting orders per symbol and side.
+func (r *OrderRepository) FetchBookDepth(ctx context.Context) ([]models.BookDepth, error) {
+	type key struct{ symbol, side string }
+	depths := make(map[key]*models.BookDepth)
+	levels := make(map[key]map[float64]bool)
+
+	err := r.Store.within(ctx, nil, false, func(t *memTx) error {
+		for _, o := range r.Store.orders {
+			if o.Status != "open" && o.Status != "partial" {
+				continue
+			}
+			k := key{o.Symbol, o.Side}
+			d, ok := depths[k]
+			if !ok {
+				d = &models.BookDepth{Symbol: o.Symbol, Side: o.Side}
+				depths[k] = d
+				levels[k] = make(map[float64]bool)
+			}
+			d.Orders++
+			d.Quantity = d.Quantity.Add(o.RemainingQty)
+			levels[k][o.Price] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []models.BookDepth
+	for k, d := range depths {
+		d.Levels = len(levels[k])
+		result = append(result, *d)
+	}
+	return result, nil
+}
+
+// ListOrders returns up to limit orders matching filter, newest first.
+func (r *OrderRepository) ListOrders(ctx context.Context, filter models.OrderFilter, limit int) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.Store.within(ctx, nil, false, func(t *memTx) error {
+		for _, o := range r.Store.orders {
+			if matches(filter, &o) && (filter.Status == "" || o.Status == filter.Status) {
+				orders = append(orders, o)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID > orders[j].ID })
+	if len(orders) > limit {
+		orders = orders[:limit]
+	}
+	return orders, nil
+}
+
+// FetchOpenOrders returns the resting orders matching filter, oldest first.
+func (r *OrderRepository) FetchOpenOrders(ctx context.Context, tx repository.Tx, filter models.OrderFilter) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.Store.within(ctx, tx, false, func(t *memTx) error {
+		for _, o := range r.Store.orders {
+			if matches(filter, &o) && (o.Status == "open" || o.Status == "partial") {
+				orders = append(orders, o)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+	return orders, nil
+}
+
+// matches reports whether o is of filter's account, symbol and side.
+func matches(filter models.OrderFilter, o *models.Order) bool {
+	return (filter.AccountID == 0 || o.AccountID == filter.AccountID) &&
+		(filter.Symbol == "" || o.Symbol == filter.Symbol) &&
+		(filter.Side == "" || o.Side == filter.Side)
+}
+
+// checkOrder enforces the CHECK constraints of the orders table, with the
+// same wording PostgreSQL uses.
+func checkOrder(o *models.Order) error {
+	var constraint string
+	switch {
+	case o.Side != "buy" && o.Side != "sell":
+		constraint = "orders_side_check"
+	case o.Type != "limit" && o.Type != "market":
+		constraint = "orders_type_check"
+	case !o.Quantity.IsPositive():
+		constraint = "orders_quantity_check"
+	case o.RemainingQty.IsNegative():
+		constraint = "orders_remaining_quantity_check"
+	case o.Status != "open" && o.Status != "partial" && o.Status != "filled" && o.Status != "canceled":
+		constraint = "orders_status_check"
+	default:
+		return nil
+	}
+	return apperr.InvalidArgument(apperr.CodeConstraintViolation, "new row for relation \"orders\" violates check constraint \"%s\"", constraint)
+}
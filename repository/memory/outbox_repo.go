@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+)
+
+type OutboxRepository struct {
+	Store *Store
+}
+
+var _ repository.OutboxRepository = (*OutboxRepository)(nil)
+
+func NewOutboxRepository(store *Store) *OutboxRepository {
+	return &OutboxRepository{Store: store}
+}
+
+// CreateOutboxEvent stores an event and assigns it the next ID.
+func (r *OutboxRepository) CreateOutboxEvent(ctx context.Context, tx repository.Tx, event *models.OutboxEvent) error {
+	return r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		s.nextOutboxID++
+		event.ID = s.nextOutboxID
+		stored := *event
+		stored.Data = slices.Clone(event.Data)
+		s.outbox[event.ID] = stored
+		t.onRollback(func() { delete(s.outbox, event.ID) })
+		return nil
+	})
+}
+
+// ListUndispatched returns the oldest undispatched events. Transactions are
+// serialized, so holding tx is lock enough.
+func (r *OutboxRepository) ListUndispatched(ctx context.Context, tx repository.Tx, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.Store.within(ctx, tx, false, func(t *memTx) error {
+		for _, e := range r.Store.outbox {
+			if e.DispatchedAt == nil {
+				e.Data = slices.Clone(e.Data)
+				events = append(events, e)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+// MarkDispatched sets the dispatch time of events.
+func (r *OutboxRepository) MarkDispatched(ctx context.Context, tx repository.Tx, ids []int64, at time.Time) error {
+	return r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		for _, id := range ids {
+			prev, ok := s.outbox[id]
+			if !ok {
+				continue
+			}
+			updated := prev
+			updated.DispatchedAt = &at
+			s.outbox[id] = updated
+			t.onRollback(func() { s.outbox[id] = prev })
+		}
+		return nil
+	})
+}
+
+// GetOutboxEvent fetches one event by ID.
+func (r *OutboxRepository) GetOutboxEvent(ctx context.Context, tx repository.Tx, id int64) (*models.OutboxEvent, error) {
+	var event models.OutboxEvent
+	err := r.Store.within(ctx, tx, false, func(t *memTx) error {
+		e, ok := r.Store.outbox[id]
+		if !ok {
+			return apperr.NotFound(apperr.CodeEventNotFound, "event with ID %d not found", id)
+		}
+		event = e
+		event.Data = slices.Clone(e.Data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
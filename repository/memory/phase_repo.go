@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+)
+
+type TradingPhaseRepository struct {
+	Store *Store
+}
+
+var _ repository.TradingPhaseRepository = (*TradingPhaseRepository)(nil)
+
+func NewTradingPhaseRepository(store *Store) *TradingPhaseRepository {
+	return &TradingPhaseRepository{Store: store}
+}
+
+// GetTradingPhase fetches a symbol's phase.
+func (r *TradingPhaseRepository) GetTradingPhase(ctx context.Context, tx repository.Tx, symbol string) (*models.TradingPhase, error) {
+	var phase models.TradingPhase
+	err := r.Store.within(ctx, tx, false, func(t *memTx) error {
+		p, ok := r.Store.phases[symbol]
+		if !ok {
+			return apperr.NotFound(apperr.CodePhaseNotFound, "trading phase of %s not found", symbol)
+		}
+		phase = p
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &phase, nil
+}
+
+// SaveTradingPhase inserts or updates a symbol's phase.
+func (r *TradingPhaseRepository) SaveTradingPhase(ctx context.Context, tx repository.Tx, phase *models.TradingPhase) error {
+	return r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		prev, existed := s.phases[phase.Symbol]
+		s.phases[phase.Symbol] = *phase
+		t.onRollback(func() {
+			if existed {
+				s.phases[phase.Symbol] = prev
+			} else {
+				delete(s.phases, phase.Symbol)
+			}
+		})
+		return nil
+	})
+}
@@ -0,0 +1,161 @@
+// Package memory is an in-memory storage backend implementing the repository
+// interfaces. It needs no database, which makes it suitable for tests and
+// local development; PostgreSQL remains the production backend.
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+)
+
+var (
+	errForeignTx = errors.New("transaction was not opened by this memory store")
+	errReadOnly  = errors.New("cannot write in a read-only transaction")
+)
+
+// Store holds all tables in memory. Transactions are serialized: a Tx owns
+// the store from BeginTx until Commit or Rollback, so every transaction is
+// trivially serializable, and writes made through it are undone on Rollback.
+type Store struct {
+	sem chan struct{}
+
+	orders       map[int64]models.Order
+	trades       map[int64]models.Trade
+	events       map[int64]models.OrderEvent
+	accounts     map[int64]models.Account
+	apiKeys      map[string]models.APIKey        // by public key ID
+	nonces       map[string]map[string]time.Time // by key ID, then nonce: expiry
+	audit        map[int64]models.AuditEntry
+	outbox       map[int64]models.OutboxEvent
+	webhooks     map[int64]models.Webhook
+	deliveries   map[int64]models.WebhookDelivery
+	fixSessions  map[string]models.FIXSession
+	fixMessages  map[string]map[int]models.FIXMessage // by session, then seq
+	fixOrders    map[int64]models.FIXOrder            // by order ID
+	phases       map[string]models.TradingPhase       // by symbol
+	nextOrderID  int64
+	nextTradeID  int64
+	nextEventID  int64
+	nextAcctID   int64
+	nextKeyID    int64
+	nextAuditID  int64
+	nextOutboxID int64
+	nextHookID   int64
+	nextDelivID  int64
+}
+
+var _ repository.TxManager = (*Store)(nil)
+
+func NewStore() *Store {
+	return &Store{
+		sem:         make(chan struct{}, 1),
+		orders:      make(map[int64]models.Order),
+		trades:      make(map[int64]models.Trade),
+		events:      make(map[int64]models.OrderEvent),
+		accounts:    make(map[int64]models.Account),
+		apiKeys:     make(map[string]models.APIKey),
+		nonces:      make(map[string]map[string]time.Time),
+		audit:       make(map[int64]models.AuditEntry),
+		outbox:      make(map[int64]models.OutboxEvent),
+		webhooks:    make(map[int64]models.Webhook),
+		deliveries:  make(map[int64]models.WebhookDelivery),
+		fixSessions: make(map[string]models.FIXSession),
+		fixMessages: make(map[string]map[int]models.FIXMessage),
+		fixOrders:   make(map[int64]models.FIXOrder),
+		phases:      make(map[string]models.TradingPhase),
+	}
+}
+
+// BeginTx waits until no other transaction is open, or ctx is done.
+func (s *Store) BeginTx(ctx context.Context, opts *sql.TxOptions) (repository.Tx, error) {
+	if err := s.acquire(ctx); err != nil {
+		return nil, err
+	}
+	return &memTx{store: s, readOnly: opts != nil && opts.ReadOnly}, nil
+}
+
+func (s *Store) acquire(ctx context.Context) error {
+	select {
+	case s.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Store) release() {
+	<-s.sem
+}
+
+// within runs fn inside tx. A nil tx runs fn in its own short transaction
+// that commits when fn succeeds, like a statement on a *sql.DB. Callers that
+// already hold a tx must pass it, or they will wait on themselves.
+func (s *Store) within(ctx context.Context, tx repository.Tx, write bool, fn func(t *memTx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if tx == nil {
+		if err := s.acquire(ctx); err != nil {
+			return err
+		}
+		t := &memTx{store: s}
+		if err := fn(t); err != nil {
+			t.Rollback()
+			return err
+		}
+		return t.Commit()
+	}
+
+	t, ok := tx.(*memTx)
+	if !ok || t.store != s {
+		return errForeignTx
+	}
+	if t.done {
+		return sql.ErrTxDone
+	}
+	if write && t.readOnly {
+		return errReadOnly
+	}
+	return fn(t)
+}
+
+type memTx struct {
+	store    *Store
+	readOnly bool
+	done     bool
+	undo     []func()
+}
+
+// onRollback records how to revert a write made in this transaction.
+func (t *memTx) onRollback(fn func()) {
+	t.undo = append(t.undo, fn)
+}
+
+func (t *memTx) Commit() error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	t.undo = nil
+	t.store.release()
+	return nil
+}
+
+func (t *memTx) Rollback() error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		t.undo[i]()
+	}
+	t.undo = nil
+	t.store.release()
+	return nil
+}
@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+)
+
+type TradeRepository struct {
+	Store *Store
+}
+
+var _ repository.TradeRepository = (*TradeRepository)(nil)
+
+func NewTradeRepository(store *Store) *TradeRepository {
+	return &TradeRepository{Store: store}
+}
+
+// CreateTrade stores a trade and assigns it the next ID.
+func (r *TradeRepository) CreateTrade(ctx context.Context, tx repository.Tx, trade *models.Trade) error {
+	return r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		s.nextTradeID++
+		trade.ID = s.nextTradeID
+		s.trades[trade.ID] = *trade
+		t.onRollback(func() { delete(s.trades, trade.ID) })
+		return nil
+	})
+}
+
+// ListTradesBySymbol fetches trades where either side's order has the symbol.
+func (r *TradeRepository) ListTradesBySymbol(ctx context.Context, symbol string) ([]models.Trade, error) {
+	var trades []models.Trade
+	err := r.Store.within(ctx, nil, false, func(t *memTx) error {
+		s := r.Store
+		for _, trade := range s.trades {
+			if s.orders[trade.BuyOrderID].Symbol == symbol || s.orders[trade.SellOrderID].Symbol == symbol {
+				trades = append(trades, trade)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(trades, func(i, j int) bool { return trades[i].ID < trades[j].ID })
+	return trades, nil
+}
+
+// GetPriceStats returns the last trade price of a symbol and its range
+// since since.
+func (r *TradeRepository) GetPriceStats(ctx context.Context, tx repository.Tx, symbol string, since time.Time) (models.PriceStats, error) {
+	var stats models.PriceStats
+	err := r.Store.within(ctx, tx, false, func(t *memTx) error {
+		s := r.Store
+		var last int64
+		for _, trade := range s.trades {
+			if s.orders[trade.BuyOrderID].Symbol != symbol {
+				continue
+			}
+			if trade.ID > last {
+				last, stats.Last = trade.ID, trade.Price
+			}
+			if trade.CreatedAt.Before(since) {
+				continue
+			}
+			if stats.Low == 0 || trade.Price < stats.Low {
+				stats.Low = trade.Price
+			}
+			stats.High = max(stats.High, trade.Price)
+		}
+		return nil
+	})
+	return stats, err
+}
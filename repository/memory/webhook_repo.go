@@ -0,0 +1,208 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+)
+
+type WebhookRepository struct {
+	Store *Store
+}
+
+var _ repository.WebhookRepository = (*WebhookRepository)(nil)
+
+func NewWebhookRepository(store *Store) *WebhookRepository {
+	return &WebhookRepository{Store: store}
+}
+
+// CreateWebhook stores a webhook and assigns it the next ID.
+func (r *WebhookRepository) CreateWebhook(ctx context.Context, tx repository.Tx, hook *models.Webhook) error {
+	return r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		s.nextHookID++
+		hook.ID = s.nextHookID
+		stored := *hook
+		stored.SecretHash = slices.Clone(hook.SecretHash)
+		s.webhooks[hook.ID] = stored
+		t.onRollback(func() { delete(s.webhooks, hook.ID) })
+		return nil
+	})
+}
+
+// GetWebhook fetches one webhook by ID.
+func (r *WebhookRepository) GetWebhook(ctx context.Context, tx repository.Tx, id int64) (*models.Webhook, error) {
+	var hook models.Webhook
+	err := r.Store.within(ctx, tx, false, func(t *memTx) error {
+		h, ok := r.Store.webhooks[id]
+		if !ok {
+			return apperr.NotFound(apperr.CodeWebhookNotFound, "webhook with ID %d not found", id)
+		}
+		hook = h
+		hook.SecretHash = slices.Clone(h.SecretHash)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// UpdateWebhook updates whether a webhook is active.
+func (r *WebhookRepository) UpdateWebhook(ctx context.Context, tx repository.Tx, hook *models.Webhook) error {
+	return r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		prev, ok := s.webhooks[hook.ID]
+		if !ok {
+			return nil // matches an UPDATE that affects no rows
+		}
+		updated := prev
+		updated.Active = hook.Active
+		s.webhooks[hook.ID] = updated
+		t.onRollback(func() { s.webhooks[hook.ID] = prev })
+		return nil
+	})
+}
+
+// ListWebhooks returns every webhook, oldest first.
+func (r *WebhookRepository) ListWebhooks(ctx context.Context, tx repository.Tx) ([]models.Webhook, error) {
+	var hooks []models.Webhook
+	err := r.Store.within(ctx, tx, false, func(t *memTx) error {
+		for _, h := range r.Store.webhooks {
+			h.SecretHash = slices.Clone(h.SecretHash)
+			hooks = append(hooks, h)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].ID < hooks[j].ID })
+	return hooks, nil
+}
+
+// CreateDelivery stores a delivery and assigns it the next ID. Like the
+// foreign keys and unique constraint in PostgreSQL, it requires an existing
+// webhook and event, and one delivery per pair.
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, tx repository.Tx, d *models.WebhookDelivery) error {
+	return r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		if _, ok := s.webhooks[d.WebhookID]; !ok {
+			return apperr.InvalidArgument(apperr.CodeConstraintViolation, "webhook %d does not exist", d.WebhookID)
+		}
+		if _, ok := s.outbox[d.EventID]; !ok {
+			return apperr.InvalidArgument(apperr.CodeConstraintViolation, "event %d does not exist", d.EventID)
+		}
+		for _, existing := range s.deliveries {
+			if existing.WebhookID == d.WebhookID && existing.EventID == d.EventID {
+				return apperr.Conflict(apperr.CodeConstraintViolation, "event %d already has a delivery to webhook %d", d.EventID, d.WebhookID)
+			}
+		}
+		s.nextDelivID++
+		d.ID = s.nextDelivID
+		s.deliveries[d.ID] = *d
+		t.onRollback(func() { delete(s.deliveries, d.ID) })
+		return nil
+	})
+}
+
+// GetDelivery fetches one delivery by ID.
+func (r *WebhookRepository) GetDelivery(ctx context.Context, tx repository.Tx, id int64) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.Store.within(ctx, tx, false, func(t *memTx) error {
+		d, ok := r.Store.deliveries[id]
+		if !ok {
+			return apperr.NotFound(apperr.CodeDeliveryNotFound, "delivery with ID %d not found", id)
+		}
+		delivery = d
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// UpdateDelivery updates the mutable fields of a delivery.
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, tx repository.Tx, d *models.WebhookDelivery) error {
+	return r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		prev, ok := s.deliveries[d.ID]
+		if !ok {
+			return nil // matches an UPDATE that affects no rows
+		}
+		updated := prev
+		updated.Status = d.Status
+		updated.Attempts = d.Attempts
+		updated.NextAttemptAt = d.NextAttemptAt
+		updated.LastError = d.LastError
+		updated.UpdatedAt = d.UpdatedAt
+		updated.DeliveredAt = d.DeliveredAt
+		s.deliveries[d.ID] = updated
+		t.onRollback(func() { s.deliveries[d.ID] = prev })
+		return nil
+	})
+}
+
+// ClaimDueDeliveries leases the deliveries that are due, oldest first.
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, tx repository.Tx, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var claimed []models.WebhookDelivery
+	err := r.Store.within(ctx, tx, true, func(t *memTx) error {
+		s := r.Store
+		var due []models.WebhookDelivery
+		for _, d := range s.deliveries {
+			if d.Status == models.DeliveryPending && !d.NextAttemptAt.After(now) && s.webhooks[d.WebhookID].Active {
+				due = append(due, d)
+			}
+		}
+		sort.Slice(due, func(i, j int) bool {
+			if !due[i].NextAttemptAt.Equal(due[j].NextAttemptAt) {
+				return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+			}
+			return due[i].ID < due[j].ID
+		})
+		if len(due) > limit {
+			due = due[:limit]
+		}
+		for _, prev := range due {
+			updated := prev
+			updated.NextAttemptAt = leaseUntil
+			s.deliveries[prev.ID] = updated
+			t.onRollback(func() { s.deliveries[prev.ID] = prev })
+			claimed = append(claimed, updated)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// ListDeliveries returns up to limit deliveries matching filter, newest first.
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, filter models.DeliveryFilter, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.Store.within(ctx, nil, false, func(t *memTx) error {
+		for _, d := range r.Store.deliveries {
+			if (filter.WebhookID == 0 || d.WebhookID == filter.WebhookID) && (filter.Status == "" || d.Status == filter.Status) {
+				deliveries = append(deliveries, d)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID > deliveries[j].ID })
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/db/postgres/providers"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+)
+
+// PostgresOrderEventRepository is the production OrderEventRepository.
+type PostgresOrderEventRepository struct {
+	DBHelper *providers.DBHelper
+}
+
+var _ OrderEventRepository = (*PostgresOrderEventRepository)(nil)
+
+func NewPostgresOrderEventRepository(db *providers.DBHelper) *PostgresOrderEventRepository {
+	return &PostgresOrderEventRepository{DBHelper: db}
+}
+
+// CreateEvent inserts an event and retrieves its ID
+func (r *PostgresOrderEventRepository) CreateEvent(ctx context.Context, tx Tx, event *models.OrderEvent) error {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO order_events (order_id, event_type, prev_status, status, prev_remaining_quantity,
+			remaining_quantity, reason, trade_id, actor, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`
+	q, err := sqlTx(tx)
+	if err != nil {
+		return err
+	}
+	err = q.QueryRowContext(ctx, query,
+		event.OrderID, event.Type, event.PrevStatus, event.Status, event.PrevRemainingQty,
+		event.RemainingQty, event.Reason, event.TradeID, event.Actor, event.CreatedAt,
+	).Scan(&event.ID)
+	return translateError(err)
+}
+
+// ListEventsByOrder fetches the history of one order, oldest first
+func (r *PostgresOrderEventRepository) ListEventsByOrder(ctx context.Context, orderID int64) ([]models.OrderEvent, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, order_id, event_type, COALESCE(prev_status, ''), status, COALESCE(prev_remaining_quantity, 0),
+			remaining_quantity, reason, trade_id, actor, created_at
+		FROM order_events
+		WHERE order_id = $1
+		ORDER BY id ASC`
+
+	rows, err := r.DBHelper.PostgresClient.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	var events []models.OrderEvent
+	for rows.Next() {
+		var e models.OrderEvent
+		var tradeID sql.NullInt64
+		if err := rows.Scan(&e.ID, &e.OrderID, &e.Type, &e.PrevStatus, &e.Status, &e.PrevRemainingQty,
+			&e.RemainingQty, &e.Reason, &tradeID, &e.Actor, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if tradeID.Valid {
+			e.TradeID = &tradeID.Int64
+		}
+		events = append(events, e)
+	}
+	return events, translateError(rows.Err())
+}
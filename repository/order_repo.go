@@ -5,7 +5,9 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
 	"github.com/Puneet-Vishnoi/order-matching-engine/db/postgres/providers"
 	"github.com/Puneet-Vishnoi/order-matching-engine/models"
 )
@@ -18,106 +20,247 @@ import (
 // 	return &CouponRepository{DBHelper: db}
 // }
 
-type OrderRepository struct {
+// PostgresOrderRepository is the production OrderRepository.
+type PostgresOrderRepository struct {
 	DBHelper *providers.DBHelper
 }
 
-func NewOrderRepository(db *providers.DBHelper) *OrderRepository {
-	return &OrderRepository{DBHelper: db}
+var _ OrderRepository = (*PostgresOrderRepository)(nil)
+
+func NewPostgresOrderRepository(db *providers.DBHelper) *PostgresOrderRepository {
+	return &PostgresOrderRepository{DBHelper: db}
 }
 
 // CreateOrder inserts a new order into the DB.
-func (r *OrderRepository) CreateOrder(ctx context.Context, tx *sql.Tx, order *models.Order) (int64, error) {
+func (r *PostgresOrderRepository) CreateOrder(ctx context.Context, tx Tx, order *models.Order) (int64, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
-		INSERT INTO orders (symbol, side, type, price, quantity, remaining_quantity, status, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO orders (account_id, symbol, side, type, price, quantity, remaining_quantity, status, created_at)
+		VALUES (NULLIF($1::BIGINT, 0), $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id`
-	err := tx.QueryRowContext(ctx, query,
+	q, err := sqlTx(tx)
+	if err != nil {
+		return 0, err
+	}
+	err = q.QueryRowContext(ctx, query, order.AccountID,
 		order.Symbol, order.Side, order.Type, order.Price,
 		order.Quantity, order.RemainingQty, order.Status, order.CreatedAt,
 	).Scan(&order.ID)
-	return order.ID, err
+	return order.ID, translateError(err)
 }
 
 // UpdateOrder updates status and remaining quantity
-func (r *OrderRepository) UpdateOrder(ctx context.Context, tx *sql.Tx, order *models.Order) error {
+func (r *PostgresOrderRepository) UpdateOrder(ctx context.Context, tx Tx, order *models.Order) error {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		UPDATE orders
 		SET remaining_quantity = $1, status = $2
 		WHERE id = $3`
-	_, err := tx.ExecContext(ctx, query, order.RemainingQty, order.Status, order.ID)
-	return err
+	q, err := sqlTx(tx)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, query, order.RemainingQty, order.Status, order.ID)
+	return translateError(err)
 }
 
 // Fetch open SELL orders for a symbol, ordered by price ASC, time ASC (used when buying)
-func (r *OrderRepository) FetchOpenSellOrders(ctx context.Context, tx *sql.Tx, symbol string) ([]models.Order, error) {
+func (r *PostgresOrderRepository) FetchOpenSellOrders(ctx context.Context, tx Tx, symbol string) ([]models.Order, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
-		SELECT id, symbol, side, type, price, quantity, remaining_quantity, status, created_at
+		SELECT id, COALESCE(account_id, 0), symbol, side, type, price, quantity, remaining_quantity, status, created_at
 		FROM orders
 		WHERE symbol = $1 AND side = 'sell' AND status IN ('open', 'partial')
 		ORDER BY price ASC, created_at ASC`
-	rows, err := tx.QueryContext(ctx, query, symbol)
+	q, err := sqlTx(tx)
 	if err != nil {
 		return nil, err
 	}
+	rows, err := q.QueryContext(ctx, query, symbol)
+	if err != nil {
+		return nil, translateError(err)
+	}
 	defer rows.Close()
 
 	var orders []models.Order
 	for rows.Next() {
 		var o models.Order
-		if err := rows.Scan(&o.ID, &o.Symbol, &o.Side, &o.Type, &o.Price, &o.Quantity, &o.RemainingQty, &o.Status, &o.CreatedAt); err != nil {
-			return nil, err
+		if err := rows.Scan(&o.ID, &o.AccountID, &o.Symbol, &o.Side, &o.Type, &o.Price, &o.Quantity, &o.RemainingQty, &o.Status, &o.CreatedAt); err != nil {
+			return nil, translateError(err)
 		}
 		orders = append(orders, o)
 	}
-	return orders, nil
+	return orders, translateError(rows.Err())
 }
 
 // Fetch open BUY orders for a symbol, ordered by price DESC, time ASC (used when selling)
-func (r *OrderRepository) FetchOpenBuyOrders(ctx context.Context, tx *sql.Tx, symbol string) ([]models.Order, error) {
+func (r *PostgresOrderRepository) FetchOpenBuyOrders(ctx context.Context, tx Tx, symbol string) ([]models.Order, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
-		SELECT id, symbol, side, type, price, quantity, remaining_quantity, status, created_at
+		SELECT id, COALESCE(account_id, 0), symbol, side, type, price, quantity, remaining_quantity, status, created_at
 		FROM orders
 		WHERE symbol = $1 AND side = 'buy' AND status IN ('open', 'partial')
 		ORDER BY price DESC, created_at ASC`
-	rows, err := tx.QueryContext(ctx, query, symbol)
+	q, err := sqlTx(tx)
 	if err != nil {
 		return nil, err
 	}
+	rows, err := q.QueryContext(ctx, query, symbol)
+	if err != nil {
+		return nil, translateError(err)
+	}
 	defer rows.Close()
 
 	var orders []models.Order
 	for rows.Next() {
 		var o models.Order
-		if err := rows.Scan(&o.ID, &o.Symbol, &o.Side, &o.Type, &o.Price, &o.Quantity, &o.RemainingQty, &o.Status, &o.CreatedAt); err != nil {
-			return nil, err
+		if err := rows.Scan(&o.ID, &o.AccountID, &o.Symbol, &o.Side, &o.Type, &o.Price, &o.Quantity, &o.RemainingQty, &o.Status, &o.CreatedAt); err != nil {
+			return nil, translateError(err)
 		}
 		orders = append(orders, o)
 	}
-	return orders, nil
+	return orders, translateError(rows.Err())
 }
 
 // GetOrderByID fetches one order by ID
-func (r *OrderRepository) GetOrderByID(ctx context.Context, tx *sql.Tx, id int64) (*models.Order, error) {
+func (r *PostgresOrderRepository) GetOrderByID(ctx context.Context, tx Tx, id int64) (*models.Order, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
-		SELECT id, symbol, side, type, price, quantity, remaining_quantity, status, created_at
+		SELECT id, COALESCE(account_id, 0), symbol, side, type, price, quantity, remaining_quantity, status, created_at
 		FROM orders WHERE id = $1`
 	var o models.Order
 
-	var row *sql.Row
-	if tx != nil {
-		row = tx.QueryRowContext(ctx, query, id)
-	} else {
-		row = r.DBHelper.PostgresClient.QueryRowContext(ctx, query, id)
+	q, err := queryer(r.DBHelper, tx)
+	if err != nil {
+		return nil, err
 	}
 
-	err := row.Scan(&o.ID, &o.Symbol, &o.Side, &o.Type, &o.Price, &o.Quantity, &o.RemainingQty, &o.Status, &o.CreatedAt)
+	err = q.QueryRowContext(ctx, query, id).Scan(&o.ID, &o.AccountID, &o.Symbol, &o.Side, &o.Type, &o.Price, &o.Quantity, &o.RemainingQty, &o.Status, &o.CreatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("order with ID %d not found", id)
+			return nil, apperr.NotFound(apperr.CodeOrderNotFound, "order with ID %d not found", id)
 		}
-		return nil, fmt.Errorf("failed to get order by ID %d: %w", id, err)
+		return nil, translateError(fmt.Errorf("failed to get order by ID %d: %w", id, err))
 	}
 
 	return &o, nil
 }
+
+// FetchBookDepth summarizes resting orders per symbol and side
+func (r *PostgresOrderRepository) FetchBookDepth(ctx context.Context) ([]models.BookDepth, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT symbol, side, COUNT(*), COUNT(DISTINCT price), COALESCE(SUM(remaining_quantity), 0)
+		FROM orders
+		WHERE status IN ('open', 'partial')
+		GROUP BY symbol, side`
+	rows, err := r.DBHelper.PostgresClient.QueryContext(ctx, query)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	var depths []models.BookDepth
+	for rows.Next() {
+		var d models.BookDepth
+		if err := rows.Scan(&d.Symbol, &d.Side, &d.Orders, &d.Levels, &d.Quantity); err != nil {
+			return nil, translateError(err)
+		}
+		depths = append(depths, d)
+	}
+	return depths, translateError(rows.Err())
+}
+
+// ListOrders fetches the latest orders matching filter
+func (r *PostgresOrderRepository) ListOrders(ctx context.Context, filter models.OrderFilter, limit int) ([]models.Order, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	conds, args := orderConds(filter)
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conds = append(conds, fmt.Sprintf("status = $%d", len(args)))
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT id, COALESCE(account_id, 0), symbol, side, type, price, quantity, remaining_quantity, status, created_at
+		FROM orders %s ORDER BY id DESC LIMIT $%d`, where, len(args))
+
+	rows, err := r.DBHelper.PostgresClient.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return scanOrders(rows)
+}
+
+// FetchOpenOrders fetches the resting orders matching filter, oldest first
+func (r *PostgresOrderRepository) FetchOpenOrders(ctx context.Context, tx Tx, filter models.OrderFilter) ([]models.Order, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	conds, args := orderConds(filter)
+	conds = append(conds, "status IN ('open', 'partial')")
+	query := fmt.Sprintf(`
+		SELECT id, COALESCE(account_id, 0), symbol, side, type, price, quantity, remaining_quantity, status, created_at
+		FROM orders WHERE %s ORDER BY id ASC`, strings.Join(conds, " AND "))
+
+	q, err := sqlTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return scanOrders(rows)
+}
+
+// orderConds returns the conditions, and their arguments, that select the
+// orders of filter's account, symbol and side.
+func orderConds(filter models.OrderFilter) ([]string, []any) {
+	var conds []string
+	var args []any
+	if filter.AccountID != 0 {
+		args = append(args, filter.AccountID)
+		conds = append(conds, fmt.Sprintf("account_id = $%d", len(args)))
+	}
+	if filter.Symbol != "" {
+		args = append(args, filter.Symbol)
+		conds = append(conds, fmt.Sprintf("symbol = $%d", len(args)))
+	}
+	if filter.Side != "" {
+		args = append(args, filter.Side)
+		conds = append(conds, fmt.Sprintf("side = $%d", len(args)))
+	}
+	return conds, args
+}
+
+func scanOrders(rows *sql.Rows) ([]models.Order, error) {
+	defer rows.Close()
+
+	var orders []models.Order
+	for rows.Next() {
+		var o models.Order
+		if err := rows.Scan(&o.ID, &o.AccountID, &o.Symbol, &o.Side, &o.Type, &o.Price, &o.Quantity, &o.RemainingQty, &o.Status, &o.CreatedAt); err != nil {
+			return nil, translateError(err)
+		}
+		orders = append(orders, o)
+	}
+	return orders, translateError(rows.Err())
+}
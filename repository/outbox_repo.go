@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/db/postgres/providers"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/lib/pq"
+)
+
+// PostgresOutboxRepository is the production OutboxRepository.
+type PostgresOutboxRepository struct {
+	DBHelper *providers.DBHelper
+}
+
+var _ OutboxRepository = (*PostgresOutboxRepository)(nil)
+
+func NewPostgresOutboxRepository(db *providers.DBHelper) *PostgresOutboxRepository {
+	return &PostgresOutboxRepository{DBHelper: db}
+}
+
+// CreateOutboxEvent inserts an event and retrieves its ID
+func (r *PostgresOutboxRepository) CreateOutboxEvent(ctx context.Context, tx Tx, event *models.OutboxEvent) error {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO outbox (event_type, payload, created_at) VALUES ($1, $2, $3) RETURNING id`
+	q, err := sqlTx(tx)
+	if err != nil {
+		return err
+	}
+	err = q.QueryRowContext(ctx, query, event.Type, []byte(event.Data), event.CreatedAt).Scan(&event.ID)
+	return translateError(err)
+}
+
+// ListUndispatched locks the oldest undispatched events, skipping those
+// another dispatcher holds
+func (r *PostgresOutboxRepository) ListUndispatched(ctx context.Context, tx Tx, limit int) ([]models.OutboxEvent, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, event_type, payload, created_at, dispatched_at
+		FROM outbox
+		WHERE dispatched_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`
+	q, err := sqlTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	var events []models.OutboxEvent
+	for rows.Next() {
+		e, err := scanOutboxEvent(rows)
+		if err != nil {
+			return nil, translateError(err)
+		}
+		events = append(events, *e)
+	}
+	return events, translateError(rows.Err())
+}
+
+// MarkDispatched sets dispatched_at of the given events
+func (r *PostgresOutboxRepository) MarkDispatched(ctx context.Context, tx Tx, ids []int64, at time.Time) error {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE outbox SET dispatched_at = $1 WHERE id = ANY($2)`
+	q, err := sqlTx(tx)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, query, at, pq.Array(ids))
+	return translateError(err)
+}
+
+// GetOutboxEvent fetches one event by ID
+func (r *PostgresOutboxRepository) GetOutboxEvent(ctx context.Context, tx Tx, id int64) (*models.OutboxEvent, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT id, event_type, payload, created_at, dispatched_at FROM outbox WHERE id = $1`
+	q, err := queryer(r.DBHelper, tx)
+	if err != nil {
+		return nil, err
+	}
+	e, err := scanOutboxEvent(q.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperr.NotFound(apperr.CodeEventNotFound, "event with ID %d not found", id)
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return e, nil
+}
+
+func scanOutboxEvent(row interface{ Scan(...any) error }) (*models.OutboxEvent, error) {
+	var e models.OutboxEvent
+	var payload []byte
+	var dispatchedAt sql.NullTime
+	if err := row.Scan(&e.ID, &e.Type, &payload, &e.CreatedAt, &dispatchedAt); err != nil {
+		return nil, err
+	}
+	e.Data = payload
+	if dispatchedAt.Valid {
+		e.DispatchedAt = &dispatchedAt.Time
+	}
+	return &e, nil
+}
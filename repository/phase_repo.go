@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/db/postgres/providers"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+)
+
+// PostgresTradingPhaseRepository is the production TradingPhaseRepository.
+type PostgresTradingPhaseRepository struct {
+	DBHelper *providers.DBHelper
+}
+
+var _ TradingPhaseRepository = (*PostgresTradingPhaseRepository)(nil)
+
+func NewPostgresTradingPhaseRepository(db *providers.DBHelper) *PostgresTradingPhaseRepository {
+	return &PostgresTradingPhaseRepository{DBHelper: db}
+}
+
+// GetTradingPhase fetches a symbol's phase
+func (r *PostgresTradingPhaseRepository) GetTradingPhase(ctx context.Context, tx Tx, symbol string) (*models.TradingPhase, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT symbol, phase, reason, COALESCE(reference_price, 0), updated_by, updated_at
+		FROM trading_phases WHERE symbol = $1`
+	q, err := queryer(r.DBHelper, tx)
+	if err != nil {
+		return nil, err
+	}
+	var p models.TradingPhase
+	err = q.QueryRowContext(ctx, query, symbol).Scan(&p.Symbol, &p.Phase, &p.Reason, &p.ReferencePrice, &p.UpdatedBy, &p.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperr.NotFound(apperr.CodePhaseNotFound, "trading phase of %s not found", symbol)
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &p, nil
+}
+
+// SaveTradingPhase upserts a symbol's phase
+func (r *PostgresTradingPhaseRepository) SaveTradingPhase(ctx context.Context, tx Tx, phase *models.TradingPhase) error {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO trading_phases (symbol, phase, reason, reference_price, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (symbol) DO UPDATE SET
+			phase = EXCLUDED.phase,
+			reason = EXCLUDED.reason,
+			reference_price = EXCLUDED.reference_price,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at`
+	q, err := sqlTx(tx)
+	if err != nil {
+		return err
+	}
+	referencePrice := sql.NullFloat64{Float64: phase.ReferencePrice, Valid: phase.ReferencePrice != 0}
+	_, err = q.ExecContext(ctx, query, phase.Symbol, phase.Phase, phase.Reason, referencePrice, phase.UpdatedBy, phase.UpdatedAt)
+	return translateError(err)
+}
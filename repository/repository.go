@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+)
+
+// Tx is a unit of work opened by a TxManager. Repository calls made with the
+// same Tx see each other's writes and become visible to others only on Commit.
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// TxManager opens transactions for a storage backend. Repositories only
+// accept transactions opened by the TxManager of the same backend.
+type TxManager interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error)
+}
+
+// OrderRepository stores orders. Methods that take a Tx run inside it; a nil
+// Tx is only accepted where documented and reads committed state directly.
+type OrderRepository interface {
+	// CreateOrder inserts a new order and sets its ID.
+	CreateOrder(ctx context.Context, tx Tx, order *models.Order) (int64, error)
+	// UpdateOrder updates status and remaining quantity.
+	UpdateOrder(ctx context.Context, tx Tx, order *models.Order) error
+	// FetchOpenSellOrders returns open SELL orders for a symbol, ordered by price ASC, time ASC.
+	FetchOpenSellOrders(ctx context.Context, tx Tx, symbol string) ([]models.Order, error)
+	// FetchOpenBuyOrders returns open BUY orders for a symbol, ordered by price DESC, time ASC.
+	FetchOpenBuyOrders(ctx context.Context, tx Tx, symbol string) ([]models.Order, error)
+	// GetOrderByID fetches one order by ID. tx may be nil.
+	GetOrderByID(ctx context.Context, tx Tx, id int64) (*models.Order, error)
+	// FetchBookDepth summarizes committed resting orders per symbol and side.
+	FetchBookDepth(ctx context.Context) ([]models.BookDepth, error)
+	// ListOrders returns up to limit committed orders matching filter, newest first.
+	ListOrders(ctx context.Context, filter models.OrderFilter, limit int) ([]models.Order, error)
+	// FetchOpenOrders returns the open and partially filled orders matching
+	// filter, whatever its Status, oldest first.
+	FetchOpenOrders(ctx context.Context, tx Tx, filter models.OrderFilter) ([]models.Order, error)
+}
+
+// TradeRepository stores executed trades.
+type TradeRepository interface {
+	// CreateTrade saves a trade and sets its ID.
+	CreateTrade(ctx context.Context, tx Tx, trade *models.Trade) error
+	// ListTradesBySymbol fetches committed trades for a symbol.
+	ListTradesBySymbol(ctx context.Context, symbol string) ([]models.Trade, error)
+	// GetPriceStats returns the price of a symbol's last trade and the range
+	// of its trade prices since since. tx may be nil.
+	GetPriceStats(ctx context.Context, tx Tx, symbol string, since time.Time) (models.PriceStats, error)
+}
+
+// OrderEventRepository stores the state-transition history of orders.
+type OrderEventRepository interface {
+	// CreateEvent records an event and sets its ID.
+	CreateEvent(ctx context.Context, tx Tx, event *models.OrderEvent) error
+	// ListEventsByOrder returns an order's committed events, oldest first.
+	ListEventsByOrder(ctx context.Context, orderID int64) ([]models.OrderEvent, error)
+}
+
+// AccountRepository stores accounts and their API keys.
+type AccountRepository interface {
+	// CreateAccount inserts an account and sets its ID.
+	CreateAccount(ctx context.Context, tx Tx, account *models.Account) error
+	// GetAccount fetches one account. tx may be nil.
+	GetAccount(ctx context.Context, tx Tx, id int64) (*models.Account, error)
+	// UpdateAccount updates the name and role of an account.
+	UpdateAccount(ctx context.Context, tx Tx, account *models.Account) error
+	// CreateAPIKey inserts a key and sets its ID.
+	CreateAPIKey(ctx context.Context, tx Tx, key *models.APIKey) error
+	// GetAPIKey fetches a key, revoked or not, by its public key ID. tx may be nil.
+	GetAPIKey(ctx context.Context, tx Tx, keyID string) (*models.APIKey, error)
+	// UpdateAPIKey updates the sealed secret, scopes and rotation/revocation times.
+	UpdateAPIKey(ctx context.Context, tx Tx, key *models.APIKey) error
+	// ListAPIKeys returns an account's committed keys, oldest first.
+	ListAPIKeys(ctx context.Context, accountID int64) ([]models.APIKey, error)
+	// UseNonce records a nonce of a key until expires and reports whether
+	// it was unused. Shared by every instance, so a signed request cannot be
+	// replayed against another one.
+	UseNonce(ctx context.Context, keyID, nonce string, expires, now time.Time) (bool, error)
+}
+
+// AuditRepository stores the admin audit log. Entries are only ever added.
+type AuditRepository interface {
+	// CreateAuditEntry records an entry and sets its ID. tx may be nil.
+	CreateAuditEntry(ctx context.Context, tx Tx, entry *models.AuditEntry) error
+	// ListAuditEntries returns up to limit committed entries, newest first.
+	ListAuditEntries(ctx context.Context, limit int) ([]models.AuditEntry, error)
+}
+
+// OutboxRepository stores events for delivery after the transaction that
+// produced them commits.
+type OutboxRepository interface {
+	// CreateOutboxEvent records an event and sets its ID.
+	CreateOutboxEvent(ctx context.Context, tx Tx, event *models.OutboxEvent) error
+	// ListUndispatched returns up to limit undispatched events, oldest first,
+	// locked until tx ends; concurrent transactions skip them.
+	ListUndispatched(ctx context.Context, tx Tx, limit int) ([]models.OutboxEvent, error)
+	// MarkDispatched sets the dispatch time of events.
+	MarkDispatched(ctx context.Context, tx Tx, ids []int64, at time.Time) error
+	// GetOutboxEvent fetches one event. tx may be nil.
+	GetOutboxEvent(ctx context.Context, tx Tx, id int64) (*models.OutboxEvent, error)
+}
+
+// WebhookRepository stores webhooks and the deliveries of outbox events to
+// them.
+type WebhookRepository interface {
+	// CreateWebhook inserts a webhook and sets its ID.
+	CreateWebhook(ctx context.Context, tx Tx, hook *models.Webhook) error
+	// GetWebhook fetches one webhook. tx may be nil.
+	GetWebhook(ctx context.Context, tx Tx, id int64) (*models.Webhook, error)
+	// UpdateWebhook updates whether a webhook is active.
+	UpdateWebhook(ctx context.Context, tx Tx, hook *models.Webhook) error
+	// ListWebhooks returns every webhook, oldest first. tx may be nil.
+	ListWebhooks(ctx context.Context, tx Tx) ([]models.Webhook, error)
+	// CreateDelivery inserts a delivery and sets its ID.
+	CreateDelivery(ctx context.Context, tx Tx, delivery *models.WebhookDelivery) error
+	// GetDelivery fetches one delivery. tx may be nil.
+	GetDelivery(ctx context.Context, tx Tx, id int64) (*models.WebhookDelivery, error)
+	// UpdateDelivery updates the status, attempts, schedule and outcome of a
+	// delivery. tx may be nil.
+	UpdateDelivery(ctx context.Context, tx Tx, delivery *models.WebhookDelivery) error
+	// ClaimDueDeliveries leases up to limit pending deliveries of active
+	// webhooks that are due at now, oldest first: their next attempt moves to
+	// leaseUntil, so other dispatchers skip them until then. tx may be nil.
+	ClaimDueDeliveries(ctx context.Context, tx Tx, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error)
+	// ListDeliveries returns up to limit committed deliveries matching filter, newest first.
+	ListDeliveries(ctx context.Context, filter models.DeliveryFilter, limit int) ([]models.WebhookDelivery, error)
+}
+
+// FIXRepository stores the state of FIX sessions and the orders placed
+// through them.
+type FIXRepository interface {
+	// GetFIXSession fetches a session's state. tx may be nil.
+	GetFIXSession(ctx context.Context, tx Tx, id string) (*models.FIXSession, error)
+	// SaveFIXSession inserts or updates a session's state. tx may be nil.
+	SaveFIXSession(ctx context.Context, tx Tx, session *models.FIXSession) error
+	// SaveFIXMessage keeps a sent message, replacing any with its sequence
+	// number. tx may be nil.
+	SaveFIXMessage(ctx context.Context, tx Tx, msg *models.FIXMessage) error
+	// ListFIXMessages returns the kept messages of a session numbered from
+	// from to to inclusive, in order; to 0 means no upper bound. tx may be nil.
+	ListFIXMessages(ctx context.Context, tx Tx, sessionID string, from, to int) ([]models.FIXMessage, error)
+	// DeleteFIXMessages drops every kept message of a session, when its
+	// sequence numbers are reset. tx may be nil.
+	DeleteFIXMessages(ctx context.Context, tx Tx, sessionID string) error
+	// CreateFIXOrder links an order to its ClOrdID.
+	CreateFIXOrder(ctx context.Context, tx Tx, order *models.FIXOrder) error
+	// GetFIXOrderByClOrdID fetches the latest order a session sent with
+	// clOrdID. tx may be nil.
+	GetFIXOrderByClOrdID(ctx context.Context, tx Tx, sessionID, clOrdID string) (*models.FIXOrder, error)
+	// ListOpenFIXOrders returns the links of every open or partially filled
+	// order. tx may be nil.
+	ListOpenFIXOrders(ctx context.Context, tx Tx) ([]models.FIXOrder, error)
+}
+
+// TradingPhaseRepository stores the trading phase of each symbol.
+type TradingPhaseRepository interface {
+	// GetTradingPhase fetches a symbol's phase; symbols whose phase was
+	// never set are not found. tx may be nil.
+	GetTradingPhase(ctx context.Context, tx Tx, symbol string) (*models.TradingPhase, error)
+	// SaveTradingPhase inserts or updates a symbol's phase.
+	SaveTradingPhase(ctx context.Context, tx Tx, phase *models.TradingPhase) error
+}
@@ -0,0 +1,479 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Puneet-Vishnoi/order-matching-engine/repository")
+
+// startSpan opens a client span for one repository call.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// TracedOrderRepository wraps an OrderRepository with a span per call.
+type TracedOrderRepository struct {
+	Inner OrderRepository
+}
+
+var _ OrderRepository = (*TracedOrderRepository)(nil)
+
+func NewTracedOrderRepository(inner OrderRepository) *TracedOrderRepository {
+	return &TracedOrderRepository{Inner: inner}
+}
+
+func (r *TracedOrderRepository) CreateOrder(ctx context.Context, tx Tx, order *models.Order) (id int64, err error) {
+	ctx, span := startSpan(ctx, "OrderRepository.CreateOrder", tracing.AttrSymbol.String(order.Symbol), tracing.AttrSide.String(order.Side))
+	defer func() { tracing.End(span, err) }()
+
+	id, err = r.Inner.CreateOrder(ctx, tx, order)
+	span.SetAttributes(tracing.AttrOrderID.Int64(id))
+	return id, err
+}
+
+func (r *TracedOrderRepository) UpdateOrder(ctx context.Context, tx Tx, order *models.Order) (err error) {
+	ctx, span := startSpan(ctx, "OrderRepository.UpdateOrder", tracing.AttrOrderID.Int64(order.ID), tracing.AttrOrderStatus.String(order.Status))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.UpdateOrder(ctx, tx, order)
+}
+
+func (r *TracedOrderRepository) FetchOpenSellOrders(ctx context.Context, tx Tx, symbol string) (orders []models.Order, err error) {
+	ctx, span := startSpan(ctx, "OrderRepository.FetchOpenSellOrders", tracing.AttrSymbol.String(symbol))
+	defer func() { tracing.End(span, err) }()
+
+	orders, err = r.Inner.FetchOpenSellOrders(ctx, tx, symbol)
+	span.SetAttributes(tracing.AttrRows.Int(len(orders)))
+	return orders, err
+}
+
+func (r *TracedOrderRepository) FetchOpenBuyOrders(ctx context.Context, tx Tx, symbol string) (orders []models.Order, err error) {
+	ctx, span := startSpan(ctx, "OrderRepository.FetchOpenBuyOrders", tracing.AttrSymbol.String(symbol))
+	defer func() { tracing.End(span, err) }()
+
+	orders, err = r.Inner.FetchOpenBuyOrders(ctx, tx, symbol)
+	span.SetAttributes(tracing.AttrRows.Int(len(orders)))
+	return orders, err
+}
+
+func (r *TracedOrderRepository) GetOrderByID(ctx context.Context, tx Tx, id int64) (order *models.Order, err error) {
+	ctx, span := startSpan(ctx, "OrderRepository.GetOrderByID", tracing.AttrOrderID.Int64(id))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.GetOrderByID(ctx, tx, id)
+}
+
+func (r *TracedOrderRepository) FetchBookDepth(ctx context.Context) (depths []models.BookDepth, err error) {
+	ctx, span := startSpan(ctx, "OrderRepository.FetchBookDepth")
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.FetchBookDepth(ctx)
+}
+
+func (r *TracedOrderRepository) ListOrders(ctx context.Context, filter models.OrderFilter, limit int) (orders []models.Order, err error) {
+	ctx, span := startSpan(ctx, "OrderRepository.ListOrders", tracing.AttrAccountID.Int64(filter.AccountID), tracing.AttrSymbol.String(filter.Symbol))
+	defer func() { tracing.End(span, err) }()
+
+	orders, err = r.Inner.ListOrders(ctx, filter, limit)
+	span.SetAttributes(tracing.AttrRows.Int(len(orders)))
+	return orders, err
+}
+
+func (r *TracedOrderRepository) FetchOpenOrders(ctx context.Context, tx Tx, filter models.OrderFilter) (orders []models.Order, err error) {
+	ctx, span := startSpan(ctx, "OrderRepository.FetchOpenOrders", tracing.AttrAccountID.Int64(filter.AccountID), tracing.AttrSymbol.String(filter.Symbol))
+	defer func() { tracing.End(span, err) }()
+
+	orders, err = r.Inner.FetchOpenOrders(ctx, tx, filter)
+	span.SetAttributes(tracing.AttrRows.Int(len(orders)))
+	return orders, err
+}
+
+// TracedTradeRepository wraps a TradeRepository with a span per call.
+type TracedTradeRepository struct {
+	Inner TradeRepository
+}
+
+var _ TradeRepository = (*TracedTradeRepository)(nil)
+
+func NewTracedTradeRepository(inner TradeRepository) *TracedTradeRepository {
+	return &TracedTradeRepository{Inner: inner}
+}
+
+func (r *TracedTradeRepository) CreateTrade(ctx context.Context, tx Tx, trade *models.Trade) (err error) {
+	ctx, span := startSpan(ctx, "TradeRepository.CreateTrade",
+		attribute.Int64("trade.buy_order_id", trade.BuyOrderID), attribute.Int64("trade.sell_order_id", trade.SellOrderID))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.CreateTrade(ctx, tx, trade)
+}
+
+func (r *TracedTradeRepository) ListTradesBySymbol(ctx context.Context, symbol string) (trades []models.Trade, err error) {
+	ctx, span := startSpan(ctx, "TradeRepository.ListTradesBySymbol", tracing.AttrSymbol.String(symbol))
+	defer func() { tracing.End(span, err) }()
+
+	trades, err = r.Inner.ListTradesBySymbol(ctx, symbol)
+	span.SetAttributes(tracing.AttrRows.Int(len(trades)))
+	return trades, err
+}
+
+func (r *TracedTradeRepository) GetPriceStats(ctx context.Context, tx Tx, symbol string, since time.Time) (stats models.PriceStats, err error) {
+	ctx, span := startSpan(ctx, "TradeRepository.GetPriceStats", tracing.AttrSymbol.String(symbol))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.GetPriceStats(ctx, tx, symbol, since)
+}
+
+// TracedOrderEventRepository wraps an OrderEventRepository with a span per call.
+type TracedOrderEventRepository struct {
+	Inner OrderEventRepository
+}
+
+var _ OrderEventRepository = (*TracedOrderEventRepository)(nil)
+
+func NewTracedOrderEventRepository(inner OrderEventRepository) *TracedOrderEventRepository {
+	return &TracedOrderEventRepository{Inner: inner}
+}
+
+func (r *TracedOrderEventRepository) CreateEvent(ctx context.Context, tx Tx, event *models.OrderEvent) (err error) {
+	ctx, span := startSpan(ctx, "OrderEventRepository.CreateEvent",
+		tracing.AttrOrderID.Int64(event.OrderID), attribute.String("order_event.type", event.Type))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.CreateEvent(ctx, tx, event)
+}
+
+func (r *TracedOrderEventRepository) ListEventsByOrder(ctx context.Context, orderID int64) (events []models.OrderEvent, err error) {
+	ctx, span := startSpan(ctx, "OrderEventRepository.ListEventsByOrder", tracing.AttrOrderID.Int64(orderID))
+	defer func() { tracing.End(span, err) }()
+
+	events, err = r.Inner.ListEventsByOrder(ctx, orderID)
+	span.SetAttributes(tracing.AttrRows.Int(len(events)))
+	return events, err
+}
+
+// TracedAccountRepository wraps an AccountRepository with a span per call.
+type TracedAccountRepository struct {
+	Inner AccountRepository
+}
+
+var _ AccountRepository = (*TracedAccountRepository)(nil)
+
+func NewTracedAccountRepository(inner AccountRepository) *TracedAccountRepository {
+	return &TracedAccountRepository{Inner: inner}
+}
+
+func (r *TracedAccountRepository) CreateAccount(ctx context.Context, tx Tx, account *models.Account) (err error) {
+	ctx, span := startSpan(ctx, "AccountRepository.CreateAccount")
+	defer func() { tracing.End(span, err) }()
+
+	err = r.Inner.CreateAccount(ctx, tx, account)
+	span.SetAttributes(tracing.AttrAccountID.Int64(account.ID))
+	return err
+}
+
+func (r *TracedAccountRepository) GetAccount(ctx context.Context, tx Tx, id int64) (account *models.Account, err error) {
+	ctx, span := startSpan(ctx, "AccountRepository.GetAccount", tracing.AttrAccountID.Int64(id))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.GetAccount(ctx, tx, id)
+}
+
+func (r *TracedAccountRepository) UpdateAccount(ctx context.Context, tx Tx, account *models.Account) (err error) {
+	ctx, span := startSpan(ctx, "AccountRepository.UpdateAccount", tracing.AttrAccountID.Int64(account.ID))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.UpdateAccount(ctx, tx, account)
+}
+
+func (r *TracedAccountRepository) CreateAPIKey(ctx context.Context, tx Tx, key *models.APIKey) (err error) {
+	ctx, span := startSpan(ctx, "AccountRepository.CreateAPIKey",
+		tracing.AttrAccountID.Int64(key.AccountID), tracing.AttrAPIKeyID.String(key.KeyID))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.CreateAPIKey(ctx, tx, key)
+}
+
+func (r *TracedAccountRepository) GetAPIKey(ctx context.Context, tx Tx, keyID string) (key *models.APIKey, err error) {
+	ctx, span := startSpan(ctx, "AccountRepository.GetAPIKey", tracing.AttrAPIKeyID.String(keyID))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.GetAPIKey(ctx, tx, keyID)
+}
+
+func (r *TracedAccountRepository) UpdateAPIKey(ctx context.Context, tx Tx, key *models.APIKey) (err error) {
+	ctx, span := startSpan(ctx, "AccountRepository.UpdateAPIKey", tracing.AttrAPIKeyID.String(key.KeyID))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.UpdateAPIKey(ctx, tx, key)
+}
+
+func (r *TracedAccountRepository) ListAPIKeys(ctx context.Context, accountID int64) (keys []models.APIKey, err error) {
+	ctx, span := startSpan(ctx, "AccountRepository.ListAPIKeys", tracing.AttrAccountID.Int64(accountID))
+	defer func() { tracing.End(span, err) }()
+
+	keys, err = r.Inner.ListAPIKeys(ctx, accountID)
+	span.SetAttributes(tracing.AttrRows.Int(len(keys)))
+	return keys, err
+}
+
+func (r *TracedAccountRepository) UseNonce(ctx context.Context, keyID, nonce string, expires, now time.Time) (unused bool, err error) {
+	ctx, span := startSpan(ctx, "AccountRepository.UseNonce", tracing.AttrAPIKeyID.String(keyID))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.UseNonce(ctx, keyID, nonce, expires, now)
+}
+
+// TracedAuditRepository wraps an AuditRepository with a span per call.
+type TracedAuditRepository struct {
+	Inner AuditRepository
+}
+
+var _ AuditRepository = (*TracedAuditRepository)(nil)
+
+func NewTracedAuditRepository(inner AuditRepository) *TracedAuditRepository {
+	return &TracedAuditRepository{Inner: inner}
+}
+
+func (r *TracedAuditRepository) CreateAuditEntry(ctx context.Context, tx Tx, entry *models.AuditEntry) (err error) {
+	ctx, span := startSpan(ctx, "AuditRepository.CreateAuditEntry")
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.CreateAuditEntry(ctx, tx, entry)
+}
+
+func (r *TracedAuditRepository) ListAuditEntries(ctx context.Context, limit int) (entries []models.AuditEntry, err error) {
+	ctx, span := startSpan(ctx, "AuditRepository.ListAuditEntries")
+	defer func() { tracing.End(span, err) }()
+
+	entries, err = r.Inner.ListAuditEntries(ctx, limit)
+	span.SetAttributes(tracing.AttrRows.Int(len(entries)))
+	return entries, err
+}
+
+// TracedOutboxRepository wraps an OutboxRepository with a span per call.
+type TracedOutboxRepository struct {
+	Inner OutboxRepository
+}
+
+var _ OutboxRepository = (*TracedOutboxRepository)(nil)
+
+func NewTracedOutboxRepository(inner OutboxRepository) *TracedOutboxRepository {
+	return &TracedOutboxRepository{Inner: inner}
+}
+
+func (r *TracedOutboxRepository) CreateOutboxEvent(ctx context.Context, tx Tx, event *models.OutboxEvent) (err error) {
+	ctx, span := startSpan(ctx, "OutboxRepository.CreateOutboxEvent")
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.CreateOutboxEvent(ctx, tx, event)
+}
+
+func (r *TracedOutboxRepository) ListUndispatched(ctx context.Context, tx Tx, limit int) (events []models.OutboxEvent, err error) {
+	ctx, span := startSpan(ctx, "OutboxRepository.ListUndispatched")
+	defer func() { tracing.End(span, err) }()
+
+	events, err = r.Inner.ListUndispatched(ctx, tx, limit)
+	span.SetAttributes(tracing.AttrRows.Int(len(events)))
+	return events, err
+}
+
+func (r *TracedOutboxRepository) MarkDispatched(ctx context.Context, tx Tx, ids []int64, at time.Time) (err error) {
+	ctx, span := startSpan(ctx, "OutboxRepository.MarkDispatched")
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.MarkDispatched(ctx, tx, ids, at)
+}
+
+func (r *TracedOutboxRepository) GetOutboxEvent(ctx context.Context, tx Tx, id int64) (event *models.OutboxEvent, err error) {
+	ctx, span := startSpan(ctx, "OutboxRepository.GetOutboxEvent")
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.GetOutboxEvent(ctx, tx, id)
+}
+
+// TracedWebhookRepository wraps a WebhookRepository with a span per call.
+type TracedWebhookRepository struct {
+	Inner WebhookRepository
+}
+
+var _ WebhookRepository = (*TracedWebhookRepository)(nil)
+
+func NewTracedWebhookRepository(inner WebhookRepository) *TracedWebhookRepository {
+	return &TracedWebhookRepository{Inner: inner}
+}
+
+func (r *TracedWebhookRepository) CreateWebhook(ctx context.Context, tx Tx, hook *models.Webhook) (err error) {
+	ctx, span := startSpan(ctx, "WebhookRepository.CreateWebhook")
+	defer func() { tracing.End(span, err) }()
+
+	err = r.Inner.CreateWebhook(ctx, tx, hook)
+	span.SetAttributes(tracing.AttrWebhookID.Int64(hook.ID))
+	return err
+}
+
+func (r *TracedWebhookRepository) GetWebhook(ctx context.Context, tx Tx, id int64) (hook *models.Webhook, err error) {
+	ctx, span := startSpan(ctx, "WebhookRepository.GetWebhook", tracing.AttrWebhookID.Int64(id))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.GetWebhook(ctx, tx, id)
+}
+
+func (r *TracedWebhookRepository) UpdateWebhook(ctx context.Context, tx Tx, hook *models.Webhook) (err error) {
+	ctx, span := startSpan(ctx, "WebhookRepository.UpdateWebhook", tracing.AttrWebhookID.Int64(hook.ID))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.UpdateWebhook(ctx, tx, hook)
+}
+
+func (r *TracedWebhookRepository) ListWebhooks(ctx context.Context, tx Tx) (hooks []models.Webhook, err error) {
+	ctx, span := startSpan(ctx, "WebhookRepository.ListWebhooks")
+	defer func() { tracing.End(span, err) }()
+
+	hooks, err = r.Inner.ListWebhooks(ctx, tx)
+	span.SetAttributes(tracing.AttrRows.Int(len(hooks)))
+	return hooks, err
+}
+
+func (r *TracedWebhookRepository) CreateDelivery(ctx context.Context, tx Tx, delivery *models.WebhookDelivery) (err error) {
+	ctx, span := startSpan(ctx, "WebhookRepository.CreateDelivery", tracing.AttrWebhookID.Int64(delivery.WebhookID))
+	defer func() { tracing.End(span, err) }()
+
+	err = r.Inner.CreateDelivery(ctx, tx, delivery)
+	span.SetAttributes(tracing.AttrDeliveryID.Int64(delivery.ID))
+	return err
+}
+
+func (r *TracedWebhookRepository) GetDelivery(ctx context.Context, tx Tx, id int64) (delivery *models.WebhookDelivery, err error) {
+	ctx, span := startSpan(ctx, "WebhookRepository.GetDelivery", tracing.AttrDeliveryID.Int64(id))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.GetDelivery(ctx, tx, id)
+}
+
+func (r *TracedWebhookRepository) UpdateDelivery(ctx context.Context, tx Tx, delivery *models.WebhookDelivery) (err error) {
+	ctx, span := startSpan(ctx, "WebhookRepository.UpdateDelivery", tracing.AttrDeliveryID.Int64(delivery.ID))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.UpdateDelivery(ctx, tx, delivery)
+}
+
+func (r *TracedWebhookRepository) ClaimDueDeliveries(ctx context.Context, tx Tx, now, leaseUntil time.Time, limit int) (deliveries []models.WebhookDelivery, err error) {
+	ctx, span := startSpan(ctx, "WebhookRepository.ClaimDueDeliveries")
+	defer func() { tracing.End(span, err) }()
+
+	deliveries, err = r.Inner.ClaimDueDeliveries(ctx, tx, now, leaseUntil, limit)
+	span.SetAttributes(tracing.AttrRows.Int(len(deliveries)))
+	return deliveries, err
+}
+
+func (r *TracedWebhookRepository) ListDeliveries(ctx context.Context, filter models.DeliveryFilter, limit int) (deliveries []models.WebhookDelivery, err error) {
+	ctx, span := startSpan(ctx, "WebhookRepository.ListDeliveries")
+	defer func() { tracing.End(span, err) }()
+
+	deliveries, err = r.Inner.ListDeliveries(ctx, filter, limit)
+	span.SetAttributes(tracing.AttrRows.Int(len(deliveries)))
+	return deliveries, err
+}
+
+// TracedFIXRepository wraps a FIXRepository with a span per call.
+type TracedFIXRepository struct {
+	Inner FIXRepository
+}
+
+var _ FIXRepository = (*TracedFIXRepository)(nil)
+
+func NewTracedFIXRepository(inner FIXRepository) *TracedFIXRepository {
+	return &TracedFIXRepository{Inner: inner}
+}
+
+func (r *TracedFIXRepository) GetFIXSession(ctx context.Context, tx Tx, id string) (session *models.FIXSession, err error) {
+	ctx, span := startSpan(ctx, "FIXRepository.GetFIXSession", tracing.AttrFIXSession.String(id))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.GetFIXSession(ctx, tx, id)
+}
+
+func (r *TracedFIXRepository) SaveFIXSession(ctx context.Context, tx Tx, session *models.FIXSession) (err error) {
+	ctx, span := startSpan(ctx, "FIXRepository.SaveFIXSession", tracing.AttrFIXSession.String(session.ID))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.SaveFIXSession(ctx, tx, session)
+}
+
+func (r *TracedFIXRepository) SaveFIXMessage(ctx context.Context, tx Tx, msg *models.FIXMessage) (err error) {
+	ctx, span := startSpan(ctx, "FIXRepository.SaveFIXMessage", tracing.AttrFIXSession.String(msg.SessionID))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.SaveFIXMessage(ctx, tx, msg)
+}
+
+func (r *TracedFIXRepository) ListFIXMessages(ctx context.Context, tx Tx, sessionID string, from, to int) (msgs []models.FIXMessage, err error) {
+	ctx, span := startSpan(ctx, "FIXRepository.ListFIXMessages", tracing.AttrFIXSession.String(sessionID))
+	defer func() { tracing.End(span, err) }()
+
+	msgs, err = r.Inner.ListFIXMessages(ctx, tx, sessionID, from, to)
+	span.SetAttributes(tracing.AttrRows.Int(len(msgs)))
+	return msgs, err
+}
+
+func (r *TracedFIXRepository) DeleteFIXMessages(ctx context.Context, tx Tx, sessionID string) (err error) {
+	ctx, span := startSpan(ctx, "FIXRepository.DeleteFIXMessages", tracing.AttrFIXSession.String(sessionID))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.DeleteFIXMessages(ctx, tx, sessionID)
+}
+
+func (r *TracedFIXRepository) CreateFIXOrder(ctx context.Context, tx Tx, order *models.FIXOrder) (err error) {
+	ctx, span := startSpan(ctx, "FIXRepository.CreateFIXOrder", tracing.AttrFIXSession.String(order.SessionID), tracing.AttrOrderID.Int64(order.OrderID))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.CreateFIXOrder(ctx, tx, order)
+}
+
+func (r *TracedFIXRepository) GetFIXOrderByClOrdID(ctx context.Context, tx Tx, sessionID, clOrdID string) (order *models.FIXOrder, err error) {
+	ctx, span := startSpan(ctx, "FIXRepository.GetFIXOrderByClOrdID", tracing.AttrFIXSession.String(sessionID))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.GetFIXOrderByClOrdID(ctx, tx, sessionID, clOrdID)
+}
+
+func (r *TracedFIXRepository) ListOpenFIXOrders(ctx context.Context, tx Tx) (orders []models.FIXOrder, err error) {
+	ctx, span := startSpan(ctx, "FIXRepository.ListOpenFIXOrders")
+	defer func() { tracing.End(span, err) }()
+
+	orders, err = r.Inner.ListOpenFIXOrders(ctx, tx)
+	span.SetAttributes(tracing.AttrRows.Int(len(orders)))
+	return orders, err
+}
+
+// TracedTradingPhaseRepository wraps a TradingPhaseRepository with a span per call.
+type TracedTradingPhaseRepository struct {
+	Inner TradingPhaseRepository
+}
+
+var _ TradingPhaseRepository = (*TracedTradingPhaseRepository)(nil)
+
+func NewTracedTradingPhaseRepository(inner TradingPhaseRepository) *TracedTradingPhaseRepository {
+	return &TracedTradingPhaseRepository{Inner: inner}
+}
+
+func (r *TracedTradingPhaseRepository) GetTradingPhase(ctx context.Context, tx Tx, symbol string) (phase *models.TradingPhase, err error) {
+	ctx, span := startSpan(ctx, "TradingPhaseRepository.GetTradingPhase", tracing.AttrSymbol.String(symbol))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.GetTradingPhase(ctx, tx, symbol)
+}
+
+func (r *TracedTradingPhaseRepository) SaveTradingPhase(ctx context.Context, tx Tx, phase *models.TradingPhase) (err error) {
+	ctx, span := startSpan(ctx, "TradingPhaseRepository.SaveTradingPhase", tracing.AttrSymbol.String(phase.Symbol))
+	defer func() { tracing.End(span, err) }()
+
+	return r.Inner.SaveTradingPhase(ctx, tx, phase)
+}
@@ -2,37 +2,51 @@ package repository
 
 import (
 	"context"
-	"database/sql"
+	"time"
 
 	"github.com/Puneet-Vishnoi/order-matching-engine/db/postgres/providers"
 	"github.com/Puneet-Vishnoi/order-matching-engine/models"
 )
 
-type TradeRepository struct {
+// PostgresTradeRepository is the production TradeRepository.
+type PostgresTradeRepository struct {
 	DBHelper *providers.DBHelper
 }
 
-func NewTradeRepository(db *providers.DBHelper) *TradeRepository {
-	return &TradeRepository{DBHelper: db}
+var _ TradeRepository = (*PostgresTradeRepository)(nil)
+
+func NewPostgresTradeRepository(db *providers.DBHelper) *PostgresTradeRepository {
+	return &PostgresTradeRepository{DBHelper: db}
 }
 
 // CreateTrade saves a trade in the DB and retrieves its ID
-func (r *TradeRepository) CreateTrade(ctx context.Context, tx *sql.Tx, trade *models.Trade) error {
+func (r *PostgresTradeRepository) CreateTrade(ctx context.Context, tx Tx, trade *models.Trade) error {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		INSERT INTO trades (buy_order_id, sell_order_id, price, quantity, created_at)
 		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id`
-	return tx.QueryRowContext(ctx, query,
+	q, err := sqlTx(tx)
+	if err != nil {
+		return err
+	}
+	err = q.QueryRowContext(ctx, query,
 		trade.BuyOrderID,
 		trade.SellOrderID,
 		trade.Price,
 		trade.Quantity,
 		trade.CreatedAt,
 	).Scan(&trade.ID)
+	return translateError(err)
 }
 
 // ListTradesBySymbol fetches trades for a symbol
-func (r *TradeRepository) ListTradesBySymbol(ctx context.Context, symbol string) ([]models.Trade, error) {
+func (r *PostgresTradeRepository) ListTradesBySymbol(ctx context.Context, symbol string) ([]models.Trade, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT DISTINCT ON (t.id) t.id, t.buy_order_id, t.sell_order_id, t.price, t.quantity, t.created_at
 		FROM trades t
@@ -42,7 +56,7 @@ func (r *TradeRepository) ListTradesBySymbol(ctx context.Context, symbol string)
 
 	rows, err := r.DBHelper.PostgresClient.QueryContext(ctx, query, symbol)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	defer rows.Close()
 
@@ -50,9 +64,37 @@ func (r *TradeRepository) ListTradesBySymbol(ctx context.Context, symbol string)
 	for rows.Next() {
 		var t models.Trade
 		if err := rows.Scan(&t.ID, &t.BuyOrderID, &t.SellOrderID, &t.Price, &t.Quantity, &t.CreatedAt); err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		trades = append(trades, t)
 	}
-	return trades, nil
-}
\ No newline at end of file
+	return trades, translateError(rows.Err())
+}
+
+// GetPriceStats fetches the last trade price of a symbol and its range since a time
+func (r *PostgresTradeRepository) GetPriceStats(ctx context.Context, tx Tx, symbol string, since time.Time) (models.PriceStats, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	// Both orders of a trade have its symbol, so the buy order tells it
+	query := `
+		SELECT
+			COALESCE((
+				SELECT t.price FROM trades t
+				JOIN orders o ON o.id = t.buy_order_id
+				WHERE o.symbol = $1
+				ORDER BY t.id DESC
+				LIMIT 1), 0),
+			COALESCE(MIN(t.price), 0),
+			COALESCE(MAX(t.price), 0)
+		FROM trades t
+		JOIN orders o ON o.id = t.buy_order_id
+		WHERE o.symbol = $1 AND t.created_at >= $2`
+	q, err := queryer(r.DBHelper, tx)
+	if err != nil {
+		return models.PriceStats{}, err
+	}
+	var stats models.PriceStats
+	err = q.QueryRowContext(ctx, query, symbol, since).Scan(&stats.Last, &stats.Low, &stats.High)
+	return stats, translateError(err)
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/db/postgres/providers"
+)
+
+var errForeignTx = errors.New("transaction was not opened by the postgres backend")
+
+// PostgresTxManager opens *sql.Tx transactions on the shared connection pool.
+type PostgresTxManager struct {
+	DBHelper *providers.DBHelper
+}
+
+func NewPostgresTxManager(db *providers.DBHelper) *PostgresTxManager {
+	return &PostgresTxManager{DBHelper: db}
+}
+
+func (m *PostgresTxManager) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	tx, err := m.DBHelper.PostgresClient.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &postgresTx{Tx: tx}, nil
+}
+
+type postgresTx struct {
+	*sql.Tx
+}
+
+// querier is the subset of *sql.DB and *sql.Tx the repositories use.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// sqlTx unwraps a Tx opened by PostgresTxManager.
+func sqlTx(tx Tx) (*sql.Tx, error) {
+	pt, ok := tx.(*postgresTx)
+	if !ok || pt == nil {
+		return nil, errForeignTx
+	}
+	return pt.Tx, nil
+}
+
+// queryer returns tx when set, otherwise the connection pool.
+func queryer(db *providers.DBHelper, tx Tx) (querier, error) {
+	if tx == nil {
+		return db.PostgresClient, nil
+	}
+	return sqlTx(tx)
+}
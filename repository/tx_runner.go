@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tracing"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SQLSTATEs PostgreSQL returns when a transaction lost a race and is safe to
+// run again from the start.
+const (
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
+// IsRetryable reports whether err means the whole transaction can be retried.
+func IsRetryable(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == sqlStateSerializationFailure || pqErr.Code == sqlStateDeadlockDetected
+	}
+	return false
+}
+
+// TxStats counts transaction outcomes of a TxRunner.
+type TxStats struct {
+	Transactions uint64 // units of work started
+	Retries      uint64 // attempts re-run after a retryable error
+	Exhausted    uint64 // units of work that failed after MaxAttempts
+}
+
+// TxRunner runs a unit of work in a transaction and retries it, with jittered
+// exponential backoff, when the backend reports a serialization failure or
+// deadlock.
+type TxRunner struct {
+	TxManager   TxManager
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+
+	transactions atomic.Uint64
+	retries      atomic.Uint64
+	exhausted    atomic.Uint64
+}
+
+func NewTxRunner(txManager TxManager) *TxRunner {
+	return &TxRunner{
+		TxManager:   txManager,
+		MaxAttempts: 5,
+		BaseDelay:   10 * time.Millisecond,
+		MaxDelay:    500 * time.Millisecond,
+	}
+}
+
+// Run opens a transaction, calls fn and commits. fn may run more than once,
+// so it must build all of its state from scratch on every call.
+func (r *TxRunner) Run(ctx context.Context, opts *sql.TxOptions, fn func(tx Tx) error) error {
+	r.transactions.Add(1)
+
+	attempts := max(r.MaxAttempts, 1)
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = r.runOnce(ctx, opts, fn)
+		if err == nil || !IsRetryable(err) {
+			return err
+		}
+		if attempt == attempts {
+			break
+		}
+
+		r.retries.Add(1)
+		trace.SpanFromContext(ctx).AddEvent("transaction.retry", trace.WithAttributes(
+			attribute.Int("attempt", attempt+1),
+			attribute.String("error", err.Error()),
+		))
+		log.Printf("Retrying transaction (attempt %d of %d): %v", attempt+1, attempts, err)
+		if err := sleep(ctx, r.backoff(attempt)); err != nil {
+			return err
+		}
+	}
+
+	r.exhausted.Add(1)
+	return apperr.Unavailable(apperr.CodeTransactionConflict, "transaction failed after %d attempts", attempts).Wrap(err)
+}
+
+func (r *TxRunner) runOnce(ctx context.Context, opts *sql.TxOptions, fn func(tx Tx) error) error {
+	tx, err := r.TxManager.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Rolls back on error and on panic; a no-op once committed
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	_, span := tracer.Start(ctx, "Tx.Commit", trace.WithSpanKind(trace.SpanKindClient))
+	err = tx.Commit()
+	tracing.End(span, err)
+	return err
+}
+
+// backoff returns a random delay in [0, min(MaxDelay, BaseDelay*2^(attempt-1))]
+// ("full jitter"), so retrying instances don't collide again in lockstep.
+func (r *TxRunner) backoff(attempt int) time.Duration {
+	ceiling := r.BaseDelay << (attempt - 1)
+	if ceiling <= 0 || ceiling > r.MaxDelay {
+		ceiling = r.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// Stats returns a snapshot of the runner's counters.
+func (r *TxRunner) Stats() TxStats {
+	return TxStats{
+		Transactions: r.transactions.Load(),
+		Retries:      r.retries.Load(),
+		Exhausted:    r.exhausted.Load(),
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
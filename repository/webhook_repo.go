@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/db/postgres/providers"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+)
+
+// PostgresWebhookRepository is the production WebhookRepository.
+type PostgresWebhookRepository struct {
+	DBHelper *providers.DBHelper
+}
+
+var _ WebhookRepository = (*PostgresWebhookRepository)(nil)
+
+func NewPostgresWebhookRepository(db *providers.DBHelper) *PostgresWebhookRepository {
+	return &PostgresWebhookRepository{DBHelper: db}
+}
+
+// CreateWebhook inserts a webhook and retrieves its ID
+func (r *PostgresWebhookRepository) CreateWebhook(ctx context.Context, tx Tx, hook *models.Webhook) error {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO webhooks (url, secret_hash, active, created_at) VALUES ($1, $2, $3, $4) RETURNING id`
+	q, err := sqlTx(tx)
+	if err != nil {
+		return err
+	}
+	err = q.QueryRowContext(ctx, query, hook.URL, hook.SecretHash, hook.Active, hook.CreatedAt).Scan(&hook.ID)
+	return translateError(err)
+}
+
+// GetWebhook fetches one webhook by ID
+func (r *PostgresWebhookRepository) GetWebhook(ctx context.Context, tx Tx, id int64) (*models.Webhook, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT id, url, secret_hash, active, created_at FROM webhooks WHERE id = $1`
+	q, err := queryer(r.DBHelper, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var h models.Webhook
+	err = q.QueryRowContext(ctx, query, id).Scan(&h.ID, &h.URL, &h.SecretHash, &h.Active, &h.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperr.NotFound(apperr.CodeWebhookNotFound, "webhook with ID %d not found", id)
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &h, nil
+}
+
+// UpdateWebhook updates whether a webhook is active
+func (r *PostgresWebhookRepository) UpdateWebhook(ctx context.Context, tx Tx, hook *models.Webhook) error {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE webhooks SET active = $1 WHERE id = $2`
+	q, err := sqlTx(tx)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, query, hook.Active, hook.ID)
+	return translateError(err)
+}
+
+// ListWebhooks fetches every webhook, oldest first
+func (r *PostgresWebhookRepository) ListWebhooks(ctx context.Context, tx Tx) ([]models.Webhook, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT id, url, secret_hash, active, created_at FROM webhooks ORDER BY id`
+	q, err := queryer(r.DBHelper, tx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	var hooks []models.Webhook
+	for rows.Next() {
+		var h models.Webhook
+		if err := rows.Scan(&h.ID, &h.URL, &h.SecretHash, &h.Active, &h.CreatedAt); err != nil {
+			return nil, translateError(err)
+		}
+		hooks = append(hooks, h)
+	}
+	return hooks, translateError(rows.Err())
+}
+
+const deliveryColumns = `id, webhook_id, event_id, event_type, status, attempts, next_attempt_at, last_error, created_at, updated_at, delivered_at`
+
+// CreateDelivery inserts a delivery and retrieves its ID
+func (r *PostgresWebhookRepository) CreateDelivery(ctx context.Context, tx Tx, d *models.WebhookDelivery) error {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, status, attempts, next_attempt_at, last_error, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`
+	q, err := sqlTx(tx)
+	if err != nil {
+		return err
+	}
+	err = q.QueryRowContext(ctx, query,
+		d.WebhookID, d.EventID, d.EventType, d.Status, d.Attempts, d.NextAttemptAt, d.LastError, d.CreatedAt, d.UpdatedAt,
+	).Scan(&d.ID)
+	return translateError(err)
+}
+
+// GetDelivery fetches one delivery by ID
+func (r *PostgresWebhookRepository) GetDelivery(ctx context.Context, tx Tx, id int64) (*models.WebhookDelivery, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+	q, err := queryer(r.DBHelper, tx)
+	if err != nil {
+		return nil, err
+	}
+	d, err := scanDelivery(q.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperr.NotFound(apperr.CodeDeliveryNotFound, "delivery with ID %d not found", id)
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return d, nil
+}
+
+// UpdateDelivery updates the mutable fields of a delivery
+func (r *PostgresWebhookRepository) UpdateDelivery(ctx context.Context, tx Tx, d *models.WebhookDelivery) error {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, updated_at = $5, delivered_at = $6
+		WHERE id = $7`
+	q, err := queryer(r.DBHelper, tx)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, query, d.Status, d.Attempts, d.NextAttemptAt, d.LastError, d.UpdatedAt, d.DeliveredAt, d.ID)
+	return translateError(err)
+}
+
+// ClaimDueDeliveries leases due deliveries in one statement, skipping rows
+// another dispatcher is claiming
+func (r *PostgresWebhookRepository) ClaimDueDeliveries(ctx context.Context, tx Tx, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE webhook_deliveries SET next_attempt_at = $1
+		WHERE id IN (
+			SELECT d.id FROM webhook_deliveries d
+			JOIN webhooks w ON w.id = d.webhook_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= $2 AND w.active
+			ORDER BY d.next_attempt_at, d.id
+			LIMIT $3
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		RETURNING ` + deliveryColumns
+	q, err := queryer(r.DBHelper, tx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.QueryContext(ctx, query, leaseUntil, now, limit)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+	return scanDeliveries(rows)
+}
+
+// ListDeliveries fetches the latest deliveries matching filter
+func (r *PostgresWebhookRepository) ListDeliveries(ctx context.Context, filter models.DeliveryFilter, limit int) ([]models.WebhookDelivery, error) {
+	ctx, cancel := r.DBHelper.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var conds []string
+	var args []any
+	if filter.WebhookID != 0 {
+		args = append(args, filter.WebhookID)
+		conds = append(conds, fmt.Sprintf("webhook_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conds = append(conds, fmt.Sprintf("status = $%d", len(args)))
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+	args = append(args, limit)
+	query := fmt.Sprintf(`SELECT %s FROM webhook_deliveries %s ORDER BY id DESC LIMIT $%d`, deliveryColumns, where, len(args))
+
+	rows, err := r.DBHelper.PostgresClient.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+	return scanDeliveries(rows)
+}
+
+func scanDeliveries(rows *sql.Rows) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, translateError(err)
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, translateError(rows.Err())
+}
+
+func scanDelivery(row interface{ Scan(...any) error }) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	var deliveredAt sql.NullTime
+	err := row.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastError, &d.CreatedAt, &d.UpdatedAt, &deliveredAt)
+	if err != nil {
+		return nil, err
+	}
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	return &d, nil
+}
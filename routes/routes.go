@@ -2,20 +2,126 @@ package routes
 
 import (
 	"github.com/Puneet-Vishnoi/order-matching-engine/handlers"
+	"github.com/Puneet-Vishnoi/order-matching-engine/health"
+	"github.com/Puneet-Vishnoi/order-matching-engine/middleware"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
 	"github.com/Puneet-Vishnoi/order-matching-engine/service"
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(router *gin.Engine, service *service.OrderService) {
+// NewRouter returns a router with the default logger and recovery that takes
+// the client IP from X-Forwarded-For only on requests from trustedProxies,
+// so that clients cannot pick the IP their requests are limited under.
+func NewRouter(trustedProxies []string) (*gin.Engine, error) {
+	router := gin.Default()
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, err
+	}
+	return router, nil
+}
+
+// RegisterRoutes mounts the API. With auth set, every /api request must be
+// signed with an API key holding the route's scope, by an account whose role
+// allows the route group, and the /admin routes are mounted; a nil auth
+// serves /api unauthenticated. Webhooks are managed under /admin when
+// webhooks is set.
+func RegisterRoutes(router *gin.Engine, service *service.OrderService, auth *service.AuthService, audit *service.AuditService, webhooks *service.WebhookService) {
 	orderHandler := handlers.NewOrderHandler(service)
 
+	router.Use(middleware.RequestID(), service.Metrics.GinMiddleware())
+	router.GET("/metrics", gin.WrapH(service.Metrics.Handler()))
+
+	// Guards are no-ops while their feature is off
+	next := func(c *gin.Context) { c.Next() }
+	scope := func(s string) gin.HandlerFunc {
+		if auth == nil {
+			return next
+		}
+		return handlers.RequireScope(s)
+	}
+	role := func(r string) gin.HandlerFunc {
+		if auth == nil {
+			return next
+		}
+		return handlers.RequireRole(r)
+	}
+	limit := func(class string) gin.HandlerFunc {
+		if service.RateLimiter == nil {
+			return next
+		}
+		return handlers.RateLimit(service.RateLimiter, class)
+	}
+
 	api := router.Group("/api")
+	if auth != nil {
+		api.Use(handlers.Authenticate(auth))
+	}
+	// Market data and own orders: every role
+	reads := api.Group("", limit(models.RateLimitRead))
 	{
-		api.POST("/orders", orderHandler.PlaceOrder)
-		api.DELETE("/orders/:id", orderHandler.CancelOrder)
-		api.GET("/orderbook", orderHandler.GetOrderBook)
+		reads.GET("/orderbook", scope(models.ScopeRead), orderHandler.GetOrderBook)
 
-		api.GET("/orders/:id", orderHandler.GetOrderStatus)
-		api.GET("/trades", orderHandler.ListTrades)
+		reads.GET("/orders/:id", scope(models.ScopeRead), orderHandler.GetOrderStatus)
+		reads.GET("/orders/:id/events", scope(models.ScopeRead), orderHandler.GetOrderEvents)
+		reads.GET("/orders/cancel-all-after", scope(models.ScopeRead), orderHandler.GetDeadMansSwitch)
+		reads.GET("/trades", scope(models.ScopeRead), orderHandler.ListTrades)
+		reads.GET("/phases/:symbol", scope(models.ScopeRead), orderHandler.GetTradingPhase)
+		if service.MarketData != nil {
+			reads.GET("/stream", scope(models.ScopeRead), orderHandler.Stream)
+		}
 	}
+	// Trading: traders and above
+	trading := api.Group("", role(models.RoleTrader))
+	{
+		trading.POST("/orders", scope(models.ScopeTrade), limit(models.RateLimitPlace), orderHandler.PlaceOrder)
+		trading.DELETE("/orders/:id", scope(models.ScopeCancel), limit(models.RateLimitCancel), orderHandler.CancelOrder)
+		trading.DELETE("/orders", scope(models.ScopeCancel), limit(models.RateLimitCancel), orderHandler.MassCancel)
+		trading.POST("/orders/cancel-all-after", scope(models.ScopeCancel), limit(models.RateLimitCancel), orderHandler.ArmDeadMansSwitch)
+		// A batch takes one token; its orders count one by one against the
+		// per-symbol limit and the order-to-trade ratio
+		trading.POST("/orders/batch", scope(models.ScopeTrade), limit(models.RateLimitPlace), orderHandler.PlaceOrders)
+		trading.DELETE("/orders/batch", scope(models.ScopeCancel), limit(models.RateLimitCancel), orderHandler.CancelOrders)
+	}
+
+	if auth == nil {
+		return
+	}
+	adminHandler := handlers.NewAdminHandler(auth, audit, webhooks)
+	// Operational endpoints: operators and admins. Every request that changes
+	// state is audited, including those the role check rejects.
+	operator := router.Group("/admin",
+		handlers.Authenticate(auth), handlers.Audit(audit),
+		handlers.RequireScope(models.ScopeAdmin), handlers.RequireRole(models.RoleOperator))
+	{
+		operator.GET("/audit", adminHandler.ListAudit)
+		operator.DELETE("/orders", orderHandler.MassCancelAll)
+		operator.PUT("/phases/:symbol", orderHandler.SetTradingPhase)
+		if webhooks != nil {
+			operator.GET("/webhooks", adminHandler.ListWebhooks)
+			operator.GET("/deliveries", adminHandler.ListDeliveries)
+			operator.POST("/deliveries/:id/replay", adminHandler.ReplayDelivery)
+			operator.POST("/webhooks/:id/replay", adminHandler.ReplayDeadDeliveries)
+		}
+	}
+	// Accounts, roles and API keys: admins only
+	admin := operator.Group("", handlers.RequireRole(models.RoleAdmin))
+	{
+		admin.POST("/accounts", adminHandler.CreateAccount)
+		admin.PUT("/accounts/:id/role", adminHandler.SetRole)
+		admin.GET("/accounts/:id/keys", adminHandler.ListKeys)
+		admin.POST("/accounts/:id/keys", adminHandler.IssueKey)
+		admin.POST("/keys/:key_id/rotate", adminHandler.RotateKey)
+		admin.DELETE("/keys/:key_id", adminHandler.RevokeKey)
+		if webhooks != nil {
+			admin.POST("/webhooks", adminHandler.CreateWebhook)
+			admin.DELETE("/webhooks/:id", adminHandler.DeactivateWebhook)
+		}
+	}
+}
+
+// RegisterHealthRoutes serves the probes outside /api so they stay
+// reachable whatever middleware later guards the API.
+func RegisterHealthRoutes(router *gin.Engine, checker *health.Checker) {
+	router.GET("/healthz", checker.Liveness)
+	router.GET("/readyz", checker.Readiness)
 }
@@ -0,0 +1,33 @@
+package service
+
+import "context"
+
+type actorKey struct{}
+
+type accountKey struct{}
+
+// WithActor records who is acting on the engine, for the order event history.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by WithActor, or "anonymous".
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return "anonymous"
+}
+
+// WithAccount records the authenticated account a request acts for. Orders
+// placed under it belong to the account, and only it can see or cancel them.
+func WithAccount(ctx context.Context, accountID int64) context.Context {
+	return context.WithValue(ctx, accountKey{}, accountID)
+}
+
+// AccountFromContext returns the account set by WithAccount. ok is false for
+// unauthenticated requests.
+func AccountFromContext(ctx context.Context) (accountID int64, ok bool) {
+	accountID, ok = ctx.Value(accountKey{}).(int64)
+	return accountID, ok
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/shopspring/decimal"
+)
+
+// auction is the outcome of uncrossing a book: every fill executed at
+// price.
+type auction struct {
+	price float64
+	fills []auctionFill
+}
+
+// auctionFill is one trade of an auction and its two orders as they stood
+// after it.
+type auctionFill struct {
+	trade     models.Trade
+	buy, sell models.Order
+}
+
+func (a *auction) result() *models.AuctionResult {
+	r := &models.AuctionResult{Price: a.price, Trades: len(a.fills)}
+	for _, f := range a.fills {
+		r.Quantity = r.Quantity.Add(f.trade.Quantity)
+	}
+	return r
+}
+
+// auctionInTx uncrosses a book's bids and asks, best first, and records the
+// outcome like a match: the trades, every filled order, their history and,
+// with Outbox set, the fill events.
+func (s *OrderService) auctionInTx(ctx context.Context, tx repository.Tx, bids, asks []models.Order) (*auction, error) {
+	a := uncross(bids, asks)
+	for i := range a.fills {
+		f := &a.fills[i]
+		if err := s.TradeRepo.CreateTrade(ctx, tx, &f.trade); err != nil {
+			return nil, err
+		}
+		for _, order := range []*models.Order{&f.buy, &f.sell} {
+			if err := s.OrderRepo.UpdateOrder(ctx, tx, order); err != nil {
+				return nil, err
+			}
+			before := order.RemainingQty.Add(f.trade.Quantity)
+			if err := s.recordEvent(ctx, tx, order, models.OrderEventFill, statusBefore(order.Quantity, before), before, models.ReasonAuction, &f.trade.ID); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.recordFillEvents(ctx, tx, &f.buy, []models.Trade{f.trade}, []models.Order{f.sell}); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// auctioned reports a committed auction on symbol to the listeners.
+func (s *OrderService) auctioned(ctx context.Context, symbol string, a *auction) {
+	label := s.metricSymbol(symbol)
+	trades := make([]models.Trade, len(a.fills))
+	executions := make([]models.Execution, 0, 2*len(a.fills))
+	fills := make(map[int64]int64)
+	for i, f := range a.fills {
+		trades[i] = f.trade
+		executions = append(executions,
+			models.Execution{Type: models.ExecTrade, Order: f.buy, Trade: &trades[i]},
+			models.Execution{Type: models.ExecTrade, Order: f.sell, Trade: &trades[i]})
+		fills[f.buy.AccountID]++
+		fills[f.sell.AccountID]++
+		s.Metrics.Trades.WithLabelValues(label).Inc()
+		s.Metrics.TradedQuantity.WithLabelValues(label).Add(f.trade.Quantity.InexactFloat64())
+	}
+	delete(fills, 0) // orders placed without authentication
+
+	if s.RateLimiter != nil {
+		s.RateLimiter.RecordFills(ctx, fills)
+	}
+	if s.MarketData != nil {
+		s.MarketData.TradesExecuted(symbol, trades)
+		s.MarketData.BookChanged(symbol)
+	}
+	if s.Executions != nil && len(executions) > 0 {
+		s.Executions.OrdersExecuted(ctx, executions)
+	}
+}
+
+// uncross runs an auction over bids and asks, best first: it finds the
+// price at which the most quantity executes, then fills the orders that
+// price reaches in price-time priority, every trade at that price. It
+// updates the orders it fills in place.
+func uncross(bids, asks []models.Order) *auction {
+	price, volume := auctionPrice(bids, asks)
+	a := &auction{price: price}
+	now := time.Now()
+	for b, s := 0, 0; volume.IsPositive(); {
+		bid, ask := &bids[b], &asks[s] // the best of each side left
+		qty := decimal.Min(bid.RemainingQty, ask.RemainingQty)
+		bid.RemainingQty = bid.RemainingQty.Sub(qty)
+		bid.Status = fillStatus(bid.RemainingQty)
+		ask.RemainingQty = ask.RemainingQty.Sub(qty)
+		ask.Status = fillStatus(ask.RemainingQty)
+		a.fills = append(a.fills, auctionFill{
+			trade: models.Trade{BuyOrderID: bid.ID, SellOrderID: ask.ID, Price: price, Quantity: qty, CreatedAt: now},
+			buy:   *bid,
+			sell:  *ask,
+		})
+
+		volume = volume.Sub(qty)
+		if bid.RemainingQty.IsZero() {
+			b++
+		}
+		if ask.RemainingQty.IsZero() {
+			s++
+		}
+	}
+	return a
+}
+
+// auctionPrice picks, among the limit prices of the book, the one at which
+// the most quantity executes, and returns it with that quantity. Ties go to
+// the price leaving the smallest surplus, then towards the side with the
+// surplus, and failing that to the price nearest the middle of the tied
+// ones, the lower of two.
+func auctionPrice(bids, asks []models.Order) (float64, decimal.Decimal) {
+	prices := make([]float64, 0, len(bids)+len(asks))
+	for _, o := range bids {
+		prices = append(prices, o.Price)
+	}
+	for _, o := range asks {
+		prices = append(prices, o.Price)
+	}
+	slices.Sort(prices)
+	prices = slices.Compact(prices)
+
+	type level struct {
+		price           float64
+		volume, surplus decimal.Decimal // surplus is the bid quantity left over, or minus the ask quantity
+	}
+	var tied []level // best levels, by ascending price
+	for _, p := range prices {
+		var demand, supply decimal.Decimal
+		for _, o := range bids {
+			if o.Price >= p {
+				demand = demand.Add(o.RemainingQty)
+			}
+		}
+		for _, o := range asks {
+			if o.Price <= p {
+				supply = supply.Add(o.RemainingQty)
+			}
+		}
+		l := level{price: p, volume: decimal.Min(demand, supply), surplus: demand.Sub(supply)}
+		switch {
+		case l.volume.IsZero():
+		case len(tied) == 0 || l.volume.GreaterThan(tied[0].volume) || l.volume.Equal(tied[0].volume) && l.surplus.Abs().LessThan(tied[0].surplus.Abs()):
+			tied = []level{l}
+		case l.volume.Equal(tied[0].volume) && l.surplus.Abs().Equal(tied[0].surplus.Abs()):
+			tied = append(tied, l)
+		}
+	}
+	if len(tied) == 0 {
+		return 0, decimal.Zero
+	}
+
+	// The surplus shrinks as the price rises, so the ends tell its side
+	lo, hi := tied[0], tied[len(tied)-1]
+	switch {
+	case hi.surplus.IsPositive():
+		return hi.price, hi.volume
+	case lo.surplus.IsNegative():
+		return lo.price, lo.volume
+	}
+	mid := (lo.price + hi.price) / 2
+	best := lo
+	for _, l := range tied[1:] {
+		if abs(l.price-mid) < abs(best.price-mid) {
+			best = l
+		}
+	}
+	return best.price, best.volume
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
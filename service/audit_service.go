@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tracing"
+)
+
+// AuditService writes and reads the admin audit log.
+//
+// Entries are written after the action, outside its transaction, so failed
+// and rejected actions are recorded too.
+type AuditService struct {
+	AuditRepo repository.AuditRepository
+	Now       func() time.Time // for tests
+}
+
+func NewAuditService(auditRepo repository.AuditRepository) *AuditService {
+	return &AuditService{AuditRepo: auditRepo, Now: time.Now}
+}
+
+// Record logs action, performed by the actor on ctx with params, as having
+// ended with result: models.AuditResultOK or an error code.
+func (s *AuditService) Record(ctx context.Context, action string, params any, result string) (err error) {
+	ctx, span := tracer.Start(ctx, "AuditService.Record")
+	defer func() { tracing.End(span, err) }()
+
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return apperr.Internal(err)
+	}
+	entry := models.AuditEntry{
+		Actor:     ActorFromContext(ctx),
+		Action:    action,
+		Params:    raw,
+		Result:    result,
+		CreatedAt: s.Now(),
+	}
+	return s.AuditRepo.CreateAuditEntry(ctx, nil, &entry)
+}
+
+// Run performs fn as action and records its outcome. fn's error is
+// returned; an error writing the entry is returned only if fn succeeded.
+func (s *AuditService) Run(ctx context.Context, action string, params any, fn func() error) error {
+	err := fn()
+	result := models.AuditResultOK
+	if err != nil {
+		result = apperr.CodeOf(err)
+	}
+	if recErr := s.Record(ctx, action, params, result); err == nil {
+		return recErr
+	}
+	return err
+}
+
+// List returns up to limit entries, newest first.
+func (s *AuditService) List(ctx context.Context, limit int) (_ *models.AuditLogResponse, err error) {
+	ctx, span := tracer.Start(ctx, "AuditService.List")
+	defer func() { tracing.End(span, err) }()
+
+	entries, err := s.AuditRepo.ListAuditEntries(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []models.AuditEntry{}
+	}
+	return &models.AuditLogResponse{Entries: entries}, nil
+}
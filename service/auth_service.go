@@ -0,0 +1,410 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AuthService manages accounts and API keys and authenticates signed
+// requests.
+//
+// A request is signed with HMAC-SHA256, keyed with the SHA-256 digest of the
+// key's secret, over
+//
+//	timestamp + "\n" + nonce + "\n" + METHOD + "\n" + path?query + "\n" + hex(SHA-256(body))
+//
+// where timestamp is Unix seconds and nonce is a string of at most 64
+// characters the client never reuses with the key within the replay window,
+// such as a random UUID. Each nonce is accepted once by every instance, so
+// identical requests need distinct nonces.
+//
+// Secrets are stored encrypted by Secrets. Whoever reads the accounts table
+// without the server's key cannot sign requests.
+type AuthService struct {
+	TxRunner     *repository.TxRunner
+	AccountRepo  repository.AccountRepository
+	Secrets      *SecretBox
+	ReplayWindow time.Duration    // max clock skew; nonces are remembered this long
+	Now          func() time.Time // for tests
+}
+
+func NewAuthService(txRunner *repository.TxRunner, accountRepo repository.AccountRepository, secrets *SecretBox, replayWindow time.Duration) *AuthService {
+	return &AuthService{
+		TxRunner:     txRunner,
+		AccountRepo:  accountRepo,
+		Secrets:      secrets,
+		ReplayWindow: replayWindow,
+		Now:          time.Now,
+	}
+}
+
+// maxNonceLength matches api_key_nonces.nonce.
+const maxNonceLength = 64
+
+// SignedRequest is what a client sent, as seen by the server.
+type SignedRequest struct {
+	KeyID     string
+	Timestamp string
+	Nonce     string
+	Signature string // hex
+	Method    string
+	Path      string // path and raw query
+	Body      []byte
+}
+
+// StringToSign returns the canonical form of a request that is signed.
+func StringToSign(timestamp, nonce, method, path string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return strings.Join([]string{timestamp, nonce, strings.ToUpper(method), path, hex.EncodeToString(sum[:])}, "\n")
+}
+
+// Sign computes the signature a client sends for a request.
+func Sign(secret, timestamp, nonce, method, path string, body []byte) string {
+	return SignString(secret, StringToSign(timestamp, nonce, method, path, body))
+}
+
+// NewNonce returns a random nonce for signing a request.
+func NewNonce() (string, error) {
+	return randomToken("", 16)
+}
+
+// SignString computes the signature of any string to sign, such as a FIX
+// logon's.
+func SignString(secret, stringToSign string) string {
+	return hex.EncodeToString(signWithHash(hashSecret(secret), stringToSign))
+}
+
+func hashSecret(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
+
+func signWithHash(secretHash []byte, stringToSign string) []byte {
+	mac := hmac.New(sha256.New, secretHash)
+	mac.Write([]byte(stringToSign))
+	return mac.Sum(nil)
+}
+
+// Principal is what a request was authenticated as: the key that signed it
+// and the account the key belongs to.
+type Principal struct {
+	Key     *models.APIKey
+	Account *models.Account
+}
+
+// Authenticate verifies a signed request and returns the key it was signed
+// with and its account. Each nonce is accepted once within the replay
+// window.
+func (s *AuthService) Authenticate(ctx context.Context, req SignedRequest) (_ *Principal, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Authenticate", trace.WithAttributes(tracing.AttrAPIKeyID.String(req.KeyID)))
+	defer func() { tracing.End(span, err) }()
+
+	if req.KeyID == "" || req.Timestamp == "" || req.Nonce == "" || req.Signature == "" {
+		return nil, apperr.Unauthenticated(apperr.CodeUnauthenticated, "API key, timestamp, nonce and signature are required")
+	}
+	if len(req.Nonce) > maxNonceLength {
+		return nil, apperr.Unauthenticated(apperr.CodeUnauthenticated, "nonce must not exceed %d characters", maxNonceLength)
+	}
+	stringToSign := StringToSign(req.Timestamp, req.Nonce, req.Method, req.Path, req.Body)
+	principal, err := s.verify(ctx, req.KeyID, req.Timestamp, req.Nonce, req.Signature, stringToSign)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(tracing.AttrAccountID.Int64(principal.Account.ID))
+	return principal, nil
+}
+
+// AuthenticateLogon verifies the signature a session-based client, such as
+// a FIX client, logs on with: the same scheme as requests, over
+// stringToSign as agreed for that protocol, at timestamp. The signature
+// serves as the nonce, so stringToSign must change with every logon.
+func (s *AuthService) AuthenticateLogon(ctx context.Context, keyID, timestamp, signature, stringToSign string) (_ *Principal, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.AuthenticateLogon", trace.WithAttributes(tracing.AttrAPIKeyID.String(keyID)))
+	defer func() { tracing.End(span, err) }()
+
+	if keyID == "" || timestamp == "" || signature == "" {
+		return nil, apperr.Unauthenticated(apperr.CodeUnauthenticated, "API key, timestamp and signature are required")
+	}
+	principal, err := s.verify(ctx, keyID, timestamp, signature, signature, stringToSign)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(tracing.AttrAccountID.Int64(principal.Account.ID))
+	return principal, nil
+}
+
+func (s *AuthService) verify(ctx context.Context, keyID, timestamp, nonce, signatureHex, stringToSign string) (*Principal, error) {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, apperr.Unauthenticated(apperr.CodeStaleTimestamp, "timestamp must be Unix seconds")
+	}
+	now := s.Now()
+	if skew := now.Sub(time.Unix(unix, 0)).Abs(); skew > s.ReplayWindow {
+		return nil, apperr.Unauthenticated(apperr.CodeStaleTimestamp, "timestamp is outside the %s replay window", s.ReplayWindow)
+	}
+
+	signature, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return nil, apperr.Unauthenticated(apperr.CodeInvalidSignature, "signature must be hex encoded")
+	}
+
+	key, err := s.AccountRepo.GetAPIKey(ctx, nil, keyID)
+	if apperr.KindOf(err) == apperr.KindNotFound {
+		return nil, apperr.Unauthenticated(apperr.CodeUnauthenticated, "unknown API key")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, apperr.Unauthenticated(apperr.CodeAPIKeyRevoked, "API key has been revoked")
+	}
+
+	if key.SealedSecret == nil {
+		return nil, apperr.Unauthenticated(apperr.CodeUnauthenticated, "API key must be rotated before use")
+	}
+	secret, err := s.Secrets.open(key.SealedSecret)
+	if err != nil {
+		return nil, apperr.Internal(err)
+	}
+	expected := signWithHash(hashSecret(secret), stringToSign)
+	if !hmac.Equal(signature, expected) {
+		return nil, apperr.Unauthenticated(apperr.CodeInvalidSignature, "signature does not match the request")
+	}
+
+	// Checked last, so only valid signatures record nonces. A nonce is kept
+	// for as long as its timestamp is accepted, which is past now for
+	// requests dated ahead of the server clock.
+	expires := now.Add(s.ReplayWindow)
+	if dated := time.Unix(unix, 0).Add(s.ReplayWindow); dated.After(expires) {
+		expires = dated
+	}
+	unused, err := s.AccountRepo.UseNonce(ctx, keyID, nonce, expires, now)
+	if err != nil {
+		return nil, err
+	}
+	if !unused {
+		return nil, apperr.Unauthenticated(apperr.CodeReplayedRequest, "nonce has already been used")
+	}
+
+	account, err := s.AccountRepo.GetAccount(ctx, nil, key.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{Key: key, Account: account}, nil
+}
+
+// CreateAccount creates an account without keys. An empty role means
+// trader.
+func (s *AuthService) CreateAccount(ctx context.Context, name, role string) (_ *models.Account, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.CreateAccount")
+	defer func() { tracing.End(span, err) }()
+
+	if role == "" {
+		role = models.RoleTrader
+	}
+	if err := checkRole(role); err != nil {
+		return nil, err
+	}
+
+	account := models.Account{Name: name, Role: role, CreatedAt: s.Now()}
+	err = s.TxRunner.Run(ctx, serializable, func(tx repository.Tx) error {
+		account.ID = 0
+		return s.AccountRepo.CreateAccount(ctx, tx, &account)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// SetRole changes an account's role. It applies to the account's keys from
+// their next request.
+func (s *AuthService) SetRole(ctx context.Context, accountID int64, role string) (_ *models.Account, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.SetRole", trace.WithAttributes(tracing.AttrAccountID.Int64(accountID)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := checkRole(role); err != nil {
+		return nil, err
+	}
+
+	var account *models.Account
+	err = s.TxRunner.Run(ctx, serializable, func(tx repository.Tx) error {
+		a, err := s.AccountRepo.GetAccount(ctx, tx, accountID)
+		if err != nil {
+			return err
+		}
+		account = a
+		account.Role = role
+		return s.AccountRepo.UpdateAccount(ctx, tx, account)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// IssueKey creates a key for an account. The returned secret is not stored
+// and cannot be retrieved again.
+func (s *AuthService) IssueKey(ctx context.Context, accountID int64, scopes []string) (_ *models.IssuedAPIKeyResponse, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.IssueKey", trace.WithAttributes(tracing.AttrAccountID.Int64(accountID)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := checkScopes(scopes); err != nil {
+		return nil, err
+	}
+
+	keyID, err := randomToken("ak_", 12)
+	if err != nil {
+		return nil, apperr.Internal(err)
+	}
+	secret, err := randomToken("", 32)
+	if err != nil {
+		return nil, apperr.Internal(err)
+	}
+
+	sealed, err := s.Secrets.seal(secret)
+	if err != nil {
+		return nil, apperr.Internal(err)
+	}
+
+	key := models.APIKey{
+		KeyID:        keyID,
+		AccountID:    accountID,
+		SealedSecret: sealed,
+		Scopes:       slices.Compact(slices.Sorted(slices.Values(scopes))),
+		CreatedAt:    s.Now(),
+	}
+	err = s.TxRunner.Run(ctx, serializable, func(tx repository.Tx) error {
+		if _, err := s.AccountRepo.GetAccount(ctx, tx, accountID); err != nil {
+			return err
+		}
+		key.ID = 0
+		return s.AccountRepo.CreateAPIKey(ctx, tx, &key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(tracing.AttrAPIKeyID.String(keyID))
+	return &models.IssuedAPIKeyResponse{APIKey: key, Secret: secret}, nil
+}
+
+// RotateKey replaces a key's secret. The old secret stops working at once;
+// the key ID and scopes are kept.
+func (s *AuthService) RotateKey(ctx context.Context, keyID string) (_ *models.IssuedAPIKeyResponse, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.RotateKey", trace.WithAttributes(tracing.AttrAPIKeyID.String(keyID)))
+	defer func() { tracing.End(span, err) }()
+
+	secret, err := randomToken("", 32)
+	if err != nil {
+		return nil, apperr.Internal(err)
+	}
+	sealed, err := s.Secrets.seal(secret)
+	if err != nil {
+		return nil, apperr.Internal(err)
+	}
+
+	var key *models.APIKey
+	err = s.TxRunner.Run(ctx, serializable, func(tx repository.Tx) error {
+		k, err := s.AccountRepo.GetAPIKey(ctx, tx, keyID)
+		if err != nil {
+			return err
+		}
+		key = k
+		if key.RevokedAt != nil {
+			return apperr.Conflict(apperr.CodeAPIKeyRevoked, "API key %s has been revoked", keyID)
+		}
+		now := s.Now()
+		key.SealedSecret = sealed
+		key.RotatedAt = &now
+		return s.AccountRepo.UpdateAPIKey(ctx, tx, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.IssuedAPIKeyResponse{APIKey: *key, Secret: secret}, nil
+}
+
+// RevokeKey disables a key permanently. Revoking a revoked key is a no-op.
+func (s *AuthService) RevokeKey(ctx context.Context, keyID string) (_ *models.APIKey, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.RevokeKey", trace.WithAttributes(tracing.AttrAPIKeyID.String(keyID)))
+	defer func() { tracing.End(span, err) }()
+
+	var key *models.APIKey
+	err = s.TxRunner.Run(ctx, serializable, func(tx repository.Tx) error {
+		k, err := s.AccountRepo.GetAPIKey(ctx, tx, keyID)
+		if err != nil {
+			return err
+		}
+		key = k
+		if key.RevokedAt != nil {
+			return nil
+		}
+		now := s.Now()
+		key.RevokedAt = &now
+		return s.AccountRepo.UpdateAPIKey(ctx, tx, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ListKeys returns an account's keys, including revoked ones.
+func (s *AuthService) ListKeys(ctx context.Context, accountID int64) (_ *models.APIKeysResponse, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.ListKeys", trace.WithAttributes(tracing.AttrAccountID.Int64(accountID)))
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := s.AccountRepo.GetAccount(ctx, nil, accountID); err != nil {
+		return nil, err
+	}
+	keys, err := s.AccountRepo.ListAPIKeys(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		keys = []models.APIKey{}
+	}
+	return &models.APIKeysResponse{AccountID: accountID, Keys: keys}, nil
+}
+
+func checkScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return apperr.InvalidArgument(apperr.CodeValidationFailed, "at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(models.Scopes, scope) {
+			return apperr.InvalidArgument(apperr.CodeValidationFailed, "unknown scope %q", scope)
+		}
+	}
+	return nil
+}
+
+func checkRole(role string) error {
+	if !slices.Contains(models.Roles, role) {
+		return apperr.InvalidArgument(apperr.CodeValidationFailed, "unknown role %q", role)
+	}
+	return nil
+}
+
+// randomToken returns prefix followed by n random bytes, base64url encoded.
+func randomToken(prefix string, n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
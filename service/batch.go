@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tracing"
+	"github.com/Puneet-Vishnoi/order-matching-engine/utils"
+	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ValidateOrder checks the fields of an order request, for callers that do
+// not bind it from JSON.
+func ValidateOrder(req *models.PlaceOrderRequest) error {
+	err := utils.GetValidator().Struct(req)
+	if err == nil {
+		return nil
+	}
+	var fields []string
+	for _, e := range err.(validator.ValidationErrors) {
+		fields = append(fields, e.Field())
+	}
+	return apperr.InvalidArgument(apperr.CodeValidationFailed, "Order validation failed: invalid %s", strings.Join(fields, ", "))
+}
+
+// PlaceOrders places a batch of orders in sequence, each in its own
+// transaction like PlaceOrder, and reports each one's outcome. Every order
+// is validated first; with allOrNone, none is placed unless all are valid.
+func (s *OrderService) PlaceOrders(ctx context.Context, reqs []models.PlaceOrderRequest, allOrNone bool) (resp *models.BatchPlaceOrdersResponse, err error) {
+	ctx, span := tracer.Start(ctx, "OrderService.PlaceOrders", trace.WithAttributes(
+		attribute.Int("batch.size", len(reqs)), attribute.Bool("batch.all_or_none", allOrNone)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.checkBatchSize(len(reqs)); err != nil {
+		return nil, err
+	}
+
+	resp = &models.BatchPlaceOrdersResponse{Results: make([]models.BatchOrderResult, len(reqs))}
+	invalid := -1 // the first invalid order
+	for i := range reqs {
+		resp.Results[i] = models.BatchOrderResult{Index: i}
+		err := ValidateOrder(&reqs[i])
+		if err == nil {
+			err = checkInstrumentRules(s.Engine.Symbol(reqs[i].Symbol), &reqs[i])
+		}
+		if err != nil {
+			s.Metrics.Rejections.WithLabelValues(apperr.CodeOf(err)).Inc()
+			resp.Results[i].Result, resp.Results[i].Error = models.BatchRejected, batchError(err)
+			if invalid < 0 {
+				invalid = i
+			}
+		}
+	}
+
+	for i := range reqs {
+		result := &resp.Results[i]
+		switch {
+		case result.Error != nil:
+		case allOrNone && invalid >= 0:
+			result.Result = models.BatchRejected
+			result.Error = batchError(apperr.InvalidArgument(apperr.CodeBatchAborted, "not placed: order %d of the batch is invalid", invalid))
+		default:
+			order, p, err := s.place(ctx, &reqs[i])
+			if err != nil {
+				result.Result, result.Error = models.BatchRejected, batchError(err)
+				break
+			}
+			placed := placedResponse(order, p, "")
+			result.Result = models.BatchAccepted
+			result.OrderID = order.ID
+			result.Status = order.Status
+			result.RemainingQuantity = order.RemainingQty
+			result.ExecutedQuantity = placed.ExecutedQuantity
+			result.AveragePrice = placed.AveragePrice
+			result.CancelReason = placed.CancelReason
+			result.QuoteSpent = placed.QuoteSpent
+			result.QuoteLeftover = placed.QuoteLeftover
+			result.Fills = p.trades
+		}
+		if result.Result == models.BatchAccepted {
+			resp.Accepted++
+		} else {
+			resp.Rejected++
+		}
+	}
+	span.SetAttributes(attribute.Int("batch.accepted", resp.Accepted))
+	return resp, nil
+}
+
+// CancelOrders cancels a batch of the caller's orders and reports each
+// one's outcome. Each is canceled in its own transaction, in sequence; with
+// allOrNone, all are canceled in one transaction, so that if one cannot be
+// canceled none is.
+func (s *OrderService) CancelOrders(ctx context.Context, ids []int64, allOrNone bool) (resp *models.BatchCancelOrdersResponse, err error) {
+	ctx, span := tracer.Start(ctx, "OrderService.CancelOrders", trace.WithAttributes(
+		attribute.Int("batch.size", len(ids)), attribute.Bool("batch.all_or_none", allOrNone)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.checkBatchSize(len(ids)); err != nil {
+		return nil, err
+	}
+
+	resp = &models.BatchCancelOrdersResponse{Results: make([]models.BatchCancelResult, len(ids))}
+	for i, id := range ids {
+		resp.Results[i] = models.BatchCancelResult{Index: i, OrderID: id, Result: models.BatchAccepted}
+	}
+
+	if allOrNone {
+		if failed, err := s.cancelAll(ctx, ids); err != nil {
+			if failed < 0 {
+				return nil, err // not the fault of any one order
+			}
+			for i := range resp.Results {
+				resp.Results[i].Result = models.BatchRejected
+				resp.Results[i].Error = batchError(apperr.Conflict(apperr.CodeBatchAborted, "not canceled: order %d of the batch cannot be canceled", ids[failed]))
+			}
+			resp.Results[failed].Error = batchError(err)
+		}
+	} else {
+		for i, id := range ids {
+			if _, err := s.cancel(ctx, id); err != nil {
+				resp.Results[i].Result, resp.Results[i].Error = models.BatchRejected, batchError(err)
+			}
+		}
+	}
+
+	for _, result := range resp.Results {
+		if result.Result == models.BatchAccepted {
+			resp.Canceled++
+		} else {
+			resp.Rejected++
+		}
+	}
+	span.SetAttributes(attribute.Int("batch.canceled", resp.Canceled))
+	return resp, nil
+}
+
+// cancelAll cancels every order in one transaction. When an order cannot be
+// canceled, it returns its index with the error; -1 means the transaction
+// itself failed.
+func (s *OrderService) cancelAll(ctx context.Context, ids []int64) (int, error) {
+	var canceled []models.Order
+	failed := -1
+	err := s.TxRunner.Run(ctx, serializable, func(tx repository.Tx) error {
+		canceled, failed = canceled[:0], -1
+		for i, id := range ids {
+			order, err := s.getOwnOrder(ctx, tx, id)
+			if err == nil {
+				err = s.cancelInTx(ctx, tx, order, models.ReasonUserRequest)
+			}
+			if err != nil {
+				if orderFault(err) {
+					failed = i
+				}
+				return err
+			}
+			canceled = append(canceled, *order)
+		}
+		return nil
+	})
+	if err != nil {
+		if !orderFault(err) {
+			failed = -1 // e.g. retries exhausted after an order failed in an earlier attempt
+		}
+		return failed, err
+	}
+	s.canceled(ctx, canceled, models.ReasonUserRequest)
+	return -1, nil
+}
+
+// orderFault reports whether err is about an order rather than the
+// transaction it was canceled in.
+func orderFault(err error) bool {
+	switch apperr.KindOf(err) {
+	case apperr.KindNotFound:
+		return true
+	case apperr.KindConflict:
+		return apperr.CodeOf(err) != apperr.CodeTransactionConflict
+	}
+	return false
+}
+
+func (s *OrderService) checkBatchSize(n int) error {
+	if n == 0 {
+		return apperr.InvalidArgument(apperr.CodeInvalidRequest, "batch is empty")
+	}
+	if limit := s.Engine.MaxBatchSize; limit > 0 && n > limit {
+		return apperr.InvalidArgument(apperr.CodeBatchTooLarge, "batch of %d exceeds the maximum of %d", n, limit)
+	}
+	return nil
+}
+
+// batchError is how the failure of one item of a batch is reported: domain
+// errors as they are, others as internal errors, logged.
+func batchError(err error) *models.ErrorBody {
+	appErr, ok := apperr.As(err)
+	if !ok {
+		log.Printf("batch item failed: %v", err)
+		appErr = apperr.Internal(err)
+	}
+	return &models.ErrorBody{Code: appErr.Code, Message: appErr.Message}
+}
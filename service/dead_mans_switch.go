@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+)
+
+// Bounds of a dead man's switch timeout.
+const (
+	DefaultMinSwitchTimeout = time.Second
+	DefaultMaxSwitchTimeout = time.Hour
+)
+
+// DeadMansSwitch cancels every order of an account that stops re-arming
+// it: a client arms its switch with a timeout and keeps arming it again
+// while it is healthy, and when it stops, its orders are canceled as the
+// timeout runs out. Switches live in the memory of the instance they were
+// armed on and do not survive a restart.
+type DeadMansSwitch struct {
+	Orders     *OrderService
+	MinTimeout time.Duration
+	MaxTimeout time.Duration
+
+	mu      sync.Mutex
+	armed   map[int64]*armedSwitch // by account
+	stopped bool
+}
+
+type armedSwitch struct {
+	timer     *time.Timer
+	timeout   time.Duration
+	triggerAt time.Time
+}
+
+func NewDeadMansSwitch(orders *OrderService) *DeadMansSwitch {
+	return &DeadMansSwitch{
+		Orders:     orders,
+		MinTimeout: DefaultMinSwitchTimeout,
+		MaxTimeout: DefaultMaxSwitchTimeout,
+		armed:      make(map[int64]*armedSwitch),
+	}
+}
+
+// Arm arms the caller's switch to fire after timeout, replacing the
+// timeout it was armed with; a zero timeout disarms it.
+func (d *DeadMansSwitch) Arm(ctx context.Context, timeout time.Duration) (*models.DeadMansSwitchResponse, error) {
+	accountID, ok := AccountFromContext(ctx)
+	if !ok {
+		return nil, apperr.Unauthenticated(apperr.CodeUnauthenticated, "an authenticated account is required")
+	}
+	if timeout != 0 && (timeout < d.MinTimeout || timeout > d.MaxTimeout) {
+		return nil, apperr.InvalidArgument(apperr.CodeValidationFailed, "timeout_ms must be 0 or between %d and %d",
+			d.MinTimeout.Milliseconds(), d.MaxTimeout.Milliseconds())
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		return nil, apperr.Unavailable(apperr.CodeShuttingDown, "server shutting down")
+	}
+	if prev := d.armed[accountID]; prev != nil {
+		prev.timer.Stop()
+		delete(d.armed, accountID)
+	}
+	if timeout == 0 {
+		return &models.DeadMansSwitchResponse{}, nil
+	}
+
+	// The cancels outlive the request, as the account and actor that armed
+	// the switch
+	fireCtx := WithAccount(WithActor(context.Background(), ActorFromContext(ctx)), accountID)
+	sw := &armedSwitch{timeout: timeout, triggerAt: time.Now().Add(timeout)}
+	sw.timer = time.AfterFunc(timeout, func() { d.fire(fireCtx, accountID, sw) })
+	d.armed[accountID] = sw
+	return sw.state(), nil
+}
+
+// State returns the caller's switch.
+func (d *DeadMansSwitch) State(ctx context.Context) (*models.DeadMansSwitchResponse, error) {
+	accountID, ok := AccountFromContext(ctx)
+	if !ok {
+		return nil, apperr.Unauthenticated(apperr.CodeUnauthenticated, "an authenticated account is required")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if sw := d.armed[accountID]; sw != nil {
+		return sw.state(), nil
+	}
+	return &models.DeadMansSwitchResponse{}, nil
+}
+
+// Stop disarms every switch, for shutdown: the orders of the accounts that
+// armed one are left as they are.
+func (d *DeadMansSwitch) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stopped = true
+	for accountID, sw := range d.armed {
+		sw.timer.Stop()
+		delete(d.armed, accountID)
+	}
+}
+
+// StreamClosed cancels the caller's orders on symbol entered since opened,
+// when a market data stream opened with cancel-on-disconnect ends. Like the
+// switches, it leaves the orders as they are once stopped for shutdown,
+// when the streams end because the server does.
+func (d *DeadMansSwitch) StreamClosed(ctx context.Context, symbol string, opened time.Time) (*models.MassCancelResponse, error) {
+	d.mu.Lock()
+	stopped := d.stopped
+	d.mu.Unlock()
+	if stopped {
+		return &models.MassCancelResponse{OrderIDs: []int64{}}, nil
+	}
+	return d.Orders.CancelOrdersSince(ctx, symbol, opened, models.ReasonDisconnect)
+}
+
+func (d *DeadMansSwitch) fire(ctx context.Context, accountID int64, sw *armedSwitch) {
+	d.mu.Lock()
+	current := d.armed[accountID] == sw
+	if current {
+		delete(d.armed, accountID)
+	}
+	d.mu.Unlock()
+	if !current {
+		return // re-armed or disarmed as it fired
+	}
+
+	resp, err := d.Orders.CancelAccountOrders(ctx, models.ReasonDeadMans)
+	if err != nil {
+		log.Printf("dead man's switch of account %d: cancel failed: %v", accountID, err)
+		return
+	}
+	log.Printf("dead man's switch of account %d fired: %d orders canceled", accountID, resp.Canceled)
+}
+
+func (sw *armedSwitch) state() *models.DeadMansSwitchResponse {
+	triggerAt := sw.triggerAt.UTC()
+	return &models.DeadMansSwitchResponse{TimeoutMS: sw.timeout.Milliseconds(), TriggerAt: &triggerAt}
+}
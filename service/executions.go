@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+)
+
+// ExecutionListener is told about every change to orders once it has
+// committed, e.g. to send FIX execution reports. ctx is that of the call
+// that made the change. It must not block order entry.
+type ExecutionListener interface {
+	OrdersExecuted(ctx context.Context, executions []models.Execution)
+}
+
+// matchExecutions lists what happened to the orders of a placement, in the
+// order recordMatchEvents records it: the fills of both sides of every
+// trade, then the cancel of an unfilled market order remainder. The
+// incoming order is listed first as opened.
+func matchExecutions(opened string, incoming *models.Order, p *placement, origOrderID int64) []models.Execution {
+	trades, counterOrders := p.trades, p.counterOrders
+	remaining := incoming.RemainingQty
+	for _, t := range trades {
+		remaining = remaining.Add(t.Quantity)
+	}
+	first := *incoming
+	first.RemainingQty = remaining
+	first.Status = statusBefore(incoming.Quantity, remaining)
+	executions := []models.Execution{{Type: opened, Order: first, OrigOrderID: origOrderID}}
+
+	for i := range trades {
+		trade := &trades[i]
+		remaining = remaining.Sub(trade.Quantity)
+		step := *incoming
+		step.RemainingQty = remaining
+		step.Status = fillStatus(remaining)
+		executions = append(executions,
+			models.Execution{Type: models.ExecTrade, Order: step, Trade: trade},
+			models.Execution{Type: models.ExecTrade, Order: counterOrders[i], Trade: trade})
+	}
+
+	if incoming.Status == "canceled" {
+		executions = append(executions, models.Execution{Type: models.ExecCanceled, Order: *incoming, Reason: p.cancelReason})
+	}
+	return executions
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/metrics"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// stageTimer measures consecutive stages of one attempt. start resets it,
+// so after a retried transaction only the attempt that committed is kept.
+type stageTimer struct {
+	last   time.Time
+	stages []string
+	took   []time.Duration
+}
+
+func (t *stageTimer) start() {
+	t.last = time.Now()
+	t.stages = t.stages[:0]
+	t.took = t.took[:0]
+}
+
+// lap ends the current stage and starts the next one.
+func (t *stageTimer) lap(stage string) {
+	now := time.Now()
+	t.stages = append(t.stages, stage)
+	t.took = append(t.took, now.Sub(t.last))
+	t.last = now
+}
+
+func (t *stageTimer) observe(h *prometheus.HistogramVec) {
+	for i, stage := range t.stages {
+		h.WithLabelValues(stage).Observe(t.took[i].Seconds())
+	}
+}
+
+// recordPlacementMetrics counts a committed order and its trades. Counting
+// after commit keeps rolled-back attempts out of the totals.
+func (s *OrderService) recordPlacementMetrics(order *models.Order, trades []models.Trade) {
+	symbol := s.metricSymbol(order.Symbol)
+	s.Metrics.OrdersPlaced.WithLabelValues(symbol, order.Side, order.Type).Inc()
+	for _, t := range trades {
+		s.Metrics.Trades.WithLabelValues(symbol).Inc()
+		s.Metrics.TradedQuantity.WithLabelValues(symbol).Add(t.Quantity.InexactFloat64())
+	}
+}
+
+// metricSymbol returns the symbol label of symbol: itself if it is listed
+// in engine.symbols, metrics.OtherSymbol otherwise.
+func (s *OrderService) metricSymbol(symbol string) string {
+	if _, ok := s.Engine.Symbols[symbol]; ok {
+		return symbol
+	}
+	return metrics.OtherSymbol
+}
+
+// bookDepth is the metrics.DepthSource of the service: the depth of every
+// book, with those of unlisted symbols summed per side under
+// metrics.OtherSymbol.
+func (s *OrderService) bookDepth(ctx context.Context) ([]models.BookDepth, error) {
+	depths, err := s.OrderRepo.FetchBookDepth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	labelled := make([]models.BookDepth, 0, len(depths))
+	other := make(map[string]int) // side -> index in labelled
+	for _, d := range depths {
+		d.Symbol = s.metricSymbol(d.Symbol)
+		i, ok := other[d.Side]
+		switch {
+		case d.Symbol != metrics.OtherSymbol:
+			labelled = append(labelled, d)
+		case !ok:
+			other[d.Side] = len(labelled)
+			labelled = append(labelled, d)
+		default:
+			labelled[i].Orders += d.Orders
+			labelled[i].Levels += d.Levels
+			labelled[i].Quantity = labelled[i].Quantity.Add(d.Quantity)
+		}
+	}
+	return labelled, nil
+}
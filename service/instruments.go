@@ -0,0 +1,41 @@
+package service
+
+import (
+	"math"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+)
+
+// checkInstrumentRules rejects orders that break the configured tick size,
+// quantity precision, lot size or quantity limits of their symbol.
+func checkInstrumentRules(rules config.SymbolConfig, req *models.PlaceOrderRequest) error {
+	if rules.TickSize > 0 && req.Type == "limit" && !isMultiple(req.Price, rules.TickSize) {
+		return apperr.Rejected(apperr.ReasonInvalidTickSize, "price %v is not a multiple of the tick size %v", req.Price, rules.TickSize)
+	}
+	if req.QuoteQuantity.IsPositive() {
+		// Sized when it is placed, see sizeQuoteOrder
+		return nil
+	}
+	if precision := rules.Precision(); !req.Quantity.Equal(req.Quantity.Truncate(precision)) {
+		return apperr.Rejected(apperr.ReasonInvalidPrecision, "quantity %s has more than %d decimals", req.Quantity, precision)
+	}
+	if rules.LotSize.IsPositive() && !req.Quantity.Mod(rules.LotSize).IsZero() {
+		return apperr.Rejected(apperr.ReasonInvalidLotSize, "quantity %s is not a multiple of the lot size %s", req.Quantity, rules.LotSize)
+	}
+	if rules.MinQuantity.IsPositive() && req.Quantity.LessThan(rules.MinQuantity) {
+		return apperr.Rejected(apperr.ReasonQuantityOutOfRange, "quantity %s is below the minimum %s", req.Quantity, rules.MinQuantity)
+	}
+	if rules.MaxQuantity.IsPositive() && req.Quantity.GreaterThan(rules.MaxQuantity) {
+		return apperr.Rejected(apperr.ReasonQuantityOutOfRange, "quantity %s exceeds the maximum %s", req.Quantity, rules.MaxQuantity)
+	}
+	return nil
+}
+
+// isMultiple tolerates the rounding error of binary floating point, so that
+// e.g. 0.3 counts as a multiple of 0.1.
+func isMultiple(v, step float64) bool {
+	n := v / step
+	return math.Abs(n-math.Round(n)) < 1e-9
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+)
+
+// MarketData distributes the book and trades to every API instance. The
+// order service reports each committed change to it and, with
+// ServeBookFromCache, reads the book back from it instead of from storage.
+type MarketData interface {
+	// BookChanged and TradesExecuted must not block order entry
+	BookChanged(symbol string)
+	TradesExecuted(symbol string, trades []models.Trade)
+	// Book returns the last published snapshot of symbol's book
+	Book(ctx context.Context, symbol string) (*models.OrderBookResponse, error)
+	// Subscribe streams a snapshot of symbol's book, then its changes and
+	// trades, until ctx is done or the channel is closed
+	Subscribe(ctx context.Context, symbol string) (<-chan models.MarketDataEvent, error)
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MassCancel cancels every open and partially filled order of the caller
+// matching filter's symbol and side, in one transaction. Authenticated
+// callers only cancel their own orders, whatever the filter's account.
+// Without an account the filter must name a symbol or side, or it would
+// cancel every order in the system.
+func (s *OrderService) MassCancel(ctx context.Context, filter models.OrderFilter) (*models.MassCancelResponse, error) {
+	if accountID, ok := AccountFromContext(ctx); ok {
+		filter.AccountID = accountID
+	} else if filter.Symbol == "" && filter.Side == "" {
+		return nil, apperr.InvalidArgument(apperr.CodeFilterRequired, "symbol or side is required without an authenticated account")
+	}
+	return s.massCancel(ctx, "OrderService.MassCancel", filter, models.ReasonMassCancel)
+}
+
+// MassCancelAll cancels every resting order on filter's symbol, of any
+// account unless the filter names one, for operators pulling a book.
+func (s *OrderService) MassCancelAll(ctx context.Context, filter models.OrderFilter) (*models.MassCancelResponse, error) {
+	if filter.Symbol == "" {
+		return nil, apperr.InvalidArgument(apperr.CodeSymbolRequired, "symbol is required")
+	}
+	return s.massCancel(ctx, "OrderService.MassCancelAll", filter, models.ReasonOperator)
+}
+
+// CancelAccountOrders cancels every resting order of the caller's account,
+// for the protections that pull an account's quotes. It needs an
+// authenticated caller: without an account there is no telling whose orders
+// to cancel.
+func (s *OrderService) CancelAccountOrders(ctx context.Context, reason string) (*models.MassCancelResponse, error) {
+	accountID, ok := AccountFromContext(ctx)
+	if !ok {
+		return nil, apperr.Unauthenticated(apperr.CodeUnauthenticated, "an authenticated account is required")
+	}
+	return s.massCancel(ctx, "OrderService.CancelAccountOrders", models.OrderFilter{AccountID: accountID}, reason)
+}
+
+// CancelOrdersSince cancels the caller's resting orders on symbol entered
+// at or after since, in one transaction, e.g. those placed while a market
+// data stream was open. It needs an authenticated caller.
+func (s *OrderService) CancelOrdersSince(ctx context.Context, symbol string, since time.Time, reason string) (resp *models.MassCancelResponse, err error) {
+	accountID, ok := AccountFromContext(ctx)
+	if !ok {
+		return nil, apperr.Unauthenticated(apperr.CodeUnauthenticated, "an authenticated account is required")
+	}
+	ctx, span := tracer.Start(ctx, "OrderService.CancelOrdersSince", trace.WithAttributes(
+		tracing.AttrAccountID.Int64(accountID), tracing.AttrSymbol.String(symbol)))
+	defer func() { tracing.End(span, err) }()
+
+	// Stored times may be truncated to microseconds
+	since = since.Truncate(time.Microsecond)
+	resp, err = s.cancelWhere(ctx, reason, func(tx repository.Tx) ([]models.Order, error) {
+		orders, err := s.OrderRepo.FetchOpenOrders(ctx, tx, models.OrderFilter{AccountID: accountID, Symbol: symbol})
+		if err != nil {
+			return nil, err
+		}
+		return slices.DeleteFunc(orders, func(o models.Order) bool { return o.CreatedAt.Before(since) }), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("orders.canceled", resp.Canceled))
+	return resp, nil
+}
+
+// CancelResting cancels those of the caller's orders ids that still rest,
+// in one transaction, e.g. the orders a FIX session entered. Orders that
+// have filled, were canceled or are not the caller's are skipped.
+func (s *OrderService) CancelResting(ctx context.Context, ids []int64, reason string) (resp *models.MassCancelResponse, err error) {
+	ctx, span := tracer.Start(ctx, "OrderService.CancelResting", trace.WithAttributes(attribute.Int("orders.requested", len(ids))))
+	defer func() { tracing.End(span, err) }()
+
+	resp, err = s.cancelWhere(ctx, reason, func(tx repository.Tx) ([]models.Order, error) {
+		var orders []models.Order
+		for _, id := range ids {
+			order, err := s.getOwnOrder(ctx, tx, id)
+			if apperr.KindOf(err) == apperr.KindNotFound {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if order.Status == "open" || order.Status == "partial" {
+				orders = append(orders, *order)
+			}
+		}
+		return orders, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("orders.canceled", resp.Canceled))
+	return resp, nil
+}
+
+func (s *OrderService) massCancel(ctx context.Context, name string, filter models.OrderFilter, reason string) (resp *models.MassCancelResponse, err error) {
+	ctx, span := tracer.Start(ctx, name, trace.WithAttributes(
+		tracing.AttrAccountID.Int64(filter.AccountID), tracing.AttrSymbol.String(filter.Symbol), tracing.AttrSide.String(filter.Side)))
+	defer func() { tracing.End(span, err) }()
+
+	if filter.Side != "" && filter.Side != "buy" && filter.Side != "sell" {
+		return nil, apperr.InvalidArgument(apperr.CodeInvalidOrderSide, "invalid order side")
+	}
+
+	resp, err = s.cancelWhere(ctx, reason, func(tx repository.Tx) ([]models.Order, error) {
+		return s.OrderRepo.FetchOpenOrders(ctx, tx, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("orders.canceled", resp.Canceled))
+	return resp, nil
+}
+
+// cancelWhere cancels the resting orders fetch selects, in one transaction,
+// then reports them to the listeners.
+func (s *OrderService) cancelWhere(ctx context.Context, reason string, fetch func(tx repository.Tx) ([]models.Order, error)) (*models.MassCancelResponse, error) {
+	var canceled []models.Order
+	err := s.TxRunner.Run(ctx, serializable, func(tx repository.Tx) error {
+		orders, err := fetch(tx)
+		if err != nil {
+			return err
+		}
+		canceled = canceled[:0]
+		for i := range orders {
+			if err := s.cancelInTx(ctx, tx, &orders[i], reason); err != nil {
+				return err
+			}
+			canceled = append(canceled, orders[i])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.canceled(ctx, canceled, reason)
+
+	resp := &models.MassCancelResponse{Canceled: len(canceled), OrderIDs: make([]int64, len(canceled))}
+	for i, order := range canceled {
+		resp.OrderIDs[i] = order.ID
+	}
+	return resp, nil
+}
@@ -2,12 +2,14 @@ package service
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"time"
 
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
 	"github.com/Puneet-Vishnoi/order-matching-engine/models"
 	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tracing"
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type MatchingEngine struct{}
@@ -16,36 +18,53 @@ func NewMatchingEngine() *MatchingEngine {
 	return &MatchingEngine{}
 }
 
+// Collar bounds the prices an incoming order may trade at. Zero fields
+// leave the corresponding bound unset.
+type Collar struct {
+	Band        float64 // edge of the symbol's price band
+	Price       float64 // worst price of a protected market order
+	SlippagePct float64 // from the best counter order at arrival
+}
+
 // Match performs order matching logic and returns:
-// - trades to be created
-// - updated counterparty orders
-// - the updated current order
+//   - trades to be created
+//   - updated counterparty orders
+//   - the updated current order
+//   - why matching stopped at collar with counter orders left that incoming
+//     would match: models.ReasonPriceBand or models.ReasonSlippage, or ""
+//
+// A market order stopped by its collar is canceled.
 func (e *MatchingEngine) Match(
 	ctx context.Context,
-	tx *sql.Tx,
+	tx repository.Tx,
 	incoming *models.Order,
-	repo *repository.OrderRepository,
-) ([]models.Trade, []models.Order, error) {
+	repo repository.OrderRepository,
+	collar Collar,
+) (trades []models.Trade, updatedOrders []models.Order, stop string, err error) {
+	ctx, span := tracer.Start(ctx, "MatchingEngine.Match", trace.WithAttributes(
+		tracing.AttrSymbol.String(incoming.Symbol), tracing.AttrSide.String(incoming.Side), tracing.AttrOrderID.Int64(incoming.ID)))
+	defer func() {
+		span.SetAttributes(tracing.AttrTrades.Int(len(trades)))
+		tracing.End(span, err)
+	}()
 
 	var counterOrders []models.Order
-	var err error
 
 	if incoming.Side == "buy" {
 		counterOrders, err = repo.FetchOpenSellOrders(ctx, tx, incoming.Symbol)
 	} else if incoming.Side == "sell" {
 		counterOrders, err = repo.FetchOpenBuyOrders(ctx, tx, incoming.Symbol)
 	} else {
-		return nil, nil, errors.New("invalid order side")
+		return nil, nil, "", apperr.InvalidArgument(apperr.CodeInvalidOrderSide, "invalid order side")
 	}
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, "", err
 	}
 
-	var trades []models.Trade
-	var updatedOrders []models.Order
+	protection := collar.protection(incoming.Side, counterOrders)
 	remaining := incoming.RemainingQty
 
-	for i := 0; i < len(counterOrders) && remaining > 0; i++ {
+	for i := 0; i < len(counterOrders) && remaining.IsPositive(); i++ {
 		resting := &counterOrders[i]
 
 		// Match rules
@@ -53,12 +72,22 @@ func (e *MatchingEngine) Match(
 			(incoming.Side == "buy" && incoming.Price >= resting.Price) ||
 			(incoming.Side == "sell" && incoming.Price <= resting.Price) {
 
-			matchQty := min(remaining, resting.RemainingQty)
+			// Counter orders come best first: the rest are beyond it too
+			if beyond(incoming.Side, resting.Price, collar.Band) {
+				stop = models.ReasonPriceBand
+				break
+			}
+			if beyond(incoming.Side, resting.Price, protection) {
+				stop = models.ReasonSlippage
+				break
+			}
+
+			matchQty := decimal.Min(remaining, resting.RemainingQty)
 			tradePrice := resting.Price
-			remaining -= matchQty
-			resting.RemainingQty -= matchQty
+			remaining = remaining.Sub(matchQty)
+			resting.RemainingQty = resting.RemainingQty.Sub(matchQty)
 
-			if resting.RemainingQty == 0 {
+			if resting.RemainingQty.IsZero() {
 				resting.Status = "filled"
 			} else {
 				resting.Status = "partial"
@@ -78,9 +107,11 @@ func (e *MatchingEngine) Match(
 	// Update incoming order
 	incoming.RemainingQty = remaining
 	switch {
-	case remaining == 0:
+	case remaining.IsZero():
 		incoming.Status = "filled" // Order is completely filled
-	case remaining < incoming.Quantity:
+	case stop != "" && incoming.Type == "market":
+		incoming.Status = "canceled" // Market order remainder cannot trade within the collar
+	case remaining.LessThan(incoming.Quantity):
 		incoming.Status = "partial" // Order is partially filled
 	case incoming.Type == "market":
 		incoming.Status = "canceled" // Market order that couldn't be filled should be canceled
@@ -88,14 +119,37 @@ func (e *MatchingEngine) Match(
 		incoming.Status = "open" // Limit order that hasn't matched yet remains open
 	}
 
-	return trades, updatedOrders, nil
+	return trades, updatedOrders, stop, nil
 }
 
-func min(a, b int) int {
-	if a < b {
-		return a
+// protection is the worst price the protection of an order on side lets it
+// trade at against counterOrders, best first: Price, tightened by the
+// slippage from the best of them. It is 0 for none.
+func (c Collar) protection(side string, counterOrders []models.Order) float64 {
+	limit := c.Price
+	if c.SlippagePct == 0 || len(counterOrders) == 0 {
+		return limit
+	}
+	best := counterOrders[0].Price
+	if side == "buy" {
+		if p := best * (1 + c.SlippagePct/100); limit == 0 || p < limit {
+			limit = p
+		}
+		return limit
+	}
+	return max(limit, best*(1-c.SlippagePct/100))
+}
+
+// beyond reports whether price is worse than limit for an order on side;
+// a zero limit is no limit.
+func beyond(side string, price, limit float64) bool {
+	if limit == 0 {
+		return false
+	}
+	if side == "buy" {
+		return price > limit
 	}
-	return b
+	return price < limit
 }
 
 func ifBuy(a, b *models.Order) int64 {
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/shopspring/decimal"
+)
+
+// recordEvent writes one transition of order, whose fields already hold the
+// new state, in the caller's transaction.
+func (s *OrderService) recordEvent(
+	ctx context.Context,
+	tx repository.Tx,
+	order *models.Order,
+	eventType, prevStatus string,
+	prevRemaining decimal.Decimal,
+	reason string,
+	tradeID *int64,
+) error {
+	return s.EventRepo.CreateEvent(ctx, tx, &models.OrderEvent{
+		OrderID:          order.ID,
+		Type:             eventType,
+		PrevStatus:       prevStatus,
+		Status:           order.Status,
+		PrevRemainingQty: prevRemaining,
+		RemainingQty:     order.RemainingQty,
+		Reason:           reason,
+		TradeID:          tradeID,
+		Actor:            ActorFromContext(ctx),
+		CreatedAt:        time.Now(),
+	})
+}
+
+// recordMatchEvents writes a fill event for both sides of every trade, in
+// execution order, then a cancel event for cancelReason if an unfilled
+// market order remainder was dropped. Match returns updated counter orders
+// aligned with trades.
+func (s *OrderService) recordMatchEvents(
+	ctx context.Context,
+	tx repository.Tx,
+	incoming *models.Order,
+	trades []models.Trade,
+	counterOrders []models.Order,
+	cancelReason string,
+) error {
+	remaining := incoming.RemainingQty
+	for _, t := range trades {
+		remaining = remaining.Add(t.Quantity)
+	}
+	for i := range trades {
+		trade := &trades[i]
+
+		// The incoming order as it stood after this fill
+		before := remaining
+		remaining = remaining.Sub(trade.Quantity)
+		step := *incoming
+		step.RemainingQty = remaining
+		step.Status = fillStatus(remaining)
+		if err := s.recordEvent(ctx, tx, &step, models.OrderEventFill, statusBefore(incoming.Quantity, before), before, models.ReasonMatched, &trade.ID); err != nil {
+			return err
+		}
+
+		counter := &counterOrders[i]
+		counterBefore := counter.RemainingQty.Add(trade.Quantity)
+		if err := s.recordEvent(ctx, tx, counter, models.OrderEventFill, statusBefore(counter.Quantity, counterBefore), counterBefore, models.ReasonMatched, &trade.ID); err != nil {
+			return err
+		}
+	}
+
+	if incoming.Status == "canceled" {
+		return s.recordEvent(ctx, tx, incoming, models.OrderEventCanceled, statusBefore(incoming.Quantity, remaining), remaining, cancelReason, nil)
+	}
+	return nil
+}
+
+// statusBefore derives the status an order had from its remaining quantity.
+func statusBefore(quantity, remaining decimal.Decimal) string {
+	if remaining.Equal(quantity) {
+		return "open"
+	}
+	return "partial"
+}
+
+func fillStatus(remaining decimal.Decimal) string {
+	if remaining.IsZero() {
+		return "filled"
+	}
+	return "partial"
+}
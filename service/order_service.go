@@ -3,153 +3,496 @@ package service
 import (
 	"context"
 	"database/sql"
-	"errors"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strconv"
 	"time"
 
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+	"github.com/Puneet-Vishnoi/order-matching-engine/metrics"
 	"github.com/Puneet-Vishnoi/order-matching-engine/models"
 	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tracing"
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/Puneet-Vishnoi/order-matching-engine/service")
+
 type OrderService struct {
-	OrderRepo      *repository.OrderRepository
-	TradeRepo      *repository.TradeRepository
+	TxRunner       *repository.TxRunner
+	OrderRepo      repository.OrderRepository
+	TradeRepo      repository.TradeRepository
+	EventRepo      repository.OrderEventRepository
 	MatchingEngine *MatchingEngine
+	Metrics        *metrics.Metrics
+	Engine         config.EngineConfig               // per-symbol trading rules; the zero value imposes none
+	RateLimiter    *RateLimiter                      // order entry limits of authenticated accounts; nil disables
+	MarketData     MarketData                        // book and trade distribution; nil disables
+	Outbox         repository.OutboxRepository       // fill events for webhooks; nil disables
+	Executions     ExecutionListener                 // committed order changes, e.g. for FIX; nil disables
+	DeadMansSwitch *DeadMansSwitch                   // cancels the orders of accounts that stop re-arming it
+	Phases         repository.TradingPhaseRepository // per-symbol trading phases; nil trades every symbol continuously
+	// ServeBookFromCache reads GetOrderBook from MarketData, for instances
+	// that serve market data without loading the database
+	ServeBookFromCache bool
 }
 
-func NewOrderService(orderRepo *repository.OrderRepository, tradeRepo *repository.TradeRepository) *OrderService {
-	return &OrderService{
+func NewOrderService(
+	txManager repository.TxManager,
+	orderRepo repository.OrderRepository,
+	tradeRepo repository.TradeRepository,
+	eventRepo repository.OrderEventRepository,
+) *OrderService {
+	s := &OrderService{
+		TxRunner:       repository.NewTxRunner(txManager),
 		OrderRepo:      orderRepo,
 		TradeRepo:      tradeRepo,
+		EventRepo:      eventRepo,
 		MatchingEngine: NewMatchingEngine(),
+		Metrics:        metrics.New(),
 	}
+	s.DeadMansSwitch = NewDeadMansSwitch(s)
+	s.Metrics.RegisterTxRunner(s.TxRunner)
+	s.Metrics.RegisterBookDepth(s.bookDepth)
+	return s
 }
 
-func (s *OrderService) PlaceOrder(ctx context.Context, req *models.PlaceOrderRequest) (*models.PlaceOrderResponse, error) {
-	tx, err := s.OrderRepo.DBHelper.PostgresClient.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+// serializable is used by every write path; TxRunner retries the
+// serialization failures it produces under contention.
+var serializable = &sql.TxOptions{Isolation: sql.LevelSerializable}
+
+func (s *OrderService) PlaceOrder(ctx context.Context, req *models.PlaceOrderRequest) (resp *models.PlaceOrderResponse, err error) {
+	ctx, span := tracer.Start(ctx, "OrderService.PlaceOrder", trace.WithAttributes(
+		tracing.AttrSymbol.String(req.Symbol), tracing.AttrSide.String(req.Side), tracing.AttrType.String(req.Type)))
+	defer func() { tracing.End(span, err) }()
+
+	order, p, err := s.place(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, err
+	}
+	span.SetAttributes(tracing.AttrOrderID.Int64(order.ID), tracing.AttrOrderStatus.String(order.Status), tracing.AttrTrades.Int(len(p.trades)))
+
+	return placedResponse(order, p, "Order placed successfully"), nil
+}
+
+// placedResponse reports a placed order and what placing it did.
+func placedResponse(order *models.Order, p *placement, message string) *models.PlaceOrderResponse {
+	notional, executed := fillTotals(p.trades)
+	resp := &models.PlaceOrderResponse{
+		OrderID:           order.ID,
+		Status:            order.Status,
+		RemainingQuantity: order.RemainingQty,
+		ExecutedQuantity:  executed,
+		Message:           message,
 	}
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
+	if executed.IsPositive() {
+		resp.AveragePrice = notional.Div(executed).InexactFloat64()
+	}
+	if order.Status == "canceled" {
+		resp.CancelReason = p.cancelReason
+	}
+	if p.quote.IsPositive() {
+		resp.QuoteSpent = notional
+		resp.QuoteLeftover = p.quote.Sub(notional)
+	}
+	return resp
+}
+
+// fillTotals returns the notional and the quantity of trades.
+func fillTotals(trades []models.Trade) (notional, quantity decimal.Decimal) {
+	for _, t := range trades {
+		notional = notional.Add(decimal.NewFromFloat(t.Price).Mul(t.Quantity))
+		quantity = quantity.Add(t.Quantity)
+	}
+	return notional, quantity
+}
+
+// place checks, places and matches a new order in its own transaction, then
+// reports the outcome to the listeners. It returns the order as committed
+// and what placing it did.
+func (s *OrderService) place(ctx context.Context, req *models.PlaceOrderRequest) (*models.Order, *placement, error) {
+	if err := checkInstrumentRules(s.Engine.Symbol(req.Symbol), req); err != nil {
+		s.Metrics.Rejections.WithLabelValues(apperr.CodeOf(err)).Inc()
+		return nil, nil, err
+	}
+
+	accountID, hasAccount := AccountFromContext(ctx)
+	if s.RateLimiter != nil && hasAccount {
+		if err := s.RateLimiter.CheckOrder(ctx, accountID, req.Symbol); err != nil {
+			s.Metrics.Rejections.WithLabelValues(apperr.CodeOf(err)).Inc()
+			return nil, nil, err
 		}
-	}()
+	}
 
-	order := models.Order{
-		Symbol:       req.Symbol,
-		Side:         req.Side,
-		Type:         req.Type,
-		Price:        req.Price,
-		Quantity:     req.Quantity,
-		RemainingQty: req.Quantity,
-		Status:       "open",
-		CreatedAt:    time.Now(),
+	var order models.Order
+	var p *placement
+	var stages stageTimer // timings of the attempt that committed
+
+	start := time.Now()
+	err := s.TxRunner.Run(ctx, serializable, func(tx repository.Tx) error {
+		stages.start()
+		order = models.Order{
+			AccountID:    accountID,
+			Symbol:       req.Symbol,
+			Side:         req.Side,
+			Type:         req.Type,
+			Price:        req.Price,
+			Quantity:     req.Quantity,
+			RemainingQty: req.Quantity,
+			Status:       "open",
+			CreatedAt:    time.Now(),
+		}
+		var err error
+		opts := placeOptions{
+			protection: Collar{Price: req.ProtectionPrice, SlippagePct: req.MaxSlippagePct},
+			quote:      req.QuoteQuantity,
+		}
+		p, err = s.placeInTx(ctx, tx, &order, opts, models.ReasonNewOrder, &stages)
+		return err
+	})
+	if err != nil {
+		s.Metrics.Rejections.WithLabelValues(apperr.CodeOf(err)).Inc()
+		return nil, nil, err
 	}
-	// Step 1: Insert Order
-	orderID, err := s.OrderRepo.CreateOrder(ctx, tx, &order)
+	stages.lap(metrics.StageCommit)
+	stages.observe(s.Metrics.PlaceOrderStageSeconds)
+	s.Metrics.PlaceOrderStageSeconds.WithLabelValues(metrics.StageTotal).Observe(time.Since(start).Seconds())
+	s.recordPlacementMetrics(&order, p.trades)
+	if s.RateLimiter != nil {
+		s.RateLimiter.RecordFills(ctx, fillsByAccount(&order, p.counterOrders))
+	}
+	if s.MarketData != nil {
+		s.MarketData.TradesExecuted(order.Symbol, p.trades)
+		s.MarketData.BookChanged(order.Symbol)
+	}
+	if s.Executions != nil {
+		s.Executions.OrdersExecuted(ctx, matchExecutions(models.ExecNew, &order, p, 0))
+	}
+	if p.halted != nil {
+		s.tripped(p.halted)
+	}
+	return &order, p, nil
+}
+
+// placeOptions are terms of a new order that only matter while it is
+// placed, and are not stored with it.
+type placeOptions struct {
+	protection Collar          // of a market order
+	quote      decimal.Decimal // amount a market buy sized in the quote currency spends
+}
+
+// placement is what placing an order did besides the order itself: its
+// trades, the counter orders they filled, aligned with them, why an
+// unfilled market order remainder was canceled, the phase a circuit
+// breaker halted the symbol in, if one tripped, and the amount a market
+// buy sized in the quote currency had to spend.
+type placement struct {
+	trades        []models.Trade
+	counterOrders []models.Order
+	cancelReason  string
+	halted        *models.TradingPhase
+	quote         decimal.Decimal
+}
+
+// placeInTx inserts a new order the trading phase of its symbol accepts,
+// matches it within the symbol's price band and, for a market order, its
+// protection, then records the outcome: the trades, every affected order,
+// their history and, with Outbox set, the fill events. A market buy sized
+// in the quote currency gets the quantity it can afford first. reason is
+// recorded on the order's created event.
+func (s *OrderService) placeInTx(ctx context.Context, tx repository.Tx, order *models.Order, opts placeOptions, reason string, stages *stageTimer) (*placement, error) {
+	p := &placement{cancelReason: models.ReasonNoLiquidity, quote: opts.quote}
+
+	// Step 0: Check the symbol's trading phase takes the order, and find the
+	// band its trades must stay within
+	phase, err := s.phaseOf(ctx, tx, order.Symbol)
 	if err != nil {
 		return nil, err
 	}
-	order.ID = orderID
+	match, err := s.admit(phase, order)
+	if err != nil {
+		return nil, err
+	}
+	var band priceBand
+	var collar Collar
+	if match {
+		if band, err = s.priceBand(ctx, tx, phase); err != nil {
+			return nil, err
+		}
+		if s.Engine.BandPolicy == config.BandCap {
+			band.capPrice(order)
+		}
+		collar = opts.protection
+		collar.Band = band.collar(order.Side)
+		if order.Type == "market" && collar.SlippagePct == 0 {
+			collar.SlippagePct = s.Engine.Symbol(order.Symbol).MarketProtectionPct
+		}
+		if opts.quote.IsPositive() {
+			if err := s.sizeQuoteOrder(ctx, tx, order, opts.quote); err != nil {
+				return nil, err
+			}
+		}
+	}
 
-	// Step 2: Match Order (get counter-orders and execute trades)
-	trades, updatedOrders, err := s.MatchingEngine.Match(ctx, tx, &order, s.OrderRepo)
+	// Step 1: Insert Order
+	orderID, err := s.OrderRepo.CreateOrder(ctx, tx, order)
 	if err != nil {
 		return nil, err
 	}
+	order.ID = orderID
+	if err := s.recordEvent(ctx, tx, order, models.OrderEventCreated, "", decimal.Zero, reason, nil); err != nil {
+		return nil, err
+	}
+	stages.lap(metrics.StageDBInsert)
+
+	// Step 2: Match Order (get counter-orders and execute trades); outside
+	// continuous trading it rests unmatched
+	if match {
+		var stop string
+		p.trades, p.counterOrders, stop, err = s.MatchingEngine.Match(ctx, tx, order, s.OrderRepo, collar)
+		if err != nil {
+			return nil, err
+		}
+		if stop == models.ReasonPriceBand && s.Engine.BandPolicy != config.BandCap {
+			return nil, apperr.Rejected(apperr.ReasonPriceOutOfBand, "order would trade beyond the price band of %s%s", order.Symbol, band)
+		}
+		if stop != "" {
+			p.cancelReason = stop
+		}
+	}
+	stages.lap(metrics.StageMatch)
+
 	// Step 3: Save Trades
-	for _, trade := range trades {
-		if err := s.TradeRepo.CreateTrade(ctx, tx, &trade); err != nil {
+	for i := range p.trades {
+		if err := s.TradeRepo.CreateTrade(ctx, tx, &p.trades[i]); err != nil {
 			return nil, err
 		}
 	}
 
 	// Step 4: Update All Affected Orders
-	for _, u := range updatedOrders {
+	for _, u := range p.counterOrders {
 		if err := s.OrderRepo.UpdateOrder(ctx, tx, &u); err != nil {
 			return nil, err
 		}
 	}
 
 	// Step 5: Update This Order
-	if err := s.OrderRepo.UpdateOrder(ctx, tx, &order); err != nil {
+	if err := s.OrderRepo.UpdateOrder(ctx, tx, order); err != nil {
 		return nil, err
 	}
 
-	if err := tx.Commit(); err != nil {
+	// Step 6: Record the fills and final state in the order history
+	if err := s.recordMatchEvents(ctx, tx, order, p.trades, p.counterOrders, p.cancelReason); err != nil {
 		return nil, err
 	}
 
-	return &models.PlaceOrderResponse{
-		OrderID:           order.ID,
-		Status:            order.Status,
-		RemainingQuantity: order.RemainingQty,
-		Message:           "Order placed successfully",
-	}, nil
+	// Step 7: Queue the fills for webhooks, committed with the trades
+	if err := s.recordFillEvents(ctx, tx, order, p.trades, p.counterOrders); err != nil {
+		return nil, err
+	}
+
+	// Step 8: Halt the symbol if its prices moved too far too fast
+	if len(p.trades) > 0 {
+		if p.halted, err = s.checkCircuitBreaker(ctx, tx, phase); err != nil {
+			return nil, err
+		}
+	}
+	stages.lap(metrics.StageTradeInsert)
+	return p, nil
 }
 
-func (s *OrderService) CancelOrder(ctx context.Context, orderIDStr string) (*models.CancelOrderResponse, error) {
+func (s *OrderService) CancelOrder(ctx context.Context, orderIDStr string) (resp *models.CancelOrderResponse, err error) {
+	ctx, span := tracer.Start(ctx, "OrderService.CancelOrder", trace.WithAttributes(attribute.String("order.id_param", orderIDStr)))
+	defer func() { tracing.End(span, err) }()
+
 	orderID, err := strconv.ParseInt(orderIDStr, 10, 64)
 	if err != nil {
-		return nil, errors.New("invalid order ID")
+		return nil, apperr.InvalidArgument(apperr.CodeInvalidOrderID, "invalid order ID")
 	}
 
-	tx, err := s.OrderRepo.DBHelper.PostgresClient.BeginTx(ctx, nil)
+	canceled, err := s.cancel(ctx, orderID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, err
 	}
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
-		}
-	}()
+	span.SetAttributes(tracing.AttrOrderID.Int64(canceled.ID), tracing.AttrSymbol.String(canceled.Symbol), tracing.AttrSide.String(canceled.Side))
+
+	return &models.CancelOrderResponse{
+		Message: fmt.Sprintf("Order %d canceled", orderID),
+	}, nil
+}
 
-	order, err := s.OrderRepo.GetOrderByID(ctx, tx, orderID)
+// cancel cancels one of the caller's orders in its own transaction, then
+// reports it to the listeners.
+func (s *OrderService) cancel(ctx context.Context, orderID int64) (*models.Order, error) {
+	var canceled models.Order
+	err := s.TxRunner.Run(ctx, serializable, func(tx repository.Tx) error {
+		order, err := s.getOwnOrder(ctx, tx, orderID)
+		if err != nil {
+			return err
+		}
+		if err := s.cancelInTx(ctx, tx, order, models.ReasonUserRequest); err != nil {
+			return err
+		}
+		canceled = *order
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	s.canceled(ctx, []models.Order{canceled}, models.ReasonUserRequest)
+	return &canceled, nil
+}
+
+// canceled reports committed cancels to the listeners. Cancels by an
+// operator do not count against the accounts' order-to-trade ratios.
+func (s *OrderService) canceled(ctx context.Context, orders []models.Order, reason string) {
+	symbols := make(map[string]bool)
+	executions := make([]models.Execution, len(orders))
+	for i, order := range orders {
+		if s.RateLimiter != nil && reason != models.ReasonOperator {
+			s.RateLimiter.RecordCancel(ctx, order.AccountID)
+		}
+		symbols[order.Symbol] = true
+		executions[i] = models.Execution{Type: models.ExecCanceled, Order: order, Reason: reason}
+	}
+	if s.MarketData != nil {
+		for symbol := range symbols {
+			s.MarketData.BookChanged(symbol)
+		}
+	}
+	if s.Executions != nil && len(executions) > 0 {
+		s.Executions.OrdersExecuted(ctx, executions)
+	}
+}
 
+// cancelInTx cancels an open or partially filled order and records why.
+func (s *OrderService) cancelInTx(ctx context.Context, tx repository.Tx, order *models.Order, reason string) error {
 	if order.Status == "filled" || order.Status == "canceled" {
-		return nil, errors.New("order cannot be canceled")
+		return apperr.Conflict(apperr.CodeOrderNotCancelable, "order cannot be canceled")
 	}
 
+	prevStatus, prevRemaining := order.Status, order.RemainingQty
 	order.Status = "canceled"
-	order.RemainingQty = 0
+	order.RemainingQty = decimal.Zero
 
 	if err := s.OrderRepo.UpdateOrder(ctx, tx, order); err != nil {
-		return nil, err
+		return err
+	}
+	return s.recordEvent(ctx, tx, order, models.OrderEventCanceled, prevStatus, prevRemaining, reason, nil)
+}
+
+// ReplaceOrder changes the price and quantity of a resting limit order by
+// canceling it and placing a replacement, atomically. The replacement has a
+// new ID, joins the back of its price level and may match at once; it
+// carries over what the original had filled, so its quantity is the new
+// total and it remains open for the difference.
+func (s *OrderService) ReplaceOrder(ctx context.Context, orderIDStr string, req *models.ReplaceOrderRequest) (resp *models.PlaceOrderResponse, err error) {
+	ctx, span := tracer.Start(ctx, "OrderService.ReplaceOrder", trace.WithAttributes(attribute.String("order.id_param", orderIDStr)))
+	defer func() { tracing.End(span, err) }()
+
+	origID, err := strconv.ParseInt(orderIDStr, 10, 64)
+	if err != nil {
+		return nil, apperr.InvalidArgument(apperr.CodeInvalidOrderID, "invalid order ID")
 	}
 
-	if err := tx.Commit(); err != nil {
+	// The replacement is a new order to the limits; the symbol it is placed
+	// on never changes, so it is read ahead of the transaction
+	if accountID, ok := AccountFromContext(ctx); ok && s.RateLimiter != nil {
+		prev, err := s.getOwnOrder(ctx, nil, origID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.RateLimiter.CheckOrder(ctx, accountID, prev.Symbol); err != nil {
+			s.Metrics.Rejections.WithLabelValues(apperr.CodeOf(err)).Inc()
+			return nil, err
+		}
+	}
+
+	var orig, order models.Order
+	var p *placement
+	var stages stageTimer
+	err = s.TxRunner.Run(ctx, serializable, func(tx repository.Tx) error {
+		stages.start()
+		prev, err := s.getOwnOrder(ctx, tx, origID)
+		if err != nil {
+			return err
+		}
+		span.SetAttributes(tracing.AttrSymbol.String(prev.Symbol), tracing.AttrSide.String(prev.Side))
+		if prev.Type != "limit" {
+			return apperr.Conflict(apperr.CodeOrderNotCancelable, "only limit orders can be replaced")
+		}
+		filled := prev.Quantity.Sub(prev.RemainingQty)
+		if req.Quantity.LessThanOrEqual(filled) {
+			return apperr.InvalidArgument(apperr.CodeInvalidRequest, "quantity %s does not exceed the %s already filled", req.Quantity, filled)
+		}
+		if err := checkInstrumentRules(s.Engine.Symbol(prev.Symbol), &models.PlaceOrderRequest{
+			Symbol: prev.Symbol, Side: prev.Side, Type: prev.Type, Price: req.Price, Quantity: req.Quantity,
+		}); err != nil {
+			return err
+		}
+		if err := s.cancelInTx(ctx, tx, prev, models.ReasonReplaced); err != nil {
+			return err
+		}
+		orig = *prev
+
+		order = models.Order{
+			AccountID:    prev.AccountID,
+			Symbol:       prev.Symbol,
+			Side:         prev.Side,
+			Type:         prev.Type,
+			Price:        req.Price,
+			Quantity:     req.Quantity,
+			RemainingQty: req.Quantity.Sub(filled),
+			Status:       statusBefore(req.Quantity, req.Quantity.Sub(filled)),
+			CreatedAt:    time.Now(),
+		}
+		p, err = s.placeInTx(ctx, tx, &order, placeOptions{}, models.ReasonReplaced, &stages)
+		return err
+	})
+	if err != nil {
+		s.Metrics.Rejections.WithLabelValues(apperr.CodeOf(err)).Inc()
 		return nil, err
 	}
+	s.recordPlacementMetrics(&order, p.trades)
+	if s.RateLimiter != nil {
+		// CheckOrder counted the replacement towards the ratio
+		s.RateLimiter.RecordFills(ctx, fillsByAccount(&order, p.counterOrders))
+	}
+	if s.MarketData != nil {
+		s.MarketData.TradesExecuted(order.Symbol, p.trades)
+		s.MarketData.BookChanged(order.Symbol)
+	}
+	if s.Executions != nil {
+		s.Executions.OrdersExecuted(ctx, matchExecutions(models.ExecReplaced, &order, p, orig.ID))
+	}
+	if p.halted != nil {
+		s.tripped(p.halted)
+	}
+	span.SetAttributes(tracing.AttrOrderID.Int64(order.ID), tracing.AttrOrderStatus.String(order.Status), tracing.AttrTrades.Int(len(p.trades)))
 
-	return &models.CancelOrderResponse{
-		Message: fmt.Sprintf("Order %d canceled", orderID),
-	}, nil
+	return placedResponse(&order, p, fmt.Sprintf("Order %d replaced", orig.ID)), nil
 }
 
-func (s *OrderService) GetOrderStatus(ctx context.Context, orderID string) (*models.OrderStatusResponse, error) {
+func (s *OrderService) GetOrderStatus(ctx context.Context, orderID string) (resp *models.OrderStatusResponse, err error) {
+	ctx, span := tracer.Start(ctx, "OrderService.GetOrderStatus", trace.WithAttributes(attribute.String("order.id_param", orderID)))
+	defer func() { tracing.End(span, err) }()
+
 	id, err := strconv.ParseInt(orderID, 10, 64)
 	if err != nil {
-		return nil, errors.New("invalid order ID")
+		return nil, apperr.InvalidArgument(apperr.CodeInvalidOrderID, "invalid order ID")
 	}
 
-	order, err := s.OrderRepo.GetOrderByID(ctx, nil, id)
+	order, err := s.getOwnOrder(ctx, nil, id)
 	if err != nil {
 		return nil, err
 	}
 
-	executedQty := order.Quantity - order.RemainingQty
+	executedQty := order.Quantity.Sub(order.RemainingQty)
 
 	return &models.OrderStatusResponse{
 		OrderID:           order.ID,
@@ -159,51 +502,184 @@ func (s *OrderService) GetOrderStatus(ctx context.Context, orderID string) (*mod
 	}, nil
 }
 
-func (s *OrderService) ListTrades(ctx context.Context, symbol string) ([]models.Trade, error) {
-	if symbol == "" {
-		return nil, errors.New("symbol is required")
+// GetOrderEvents returns the recorded state transitions of an order.
+func (s *OrderService) GetOrderEvents(ctx context.Context, orderID string) (resp *models.OrderEventsResponse, err error) {
+	ctx, span := tracer.Start(ctx, "OrderService.GetOrderEvents", trace.WithAttributes(attribute.String("order.id_param", orderID)))
+	defer func() { tracing.End(span, err) }()
+
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return nil, apperr.InvalidArgument(apperr.CodeInvalidOrderID, "invalid order ID")
 	}
 
-	trades, err := s.TradeRepo.ListTradesBySymbol(ctx, symbol)
+	// Distinguishes an unknown order from one without history
+	if _, err := s.getOwnOrder(ctx, nil, id); err != nil {
+		return nil, err
+	}
+
+	events, err := s.EventRepo.ListEventsByOrder(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	return trades, nil
+	if events == nil {
+		events = []models.OrderEvent{}
+	}
+
+	return &models.OrderEventsResponse{OrderID: id, Events: events}, nil
+}
+
+// GetOrder returns one of the caller's orders in full.
+func (s *OrderService) GetOrder(ctx context.Context, orderID string) (_ *models.Order, err error) {
+	ctx, span := tracer.Start(ctx, "OrderService.GetOrder", trace.WithAttributes(attribute.String("order.id_param", orderID)))
+	defer func() { tracing.End(span, err) }()
+
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return nil, apperr.InvalidArgument(apperr.CodeInvalidOrderID, "invalid order ID")
+	}
+	return s.getOwnOrder(ctx, nil, id)
 }
 
-func (s *OrderService) GetOrderBook(ctx context.Context, symbol string) (*models.OrderBookResponse, error) {
-	tx, err := s.OrderRepo.DBHelper.PostgresClient.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+// Bounds of ListOrders' limit
+const (
+	DefaultOrderListLimit = 100
+	MaxOrderListLimit     = 1000
+)
+
+// ListOrders returns up to limit orders matching filter, newest first; a
+// limit of 0 means DefaultOrderListLimit. Authenticated callers only see
+// their own orders, whatever the filter's account.
+func (s *OrderService) ListOrders(ctx context.Context, filter models.OrderFilter, limit int) (_ []models.Order, err error) {
+	ctx, span := tracer.Start(ctx, "OrderService.ListOrders", trace.WithAttributes(tracing.AttrSymbol.String(filter.Symbol)))
+	defer func() { tracing.End(span, err) }()
+
+	if limit == 0 {
+		limit = DefaultOrderListLimit
+	}
+	if limit < 0 || limit > MaxOrderListLimit {
+		return nil, apperr.InvalidArgument(apperr.CodeInvalidRequest, "limit must be between 1 and %d", MaxOrderListLimit)
+	}
+	if accountID, ok := AccountFromContext(ctx); ok {
+		filter.AccountID = accountID
+	}
+
+	orders, err := s.OrderRepo.ListOrders(ctx, filter, limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, err
+	}
+	if orders == nil {
+		orders = []models.Order{}
 	}
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
+	return orders, nil
+}
+
+// recordFillEvents writes a trade.executed event per trade to the outbox;
+// counterOrders are aligned with trades.
+func (s *OrderService) recordFillEvents(ctx context.Context, tx repository.Tx, incoming *models.Order, trades []models.Trade, counterOrders []models.Order) error {
+	if s.Outbox == nil {
+		return nil
+	}
+	for i, trade := range trades {
+		buyer, seller := incoming, &counterOrders[i]
+		if incoming.Side == "sell" {
+			buyer, seller = seller, buyer
+		}
+		data, err := json.Marshal(models.TradeExecutedEvent{
+			Symbol:        incoming.Symbol,
+			Trade:         trade,
+			BuyAccountID:  buyer.AccountID,
+			SellAccountID: seller.AccountID,
+		})
+		if err != nil {
+			return err
 		}
-	}()
+		event := models.OutboxEvent{Type: models.EventTradeExecuted, Data: data, CreatedAt: trade.CreatedAt}
+		if err := s.Outbox.CreateOutboxEvent(ctx, tx, &event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fillsByAccount counts the fills of each account taking part in a match;
+// counterOrders are aligned with the trades of incoming.
+func fillsByAccount(incoming *models.Order, counterOrders []models.Order) map[int64]int64 {
+	fills := make(map[int64]int64)
+	for _, counter := range counterOrders {
+		fills[incoming.AccountID]++
+		fills[counter.AccountID]++
+	}
+	delete(fills, 0) // orders placed without authentication
+	return fills
+}
 
-	buyOrders, err := s.OrderRepo.FetchOpenBuyOrders(ctx, tx, symbol)
+// getOwnOrder fetches an order on behalf of the caller. Authenticated callers
+// only see their own orders; others' are reported as not found so their IDs
+// leak nothing.
+func (s *OrderService) getOwnOrder(ctx context.Context, tx repository.Tx, id int64) (*models.Order, error) {
+	order, err := s.OrderRepo.GetOrderByID(ctx, tx, id)
 	if err != nil {
 		return nil, err
 	}
+	if accountID, ok := AccountFromContext(ctx); ok && order.AccountID != accountID {
+		return nil, apperr.NotFound(apperr.CodeOrderNotFound, "order with ID %d not found", id)
+	}
+	return order, nil
+}
 
-	sellOrders, err := s.OrderRepo.FetchOpenSellOrders(ctx, tx, symbol)
+func (s *OrderService) ListTrades(ctx context.Context, symbol string) (_ []models.Trade, err error) {
+	ctx, span := tracer.Start(ctx, "OrderService.ListTrades", trace.WithAttributes(tracing.AttrSymbol.String(symbol)))
+	defer func() { tracing.End(span, err) }()
+
+	if symbol == "" {
+		return nil, apperr.InvalidArgument(apperr.CodeSymbolRequired, "symbol is required")
+	}
+
+	trades, err := s.TradeRepo.ListTradesBySymbol(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	return trades, nil
+}
+
+func (s *OrderService) GetOrderBook(ctx context.Context, symbol string) (_ *models.OrderBookResponse, err error) {
+	ctx, span := tracer.Start(ctx, "OrderService.GetOrderBook", trace.WithAttributes(tracing.AttrSymbol.String(symbol)))
+	defer func() { tracing.End(span, err) }()
+
+	if s.ServeBookFromCache && s.MarketData != nil {
+		return s.MarketData.Book(ctx, symbol)
+	}
+	return s.LoadOrderBook(ctx, symbol)
+}
+
+// LoadOrderBook aggregates the open orders of symbol in storage by price level.
+func (s *OrderService) LoadOrderBook(ctx context.Context, symbol string) (_ *models.OrderBookResponse, err error) {
+	ctx, span := tracer.Start(ctx, "OrderService.LoadOrderBook", trace.WithAttributes(tracing.AttrSymbol.String(symbol)))
+	defer func() { tracing.End(span, err) }()
+
+	var buyOrders, sellOrders []models.Order
+
+	err = s.TxRunner.Run(ctx, &sql.TxOptions{ReadOnly: true}, func(tx repository.Tx) error {
+		var err error
+		if buyOrders, err = s.OrderRepo.FetchOpenBuyOrders(ctx, tx, symbol); err != nil {
+			return err
+		}
+		sellOrders, err = s.OrderRepo.FetchOpenSellOrders(ctx, tx, symbol)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	// Group orders by price level
-	bidMap := make(map[float64]int)
+	bidMap := make(map[float64]decimal.Decimal)
 	for _, o := range buyOrders {
-		bidMap[o.Price] += o.RemainingQty
+		bidMap[o.Price] = bidMap[o.Price].Add(o.RemainingQty)
 	}
 
-	askMap := make(map[float64]int)
+	askMap := make(map[float64]decimal.Decimal)
 	for _, o := range sellOrders {
-		askMap[o.Price] += o.RemainingQty
+		askMap[o.Price] = askMap[o.Price].Add(o.RemainingQty)
 	}
 
 	// Convert to sorted slices
@@ -217,7 +693,7 @@ func (s *OrderService) GetOrderBook(ctx context.Context, symbol string) (*models
 	}, nil
 }
 
-func flattenAndSortOrderBook(book map[float64]int, desc bool) []models.OrderBookEntry {
+func flattenAndSortOrderBook(book map[float64]decimal.Decimal, desc bool) []models.OrderBookEntry {
 	var prices []float64
 	for price := range book {
 		prices = append(prices, price)
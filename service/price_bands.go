@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+)
+
+// CircuitBreakerActor is recorded as the actor of the halts circuit
+// breakers set.
+const CircuitBreakerActor = "circuit_breaker"
+
+// priceBand is the range the trades of a symbol must stay within; a zero
+// edge leaves that side open.
+type priceBand struct {
+	low, high float64
+}
+
+// priceBand returns the band of phase's symbol: the static band around its
+// reference price narrowed by the dynamic band around its last trade, the
+// edges rounded inwards to the tick size.
+func (s *OrderService) priceBand(ctx context.Context, tx repository.Tx, phase *models.TradingPhase) (priceBand, error) {
+	rules := s.Engine.Symbol(phase.Symbol)
+	var band priceBand
+	if rules.StaticBandPct > 0 {
+		reference := phase.ReferencePrice
+		if reference == 0 {
+			reference = rules.ReferencePrice
+		}
+		band.narrow(reference, rules.StaticBandPct)
+	}
+	if rules.DynamicBandPct > 0 {
+		stats, err := s.TradeRepo.GetPriceStats(ctx, tx, phase.Symbol, time.Now())
+		if err != nil {
+			return priceBand{}, err
+		}
+		band.narrow(stats.Last, rules.DynamicBandPct)
+	}
+	if band.low > 0 {
+		band.low = snap(band.low, rules.TickSize, true)
+	}
+	if band.high > 0 {
+		band.high = snap(band.high, rules.TickSize, false)
+	}
+	return band, nil
+}
+
+// narrow intersects b with the band pct percent either side of reference;
+// without a reference it leaves b as is.
+func (b *priceBand) narrow(reference, pct float64) {
+	if reference <= 0 {
+		return
+	}
+	low, high := reference*(1-pct/100), reference*(1+pct/100)
+	b.low = max(b.low, low)
+	if b.high == 0 || high < b.high {
+		b.high = high
+	}
+}
+
+// collar is the worst price an order on side may trade at, 0 for none.
+// Only the direction an order pushes the price in is guarded.
+func (b priceBand) collar(side string) float64 {
+	if side == "buy" {
+		return b.high
+	}
+	return b.low
+}
+
+// capPrice moves the limit price of order to the edge of the band if it
+// lies beyond it.
+func (b priceBand) capPrice(order *models.Order) {
+	if order.Type != "limit" {
+		return
+	}
+	switch {
+	case order.Side == "buy" && b.high > 0 && order.Price > b.high:
+		order.Price = b.high
+	case order.Side == "sell" && b.low > 0 && order.Price < b.low:
+		order.Price = b.low
+	}
+}
+
+func (b priceBand) String() string {
+	edge := func(p float64) string {
+		if p == 0 {
+			return "-"
+		}
+		return fmt.Sprint(p)
+	}
+	return fmt.Sprintf(" [%s, %s]", edge(b.low), edge(b.high))
+}
+
+// snap rounds price to a multiple of step, up or down. Prices are stored
+// with two decimals, the finest step there is.
+func snap(price, step float64, up bool) float64 {
+	step = max(step, 0.01)
+	n := price / step
+	if up {
+		n = math.Ceil(n - 1e-9)
+	} else {
+		n = math.Floor(n + 1e-9)
+	}
+	return math.Round(n*step*100) / 100
+}
+
+// checkCircuitBreaker halts phase's symbol if its trade prices ranged over
+// more than the configured percentage within the breaker's window, and
+// returns the halted phase; it returns nil if the breaker did not trip. The
+// window starts no earlier than the symbol last entered its phase, so that
+// the trades before a halt cannot halt it again once resumed.
+func (s *OrderService) checkCircuitBreaker(ctx context.Context, tx repository.Tx, phase *models.TradingPhase) (*models.TradingPhase, error) {
+	rules := s.Engine.Symbol(phase.Symbol)
+	if rules.CircuitBreakerPct <= 0 || s.Phases == nil {
+		return nil, nil
+	}
+	since := time.Now().Add(-rules.CircuitBreakerWindow)
+	if phase.UpdatedAt.After(since) {
+		since = phase.UpdatedAt
+	}
+	stats, err := s.TradeRepo.GetPriceStats(ctx, tx, phase.Symbol, since)
+	if err != nil || stats.Low <= 0 {
+		return nil, err
+	}
+	move := (stats.High - stats.Low) / stats.Low * 100
+	if move <= rules.CircuitBreakerPct {
+		return nil, nil
+	}
+
+	halted := *phase
+	halted.Phase = models.PhaseHalted
+	halted.Reason = fmt.Sprintf("circuit breaker: prices moved %.2f%% within %s", move, rules.CircuitBreakerWindow)
+	halted.UpdatedBy = CircuitBreakerActor
+	halted.UpdatedAt = time.Now()
+	if err := s.Phases.SaveTradingPhase(ctx, tx, &halted); err != nil {
+		return nil, err
+	}
+	return &halted, nil
+}
+
+// tripped reports a committed circuit breaker halt.
+func (s *OrderService) tripped(phase *models.TradingPhase) {
+	log.Printf("trading in %s halted: %s", phase.Symbol, phase.Reason)
+	s.Metrics.CircuitBreakerTrips.WithLabelValues(s.metricSymbol(phase.Symbol)).Inc()
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/shopspring/decimal"
+)
+
+// sizeQuoteOrder sets the quantity of order, a market buy spending quote:
+// what quote buys from the asks, best first, in whole lots, to the symbol's
+// quantity precision and within its quantity limits. The leftover is not
+// spent. Like any market order, it then matches within its collar, and the
+// part beyond it is canceled with the collar's reason.
+func (s *OrderService) sizeQuoteOrder(ctx context.Context, tx repository.Tx, order *models.Order, quote decimal.Decimal) error {
+	asks, err := s.OrderRepo.FetchOpenSellOrders(ctx, tx, order.Symbol)
+	if err != nil {
+		return err
+	}
+	rules := s.Engine.Symbol(order.Symbol)
+
+	left := quote
+	var quantity decimal.Decimal
+	for _, ask := range asks {
+		price := decimal.NewFromFloat(ask.Price)
+		qty := decimal.Min(ask.RemainingQty, left.Div(price).RoundFloor(rules.Precision()))
+		quantity = quantity.Add(qty)
+		left = left.Sub(qty.Mul(price))
+		if qty.LessThan(ask.RemainingQty) {
+			break // the next asks cost as much or more
+		}
+	}
+	if rules.MaxQuantity.IsPositive() {
+		quantity = decimal.Min(quantity, rules.MaxQuantity)
+	}
+	if rules.LotSize.IsPositive() {
+		quantity = quantity.Sub(quantity.Mod(rules.LotSize))
+	}
+
+	if quantity.IsZero() || quantity.LessThan(rules.MinQuantity) {
+		if len(asks) == 0 {
+			return apperr.Rejected(apperr.ReasonQuoteTooSmall, "%s has no asks to spend the quote quantity on", order.Symbol)
+		}
+		return apperr.Rejected(apperr.ReasonQuoteTooSmall, "the quote quantity does not buy the minimum quantity of %s at the current prices", order.Symbol)
+	}
+	order.Quantity, order.RemainingQty = quantity, quantity
+	return nil
+}
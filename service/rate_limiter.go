@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+	"github.com/Puneet-Vishnoi/order-matching-engine/metrics"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/ratelimit"
+)
+
+// Fields of an account's order-to-trade counters.
+const (
+	otrMessages = "messages"
+	otrFills    = "fills"
+)
+
+// RateLimiter enforces config.RateLimitConfig. When the store fails, the
+// error is logged and the request allowed: an unreachable Redis degrades
+// protection instead of halting trading.
+type RateLimiter struct {
+	Store   ratelimit.Store
+	Config  config.RateLimitConfig
+	Metrics *metrics.Metrics
+	Now     func() time.Time // for tests
+}
+
+func NewRateLimiter(store ratelimit.Store, cfg config.RateLimitConfig, m *metrics.Metrics) *RateLimiter {
+	return &RateLimiter{Store: store, Config: cfg, Metrics: m, Now: time.Now}
+}
+
+// AccountSubject is the rate limit subject of an account.
+func AccountSubject(accountID int64) string {
+	return "account:" + strconv.FormatInt(accountID, 10)
+}
+
+func (l *RateLimiter) limit(class string) ratelimit.Limit {
+	switch class {
+	case models.RateLimitRead:
+		return l.Config.Read
+	case models.RateLimitPlace:
+		return l.Config.Place
+	case models.RateLimitCancel:
+		return l.Config.Cancel
+	case models.RateLimitPlacePerSymbol:
+		return l.Config.PlacePerSymbol
+	}
+	return ratelimit.Limit{}
+}
+
+// Allow takes a token from subject's bucket for class, or returns a rate
+// limited error saying when to retry.
+func (l *RateLimiter) Allow(ctx context.Context, class, subject string) error {
+	return l.take(ctx, class, class+":"+subject)
+}
+
+func (l *RateLimiter) take(ctx context.Context, class, key string) error {
+	limit := l.limit(class)
+	if !limit.Enabled() {
+		return nil
+	}
+	res, err := l.Store.Take(ctx, "bucket:"+key, limit, l.Now())
+	if err != nil {
+		log.Printf("rate limiter: allowing %s request: %v", class, err)
+		return nil
+	}
+	if !res.Allowed {
+		l.Metrics.RateLimited.WithLabelValues(class).Inc()
+		return apperr.RateLimited(apperr.CodeRateLimited, res.RetryAfter,
+			"%s rate limit of %g per second (burst %d) exceeded", class, limit.Rate, limit.Burst)
+	}
+	return nil
+}
+
+// CheckOrder applies the limits that depend on the order itself: the
+// account's bucket for symbol and the order-to-trade ratio guard. The order
+// counts as a message towards the ratio even if the guard refuses it.
+func (l *RateLimiter) CheckOrder(ctx context.Context, accountID int64, symbol string) error {
+	if err := l.take(ctx, models.RateLimitPlacePerSymbol, models.RateLimitPlacePerSymbol+":"+AccountSubject(accountID)+":"+symbol); err != nil {
+		return err
+	}
+
+	otr := l.Config.OrderToTrade
+	if otr.MaxRatio <= 0 {
+		return nil
+	}
+	now := l.Now()
+	counts, windowEnd, err := l.count(ctx, accountID, otrMessages, 1, now)
+	if err != nil {
+		log.Printf("rate limiter: skipping order-to-trade check: %v", err)
+		return nil
+	}
+	messages, fills := counts[otrMessages], counts[otrFills]
+	if messages < int64(otr.MinMessages) || float64(messages) <= otr.MaxRatio*math.Max(float64(fills), 1) {
+		return nil
+	}
+	l.Metrics.RateLimited.WithLabelValues(models.RateLimitOrderToTrade).Inc()
+	return apperr.RateLimited(apperr.CodeOrderToTradeRatio, windowEnd.Sub(now),
+		"%d orders and cancels for %d fills exceeds the order-to-trade ratio of %g", messages, fills, otr.MaxRatio)
+}
+
+// RecordCancel counts a cancel towards the account's order-to-trade ratio.
+func (l *RateLimiter) RecordCancel(ctx context.Context, accountID int64) {
+	l.record(ctx, accountID, otrMessages, 1)
+}
+
+// RecordFills counts fills towards each account's order-to-trade ratio.
+func (l *RateLimiter) RecordFills(ctx context.Context, fills map[int64]int64) {
+	for accountID, n := range fills {
+		l.record(ctx, accountID, otrFills, n)
+	}
+}
+
+func (l *RateLimiter) record(ctx context.Context, accountID int64, field string, n int64) {
+	if l.Config.OrderToTrade.MaxRatio <= 0 || accountID == 0 {
+		return
+	}
+	if _, _, err := l.count(ctx, accountID, field, n, l.Now()); err != nil {
+		log.Printf("rate limiter: failed to count %s: %v", field, err)
+	}
+}
+
+// count adds n to field of the account's counters for the window holding
+// now, and returns the window's counters and end.
+func (l *RateLimiter) count(ctx context.Context, accountID int64, field string, n int64, now time.Time) (map[string]int64, time.Time, error) {
+	window := l.Config.OrderToTrade.Window
+	start := now.Truncate(window)
+	key := fmt.Sprintf("otr:%s:%d", AccountSubject(accountID), start.Unix())
+	counts, err := l.Store.Count(ctx, key, field, n, window)
+	return counts, start.Add(window), err
+}
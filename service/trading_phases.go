@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// GetTradingPhase returns the phase symbol trades in.
+func (s *OrderService) GetTradingPhase(ctx context.Context, symbol string) (_ *models.TradingPhase, err error) {
+	ctx, span := tracer.Start(ctx, "OrderService.GetTradingPhase", trace.WithAttributes(tracing.AttrSymbol.String(symbol)))
+	defer func() { tracing.End(span, err) }()
+
+	if symbol == "" {
+		return nil, apperr.InvalidArgument(apperr.CodeSymbolRequired, "symbol is required")
+	}
+	return s.phaseOf(ctx, nil, symbol)
+}
+
+// SetTradingPhase moves symbol to req's phase. Orders queued while a symbol
+// was not trading can leave its book crossed: moving it to continuous then
+// takes an auction, which req.Auction runs before the move, and is refused
+// without one. The reference price of the symbol's static band carries over
+// unless req sets one or the auction trades.
+func (s *OrderService) SetTradingPhase(ctx context.Context, symbol string, req *models.SetTradingPhaseRequest) (resp *models.TradingPhaseResponse, err error) {
+	ctx, span := tracer.Start(ctx, "OrderService.SetTradingPhase", trace.WithAttributes(
+		tracing.AttrSymbol.String(symbol), attribute.String("trading.phase", req.Phase)))
+	defer func() { tracing.End(span, err) }()
+
+	if symbol == "" {
+		return nil, apperr.InvalidArgument(apperr.CodeSymbolRequired, "symbol is required")
+	}
+	if req.Auction && req.Phase != models.PhaseContinuous {
+		return nil, apperr.InvalidArgument(apperr.CodeValidationFailed, "an auction only reopens a symbol to continuous trading")
+	}
+	if s.Phases == nil {
+		return nil, apperr.Internal(errors.New("trading phases are not stored"))
+	}
+
+	var phase models.TradingPhase
+	var a *auction
+	err = s.TxRunner.Run(ctx, serializable, func(tx repository.Tx) error {
+		a = nil
+		prev, err := s.phaseOf(ctx, tx, symbol)
+		if err != nil {
+			return err
+		}
+		if req.Phase == models.PhaseContinuous {
+			bids, err := s.OrderRepo.FetchOpenBuyOrders(ctx, tx, symbol)
+			if err != nil {
+				return err
+			}
+			asks, err := s.OrderRepo.FetchOpenSellOrders(ctx, tx, symbol)
+			if err != nil {
+				return err
+			}
+			switch {
+			case req.Auction:
+				if a, err = s.auctionInTx(ctx, tx, bids, asks); err != nil {
+					return err
+				}
+			case crosses(bids, asks):
+				return apperr.Conflict(apperr.CodeBookCrossed, "the book of %s is crossed; reopen it through an auction", symbol)
+			}
+		}
+
+		phase = models.TradingPhase{
+			Symbol:    symbol,
+			Phase:     req.Phase,
+			Reason:    req.Reason,
+			UpdatedBy: ActorFromContext(ctx),
+			UpdatedAt: time.Now(),
+		}
+		switch {
+		case a != nil && len(a.fills) > 0:
+			phase.ReferencePrice = a.price
+		case req.ReferencePrice > 0:
+			phase.ReferencePrice = req.ReferencePrice
+		default:
+			phase.ReferencePrice = prev.ReferencePrice
+		}
+		return s.Phases.SaveTradingPhase(ctx, tx, &phase)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp = &models.TradingPhaseResponse{TradingPhase: phase}
+	if a != nil {
+		s.auctioned(ctx, symbol, a)
+		resp.Auction = a.result()
+		span.SetAttributes(tracing.AttrTrades.Int(len(a.fills)))
+	}
+	return resp, nil
+}
+
+// phaseOf returns symbol's trading phase; symbols whose phase was never set
+// trade continuously.
+func (s *OrderService) phaseOf(ctx context.Context, tx repository.Tx, symbol string) (*models.TradingPhase, error) {
+	if s.Phases != nil {
+		phase, err := s.Phases.GetTradingPhase(ctx, tx, symbol)
+		if apperr.KindOf(err) != apperr.KindNotFound {
+			return phase, err
+		}
+	}
+	return &models.TradingPhase{Symbol: symbol, Phase: models.PhaseContinuous}, nil
+}
+
+// admit checks phase, the trading phase of order's symbol, accepts it, and
+// reports whether it may match. Outside continuous trading, limit orders the
+// phase accepts rest unmatched until the symbol reopens, and market orders,
+// which cannot rest, are rejected.
+func (s *OrderService) admit(phase *models.TradingPhase, order *models.Order) (match bool, err error) {
+	switch phase.Phase {
+	case models.PhaseContinuous:
+		return true, nil
+	case models.PhaseClosed:
+		return false, apperr.Rejected(apperr.ReasonMarketClosed, "%s is closed%s", order.Symbol, because(phase.Reason))
+	case models.PhaseHalted:
+		if s.Engine.HaltPolicy != config.HaltQueue {
+			return false, apperr.Rejected(apperr.ReasonTradingHalted, "trading in %s is halted%s", order.Symbol, because(phase.Reason))
+		}
+	}
+	if order.Type == "market" {
+		return false, apperr.Rejected(apperr.ReasonNotContinuous, "%s is not trading continuously; only limit orders are accepted", order.Symbol)
+	}
+	return false, nil
+}
+
+func because(reason string) string {
+	if reason == "" {
+		return ""
+	}
+	return ": " + reason
+}
+
+// crosses reports whether the best bid reaches the best ask.
+func crosses(bids, asks []models.Order) bool {
+	return len(bids) > 0 && len(asks) > 0 && bids[0].Price >= asks[0].Price
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Run dispatches outbox events and attempts due deliveries every
+// PollInterval until ctx is done. Any number of instances may run it: events
+// and deliveries are claimed with row locks and leases.
+func (s *WebhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Config.PollInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.DispatchOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("webhooks: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce creates deliveries for a batch of outbox events, then
+// attempts a batch of due deliveries.
+func (s *WebhookService) DispatchOnce(ctx context.Context) error {
+	if err := s.fanOut(ctx); err != nil {
+		return fmt.Errorf("dispatching outbox: %w", err)
+	}
+	if err := s.deliverDue(ctx); err != nil {
+		return fmt.Errorf("delivering: %w", err)
+	}
+	return nil
+}
+
+// fanOut creates a pending delivery of each undispatched event for every
+// active webhook, in the transaction that marks the events dispatched.
+func (s *WebhookService) fanOut(ctx context.Context) (err error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.fanOut")
+	defer func() { tracing.End(span, err) }()
+
+	return s.TxRunner.Run(ctx, serializable, func(tx repository.Tx) error {
+		events, err := s.Outbox.ListUndispatched(ctx, tx, s.Config.BatchSize)
+		if err != nil || len(events) == 0 {
+			return err
+		}
+		hooks, err := s.Webhooks.ListWebhooks(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		now := s.Now()
+		ids := make([]int64, 0, len(events))
+		for _, event := range events {
+			for _, hook := range hooks {
+				if !hook.Active {
+					continue
+				}
+				delivery := models.WebhookDelivery{
+					WebhookID:     hook.ID,
+					EventID:       event.ID,
+					EventType:     event.Type,
+					Status:        models.DeliveryPending,
+					NextAttemptAt: now,
+					CreatedAt:     now,
+					UpdatedAt:     now,
+				}
+				if err := s.Webhooks.CreateDelivery(ctx, tx, &delivery); err != nil {
+					return err
+				}
+			}
+			ids = append(ids, event.ID)
+		}
+		return s.Outbox.MarkDispatched(ctx, tx, ids, now)
+	})
+}
+
+// lease is how long a claimed delivery stays hidden from other instances: a
+// whole batch going through Concurrency workers, each attempt timing out,
+// plus one more attempt of slack. An instance that dies mid-batch leaves its
+// claims to be retried once it expires.
+func (s *WebhookService) lease() time.Duration {
+	rounds := (s.Config.BatchSize + s.Config.Concurrency - 1) / s.Config.Concurrency
+	return time.Duration(rounds+1) * s.Config.Timeout
+}
+
+// deliverDue claims due deliveries and attempts them, at most Concurrency
+// at a time.
+func (s *WebhookService) deliverDue(ctx context.Context) error {
+	now := s.Now()
+	claimed, err := s.Webhooks.ClaimDueDeliveries(ctx, nil, now, now.Add(s.lease()), s.Config.BatchSize)
+	if err != nil || len(claimed) == 0 {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.Config.Concurrency)
+	for i := range claimed {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(d *models.WebhookDelivery) {
+			defer func() { <-sem; wg.Done() }()
+			if err := s.attempt(ctx, d); err != nil && ctx.Err() == nil {
+				log.Printf("webhooks: delivery %d: %v", d.ID, err)
+			}
+		}(&claimed[i])
+	}
+	wg.Wait()
+	return nil
+}
+
+// attempt POSTs one delivery and records the outcome. The returned error is
+// about recording it; a failed POST is an outcome.
+func (s *WebhookService) attempt(ctx context.Context, d *models.WebhookDelivery) (err error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.attempt", trace.WithAttributes(
+		tracing.AttrWebhookID.Int64(d.WebhookID),
+		tracing.AttrDeliveryID.Int64(d.ID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	hook, err := s.Webhooks.GetWebhook(ctx, nil, d.WebhookID)
+	if err != nil {
+		return err
+	}
+	event, err := s.Outbox.GetOutboxEvent(ctx, nil, d.EventID)
+	if err != nil {
+		return err
+	}
+
+	postErr := s.post(ctx, hook, event)
+	if ctx.Err() != nil {
+		// Shutting down: leave it to be retried when the lease expires
+		return nil
+	}
+
+	now := s.Now()
+	d.Attempts++
+	d.UpdatedAt = now
+	result := "delivered"
+	switch {
+	case postErr == nil:
+		d.Status = models.DeliveryDelivered
+		d.LastError = ""
+		d.DeliveredAt = &now
+	case d.Attempts >= s.Config.MaxAttempts:
+		result = "dead"
+		d.Status = models.DeliveryDead
+		d.LastError = postErr.Error()
+	default:
+		result = "failed"
+		d.LastError = postErr.Error()
+		d.NextAttemptAt = now.Add(s.backoff(d.Attempts))
+	}
+	s.Metrics.WebhookDeliveries.WithLabelValues(result).Inc()
+	return s.Webhooks.UpdateDelivery(ctx, nil, d)
+}
+
+// backoff is the wait after the given number of failed attempts.
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	wait := s.Config.InitialBackoff
+	for i := 1; i < attempts && wait < s.Config.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > s.Config.MaxBackoff {
+		return s.Config.MaxBackoff
+	}
+	return wait
+}
+
+// post sends an event to a webhook. Any 2xx response is a success.
+func (s *WebhookService) post(ctx context.Context, hook *models.Webhook, event *models.OutboxEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	ts := strconv.FormatInt(s.Now().Unix(), 10)
+	signature := hex.EncodeToString(signWithHash(hook.SecretHash, webhookStringToSign(ts, body)))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookID, strconv.FormatInt(hook.ID, 10))
+	req.Header.Set(HeaderWebhookEvent, event.Type)
+	req.Header.Set(HeaderWebhookEventID, strconv.FormatInt(event.ID, 10))
+	req.Header.Set(HeaderWebhookTimestamp, ts)
+	req.Header.Set(HeaderWebhookSignature, signature)
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s", errorSnippet(resp.Status, snippet))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// errorSnippet trims a response body for LastError.
+func errorSnippet(status string, body []byte) string {
+	const max = 200
+	msg := strings.TrimSpace(string(body))
+	if len(msg) > max {
+		msg = msg[:max] + "..."
+	}
+	if msg == "" {
+		return status
+	}
+	return status + ": " + msg
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+	"github.com/Puneet-Vishnoi/order-matching-engine/metrics"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Headers of a webhook delivery
+const (
+	HeaderWebhookID        = "X-Webhook-Id"
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookEventID   = "X-Webhook-Event-Id" // receivers dedupe on it
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+// replayBatch bounds how many dead deliveries one ReplayDead call resets.
+const replayBatch = 1000
+
+// WebhookService manages webhooks and delivers outbox events to them.
+//
+// Every event is delivered at least once to each webhook that was active
+// when it was dispatched, as a POST of the models.OutboxEvent JSON. Like
+// API requests, deliveries are signed with HMAC-SHA256 keyed with the
+// SHA-256 digest of the webhook's secret, here over
+//
+//	timestamp + "\n" + hex(SHA-256(body))
+//
+// where timestamp is Unix seconds, sent in X-Webhook-Timestamp.
+type WebhookService struct {
+	TxRunner *repository.TxRunner
+	Webhooks repository.WebhookRepository
+	Outbox   repository.OutboxRepository
+	Config   config.WebhookConfig
+	Client   *http.Client
+	Metrics  *metrics.Metrics
+	Now      func() time.Time // for tests
+}
+
+func NewWebhookService(txRunner *repository.TxRunner, webhooks repository.WebhookRepository, outbox repository.OutboxRepository, cfg config.WebhookConfig, m *metrics.Metrics) *WebhookService {
+	return &WebhookService{
+		TxRunner: txRunner,
+		Webhooks: webhooks,
+		Outbox:   outbox,
+		Config:   cfg,
+		Client:   &http.Client{Timeout: cfg.Timeout},
+		Metrics:  m,
+		Now:      time.Now,
+	}
+}
+
+// SignWebhook computes the X-Webhook-Signature of a delivery, as receivers
+// should to verify it.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	return hex.EncodeToString(signWithHash(hashSecret(secret), webhookStringToSign(timestamp, body)))
+}
+
+func webhookStringToSign(timestamp string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return timestamp + "\n" + hex.EncodeToString(sum[:])
+}
+
+// Register adds a webhook. The returned secret is not stored and cannot be
+// retrieved again.
+func (s *WebhookService) Register(ctx context.Context, url string) (_ *models.CreatedWebhookResponse, err error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.Register")
+	defer func() { tracing.End(span, err) }()
+
+	secret, err := randomToken("", 32)
+	if err != nil {
+		return nil, apperr.Internal(err)
+	}
+
+	hook := models.Webhook{
+		URL:        url,
+		SecretHash: hashSecret(secret),
+		Active:     true,
+		CreatedAt:  s.Now(),
+	}
+	err = s.TxRunner.Run(ctx, serializable, func(tx repository.Tx) error {
+		hook.ID = 0
+		return s.Webhooks.CreateWebhook(ctx, tx, &hook)
+	})
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(tracing.AttrWebhookID.Int64(hook.ID))
+	return &models.CreatedWebhookResponse{Webhook: hook, Secret: secret}, nil
+}
+
+// List returns every webhook, deactivated ones included.
+func (s *WebhookService) List(ctx context.Context) (_ *models.WebhookListResponse, err error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.List")
+	defer func() { tracing.End(span, err) }()
+
+	hooks, err := s.Webhooks.ListWebhooks(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if hooks == nil {
+		hooks = []models.Webhook{}
+	}
+	return &models.WebhookListResponse{Webhooks: hooks}, nil
+}
+
+// Deactivate stops deliveries to a webhook. Its pending deliveries stay
+// pending and are not attempted; it gets no deliveries for later events.
+func (s *WebhookService) Deactivate(ctx context.Context, id int64) (_ *models.Webhook, err error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.Deactivate", trace.WithAttributes(tracing.AttrWebhookID.Int64(id)))
+	defer func() { tracing.End(span, err) }()
+
+	var hook *models.Webhook
+	err = s.TxRunner.Run(ctx, serializable, func(tx repository.Tx) error {
+		var err error
+		if hook, err = s.Webhooks.GetWebhook(ctx, tx, id); err != nil {
+			return err
+		}
+		hook.Active = false
+		return s.Webhooks.UpdateWebhook(ctx, tx, hook)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+// ListDeliveries returns up to limit deliveries matching filter, newest first.
+func (s *WebhookService) ListDeliveries(ctx context.Context, filter models.DeliveryFilter, limit int) (_ *models.DeliveryListResponse, err error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.ListDeliveries")
+	defer func() { tracing.End(span, err) }()
+
+	deliveries, err := s.Webhooks.ListDeliveries(ctx, filter, limit)
+	if err != nil {
+		return nil, err
+	}
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+	return &models.DeliveryListResponse{Deliveries: deliveries}, nil
+}
+
+// Replay schedules a delivery again, whatever its status, with a fresh set
+// of attempts.
+func (s *WebhookService) Replay(ctx context.Context, id int64) (_ *models.WebhookDelivery, err error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.Replay", trace.WithAttributes(tracing.AttrDeliveryID.Int64(id)))
+	defer func() { tracing.End(span, err) }()
+
+	var delivery *models.WebhookDelivery
+	err = s.TxRunner.Run(ctx, serializable, func(tx repository.Tx) error {
+		var err error
+		if delivery, err = s.Webhooks.GetDelivery(ctx, tx, id); err != nil {
+			return err
+		}
+		s.reset(delivery)
+		return s.Webhooks.UpdateDelivery(ctx, tx, delivery)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// ReplayDead schedules the dead deliveries of a webhook again, up to
+// replayBatch per call.
+func (s *WebhookService) ReplayDead(ctx context.Context, webhookID int64) (_ *models.ReplayResponse, err error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.ReplayDead", trace.WithAttributes(tracing.AttrWebhookID.Int64(webhookID)))
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := s.Webhooks.GetWebhook(ctx, nil, webhookID); err != nil {
+		return nil, err
+	}
+	dead, err := s.Webhooks.ListDeliveries(ctx, models.DeliveryFilter{WebhookID: webhookID, Status: models.DeliveryDead}, replayBatch)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.TxRunner.Run(ctx, serializable, func(tx repository.Tx) error {
+		for i := range dead {
+			d := dead[i]
+			s.reset(&d)
+			if err := s.Webhooks.UpdateDelivery(ctx, tx, &d); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.ReplayResponse{Replayed: len(dead)}, nil
+}
+
+func (s *WebhookService) reset(d *models.WebhookDelivery) {
+	now := s.Now()
+	d.Status = models.DeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = now
+	d.UpdatedAt = now
+	d.DeliveredAt = nil
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/Puneet-Vishnoi/order-matching-engine/service"
 	"github.com/Puneet-Vishnoi/order-matching-engine/tests/mockdb"
 	"github.com/go-playground/assert"
+	"github.com/shopspring/decimal"
 )
 
 const baseURL = "http://app:8080/api"
@@ -92,13 +93,13 @@ func testPlaceOrderIntegration(t *testing.T) {
 				Side:     "buy",
 				Type:     "limit",
 				Price:    150.0,
-				Quantity: 100,
+				Quantity: decimal.NewFromInt(100),
 			},
 			expectedStatus: http.StatusOK,
 			expectedMsg:    "Order placed successfully",
 			validateFunc: func(t *testing.T, response *models.PlaceOrderResponse) {
 				assert.Equal(t, response.Status, "open")
-				assert.Equal(t, response.RemainingQuantity, 100)
+				assert.Equal(t, response.RemainingQuantity.String(), "100")
 			},
 		},
 		{
@@ -107,7 +108,7 @@ func testPlaceOrderIntegration(t *testing.T) {
 				Symbol:   "GOOGL",
 				Side:     "sell",
 				Type:     "market",
-				Quantity: 50,
+				Quantity: decimal.NewFromInt(50),
 			},
 			expectedStatus: http.StatusOK,
 			expectedMsg:    "Order placed successfully",
@@ -121,7 +122,7 @@ func testPlaceOrderIntegration(t *testing.T) {
 				Side:     "buy",
 				Type:     "limit",
 				Price:    150.0,
-				Quantity: 100,
+				Quantity: decimal.NewFromInt(100),
 			},
 			expectedStatus: http.StatusBadRequest,
 		},
@@ -132,7 +133,7 @@ func testPlaceOrderIntegration(t *testing.T) {
 				Side:     "buy",
 				Type:     "limit",
 				Price:    200.0,
-				Quantity: -10,
+				Quantity: decimal.NewFromInt(-10),
 			},
 			expectedStatus: http.StatusBadRequest,
 		},
@@ -143,7 +144,7 @@ func testPlaceOrderIntegration(t *testing.T) {
 				Side:     "invalid",
 				Type:     "limit",
 				Price:    100.0,
-				Quantity: 50,
+				Quantity: decimal.NewFromInt(50),
 			},
 			expectedStatus: http.StatusBadRequest,
 		},
@@ -195,7 +196,7 @@ func testOrderMatchingIntegration(t *testing.T) {
 					Side:     "sell",
 					Type:     "limit",
 					Price:    100.0,
-					Quantity: 50,
+					Quantity: decimal.NewFromInt(50),
 				},
 			},
 			incomingOrder: models.PlaceOrderRequest{
@@ -203,7 +204,7 @@ func testOrderMatchingIntegration(t *testing.T) {
 				Side:     "buy",
 				Type:     "limit",
 				Price:    100.0,
-				Quantity: 50,
+				Quantity: decimal.NewFromInt(50),
 			},
 			expectedTrades:    1,
 			expectedStatus:    "filled",
@@ -217,7 +218,7 @@ func testOrderMatchingIntegration(t *testing.T) {
 					Side:     "sell",
 					Type:     "limit",
 					Price:    200.0,
-					Quantity: 30,
+					Quantity: decimal.NewFromInt(30),
 				},
 			},
 			incomingOrder: models.PlaceOrderRequest{
@@ -225,7 +226,7 @@ func testOrderMatchingIntegration(t *testing.T) {
 				Side:     "buy",
 				Type:     "limit",
 				Price:    200.0,
-				Quantity: 50,
+				Quantity: decimal.NewFromInt(50),
 			},
 			expectedTrades:    1,
 			expectedStatus:    "partial",
@@ -239,14 +240,14 @@ func testOrderMatchingIntegration(t *testing.T) {
 					Side:     "sell",
 					Type:     "limit",
 					Price:    300.0,
-					Quantity: 25,
+					Quantity: decimal.NewFromInt(25),
 				},
 				{
 					Symbol:   "TSLA",
 					Side:     "sell",
 					Type:     "limit",
 					Price:    301.0,
-					Quantity: 30,
+					Quantity: decimal.NewFromInt(30),
 				},
 			},
 			incomingOrder: models.PlaceOrderRequest{
@@ -254,7 +255,7 @@ func testOrderMatchingIntegration(t *testing.T) {
 				Side:     "buy",
 				Type:     "limit",
 				Price:    301.0,
-				Quantity: 40,
+				Quantity: decimal.NewFromInt(40),
 			},
 			expectedTrades:    2,
 			expectedStatus:    "filled",
@@ -268,7 +269,7 @@ func testOrderMatchingIntegration(t *testing.T) {
 					Side:     "sell",
 					Type:     "limit",
 					Price:    400.0,
-					Quantity: 50,
+					Quantity: decimal.NewFromInt(50),
 				},
 			},
 			incomingOrder: models.PlaceOrderRequest{
@@ -276,7 +277,7 @@ func testOrderMatchingIntegration(t *testing.T) {
 				Side:     "buy",
 				Type:     "limit",
 				Price:    390.0,
-				Quantity: 25,
+				Quantity: decimal.NewFromInt(25),
 			},
 			expectedTrades:    0,
 			expectedStatus:    "open",
@@ -290,21 +291,21 @@ func testOrderMatchingIntegration(t *testing.T) {
 					Side:     "sell",
 					Type:     "limit",
 					Price:    80.0,
-					Quantity: 20,
+					Quantity: decimal.NewFromInt(20),
 				},
 				{
 					Symbol:   "AMD",
 					Side:     "sell",
 					Type:     "limit",
 					Price:    82.0,
-					Quantity: 15,
+					Quantity: decimal.NewFromInt(15),
 				},
 			},
 			incomingOrder: models.PlaceOrderRequest{
 				Symbol:   "AMD",
 				Side:     "buy",
 				Type:     "market",
-				Quantity: 30,
+				Quantity: decimal.NewFromInt(30),
 			},
 			expectedTrades:    2,
 			expectedStatus:    "filled",
@@ -344,7 +345,7 @@ func testOrderMatchingIntegration(t *testing.T) {
 
 			// Validate the response
 			assert.Equal(t, result.Status, tc.expectedStatus)
-			assert.Equal(t, result.RemainingQuantity, tc.expectedRemaining)
+			assert.Equal(t, result.RemainingQuantity.String(), strconv.Itoa(tc.expectedRemaining))
 
 			// Check trades were created
 			if tc.expectedTrades > 0 {
@@ -377,7 +378,7 @@ func testCancelOrderIntegration(t *testing.T) {
 				Side:     "buy",
 				Type:     "limit",
 				Price:    150.0,
-				Quantity: 100,
+				Quantity: decimal.NewFromInt(100),
 			},
 			cancelAfter:    time.Millisecond * 100,
 			expectedStatus: http.StatusOK,
@@ -389,7 +390,7 @@ func testCancelOrderIntegration(t *testing.T) {
 				Side:     "sell",
 				Type:     "limit",
 				Price:    200.0,
-				Quantity: 50,
+				Quantity: decimal.NewFromInt(50),
 			},
 			expectedStatus: http.StatusNotFound,
 		},
@@ -449,7 +450,7 @@ func testOrderStatusIntegration(t *testing.T) {
 		Side:     "buy",
 		Type:     "limit",
 		Price:    150.0,
-		Quantity: 100,
+		Quantity: decimal.NewFromInt(100),
 	}
 
 	orderJSON, _ := json.Marshal(order)
@@ -475,8 +476,8 @@ func testOrderStatusIntegration(t *testing.T) {
 			validateFunc: func(t *testing.T, response *models.OrderStatusResponse) {
 				assert.Equal(t, response.OrderID, orderResult.OrderID)
 				assert.Equal(t, response.Status, "open")
-				assert.Equal(t, response.RemainingQuantity, 100)
-				assert.Equal(t, response.ExecutedQuantity, 0)
+				assert.Equal(t, response.RemainingQuantity.String(), "100")
+				assert.Equal(t, response.ExecutedQuantity.String(), "0")
 			},
 		},
 		{
@@ -515,10 +516,10 @@ func testOrderBookIntegration(t *testing.T) {
 
 	// Setup orders to create order book
 	setupOrders := []models.PlaceOrderRequest{
-		{Symbol: symbol, Side: "buy", Type: "limit", Price: 149.0, Quantity: 100},
-		{Symbol: symbol, Side: "buy", Type: "limit", Price: 148.5, Quantity: 50},
-		{Symbol: symbol, Side: "sell", Type: "limit", Price: 151.0, Quantity: 75},
-		{Symbol: symbol, Side: "sell", Type: "limit", Price: 152.0, Quantity: 25},
+		{Symbol: symbol, Side: "buy", Type: "limit", Price: 149.0, Quantity: decimal.NewFromInt(100)},
+		{Symbol: symbol, Side: "buy", Type: "limit", Price: 148.5, Quantity: decimal.NewFromInt(50)},
+		{Symbol: symbol, Side: "sell", Type: "limit", Price: 151.0, Quantity: decimal.NewFromInt(75)},
+		{Symbol: symbol, Side: "sell", Type: "limit", Price: 152.0, Quantity: decimal.NewFromInt(25)},
 	}
 
 	for _, order := range setupOrders {
@@ -586,10 +587,10 @@ func testTradeHistoryIntegration(t *testing.T) {
 
 	// Create matching orders to generate trades
 	sellOrder := models.PlaceOrderRequest{
-		Symbol: symbol, Side: "sell", Type: "limit", Price: 100.0, Quantity: 50,
+		Symbol: symbol, Side: "sell", Type: "limit", Price: 100.0, Quantity: decimal.NewFromInt(50),
 	}
 	buyOrder := models.PlaceOrderRequest{
-		Symbol: symbol, Side: "buy", Type: "limit", Price: 100.0, Quantity: 50,
+		Symbol: symbol, Side: "buy", Type: "limit", Price: 100.0, Quantity: decimal.NewFromInt(50),
 	}
 
 	// Place sell order first
@@ -615,7 +616,7 @@ func testTradeHistoryIntegration(t *testing.T) {
 			validateFunc: func(t *testing.T, trades []models.Trade) {
 				assert.Equal(t, len(trades), 1)
 				assert.Equal(t, trades[0].Price, 100.0)
-				assert.Equal(t, trades[0].Quantity, 50)
+				assert.Equal(t, trades[0].Quantity.String(), "50")
 			},
 		},
 		{
@@ -659,8 +660,8 @@ func testComplexMatchingScenarios(t *testing.T) {
 
 				// Place orders with same price but different times
 				orders := []models.PlaceOrderRequest{
-					{Symbol: symbol, Side: "sell", Type: "limit", Price: 100.0, Quantity: 30},
-					{Symbol: symbol, Side: "sell", Type: "limit", Price: 100.0, Quantity: 20}, // Should match second due to time priority
+					{Symbol: symbol, Side: "sell", Type: "limit", Price: 100.0, Quantity: decimal.NewFromInt(30)},
+					{Symbol: symbol, Side: "sell", Type: "limit", Price: 100.0, Quantity: decimal.NewFromInt(20)}, // Should match second due to time priority
 				}
 
 				for _, order := range orders {
@@ -672,7 +673,7 @@ func testComplexMatchingScenarios(t *testing.T) {
 
 				// Place buy order that should match first sell order
 				buyOrder := models.PlaceOrderRequest{
-					Symbol: symbol, Side: "buy", Type: "limit", Price: 100.0, Quantity: 25,
+					Symbol: symbol, Side: "buy", Type: "limit", Price: 100.0, Quantity: decimal.NewFromInt(25),
 				}
 				buyJSON, _ := json.Marshal(buyOrder)
 				resp, _ := http.Post(fmt.Sprintf("%s/orders", baseURL), "application/json", bytes.NewBuffer(buyJSON))
@@ -682,7 +683,7 @@ func testComplexMatchingScenarios(t *testing.T) {
 				resp.Body.Close()
 
 				assert.Equal(t, result.Status, "filled")
-				assert.Equal(t, result.RemainingQuantity, 0)
+				assert.Equal(t, result.RemainingQuantity.String(), "0")
 			},
 		},
 		{
@@ -692,7 +693,7 @@ func testComplexMatchingScenarios(t *testing.T) {
 
 				// Place large sell order
 				sellOrder := models.PlaceOrderRequest{
-					Symbol: symbol, Side: "sell", Type: "limit", Price: 200.0, Quantity: 100,
+					Symbol: symbol, Side: "sell", Type: "limit", Price: 200.0, Quantity: decimal.NewFromInt(100),
 				}
 				sellJSON, _ := json.Marshal(sellOrder)
 				resp, _ := http.Post(fmt.Sprintf("%s/orders", baseURL), "application/json", bytes.NewBuffer(sellJSON))
@@ -702,7 +703,7 @@ func testComplexMatchingScenarios(t *testing.T) {
 
 				// Place smaller buy order
 				buyOrder := models.PlaceOrderRequest{
-					Symbol: symbol, Side: "buy", Type: "limit", Price: 200.0, Quantity: 30,
+					Symbol: symbol, Side: "buy", Type: "limit", Price: 200.0, Quantity: decimal.NewFromInt(30),
 				}
 				buyJSON, _ := json.Marshal(buyOrder)
 				resp, _ = http.Post(fmt.Sprintf("%s/orders", baseURL), "application/json", bytes.NewBuffer(buyJSON))
@@ -717,12 +718,12 @@ func testComplexMatchingScenarios(t *testing.T) {
 				statusResp.Body.Close()
 
 				assert.Equal(t, sellStatus.Status, "partial")
-				assert.Equal(t, sellStatus.ExecutedQuantity, 30)
-				assert.Equal(t, sellStatus.RemainingQuantity, 70)
+				assert.Equal(t, sellStatus.ExecutedQuantity.String(), "30")
+				assert.Equal(t, sellStatus.RemainingQuantity.String(), "70")
 
 				// Check buy order is filled
 				assert.Equal(t, buyResult.Status, "filled")
-				assert.Equal(t, buyResult.RemainingQuantity, 0)
+				assert.Equal(t, buyResult.RemainingQuantity.String(), "0")
 			},
 		},
 	}
@@ -1,17 +1,21 @@
 package mockdb
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
+	"time"
 
 	"github.com/joho/godotenv"
 
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
 	"github.com/Puneet-Vishnoi/order-matching-engine/db/postgres"
+	"github.com/Puneet-Vishnoi/order-matching-engine/db/postgres/migrate"
 	providers "github.com/Puneet-Vishnoi/order-matching-engine/db/postgres/providers"
 	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository/memory"
 	"github.com/Puneet-Vishnoi/order-matching-engine/service"
 
 	_ "github.com/lib/pq"
@@ -19,9 +23,19 @@ import (
 
 type TestDeps struct {
 	Service        *service.OrderService
-	OrderRepo      *repository.OrderRepository
-	TradeRepo      *repository.TradeRepository
-	PostgresClient *postgres.Db
+	OrderRepo      repository.OrderRepository
+	TradeRepo      repository.TradeRepository
+	EventRepo      repository.OrderEventRepository
+	AccountRepo    repository.AccountRepository
+	AuditRepo      repository.AuditRepository
+	OutboxRepo     repository.OutboxRepository
+	WebhookRepo    repository.WebhookRepository
+	FIXRepo        repository.FIXRepository
+	PhaseRepo      repository.TradingPhaseRepository
+	Auth           *service.AuthService
+	Audit          *service.AuditService
+	Webhooks       *service.WebhookService // Service.Outbox is left unset, as with features.webhooks off
+	PostgresClient *postgres.Db            // nil for the in-memory backend
 	Cleanup        func()
 }
 
@@ -48,21 +62,23 @@ func ConnectTestDB() *postgres.Db {
 	return &postgres.Db{PostgresClient: db}
 }
 
+// InitSchema resets the test database by rolling back every migration and
+// re-applying them, so each test run starts from an empty schema.
 func InitSchema(db *postgres.Db) error {
-
-	schemaPath := filepath.Join("../../", "db", "postgres", "schema.sql")
-	fmt.Println(schemaPath, "scpth")
-	content, err := os.ReadFile(schemaPath)
+	migrator, err := migrate.NewMigrator(db.PostgresClient)
 	if err != nil {
-		return fmt.Errorf("failed to read schema file: %w", err)
+		return err
 	}
 
-	_, err = db.PostgresClient.Exec(string(content))
-	if err != nil {
-		return fmt.Errorf("failed to execute schema: %w", err)
+	ctx := context.Background()
+	if _, err := migrator.Down(ctx, 0); err != nil {
+		return fmt.Errorf("failed to roll back migrations: %w", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
 	}
 
-	fmt.Println("Database schema initialized successfully from file.")
+	fmt.Println("Database schema initialized successfully from migrations.")
 	return nil
 }
 
@@ -85,19 +101,81 @@ func GetTestInstance() *TestDeps {
 	if err != nil {
 		log.Fatalf("failed to get dbHelper: %v", err)
 	}
-	orderRepo := repository.NewOrderRepository(dbHelper)
-	tradeRepo := repository.NewTradeRepository(dbHelper)
+	txManager := repository.NewPostgresTxManager(dbHelper)
+	orderRepo := repository.NewPostgresOrderRepository(dbHelper)
+	tradeRepo := repository.NewPostgresTradeRepository(dbHelper)
+	eventRepo := repository.NewPostgresOrderEventRepository(dbHelper)
+	accountRepo := repository.NewPostgresAccountRepository(dbHelper)
+	auditRepo := repository.NewPostgresAuditRepository(dbHelper)
+	outboxRepo := repository.NewPostgresOutboxRepository(dbHelper)
+	webhookRepo := repository.NewPostgresWebhookRepository(dbHelper)
+	fixRepo := repository.NewPostgresFIXRepository(dbHelper)
+	phaseRepo := repository.NewPostgresTradingPhaseRepository(dbHelper)
 
 	// 4. Build service
-	svc := service.NewOrderService(orderRepo, tradeRepo)
+	svc := service.NewOrderService(txManager, orderRepo, tradeRepo, eventRepo)
+	svc.Phases = phaseRepo
 
 	return &TestDeps{
 		Service:        svc,
 		OrderRepo:      orderRepo,
 		TradeRepo:      tradeRepo,
+		EventRepo:      eventRepo,
+		AccountRepo:    accountRepo,
+		AuditRepo:      auditRepo,
+		OutboxRepo:     outboxRepo,
+		WebhookRepo:    webhookRepo,
+		FIXRepo:        fixRepo,
+		PhaseRepo:      phaseRepo,
+		Auth:           service.NewAuthService(svc.TxRunner, accountRepo, testSecrets(), 30*time.Second),
+		Audit:          service.NewAuditService(auditRepo),
+		Webhooks:       service.NewWebhookService(svc.TxRunner, webhookRepo, outboxRepo, config.Default().Webhooks, svc.Metrics),
 		PostgresClient: pgClient,
 		Cleanup: func() {
 			pgClient.Stop()
 		},
 	}
 }
+
+// GetMemoryTestInstance returns services backed by the in-memory store, so
+// tests can run without a database.
+func GetMemoryTestInstance() *TestDeps {
+	store := memory.NewStore()
+	orderRepo := memory.NewOrderRepository(store)
+	tradeRepo := memory.NewTradeRepository(store)
+	eventRepo := memory.NewOrderEventRepository(store)
+	accountRepo := memory.NewAccountRepository(store)
+	auditRepo := memory.NewAuditRepository(store)
+	outboxRepo := memory.NewOutboxRepository(store)
+	webhookRepo := memory.NewWebhookRepository(store)
+	fixRepo := memory.NewFIXRepository(store)
+	phaseRepo := memory.NewTradingPhaseRepository(store)
+	svc := service.NewOrderService(store, orderRepo, tradeRepo, eventRepo)
+	svc.Phases = phaseRepo
+
+	return &TestDeps{
+		Service:     svc,
+		OrderRepo:   orderRepo,
+		TradeRepo:   tradeRepo,
+		EventRepo:   eventRepo,
+		AccountRepo: accountRepo,
+		AuditRepo:   auditRepo,
+		OutboxRepo:  outboxRepo,
+		WebhookRepo: webhookRepo,
+		FIXRepo:     fixRepo,
+		PhaseRepo:   phaseRepo,
+		Auth:        service.NewAuthService(svc.TxRunner, accountRepo, testSecrets(), 30*time.Second),
+		Audit:       service.NewAuditService(auditRepo),
+		Webhooks:    service.NewWebhookService(svc.TxRunner, webhookRepo, outboxRepo, config.Default().Webhooks, svc.Metrics),
+		Cleanup:     func() {},
+	}
+}
+
+// testSecrets seals API key secrets with a fixed key.
+func testSecrets() *service.SecretBox {
+	box, err := service.NewSecretBox([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		panic(err)
+	}
+	return box
+}
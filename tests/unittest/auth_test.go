@@ -0,0 +1,273 @@
+package unittest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/handlers"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/routes"
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tests/mockdb"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuthRouter(deps *mockdb.TestDeps) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	routes.RegisterRoutes(router, deps.Service, deps.Auth, deps.Audit, deps.Webhooks)
+	return router
+}
+
+// newTestKey creates an account with role and a key holding scopes.
+func newTestKey(t *testing.T, deps *mockdb.TestDeps, name, role string, scopes ...string) *models.IssuedAPIKeyResponse {
+	ctx := context.Background()
+	account, err := deps.Auth.CreateAccount(ctx, name, role)
+	require.NoError(t, err)
+	key, err := deps.Auth.IssueKey(ctx, account.ID, scopes)
+	require.NoError(t, err)
+	return key
+}
+
+// doSignedRequest signs a request with key, as a client would, at ts.
+func doSignedRequest(router http.Handler, key *models.IssuedAPIKeyResponse, method, path string, body any, ts time.Time) *httptest.ResponseRecorder {
+	nonce, _ := service.NewNonce()
+	return doNoncedRequest(router, key, method, path, body, ts, nonce)
+}
+
+// doNoncedRequest signs a request with key and a given nonce at ts.
+func doNoncedRequest(router http.Handler, key *models.IssuedAPIKeyResponse, method, path string, body any, ts time.Time, nonce string) *httptest.ResponseRecorder {
+	var raw []byte
+	if body != nil {
+		raw, _ = json.Marshal(body)
+	}
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	req := httptest.NewRequest(method, path, bytes.NewReader(raw))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(handlers.HeaderAPIKey, key.KeyID)
+	req.Header.Set(handlers.HeaderTimestamp, timestamp)
+	req.Header.Set(handlers.HeaderNonce, nonce)
+	req.Header.Set(handlers.HeaderSignature, service.Sign(key.Secret, timestamp, nonce, method, path, raw))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	var resp models.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return resp.Error.Code
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	router := newAuthRouter(deps)
+	key := newTestKey(t, deps, "alice", models.RoleTrader, models.ScopeRead, models.ScopeTrade)
+	order := models.PlaceOrderRequest{Symbol: "AUTH", Side: "buy", Type: "limit", Price: 10, Quantity: qty(1)}
+	now := time.Now()
+
+	// Unsigned requests are rejected
+	w := doRequest(router, http.MethodGet, "/api/orderbook?symbol=AUTH", nil, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "unauthenticated", errorCode(t, w))
+
+	// A correctly signed request goes through and the order belongs to the account
+	w = doSignedRequest(router, key, http.MethodPost, "/api/orders", order, now)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var placed models.PlaceOrderResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &placed))
+	stored, err := deps.OrderRepo.GetOrderByID(context.Background(), nil, placed.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, key.AccountID, stored.AccountID)
+
+	events, err := deps.EventRepo.ListEventsByOrder(context.Background(), placed.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, "account:"+strconv.FormatInt(key.AccountID, 10)+"/"+key.KeyID, events[0].Actor)
+
+	tests := []struct {
+		name       string
+		key        *models.IssuedAPIKeyResponse
+		method     string
+		path       string
+		ts         time.Time
+		wantStatus int
+		wantCode   string
+	}{
+		{"Wrong Secret", &models.IssuedAPIKeyResponse{APIKey: key.APIKey, Secret: "guess"}, http.MethodGet, "/api/orderbook?symbol=AUTH", now, http.StatusUnauthorized, "invalid_signature"},
+		{"Unknown Key", &models.IssuedAPIKeyResponse{APIKey: models.APIKey{KeyID: "ak_nope"}, Secret: key.Secret}, http.MethodGet, "/api/orderbook?symbol=AUTH", now, http.StatusUnauthorized, "unauthenticated"},
+		{"Stale Timestamp", key, http.MethodGet, "/api/orderbook?symbol=AUTH", now.Add(-time.Minute), http.StatusUnauthorized, "stale_timestamp"},
+		{"Future Timestamp", key, http.MethodGet, "/api/orderbook?symbol=AUTH", now.Add(time.Minute), http.StatusUnauthorized, "stale_timestamp"},
+		{"Missing Scope", key, http.MethodDelete, "/api/orders/" + strconv.FormatInt(placed.OrderID, 10), now, http.StatusForbidden, "insufficient_scope"},
+		{"Admin Route", key, http.MethodPost, "/admin/accounts", now, http.StatusForbidden, "insufficient_scope"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doSignedRequest(router, tc.key, tc.method, tc.path, nil, tc.ts)
+			assert.Equal(t, tc.wantStatus, w.Code)
+			assert.Equal(t, tc.wantCode, errorCode(t, w))
+		})
+	}
+
+	t.Run("Replay", func(t *testing.T) {
+		ts := now.Add(-time.Second)
+		first := doNoncedRequest(router, key, http.MethodGet, "/api/orderbook?symbol=AUTH", nil, ts, "n-1")
+		assert.Equal(t, http.StatusOK, first.Code)
+		replay := doNoncedRequest(router, key, http.MethodGet, "/api/orderbook?symbol=AUTH", nil, ts, "n-1")
+		assert.Equal(t, http.StatusUnauthorized, replay.Code)
+		assert.Equal(t, "replayed_request", errorCode(t, replay))
+
+		// The same request within the same second, with its own nonce
+		again := doNoncedRequest(router, key, http.MethodGet, "/api/orderbook?symbol=AUTH", nil, ts, "n-2")
+		assert.Equal(t, http.StatusOK, again.Code)
+	})
+
+	t.Run("Future Dated Replay", func(t *testing.T) {
+		clock := now
+		deps.Auth.Now = func() time.Time { return clock }
+		defer func() { deps.Auth.Now = time.Now }()
+		window := deps.Auth.ReplayWindow
+
+		// Dated ahead of the server clock, within the window
+		ts := now.Add(window - time.Second)
+		first := doNoncedRequest(router, key, http.MethodGet, "/api/orderbook?symbol=AUTH", nil, ts, "n-future")
+		assert.Equal(t, http.StatusOK, first.Code)
+
+		// Past now + window, the timestamp is still accepted, so the nonce
+		// must still be remembered
+		clock = now.Add(window + time.Second)
+		replay := doNoncedRequest(router, key, http.MethodGet, "/api/orderbook?symbol=AUTH", nil, ts, "n-future")
+		assert.Equal(t, http.StatusUnauthorized, replay.Code)
+		assert.Equal(t, "replayed_request", errorCode(t, replay))
+	})
+
+	t.Run("Missing Nonce", func(t *testing.T) {
+		w := doNoncedRequest(router, key, http.MethodGet, "/api/orderbook?symbol=AUTH", nil, now, "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "unauthenticated", errorCode(t, w))
+	})
+
+	t.Run("Tampered Body", func(t *testing.T) {
+		raw, _ := json.Marshal(order)
+		timestamp := strconv.FormatInt(now.Unix(), 10)
+		req := httptest.NewRequest(http.MethodPost, "/api/orders", bytes.NewReader([]byte(`{"symbol":"AUTH","side":"buy","type":"limit","price":10,"quantity":1000}`)))
+		req.Header.Set(handlers.HeaderAPIKey, key.KeyID)
+		req.Header.Set(handlers.HeaderTimestamp, timestamp)
+		req.Header.Set(handlers.HeaderNonce, "tampered")
+		req.Header.Set(handlers.HeaderSignature, service.Sign(key.Secret, timestamp, "tampered", http.MethodPost, "/api/orders", raw))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestAPIKeySecretsAreSealed(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	router := newAuthRouter(deps)
+	key := newTestKey(t, deps, "alice", models.RoleTrader, models.ScopeRead)
+	ctx := context.Background()
+
+	// Neither the secret nor the digest requests are signed with is stored
+	stored, err := deps.AccountRepo.GetAPIKey(ctx, nil, key.KeyID)
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(key.Secret))
+	assert.NotContains(t, string(stored.SealedSecret), key.Secret)
+	assert.False(t, bytes.Contains(stored.SealedSecret, digest[:]))
+
+	// Keys stored before secrets were sealed must be rotated
+	stored.SealedSecret = nil
+	require.NoError(t, deps.AccountRepo.UpdateAPIKey(ctx, nil, stored))
+	w := doSignedRequest(router, key, http.MethodGet, "/api/orderbook?symbol=SEAL", nil, time.Now())
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	rotated, err := deps.Auth.RotateKey(ctx, key.KeyID)
+	require.NoError(t, err)
+	w = doSignedRequest(router, rotated, http.MethodGet, "/api/orderbook?symbol=SEAL", nil, time.Now())
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestOrdersAreVisibleToTheirAccountOnly(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	router := newAuthRouter(deps)
+	alice := newTestKey(t, deps, "alice", models.RoleTrader, models.ScopeRead, models.ScopeTrade, models.ScopeCancel)
+	bob := newTestKey(t, deps, "bob", models.RoleTrader, models.ScopeRead, models.ScopeCancel)
+	now := time.Now()
+
+	w := doSignedRequest(router, alice, http.MethodPost, "/api/orders", models.PlaceOrderRequest{Symbol: "OWN", Side: "sell", Type: "limit", Price: 5, Quantity: qty(1)}, now)
+	require.Equal(t, http.StatusOK, w.Code)
+	var placed models.PlaceOrderResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &placed))
+	path := "/api/orders/" + strconv.FormatInt(placed.OrderID, 10)
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		w = doSignedRequest(router, bob, method, path, nil, now)
+		assert.Equal(t, http.StatusNotFound, w.Code, method)
+		assert.Equal(t, "order_not_found", errorCode(t, w))
+	}
+
+	w = doSignedRequest(router, alice, http.MethodDelete, path, nil, now)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAdminKeyLifecycle(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	router := newAuthRouter(deps)
+	admin := newTestKey(t, deps, "ops", models.RoleAdmin, models.ScopeAdmin)
+	now := time.Now() // each request differs in path or body, so none is a replay
+
+	// Create an account and issue it a key
+	w := doSignedRequest(router, admin, http.MethodPost, "/admin/accounts", models.CreateAccountRequest{Name: "carol"}, now)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var account models.Account
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &account))
+
+	keysPath := "/admin/accounts/" + strconv.FormatInt(account.ID, 10) + "/keys"
+	w = doSignedRequest(router, admin, http.MethodPost, keysPath, models.IssueAPIKeyRequest{Scopes: []string{"bogus"}}, now)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doSignedRequest(router, admin, http.MethodPost, keysPath, models.IssueAPIKeyRequest{Scopes: []string{models.ScopeRead}}, now)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var carol models.IssuedAPIKeyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &carol))
+	assert.NotEmpty(t, carol.Secret)
+	assert.Equal(t, []string{models.ScopeRead}, carol.Scopes)
+
+	assert.Equal(t, http.StatusOK, doSignedRequest(router, &carol, http.MethodGet, "/api/trades?symbol=X", nil, now).Code)
+
+	// Rotation invalidates the old secret
+	w = doSignedRequest(router, admin, http.MethodPost, "/admin/keys/"+carol.KeyID+"/rotate", nil, now)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var rotated models.IssuedAPIKeyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rotated))
+	assert.Equal(t, carol.KeyID, rotated.KeyID)
+	assert.NotEqual(t, carol.Secret, rotated.Secret)
+	assert.NotNil(t, rotated.RotatedAt)
+
+	w = doSignedRequest(router, &carol, http.MethodGet, "/api/trades?symbol=Y", nil, now)
+	assert.Equal(t, "invalid_signature", errorCode(t, w))
+	assert.Equal(t, http.StatusOK, doSignedRequest(router, &rotated, http.MethodGet, "/api/trades?symbol=Y", nil, now).Code)
+
+	// Revocation disables the key
+	w = doSignedRequest(router, admin, http.MethodDelete, "/admin/keys/"+carol.KeyID, nil, now)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doSignedRequest(router, &rotated, http.MethodGet, "/api/trades?symbol=Z", nil, now)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "api_key_revoked", errorCode(t, w))
+
+	w = doSignedRequest(router, admin, http.MethodGet, keysPath, nil, now)
+	require.Equal(t, http.StatusOK, w.Code)
+	var listed models.APIKeysResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed.Keys, 1)
+	assert.NotNil(t, listed.Keys[0].RevokedAt)
+	assert.NotContains(t, w.Body.String(), "secret")
+}
@@ -0,0 +1,126 @@
+package unittest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tests/mockdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	var v T
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &v), w.Body.String())
+	return v
+}
+
+func TestBatchPlaceOrders(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	router := newTestRouter(deps)
+
+	doRequest(router, http.MethodPost, "/api/orders", models.PlaceOrderRequest{Symbol: "BAT", Side: "sell", Type: "limit", Price: 10, Quantity: qty(5)}, nil)
+
+	w := doRequest(router, http.MethodPost, "/api/orders/batch", models.BatchPlaceOrdersRequest{Orders: []models.PlaceOrderRequest{
+		{Symbol: "BAT", Side: "buy", Type: "limit", Price: 10, Quantity: qty(3)},
+		{Symbol: "BAT", Side: "buy", Type: "limit", Price: 10},
+		{Symbol: "BAT", Side: "sell", Type: "limit", Price: 12, Quantity: qty(2)},
+	}}, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	resp := decode[models.BatchPlaceOrdersResponse](t, w)
+	assert.Equal(t, 2, resp.Accepted)
+	assert.Equal(t, 1, resp.Rejected)
+	require.Len(t, resp.Results, 3)
+
+	assert.Equal(t, models.BatchAccepted, resp.Results[0].Result)
+	assert.Equal(t, "filled", resp.Results[0].Status)
+	require.Len(t, resp.Results[0].Fills, 1)
+	assertQty(t, 3, resp.Results[0].Fills[0].Quantity)
+
+	assert.Equal(t, models.BatchRejected, resp.Results[1].Result)
+	require.NotNil(t, resp.Results[1].Error)
+	assert.Equal(t, "validation_failed", resp.Results[1].Error.Code)
+
+	assert.Equal(t, models.BatchAccepted, resp.Results[2].Result)
+	assert.Equal(t, "open", resp.Results[2].Status)
+	assertQty(t, 2, resp.Results[2].RemainingQuantity)
+	assert.Empty(t, resp.Results[2].Fills)
+
+	t.Run("All Or None", func(t *testing.T) {
+		w := doRequest(router, http.MethodPost, "/api/orders/batch", models.BatchPlaceOrdersRequest{AllOrNone: true, Orders: []models.PlaceOrderRequest{
+			{Symbol: "BAT", Side: "buy", Type: "limit", Price: 9, Quantity: qty(1)},
+			{Symbol: "BAT", Side: "hold", Type: "limit", Price: 9, Quantity: qty(1)},
+		}}, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		resp := decode[models.BatchPlaceOrdersResponse](t, w)
+		assert.Equal(t, 0, resp.Accepted)
+		assert.Equal(t, 2, resp.Rejected)
+		assert.Equal(t, "batch_aborted", resp.Results[0].Error.Code)
+		assert.Equal(t, "validation_failed", resp.Results[1].Error.Code)
+
+		book := doRequest(router, http.MethodGet, "/api/orderbook?symbol=BAT", nil, nil)
+		assert.Empty(t, decode[models.OrderBookResponse](t, book).Bids)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		w := doRequest(router, http.MethodPost, "/api/orders/batch", models.BatchPlaceOrdersRequest{}, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "invalid_request", errorCode(t, w))
+	})
+
+	t.Run("Too Large", func(t *testing.T) {
+		deps.Service.Engine.MaxBatchSize = 1
+		defer func() { deps.Service.Engine.MaxBatchSize = 0 }()
+		w := doRequest(router, http.MethodPost, "/api/orders/batch", models.BatchPlaceOrdersRequest{Orders: make([]models.PlaceOrderRequest, 2)}, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "batch_too_large", errorCode(t, w))
+	})
+}
+
+func TestBatchCancelOrders(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	router := newTestRouter(deps)
+
+	var ids []int64
+	for _, price := range []float64{10, 11, 12} {
+		w := doRequest(router, http.MethodPost, "/api/orders", models.PlaceOrderRequest{Symbol: "BCX", Side: "sell", Type: "limit", Price: price, Quantity: qty(1)}, nil)
+		ids = append(ids, decode[models.PlaceOrderResponse](t, w).OrderID)
+	}
+
+	t.Run("All Or None", func(t *testing.T) {
+		w := doRequest(router, http.MethodDelete, "/api/orders/batch", models.BatchCancelOrdersRequest{AllOrNone: true, OrderIDs: []int64{ids[0], 99999}}, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		resp := decode[models.BatchCancelOrdersResponse](t, w)
+		assert.Equal(t, 0, resp.Canceled)
+		assert.Equal(t, 2, resp.Rejected)
+		assert.Equal(t, "batch_aborted", resp.Results[0].Error.Code)
+		assert.Equal(t, "order_not_found", resp.Results[1].Error.Code)
+
+		// Rolled back: the first order still rests
+		book := doRequest(router, http.MethodGet, "/api/orderbook?symbol=BCX", nil, nil)
+		assert.Len(t, decode[models.OrderBookResponse](t, book).Asks, 3)
+	})
+
+	t.Run("Each", func(t *testing.T) {
+		w := doRequest(router, http.MethodDelete, "/api/orders/batch", models.BatchCancelOrdersRequest{OrderIDs: []int64{ids[0], 99999, ids[1]}}, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		resp := decode[models.BatchCancelOrdersResponse](t, w)
+		assert.Equal(t, 2, resp.Canceled)
+		assert.Equal(t, 1, resp.Rejected)
+		assert.Equal(t, models.BatchAccepted, resp.Results[0].Result)
+		assert.Equal(t, "order_not_found", resp.Results[1].Error.Code)
+		assert.Equal(t, ids[1], resp.Results[2].OrderID)
+
+		book := doRequest(router, http.MethodGet, "/api/orderbook?symbol=BCX", nil, nil)
+		assert.Len(t, decode[models.OrderBookResponse](t, book).Asks, 1)
+	})
+
+	t.Run("All Or None Succeeds", func(t *testing.T) {
+		w := doRequest(router, http.MethodDelete, "/api/orders/batch", models.BatchCancelOrdersRequest{AllOrNone: true, OrderIDs: []int64{ids[2]}}, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 1, decode[models.BatchCancelOrdersResponse](t, w).Canceled)
+	})
+}
@@ -0,0 +1,206 @@
+package unittest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/fix"
+	"github.com/Puneet-Vishnoi/order-matching-engine/handlers"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tests/mockdb"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requireCanceled waits for an order to be canceled for reason.
+func requireCanceled(t *testing.T, deps *mockdb.TestDeps, orderID int64, reason string) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		order, err := deps.OrderRepo.GetOrderByID(context.Background(), nil, orderID)
+		return err == nil && order.Status == "canceled"
+	}, 2*time.Second, 10*time.Millisecond, "order %d is not canceled", orderID)
+
+	events, err := deps.EventRepo.ListEventsByOrder(context.Background(), orderID)
+	require.NoError(t, err)
+	assert.Equal(t, reason, events[len(events)-1].Reason)
+}
+
+func requireResting(t *testing.T, deps *mockdb.TestDeps, orderID int64) {
+	t.Helper()
+	order, err := deps.OrderRepo.GetOrderByID(context.Background(), nil, orderID)
+	require.NoError(t, err)
+	assert.Equal(t, "open", order.Status)
+}
+
+func TestDeadMansSwitch(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	deps.Service.DeadMansSwitch.MinTimeout = 10 * time.Millisecond
+	router := newAuthRouter(deps)
+	mm := newTestKey(t, deps, "mm", models.RoleMarketMaker, models.ScopeRead, models.ScopeTrade, models.ScopeCancel)
+	other := newTestKey(t, deps, "other", models.RoleTrader, models.ScopeRead, models.ScopeTrade, models.ScopeCancel)
+	now := time.Now()
+
+	place := func(key *models.IssuedAPIKeyResponse, price float64) int64 {
+		w := doSignedRequest(router, key, http.MethodPost, "/api/orders", models.PlaceOrderRequest{Symbol: "DMS", Side: "sell", Type: "limit", Price: price, Quantity: qty(1)}, now)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		return decode[models.PlaceOrderResponse](t, w).OrderID
+	}
+	arm := func(timeout int64, ts time.Time) *httptest.ResponseRecorder {
+		return doSignedRequest(router, mm, http.MethodPost, "/api/orders/cancel-all-after", models.DeadMansSwitchRequest{TimeoutMS: &timeout}, ts)
+	}
+	quote := place(mm, 10)
+	otherQuote := place(other, 11)
+
+	w := arm(-1, now)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "validation_failed", errorCode(t, w))
+
+	// Armed, then disarmed: nothing fires
+	w = arm(50, now)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	state := decode[models.DeadMansSwitchResponse](t, w)
+	assert.Equal(t, int64(50), state.TimeoutMS)
+	require.NotNil(t, state.TriggerAt)
+
+	w = doSignedRequest(router, mm, http.MethodGet, "/api/orders/cancel-all-after", nil, now)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, int64(50), decode[models.DeadMansSwitchResponse](t, w).TimeoutMS)
+
+	w = arm(0, now.Add(time.Second))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Nil(t, decode[models.DeadMansSwitchResponse](t, w).TriggerAt)
+	time.Sleep(100 * time.Millisecond)
+	requireResting(t, deps, quote)
+
+	// Armed and left to run out: only the account's orders are canceled
+	w = arm(20, now.Add(2*time.Second))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	requireCanceled(t, deps, quote, models.ReasonDeadMans)
+	requireResting(t, deps, otherQuote)
+
+	w = doSignedRequest(router, mm, http.MethodGet, "/api/orders/cancel-all-after", nil, now.Add(3*time.Second))
+	assert.Nil(t, decode[models.DeadMansSwitchResponse](t, w).TriggerAt)
+
+	t.Run("Needs An Account", func(t *testing.T) {
+		timeout := int64(1000)
+		w := doRequest(newTestRouter(deps), http.MethodPost, "/api/orders/cancel-all-after", models.DeadMansSwitchRequest{TimeoutMS: &timeout}, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestStreamCancelOnDisconnect(t *testing.T) {
+	mr := miniredis.RunT(t)
+	deps := newMarketDataDeps(t, mr)
+	server := httptest.NewServer(newAuthRouter(deps))
+	defer server.Close()
+	mm := newTestKey(t, deps, "mm", models.RoleMarketMaker, models.ScopeRead, models.ScopeTrade, models.ScopeCancel)
+	viewer := newTestKey(t, deps, "viewer", models.RoleTrader, models.ScopeRead)
+
+	dial := func(key *models.IssuedAPIKeyResponse, path string) (*websocket.Conn, *http.Response, error) {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		header := http.Header{}
+		header.Set(handlers.HeaderAPIKey, key.KeyID)
+		nonce, _ := service.NewNonce()
+		header.Set(handlers.HeaderTimestamp, ts)
+		header.Set(handlers.HeaderNonce, nonce)
+		header.Set(handlers.HeaderSignature, service.Sign(key.Secret, ts, nonce, http.MethodGet, path, nil))
+		return websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+path, header)
+	}
+
+	_, resp, err := dial(viewer, "/api/stream?symbol=COD&cancel_on_disconnect=true")
+	require.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	ctx := service.WithAccount(context.Background(), mm.AccountID)
+	place := func(symbol string, price float64) int64 {
+		placed, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: symbol, Side: "buy", Type: "limit", Price: price, Quantity: qty(1)})
+		require.NoError(t, err)
+		return placed.OrderID
+	}
+	open := func() *websocket.Conn {
+		conn, _, err := dial(mm, "/api/stream?symbol=COD&cancel_on_disconnect=true")
+		require.NoError(t, err)
+		var event models.MarketDataEvent
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		require.NoError(t, conn.ReadJSON(&event))
+		return conn
+	}
+	before := place("COD", 5)
+
+	conn := open()
+	during := place("COD", 6)
+	otherSymbol := place("CODX", 6)
+	requireResting(t, deps, during)
+
+	// Only the orders entered on the stream's symbol while it was open
+	conn.Close()
+	requireCanceled(t, deps, during, models.ReasonDisconnect)
+	requireResting(t, deps, before)
+	requireResting(t, deps, otherSymbol)
+
+	t.Run("Not On Shutdown", func(t *testing.T) {
+		conn := open()
+		during := place("COD", 7)
+		deps.Service.DeadMansSwitch.Stop()
+		conn.Close()
+		time.Sleep(100 * time.Millisecond)
+		requireResting(t, deps, during)
+	})
+}
+
+func TestFIXCancelOnDisconnect(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	acceptor := startAcceptor(t, deps, nil)
+	t.Cleanup(func() { closeAcceptor(t, acceptor) })
+
+	// logon logs a session on, opting into cancel-on-disconnect or not, and
+	// rests an order
+	logon := func(compID string, cancelOnDisconnect bool) (*fixClient, int64) {
+		c := dialFIX(t, acceptor, compID)
+		m := fix.NewMessage(fix.MsgLogon).SetInt(fix.TagEncryptMethod, 0).SetInt(fix.TagHeartBtInt, 30)
+		if cancelOnDisconnect {
+			m.Set(fix.TagCancelOrdersOnDisconnect, "Y")
+		}
+		c.send(m)
+		reply := c.expect(fix.MsgLogon)
+		assert.Equal(t, cancelOnDisconnect, reply.Bool(fix.TagCancelOrdersOnDisconnect))
+
+		c.send(newOrderSingle(compID+"-1", fix.SideSell, 1, 10))
+		ack := c.expect(fix.MsgExecutionReport)
+		require.Equal(t, fix.ExecTypeNew, ack.String(fix.TagExecType))
+		id, err := strconv.ParseInt(ack.String(fix.TagOrderID), 10, 64)
+		require.NoError(t, err)
+		return c, id
+	}
+	keeper, kept := logon("KEEPER", false)
+	quoter, quote := logon("QUOTER", true)
+
+	// Dropped without a Logout
+	quoter.conn.Close()
+	requireCanceled(t, deps, quote, models.ReasonDisconnect)
+	keeper.conn.Close()
+	time.Sleep(50 * time.Millisecond)
+	requireResting(t, deps, kept)
+
+	// The cancel is reported when the session logs on again
+	require.Eventually(t, func() bool {
+		kept, err := deps.FIXRepo.ListFIXMessages(context.Background(), nil, "QUOTER", 3, 3)
+		return err == nil && len(kept) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	again := dialFIX(t, acceptor, "QUOTER")
+	again.seq = 3
+	again.send(fix.NewMessage(fix.MsgLogon).SetInt(fix.TagEncryptMethod, 0).SetInt(fix.TagHeartBtInt, 30))
+	again.expect(fix.MsgLogon)
+	again.send(fix.NewMessage(fix.MsgResendRequest).SetInt(fix.TagBeginSeqNo, 3).SetInt(fix.TagEndSeqNo, 0))
+	report := again.expect(fix.MsgExecutionReport)
+	assert.Equal(t, fix.ExecTypeCanceled, report.String(fix.TagExecType))
+	assert.Equal(t, models.ReasonDisconnect, report.String(fix.TagText))
+}
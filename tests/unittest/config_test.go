@@ -0,0 +1,213 @@
+package unittest
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+	"github.com/Puneet-Vishnoi/order-matching-engine/db/postgres/providers"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tests/mockdb"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSecretKey is a valid auth.secret_key.
+const testSecretKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	path := writeConfigFile(t, `
+server:
+  port: 9000
+  drain_delay: 1s
+postgres:
+  host: file-host
+  user: app
+  password: s3cret
+  database: orders
+  max_open_conns: 40
+engine:
+  defaults:
+    lot_size: 10
+  symbols:
+    BTCUSD:
+      tick_size: 0.5
+      quantity_precision: 0
+`)
+	t.Setenv("POSTGRES_HOST", "env-host")
+	t.Setenv("POSTGRES_MAX_OPEN_CONNS", "30")
+	t.Setenv("AUTH_SECRET_KEY", testSecretKey)
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.1, 10.1.0.0/16")
+	t.Setenv("ENGINE_QUANTITY_PRECISION", "4")
+
+	cfg, rest, err := config.Load([]string{"-config", path, "-postgres.max_open_conns", "50", "migrate", "up"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"migrate", "up"}, rest)
+	assert.Equal(t, 9000, cfg.Server.Port, "file overrides default")
+	assert.Equal(t, time.Second, cfg.Server.DrainDelay)
+	assert.Equal(t, []string{"10.0.0.1", "10.1.0.0/16"}, cfg.Server.TrustedProxies)
+	assert.Equal(t, "env-host", cfg.Postgres.Host, "env overrides file")
+	assert.Equal(t, 50, cfg.Postgres.MaxOpenConns, "flag overrides env and file")
+	assert.Equal(t, 10, cfg.Postgres.MaxIdleConns, "untouched values keep their default")
+	// A symbol may override the default precision down to 0
+	assert.Equal(t, config.SymbolConfig{TickSize: 0.5, LotSize: qty(10), QuantityPrecision: decimals(0)}, cfg.Engine.Symbol("BTCUSD"))
+	assert.Equal(t, config.SymbolConfig{LotSize: qty(10), QuantityPrecision: decimals(4)}, cfg.Engine.Symbol("ETHUSD"))
+}
+
+func TestConfigValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		args    []string
+		wantErr string
+	}{
+		{"Unknown Key", "server:\n  prot: 1\n", nil, "field prot not found"},
+		{"Malformed Flag Value", "", []string{"-server.port", "http"}, `flag -server.port: invalid value "http"`},
+		{"Missing Postgres Settings", "", nil, "postgres.user: required"},
+		{"Bad SSL Mode", "postgres: {user: u, database: d, sslmode: on}\n", nil, "postgres.sslmode"},
+		{"Idle Exceeds Open", "postgres: {user: u, database: d, max_open_conns: 2, max_idle_conns: 5}\n", nil, "max_idle_conns: 5 exceeds max_open_conns 2"},
+		{"Bad Backend", "storage: {backend: sqlite}\n", nil, "storage.backend"},
+		{"Certificates Without TLS", "postgres: {user: u, database: d, sslrootcert: ca.pem}\n", nil, "certificates are set but sslmode is disable"},
+		{"Client Cert Without Key", "postgres: {user: u, database: d, sslmode: verify-full, sslcert: /nonexistent.crt}\n", nil, "sslcert and sslkey must be set together"},
+		{"Missing CA File", "postgres: {user: u, database: d, sslmode: verify-ca, sslrootcert: /nonexistent/ca.pem}\n", nil, "postgres.sslrootcert"},
+		{"Symbol Limits", "storage: {backend: memory}\nengine: {symbols: {X: {min_quantity: 5, max_quantity: 1}}}\n", nil, "engine.symbols.X: min_quantity 5 exceeds max_quantity 1"},
+		{"Bad Trusted Proxy", "storage: {backend: memory}\nserver: {trusted_proxies: [10.0.0.0/8, proxy.local]}\n", nil, `server.trusted_proxies: "proxy.local" is not an IP or CIDR`},
+		{"Short Secret Key", "storage: {backend: memory}\nauth: {secret_key: c2hvcnQ=}\n", nil, "auth.secret_key: 5 bytes, not 32"},
+		{"Lot Size Precision", "storage: {backend: memory}\nengine: {symbols: {X: {lot_size: 0.05, quantity_precision: 1}}}\n", nil, "engine.symbols.X: lot_size 0.05 has more decimals than quantity_precision 1"},
+	}
+	// Keep the developer's environment from satisfying the required settings
+	t.Setenv("POSTGRES_USER", "")
+	t.Setenv("POSTGRES_DB", "")
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			args := append([]string{"-config", writeConfigFile(t, tc.file)}, tc.args...)
+			_, _, err := config.Load(args)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestConfigPrintRedactsSecrets(t *testing.T) {
+	t.Setenv("POSTGRES_USER", "app")
+	t.Setenv("POSTGRES_DB", "orders")
+	t.Setenv("POSTGRES_PASSWORD", "hunter2")
+	t.Setenv("AUTH_SECRET_KEY", testSecretKey)
+
+	cfg, _, err := config.Load(nil)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, cfg.Print(&out))
+	assert.NotContains(t, out.String(), "hunter2")
+	assert.NotContains(t, out.String(), testSecretKey)
+	assert.Contains(t, out.String(), "password: REDACTED")
+	assert.Contains(t, out.String(), "drain_delay: 5s")
+	assert.Equal(t, "hunter2", cfg.Postgres.Password, "redaction must not touch the live config")
+}
+
+func TestInstrumentRules(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	deps.Service.Engine = config.EngineConfig{
+		Defaults: config.SymbolConfig{LotSize: qty(5)},
+		Symbols:  map[string]config.SymbolConfig{"TICK": {TickSize: 0.1, MaxQuantity: qty(100)}},
+	}
+	router := newTestRouter(deps)
+
+	tests := []struct {
+		name     string
+		req      models.PlaceOrderRequest
+		wantCode string
+	}{
+		{"On Tick And Lot", models.PlaceOrderRequest{Symbol: "TICK", Side: "buy", Type: "limit", Price: 10.3, Quantity: qty(10)}, ""},
+		{"Off Tick", models.PlaceOrderRequest{Symbol: "TICK", Side: "buy", Type: "limit", Price: 10.05, Quantity: qty(10)}, apperr.ReasonInvalidTickSize},
+		{"Market Ignores Tick", models.PlaceOrderRequest{Symbol: "TICK", Side: "sell", Type: "market", Quantity: qty(5)}, ""},
+		{"Off Lot", models.PlaceOrderRequest{Symbol: "OTHER", Side: "buy", Type: "limit", Price: 1, Quantity: qty(7)}, apperr.ReasonInvalidLotSize},
+		{"Above Max", models.PlaceOrderRequest{Symbol: "TICK", Side: "buy", Type: "limit", Price: 1, Quantity: qty(105)}, apperr.ReasonQuantityOutOfRange},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doRequest(router, http.MethodPost, "/api/orders", tc.req, nil)
+			if tc.wantCode == "" {
+				assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+				return
+			}
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+			assert.Contains(t, w.Body.String(), `"code":"`+tc.wantCode+`"`)
+		})
+	}
+}
+
+func TestPostgresDSN(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"ca.pem", "client.crt", "client.key"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o600))
+	}
+	cfg := config.Default().Postgres
+	cfg.User = "app"
+	cfg.Password = "it's secret"
+	cfg.Database = "orders"
+	cfg.SSLMode = "verify-full"
+	cfg.SSLRootCert = filepath.Join(dir, "ca.pem")
+	cfg.SSLCert = filepath.Join(dir, "client.crt")
+	cfg.SSLKey = filepath.Join(dir, "client.key")
+	cfg.ConnectTimeout = 1500 * time.Millisecond
+
+	full := config.Default()
+	full.Postgres = cfg
+	full.Auth.SecretKey = testSecretKey
+	require.NoError(t, full.Validate())
+
+	dsn := cfg.DSN()
+	assert.Contains(t, dsn, `password='it\'s secret'`)
+	assert.Contains(t, dsn, "sslmode='verify-full'")
+	assert.Contains(t, dsn, "sslrootcert='"+cfg.SSLRootCert+"'")
+	assert.Contains(t, dsn, "sslkey='"+cfg.SSLKey+"'")
+	assert.Contains(t, dsn, "connect_timeout=2")
+	assert.Contains(t, dsn, "statement_timeout=30000")
+
+	// lib/pq accepts the string, including the session parameter
+	connector, err := pq.NewConnector(dsn)
+	require.NoError(t, err)
+	assert.NotNil(t, connector)
+}
+
+func TestQueryTimeout(t *testing.T) {
+	helper := &providers.DBHelper{QueryTimeout: 50 * time.Millisecond}
+	ctx, cancel := helper.WithQueryTimeout(context.Background())
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(50*time.Millisecond), deadline, 20*time.Millisecond)
+
+	// An earlier caller deadline wins
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancelParent()
+	ctx, cancel = helper.WithQueryTimeout(parent)
+	defer cancel()
+	parentDeadline, _ := parent.Deadline()
+	deadline, _ = ctx.Deadline()
+	assert.Equal(t, parentDeadline, deadline)
+
+	helper.QueryTimeout = 0
+	ctx, cancel = helper.WithQueryTimeout(context.Background())
+	defer cancel()
+	_, ok = ctx.Deadline()
+	assert.False(t, ok)
+}
@@ -0,0 +1,369 @@
+package unittest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+	"github.com/Puneet-Vishnoi/order-matching-engine/fix"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tests/mockdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startAcceptor starts a FIX acceptor on a free port, reporting the orders
+// of deps.Service. Register closeAcceptor before dialing it, so clients
+// disconnect first.
+func startAcceptor(t *testing.T, deps *mockdb.TestDeps, auth *service.AuthService) *fix.Acceptor {
+	acceptor := fix.NewAcceptor(config.FIXConfig{SenderCompID: "ENGINE", LogonTimeout: 5 * time.Second}, deps.Service, auth, deps.FIXRepo)
+	deps.Service.Executions = acceptor
+	require.NoError(t, acceptor.Start())
+	return acceptor
+}
+
+func closeAcceptor(t *testing.T, acceptor *fix.Acceptor) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, acceptor.Close(ctx))
+}
+
+// fixClient is a minimal FIX initiator, numbering what it sends from seq.
+type fixClient struct {
+	t      *testing.T
+	conn   net.Conn
+	r      *bufio.Reader
+	compID string
+	seq    int
+}
+
+func dialFIX(t *testing.T, acceptor *fix.Acceptor, compID string) *fixClient {
+	port := acceptor.Addr().(*net.TCPAddr).Port
+	conn, err := net.Dial("tcp", "127.0.0.1:"+strconv.Itoa(port))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return &fixClient{t: t, conn: conn, r: bufio.NewReader(conn), compID: compID, seq: 1}
+}
+
+func (c *fixClient) send(m *fix.Message) {
+	if _, ok := m.Get(fix.TagSendingTime); !ok {
+		m.Set(fix.TagSendingTime, time.Now().UTC().Format(fix.TimeFormat))
+	}
+	m.Set(fix.TagSenderCompID, c.compID).Set(fix.TagTargetCompID, "ENGINE").SetInt(fix.TagMsgSeqNum, c.seq)
+	c.seq++
+	_, err := c.conn.Write(m.Bytes())
+	require.NoError(c.t, err)
+}
+
+// logon logs on, signed with key unless it is nil, and returns the reply.
+func (c *fixClient) logon(key *models.IssuedAPIKeyResponse) *fix.Message {
+	sendingTime := time.Now().UTC().Format(fix.TimeFormat)
+	m := fix.NewMessage(fix.MsgLogon).
+		Set(fix.TagSendingTime, sendingTime).
+		SetInt(fix.TagEncryptMethod, 0).
+		SetInt(fix.TagHeartBtInt, 30)
+	if key != nil {
+		stringToSign := fix.LogonStringToSign(sendingTime, strconv.Itoa(c.seq), c.compID, "ENGINE")
+		m.Set(fix.TagUsername, key.KeyID).Set(fix.TagRawData, service.SignString(key.Secret, stringToSign))
+	}
+	c.send(m)
+	return c.read()
+}
+
+func (c *fixClient) read() *fix.Message {
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	raw, err := fix.ReadMessage(c.r)
+	require.NoError(c.t, err)
+	m, err := fix.Parse(raw)
+	require.NoError(c.t, err)
+	return m
+}
+
+// expect reads the next message, which must be of msgType.
+func (c *fixClient) expect(msgType string) *fix.Message {
+	m := c.read()
+	require.Equal(c.t, msgType, m.MsgType(), "got %v", m.Fields)
+	return m
+}
+
+// expectClosed checks the acceptor has dropped the connection.
+func (c *fixClient) expectClosed() {
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err := fix.ReadMessage(c.r)
+	require.Error(c.t, err)
+	assert.NotErrorIs(c.t, err, os.ErrDeadlineExceeded)
+}
+
+func newOrderSingle(clOrdID, side string, qty int, price float64) *fix.Message {
+	return fix.NewMessage(fix.MsgNewOrderSingle).
+		Set(fix.TagClOrdID, clOrdID).
+		Set(fix.TagSymbol, "FIX").
+		Set(fix.TagSide, side).
+		SetInt(fix.TagOrderQty, qty).
+		Set(fix.TagOrdType, fix.OrdTypeLimit).
+		SetFloat(fix.TagPrice, price)
+}
+
+func cancelRequest(clOrdID, origClOrdID string) *fix.Message {
+	return fix.NewMessage(fix.MsgOrderCancelRequest).
+		Set(fix.TagClOrdID, clOrdID).
+		Set(fix.TagOrigClOrdID, origClOrdID).
+		Set(fix.TagSymbol, "FIX").
+		Set(fix.TagSide, fix.SideSell)
+}
+
+func TestFIXOrderLifecycle(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	alice := newTestKey(t, deps, "alice", models.RoleTrader, models.ScopeTrade)
+	bob := newTestKey(t, deps, "bob", models.RoleTrader, models.ScopeTrade)
+	acceptor := startAcceptor(t, deps, deps.Auth)
+	t.Cleanup(func() { closeAcceptor(t, acceptor) })
+
+	a := dialFIX(t, acceptor, "ALICE")
+	reply := a.logon(alice)
+	require.Equal(t, fix.MsgLogon, reply.MsgType(), "got %v", reply.Fields)
+	assert.Equal(t, "30", reply.String(fix.TagHeartBtInt))
+
+	a.send(newOrderSingle("a1", fix.SideSell, 10, 100))
+	er := a.expect(fix.MsgExecutionReport)
+	assert.Equal(t, fix.ExecTypeNew, er.String(fix.TagExecType))
+	assert.Equal(t, fix.OrdStatusNew, er.String(fix.TagOrdStatus))
+	assert.Equal(t, "a1", er.String(fix.TagClOrdID))
+	assert.Equal(t, "10", er.String(fix.TagLeavesQty))
+	assert.Equal(t, strconv.FormatInt(alice.AccountID, 10), er.String(fix.TagAccount))
+	origID := er.String(fix.TagOrderID)
+
+	// A replacement is a new order that keeps the ClOrdID chain
+	a.send(fix.NewMessage(fix.MsgOrderCancelReplaceRequest).
+		Set(fix.TagClOrdID, "a2").
+		Set(fix.TagOrigClOrdID, "a1").
+		Set(fix.TagSymbol, "FIX").
+		Set(fix.TagSide, fix.SideSell).
+		SetInt(fix.TagOrderQty, 8).
+		Set(fix.TagOrdType, fix.OrdTypeLimit).
+		SetFloat(fix.TagPrice, 101))
+	er = a.expect(fix.MsgExecutionReport)
+	assert.Equal(t, fix.ExecTypeReplaced, er.String(fix.TagExecType))
+	assert.Equal(t, "a2", er.String(fix.TagClOrdID))
+	assert.Equal(t, "a1", er.String(fix.TagOrigClOrdID))
+	assert.Equal(t, "101", er.String(fix.TagPrice))
+	assert.Equal(t, "8", er.String(fix.TagLeavesQty))
+	assert.NotEqual(t, origID, er.String(fix.TagOrderID))
+
+	// A crossing order from another session fills both sides
+	b := dialFIX(t, acceptor, "BOB")
+	require.Equal(t, fix.MsgLogon, b.logon(bob).MsgType())
+	b.send(newOrderSingle("b1", fix.SideBuy, 5, 102))
+	assert.Equal(t, fix.ExecTypeNew, b.expect(fix.MsgExecutionReport).String(fix.TagExecType))
+	er = b.expect(fix.MsgExecutionReport)
+	assert.Equal(t, fix.ExecTypeTrade, er.String(fix.TagExecType))
+	assert.Equal(t, fix.OrdStatusFilled, er.String(fix.TagOrdStatus))
+	assert.Equal(t, "5", er.String(fix.TagLastQty))
+	assert.Equal(t, "101", er.String(fix.TagLastPx))
+	assert.Equal(t, "101", er.String(fix.TagAvgPx))
+	tradeID, bobExecID := er.String(fix.TagTradeID), er.String(fix.TagExecID)
+
+	er = a.expect(fix.MsgExecutionReport)
+	assert.Equal(t, fix.ExecTypeTrade, er.String(fix.TagExecType))
+	assert.Equal(t, "a2", er.String(fix.TagClOrdID))
+	assert.Equal(t, fix.OrdStatusPartiallyFilled, er.String(fix.TagOrdStatus))
+	assert.Equal(t, "5", er.String(fix.TagCumQty))
+	assert.Equal(t, "3", er.String(fix.TagLeavesQty))
+	assert.Equal(t, tradeID, er.String(fix.TagTradeID))
+	assert.NotEqual(t, bobExecID, er.String(fix.TagExecID), "each side has its own ExecID")
+
+	a.send(fix.NewMessage(fix.MsgOrderStatusRequest).Set(fix.TagClOrdID, "a2").Set(fix.TagSymbol, "FIX").Set(fix.TagSide, fix.SideSell))
+	er = a.expect(fix.MsgExecutionReport)
+	assert.Equal(t, fix.ExecTypeOrderStatus, er.String(fix.TagExecType))
+	assert.Equal(t, fix.OrdStatusPartiallyFilled, er.String(fix.TagOrdStatus))
+	assert.Equal(t, "101", er.String(fix.TagAvgPx))
+
+	a.send(cancelRequest("a3", "a2"))
+	er = a.expect(fix.MsgExecutionReport)
+	assert.Equal(t, fix.ExecTypeCanceled, er.String(fix.TagExecType))
+	assert.Equal(t, "a3", er.String(fix.TagClOrdID))
+	assert.Equal(t, "a2", er.String(fix.TagOrigClOrdID))
+	assert.Equal(t, "5", er.String(fix.TagCumQty))
+	assert.Equal(t, "0", er.String(fix.TagLeavesQty))
+
+	a.send(cancelRequest("a4", "a2"))
+	rej := a.expect(fix.MsgOrderCancelReject)
+	assert.Equal(t, fix.CxlRejTooLate, rej.String(fix.TagCxlRejReason))
+	assert.Equal(t, fix.OrdStatusCanceled, rej.String(fix.TagOrdStatus))
+	assert.Equal(t, fix.CxlRejResponseToCancel, rej.String(fix.TagCxlRejResponseTo))
+
+	// Other sessions' ClOrdIDs are unknown
+	b.send(cancelRequest("b2", "a1"))
+	rej = b.expect(fix.MsgOrderCancelReject)
+	assert.Equal(t, fix.CxlRejUnknownOrder, rej.String(fix.TagCxlRejReason))
+	assert.Equal(t, "NONE", rej.String(fix.TagOrderID))
+
+	// A ClOrdID cannot be reused while its order is open
+	a.send(newOrderSingle("a5", fix.SideSell, 1, 200))
+	a.expect(fix.MsgExecutionReport)
+	a.send(newOrderSingle("a5", fix.SideSell, 1, 200))
+	er = a.expect(fix.MsgExecutionReport)
+	assert.Equal(t, fix.ExecTypeRejected, er.String(fix.TagExecType))
+	assert.Equal(t, fix.OrdRejDuplicate, er.String(fix.TagOrdRejReason))
+}
+
+func TestFIXSessionLevelMessages(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	acceptor := startAcceptor(t, deps, nil)
+	t.Cleanup(func() { closeAcceptor(t, acceptor) })
+
+	c := dialFIX(t, acceptor, "CLIENT")
+	require.Equal(t, fix.MsgLogon, c.logon(nil).MsgType())
+
+	c.send(fix.NewMessage(fix.MsgTestRequest).Set(fix.TagTestReqID, "ping"))
+	assert.Equal(t, "ping", c.expect(fix.MsgHeartbeat).String(fix.TagTestReqID))
+
+	c.send(fix.NewMessage("B").Set(fix.TagText, "news"))
+	rej := c.expect(fix.MsgBusinessMessageReject)
+	assert.Equal(t, "B", rej.String(fix.TagRefMsgType))
+	assert.Equal(t, fix.BusinessRejectUnsupportedMsgType, rej.String(fix.TagBusinessRejectReason))
+
+	nos := newOrderSingle("", fix.SideBuy, 1, 10)
+	nos.Fields = nos.Fields[:1]
+	c.send(nos.Set(fix.TagSymbol, "FIX"))
+	rej = c.expect(fix.MsgReject)
+	assert.Equal(t, strconv.Itoa(fix.TagClOrdID), rej.String(fix.TagRefTagID))
+	assert.Equal(t, fix.SessionRejectRequiredTagMissing, rej.String(fix.TagSessionRejectReason))
+
+	// Messages lost in transit are asked for again...
+	expected := c.seq
+	c.seq += 2
+	c.send(fix.NewMessage(fix.MsgHeartbeat))
+	resend := c.expect(fix.MsgResendRequest)
+	assert.Equal(t, strconv.Itoa(expected), resend.String(fix.TagBeginSeqNo))
+	assert.Equal(t, "0", resend.String(fix.TagEndSeqNo))
+
+	// ...and a gap fill skips them
+	next := c.seq
+	c.seq = expected
+	c.send(fix.NewMessage(fix.MsgSequenceReset).Set(fix.TagPossDupFlag, "Y").Set(fix.TagGapFillFlag, "Y").SetInt(fix.TagNewSeqNo, next))
+	c.seq = next
+	c.send(fix.NewMessage(fix.MsgTestRequest).Set(fix.TagTestReqID, "in-sync"))
+	assert.Equal(t, "in-sync", c.expect(fix.MsgHeartbeat).String(fix.TagTestReqID))
+
+	c.send(fix.NewMessage(fix.MsgLogout))
+	c.expect(fix.MsgLogout)
+	c.expectClosed()
+}
+
+func TestFIXLogonIsAuthenticated(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	alice := newTestKey(t, deps, "alice", models.RoleTrader, models.ScopeTrade)
+	bob := newTestKey(t, deps, "bob", models.RoleTrader, models.ScopeTrade)
+	viewer := newTestKey(t, deps, "victor", models.RoleViewer, models.ScopeTrade)
+	reader := newTestKey(t, deps, "rita", models.RoleTrader, models.ScopeRead)
+	acceptor := startAcceptor(t, deps, deps.Auth)
+	t.Cleanup(func() { closeAcceptor(t, acceptor) })
+
+	tests := []struct {
+		name string
+		key  *models.IssuedAPIKeyResponse
+		want string
+	}{
+		{"unsigned", nil, "API key, timestamp and signature are required"},
+		{"wrong secret", &models.IssuedAPIKeyResponse{APIKey: alice.APIKey, Secret: "guess"}, "signature does not match the request"},
+		{"viewer", viewer, "account role viewer cannot trade"},
+		{"read only key", reader, "API key lacks the trade scope"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := dialFIX(t, acceptor, "ALICE")
+			reply := c.logon(tc.key)
+			require.Equal(t, fix.MsgLogout, reply.MsgType())
+			assert.Equal(t, tc.want, reply.String(fix.TagText))
+			c.expectClosed()
+		})
+	}
+
+	// A session is bound to the account that first logs on to it
+	a := dialFIX(t, acceptor, "ALICE")
+	require.Equal(t, fix.MsgLogon, a.logon(alice).MsgType())
+	a.send(fix.NewMessage(fix.MsgLogout))
+	a.expect(fix.MsgLogout)
+
+	b := dialFIX(t, acceptor, "ALICE")
+	b.seq = a.seq
+	reply := b.logon(bob)
+	require.Equal(t, fix.MsgLogout, reply.MsgType())
+	assert.Equal(t, "session belongs to another account", reply.String(fix.TagText))
+}
+
+func TestFIXSequenceNumbersSurviveRestart(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	alice := newTestKey(t, deps, "alice", models.RoleTrader, models.ScopeTrade)
+	ctx := context.Background()
+
+	acceptor := startAcceptor(t, deps, deps.Auth)
+	a := dialFIX(t, acceptor, "ALICE")
+	require.Equal(t, fix.MsgLogon, a.logon(alice).MsgType())
+	a.send(newOrderSingle("a1", fix.SideSell, 10, 100))
+	a.expect(fix.MsgExecutionReport)
+	a.send(fix.NewMessage(fix.MsgLogout))
+	a.expect(fix.MsgLogout)
+	closeAcceptor(t, acceptor)
+
+	state, err := deps.FIXRepo.GetFIXSession(ctx, nil, "ALICE")
+	require.NoError(t, err)
+	assert.Equal(t, 4, state.NextSenderSeq)
+	assert.Equal(t, 4, state.NextTargetSeq)
+	assert.Equal(t, alice.AccountID, state.AccountID)
+
+	// After a restart, a fill while Alice is away is numbered and kept
+	acceptor = startAcceptor(t, deps, deps.Auth)
+	t.Cleanup(func() { closeAcceptor(t, acceptor) })
+	_, err = deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "FIX", Side: "buy", Type: "market", Quantity: qty(4)})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		state, err := deps.FIXRepo.GetFIXSession(ctx, nil, "ALICE")
+		return err == nil && state.NextSenderSeq == 5
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Sequence numbers do not start over
+	stale := dialFIX(t, acceptor, "ALICE")
+	reply := stale.logon(alice)
+	require.Equal(t, fix.MsgLogout, reply.MsgType())
+	assert.Equal(t, "MsgSeqNum too low, expecting 4 but received 1", reply.String(fix.TagText))
+	stale.expectClosed()
+
+	a = dialFIX(t, acceptor, "ALICE")
+	a.seq = 4
+	reply = a.logon(alice)
+	require.Equal(t, fix.MsgLogon, reply.MsgType())
+	assert.Equal(t, 6, reply.SeqNum(), "the refused logon's Logout was 5")
+
+	// The missed report is resent as a possible duplicate, the rest gap filled
+	a.send(fix.NewMessage(fix.MsgResendRequest).SetInt(fix.TagBeginSeqNo, 4).SetInt(fix.TagEndSeqNo, 0))
+	er := a.expect(fix.MsgExecutionReport)
+	assert.Equal(t, 4, er.SeqNum())
+	assert.True(t, er.Bool(fix.TagPossDupFlag))
+	assert.NotEmpty(t, er.String(fix.TagOrigSendingTime))
+	assert.Equal(t, fix.ExecTypeTrade, er.String(fix.TagExecType))
+	assert.Equal(t, "a1", er.String(fix.TagClOrdID))
+	assert.Equal(t, "4", er.String(fix.TagCumQty))
+	assert.Equal(t, "6", er.String(fix.TagLeavesQty))
+	assert.Equal(t, "100", er.String(fix.TagAvgPx))
+
+	gapFill := a.expect(fix.MsgSequenceReset)
+	assert.Equal(t, 5, gapFill.SeqNum())
+	assert.True(t, gapFill.Bool(fix.TagGapFillFlag))
+	assert.Equal(t, "7", gapFill.String(fix.TagNewSeqNo))
+
+	// ClOrdIDs from before the restart still work
+	a.send(cancelRequest("a2", "a1"))
+	er = a.expect(fix.MsgExecutionReport)
+	assert.Equal(t, 7, er.SeqNum())
+	assert.Equal(t, fix.ExecTypeCanceled, er.String(fix.TagExecType))
+	assert.Equal(t, fmt.Sprint(alice.AccountID), er.String(fix.TagAccount))
+}
@@ -0,0 +1,286 @@
+package unittest
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	tradingv1 "github.com/Puneet-Vishnoi/order-matching-engine/api/trading/v1"
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+	"github.com/Puneet-Vishnoi/order-matching-engine/grpcapi"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/ratelimit"
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tests/mockdb"
+)
+
+// dialGRPC serves the gRPC API of deps over an in-memory listener and
+// returns a connection to it, with opts applied.
+func dialGRPC(t *testing.T, deps *mockdb.TestDeps, auth *service.AuthService, opts ...grpc.DialOption) *grpc.ClientConn {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpcapi.NewServer(deps.Service, auth)
+	go srv.Serve(lis)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		assert.NoError(t, srv.Shutdown(ctx))
+	})
+
+	opts = append(opts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// requireStatus checks err is a status with code and, unless reason is
+// empty, an ErrorInfo with reason; it returns the status.
+func requireStatus(t *testing.T, err error, code codes.Code, reason string) *status.Status {
+	t.Helper()
+	st, ok := status.FromError(err)
+	require.True(t, ok, "not a status: %v", err)
+	require.Equal(t, code, st.Code(), st.Message())
+	if reason != "" {
+		var found bool
+		for _, d := range st.Details() {
+			if info, ok := d.(*errdetails.ErrorInfo); ok {
+				assert.Equal(t, reason, info.Reason)
+				found = true
+			}
+		}
+		assert.True(t, found, "no ErrorInfo in %v", st.Details())
+	}
+	return st
+}
+
+func TestGRPCTrading(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	client := tradingv1.NewTradingClient(dialGRPC(t, deps, nil))
+	ctx := context.Background()
+
+	sell, err := client.PlaceOrder(ctx, &tradingv1.PlaceOrderRequest{
+		Symbol: "RPC", Side: tradingv1.Side_SIDE_SELL, Type: tradingv1.OrderType_ORDER_TYPE_LIMIT, Price: 10, QuantityDecimal: "5",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, tradingv1.OrderStatus_ORDER_STATUS_OPEN, sell.Status)
+
+	buy, err := client.PlaceOrder(ctx, &tradingv1.PlaceOrderRequest{
+		Symbol: "RPC", Side: tradingv1.Side_SIDE_BUY, Type: tradingv1.OrderType_ORDER_TYPE_LIMIT, Price: 10, QuantityDecimal: "2",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, tradingv1.OrderStatus_ORDER_STATUS_FILLED, buy.Status)
+
+	order, err := client.GetOrder(ctx, &tradingv1.GetOrderRequest{OrderId: sell.OrderId})
+	require.NoError(t, err)
+	assert.Equal(t, tradingv1.OrderStatus_ORDER_STATUS_PARTIAL, order.Status)
+	assert.Equal(t, tradingv1.Side_SIDE_SELL, order.Side)
+	assert.Equal(t, "3", order.RemainingQuantityDecimal)
+	assert.False(t, order.CreatedAt.AsTime().IsZero())
+
+	book, err := client.GetOrderBook(ctx, &tradingv1.GetOrderBookRequest{Symbol: "RPC"})
+	require.NoError(t, err)
+	assert.Empty(t, book.Bids)
+	require.Len(t, book.Asks, 1)
+	assert.Equal(t, 10.0, book.Asks[0].Price)
+	assert.Equal(t, "3", book.Asks[0].QuantityDecimal)
+
+	trades, err := client.ListTrades(ctx, &tradingv1.ListTradesRequest{Symbol: "RPC"})
+	require.NoError(t, err)
+	require.Len(t, trades.Trades, 1)
+	assert.Equal(t, buy.OrderId, trades.Trades[0].BuyOrderId)
+	assert.Equal(t, sell.OrderId, trades.Trades[0].SellOrderId)
+	assert.Equal(t, "RPC", trades.Trades[0].Symbol)
+
+	list, err := client.ListOrders(ctx, &tradingv1.ListOrdersRequest{Symbol: "RPC"})
+	require.NoError(t, err)
+	require.Len(t, list.Orders, 2)
+	assert.Equal(t, buy.OrderId, list.Orders[0].Id, "newest first")
+	list, err = client.ListOrders(ctx, &tradingv1.ListOrdersRequest{Status: tradingv1.OrderStatus_ORDER_STATUS_PARTIAL})
+	require.NoError(t, err)
+	require.Len(t, list.Orders, 1)
+	assert.Equal(t, sell.OrderId, list.Orders[0].Id)
+
+	_, err = client.CancelOrder(ctx, &tradingv1.CancelOrderRequest{OrderId: sell.OrderId})
+	require.NoError(t, err)
+
+	// Domain errors map to status codes, with the stable code as reason
+	_, err = client.CancelOrder(ctx, &tradingv1.CancelOrderRequest{OrderId: buy.OrderId})
+	requireStatus(t, err, codes.FailedPrecondition, "order_not_cancelable")
+	_, err = client.GetOrder(ctx, &tradingv1.GetOrderRequest{OrderId: 999})
+	requireStatus(t, err, codes.NotFound, "order_not_found")
+	_, err = client.PlaceOrder(ctx, &tradingv1.PlaceOrderRequest{Symbol: "RPC", Type: tradingv1.OrderType_ORDER_TYPE_LIMIT, Price: 10, QuantityDecimal: "1"})
+	st := requireStatus(t, err, codes.InvalidArgument, "validation_failed")
+	assert.Contains(t, st.Message(), "Side")
+	_, err = client.ListTrades(ctx, &tradingv1.ListTradesRequest{})
+	requireStatus(t, err, codes.InvalidArgument, "symbol_required")
+	_, err = client.ListOrders(ctx, &tradingv1.ListOrdersRequest{Limit: 5000})
+	requireStatus(t, err, codes.InvalidArgument, "invalid_request")
+}
+
+func TestGRPCAuthentication(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	alice := newTestKey(t, deps, "alice", models.RoleTrader, models.ScopeRead, models.ScopeTrade)
+	bob := newTestKey(t, deps, "bob", models.RoleTrader, models.ScopeRead, models.ScopeCancel)
+	viewer := newTestKey(t, deps, "viewer", models.RoleViewer, models.ScopeRead, models.ScopeTrade)
+	ctx := context.Background()
+	place := &tradingv1.PlaceOrderRequest{
+		Symbol: "AUTH", Side: tradingv1.Side_SIDE_BUY, Type: tradingv1.OrderType_ORDER_TYPE_LIMIT, Price: 10, QuantityDecimal: "1",
+	}
+
+	unsigned := tradingv1.NewTradingClient(dialGRPC(t, deps, deps.Auth))
+	_, err := unsigned.PlaceOrder(ctx, place)
+	requireStatus(t, err, codes.Unauthenticated, "unauthenticated")
+
+	// The signature covers the request message
+	signed, err := grpcapi.SignContext(ctx, alice.KeyID, alice.Secret, tradingv1.Trading_PlaceOrder_FullMethodName, place)
+	require.NoError(t, err)
+	_, err = unsigned.PlaceOrder(signed, &tradingv1.PlaceOrderRequest{
+		Symbol: "AUTH", Side: tradingv1.Side_SIDE_BUY, Type: tradingv1.OrderType_ORDER_TYPE_LIMIT, Price: 10, QuantityDecimal: "1000",
+	})
+	requireStatus(t, err, codes.Unauthenticated, "invalid_signature")
+
+	aliceClient := tradingv1.NewTradingClient(dialGRPC(t, deps, deps.Auth, grpc.WithUnaryInterceptor(grpcapi.SigningInterceptor(alice.KeyID, alice.Secret))))
+	placed, err := aliceClient.PlaceOrder(ctx, place)
+	require.NoError(t, err)
+	order, err := aliceClient.GetOrder(ctx, &tradingv1.GetOrderRequest{OrderId: placed.OrderId})
+	require.NoError(t, err)
+	assert.Equal(t, alice.AccountID, order.AccountId)
+
+	// Other accounts neither see nor cancel the order
+	bobClient := tradingv1.NewTradingClient(dialGRPC(t, deps, deps.Auth, grpc.WithUnaryInterceptor(grpcapi.SigningInterceptor(bob.KeyID, bob.Secret))))
+	_, err = bobClient.GetOrder(ctx, &tradingv1.GetOrderRequest{OrderId: placed.OrderId})
+	requireStatus(t, err, codes.NotFound, "order_not_found")
+	_, err = bobClient.CancelOrder(ctx, &tradingv1.CancelOrderRequest{OrderId: placed.OrderId})
+	requireStatus(t, err, codes.NotFound, "order_not_found")
+	_, err = bobClient.PlaceOrder(ctx, place)
+	requireStatus(t, err, codes.PermissionDenied, "insufficient_scope")
+	list, err := bobClient.ListOrders(ctx, &tradingv1.ListOrdersRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, list.Orders)
+
+	// Viewers read but do not trade, whatever their key's scopes
+	viewerClient := tradingv1.NewTradingClient(dialGRPC(t, deps, deps.Auth, grpc.WithUnaryInterceptor(grpcapi.SigningInterceptor(viewer.KeyID, viewer.Secret))))
+	_, err = viewerClient.GetOrderBook(ctx, &tradingv1.GetOrderBookRequest{Symbol: "AUTH"})
+	require.NoError(t, err)
+	_, err = viewerClient.PlaceOrder(ctx, place)
+	requireStatus(t, err, codes.PermissionDenied, "insufficient_role")
+}
+
+func TestGRPCWholeQuantityClients(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	deps.Service.Engine.Symbols = map[string]config.SymbolConfig{"OLD": {QuantityPrecision: decimals(1)}}
+	client := tradingv1.NewTradingClient(dialGRPC(t, deps, nil))
+	ctx := context.Background()
+
+	// Clients built before quantities were decimal send and read whole units
+	sell, err := client.PlaceOrder(ctx, &tradingv1.PlaceOrderRequest{
+		Symbol: "OLD", Side: tradingv1.Side_SIDE_SELL, Type: tradingv1.OrderType_ORDER_TYPE_LIMIT, Price: 10, Quantity: 5,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), sell.RemainingQuantity)
+	assert.Equal(t, "5", sell.RemainingQuantityDecimal)
+
+	_, err = client.PlaceOrder(ctx, &tradingv1.PlaceOrderRequest{
+		Symbol: "OLD", Side: tradingv1.Side_SIDE_BUY, Type: tradingv1.OrderType_ORDER_TYPE_LIMIT, Price: 10, QuantityDecimal: "1.5",
+	})
+	require.NoError(t, err)
+	order, err := client.GetOrder(ctx, &tradingv1.GetOrderRequest{OrderId: sell.OrderId})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), order.RemainingQuantity, "truncated")
+	assert.Equal(t, "3.5", order.RemainingQuantityDecimal)
+
+	_, err = client.PlaceOrder(ctx, &tradingv1.PlaceOrderRequest{
+		Symbol: "OLD", Side: tradingv1.Side_SIDE_BUY, Type: tradingv1.OrderType_ORDER_TYPE_LIMIT, Price: 10, Quantity: 1, QuantityDecimal: "1",
+	})
+	requireStatus(t, err, codes.InvalidArgument, "validation_failed")
+}
+
+func TestGRPCRateLimits(t *testing.T) {
+	now := time.Now()
+	deps := newLimitedDeps(config.RateLimitConfig{Place: ratelimit.Limit{Rate: 0.5, Burst: 1}}, &now)
+	alice := newTestKey(t, deps, "alice", models.RoleTrader, models.ScopeTrade)
+	client := tradingv1.NewTradingClient(dialGRPC(t, deps, deps.Auth, grpc.WithUnaryInterceptor(grpcapi.SigningInterceptor(alice.KeyID, alice.Secret))))
+	place := func(quantity string) error {
+		_, err := client.PlaceOrder(context.Background(), &tradingv1.PlaceOrderRequest{
+			Symbol: "RATE", Side: tradingv1.Side_SIDE_BUY, Type: tradingv1.OrderType_ORDER_TYPE_LIMIT, Price: 10, QuantityDecimal: quantity,
+		})
+		return err
+	}
+
+	require.NoError(t, place("1"))
+	st := requireStatus(t, place("2"), codes.ResourceExhausted, "rate_limited")
+	var retry *errdetails.RetryInfo
+	for _, d := range st.Details() {
+		if r, ok := d.(*errdetails.RetryInfo); ok {
+			retry = r
+		}
+	}
+	require.NotNil(t, retry)
+	assert.Equal(t, 2*time.Second, retry.RetryDelay.AsDuration())
+}
+
+func TestGRPCMarketDataStreams(t *testing.T) {
+	mr := miniredis.RunT(t)
+	deps := newMarketDataDeps(t, mr)
+	conn := dialGRPC(t, deps, nil)
+	client := tradingv1.NewMarketDataClient(conn)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := mustStream(client.StreamOrderBook(ctx, &tradingv1.StreamOrderBookRequest{}))
+	requireStatus(t, err, codes.InvalidArgument, "symbol_required")
+
+	books, err := client.StreamOrderBook(ctx, &tradingv1.StreamOrderBookRequest{Symbol: "GRPC"})
+	require.NoError(t, err)
+	update, err := books.Recv()
+	require.NoError(t, err)
+	assert.True(t, update.Snapshot)
+	trades, err := client.StreamTrades(ctx, &tradingv1.StreamTradesRequest{Symbol: "GRPC"})
+	require.NoError(t, err)
+	_, err = trades.Header() // sent once subscribed
+	require.NoError(t, err)
+
+	_, err = deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "GRPC", Side: "sell", Type: "limit", Price: 7, Quantity: qty(3)})
+	require.NoError(t, err)
+	update, err = books.Recv()
+	require.NoError(t, err)
+	assert.False(t, update.Snapshot)
+	require.Len(t, update.Asks, 1)
+	assert.Equal(t, 7.0, update.Asks[0].Price)
+	assert.Equal(t, "3", update.Asks[0].QuantityDecimal)
+
+	_, err = deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "GRPC", Side: "buy", Type: "limit", Price: 7, Quantity: qty(1)})
+	require.NoError(t, err)
+	trade, err := trades.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "GRPC", trade.Symbol)
+	assert.Equal(t, "1", trade.QuantityDecimal)
+	assert.Equal(t, 7.0, trade.Price)
+
+	// The match changed the book too
+	update, err = books.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "2", update.Asks[0].QuantityDecimal)
+}
+
+// mustStream returns the first message of a stream, or the error that
+// opened or ended it.
+func mustStream[T any](stream grpc.ServerStreamingClient[T], err error) (*T, error) {
+	if err != nil {
+		return nil, err
+	}
+	return stream.Recv()
+}
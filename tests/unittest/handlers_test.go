@@ -0,0 +1,85 @@
+package unittest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/routes"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tests/mockdb"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRouter(deps *mockdb.TestDeps) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	routes.RegisterRoutes(router, deps.Service, nil, nil, nil)
+	return router
+}
+
+func doRequest(router http.Handler, method, path string, body any, headers map[string]string) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	router := newTestRouter(deps)
+
+	// A filled order that can no longer be canceled
+	doRequest(router, http.MethodPost, "/api/orders", models.PlaceOrderRequest{Symbol: "ERR", Side: "sell", Type: "limit", Price: 10, Quantity: qty(1)}, nil)
+	filled := doRequest(router, http.MethodPost, "/api/orders", models.PlaceOrderRequest{Symbol: "ERR", Side: "buy", Type: "market", Quantity: qty(1)}, nil)
+	var filledResp models.PlaceOrderResponse
+	require.NoError(t, json.Unmarshal(filled.Body.Bytes(), &filledResp))
+	require.Equal(t, "filled", filledResp.Status)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       any
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{"Malformed Body", http.MethodPost, "/api/orders", "not an order", http.StatusBadRequest, "invalid_request", ""},
+		{"Validation Failure", http.MethodPost, "/api/orders", models.PlaceOrderRequest{Side: "buy", Type: "limit", Price: 1, Quantity: qty(1)}, http.StatusBadRequest, "validation_failed", "Symbol"},
+		{"Invalid Order ID", http.MethodGet, "/api/orders/abc", nil, http.StatusBadRequest, "invalid_order_id", ""},
+		{"Order Not Found", http.MethodGet, "/api/orders/99999", nil, http.StatusNotFound, "order_not_found", ""},
+		{"Cancel Not Found", http.MethodDelete, "/api/orders/99999", nil, http.StatusNotFound, "order_not_found", ""},
+		{"Cancel Filled Order", http.MethodDelete, "/api/orders/2", nil, http.StatusConflict, "order_not_cancelable", ""},
+		{"Missing Symbol", http.MethodGet, "/api/trades", nil, http.StatusBadRequest, "symbol_required", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doRequest(router, tc.method, tc.path, tc.body, map[string]string{"X-Request-ID": "req-" + tc.wantCode})
+
+			assert.Equal(t, tc.wantStatus, w.Code)
+			var resp models.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tc.wantCode, resp.Error.Code)
+			assert.NotEmpty(t, resp.Error.Message)
+			assert.Equal(t, "req-"+tc.wantCode, resp.Error.RequestID)
+			assert.Equal(t, "req-"+tc.wantCode, w.Header().Get("X-Request-ID"))
+			if tc.wantDetail != "" {
+				assert.Contains(t, resp.Error.Details, tc.wantDetail)
+			}
+		})
+	}
+}
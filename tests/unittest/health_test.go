@@ -0,0 +1,63 @@
+package unittest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/health"
+	"github.com/Puneet-Vishnoi/order-matching-engine/routes"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthProbes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	checker := health.NewChecker()
+	dbErr := errors.New("connection refused")
+	var failing bool
+	checker.AddCheck("postgres", func(ctx context.Context) error {
+		if failing {
+			return dbErr
+		}
+		return nil
+	})
+	routes.RegisterHealthRoutes(router, checker)
+
+	readyz := func() (int, health.Response) {
+		w := doRequest(router, http.MethodGet, "/readyz", nil, nil)
+		var resp health.Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w.Code, resp
+	}
+
+	// Liveness never depends on dependencies or warm-up
+	assert.Equal(t, http.StatusOK, doRequest(router, http.MethodGet, "/healthz", nil, nil).Code)
+
+	code, resp := readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "in progress", resp.Checks["warmup"])
+
+	checker.MarkWarm()
+	code, resp = readyz()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", resp.Status)
+	assert.Equal(t, "ok", resp.Checks["postgres"])
+
+	failing = true
+	code, resp = readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not_ready", resp.Status)
+	assert.Equal(t, "failed", resp.Checks["postgres"], "errors stay in the server log")
+
+	failing = false
+	checker.StartDraining()
+	code, resp = readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "draining", resp.Checks["shutdown"])
+	assert.Equal(t, http.StatusOK, doRequest(router, http.MethodGet, "/healthz", nil, nil).Code)
+}
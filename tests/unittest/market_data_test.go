@@ -0,0 +1,162 @@
+package unittest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/cache/redis/providers"
+	"github.com/Puneet-Vishnoi/order-matching-engine/marketdata"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tests/mockdb"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMarketDataDeps returns services that publish to the Redis of mr, as one
+// API instance of several sharing it.
+func newMarketDataDeps(t *testing.T, mr *miniredis.Miniredis) *mockdb.TestDeps {
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	helper, err := providers.NewRedisProvider(client)
+	require.NoError(t, err)
+
+	deps := mockdb.GetMemoryTestInstance()
+	feed := marketdata.NewFeed(helper, deps.Service.LoadOrderBook)
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, feed.Start(ctx))
+	t.Cleanup(func() {
+		cancel()
+		<-feed.Done()
+		client.Close()
+	})
+	deps.Service.MarketData = feed
+	return deps
+}
+
+func nextEvent(t *testing.T, events <-chan models.MarketDataEvent) models.MarketDataEvent {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "stream closed")
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("no market data event")
+		return models.MarketDataEvent{}
+	}
+}
+
+func TestMarketDataPublishesLevelChangesAndTrades(t *testing.T) {
+	mr := miniredis.RunT(t)
+	deps := newMarketDataDeps(t, mr)
+	ctx := context.Background()
+
+	events, err := deps.Service.MarketData.Subscribe(ctx, "MD")
+	require.NoError(t, err)
+	snapshot := nextEvent(t, events)
+	assert.Equal(t, models.MarketDataSnapshot, snapshot.Type)
+	assert.Empty(t, snapshot.Asks)
+
+	sell := func(price, quantity float64) *models.PlaceOrderResponse {
+		resp, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "MD", Side: "sell", Type: "limit", Price: price, Quantity: qty(quantity)})
+		require.NoError(t, err)
+		return resp
+	}
+	sell(10, 5)
+	update := nextEvent(t, events)
+	assert.Equal(t, models.MarketDataBook, update.Type)
+	assert.Equal(t, snapshot.Sequence+1, update.Sequence)
+	assert.Equal(t, []models.OrderBookEntry{{Price: 10, Quantity: qty(5)}}, update.Asks)
+
+	// A fill publishes the trade, then the level it reduced
+	_, err = deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "MD", Side: "buy", Type: "market", Quantity: qty(2)})
+	require.NoError(t, err)
+	trade := nextEvent(t, events)
+	assert.Equal(t, models.MarketDataTrade, trade.Type)
+	require.NotNil(t, trade.Trade)
+	assertQty(t, 2, trade.Trade.Quantity)
+	update = nextEvent(t, events)
+	assert.Equal(t, snapshot.Sequence+2, update.Sequence)
+	assert.Equal(t, []models.OrderBookEntry{{Price: 10, Quantity: qty(3)}}, update.Asks)
+
+	// Top of book and depth are cached for other instances
+	key := "md:book:MD"
+	assert.Equal(t, "10", mr.HGet(key, "best_ask"))
+	assert.Equal(t, "3", mr.HGet(key, "best_ask_qty"))
+	assert.Equal(t, "", mr.HGet(key, "best_bid"))
+	assert.JSONEq(t, `[{"price":10,"quantity":"3"}]`, mr.HGet(key, "asks"))
+
+	// A removed level is published with quantity 0
+	resting := sell(12, 1)
+	update = nextEvent(t, events)
+	assert.Equal(t, []models.OrderBookEntry{{Price: 12, Quantity: qty(1)}}, update.Asks)
+	_, err = deps.Service.CancelOrder(ctx, strconv.FormatInt(resting.OrderID, 10))
+	require.NoError(t, err)
+	update = nextEvent(t, events)
+	assert.Equal(t, []models.OrderBookEntry{{Price: 12, Quantity: qty(0)}}, update.Asks)
+}
+
+func TestOrderBookServedFromCache(t *testing.T) {
+	mr := miniredis.RunT(t)
+	owner := newMarketDataDeps(t, mr)
+	// Another instance, whose own storage knows nothing of the owner's orders
+	replica := newMarketDataDeps(t, mr)
+	replica.Service.ServeBookFromCache = true
+
+	events, err := owner.Service.MarketData.Subscribe(context.Background(), "CACHE")
+	require.NoError(t, err)
+	nextEvent(t, events)
+	_, err = owner.Service.PlaceOrder(context.Background(), &models.PlaceOrderRequest{Symbol: "CACHE", Side: "buy", Type: "limit", Price: 9.5, Quantity: qty(4)})
+	require.NoError(t, err)
+	nextEvent(t, events)
+
+	w := doRequest(newTestRouter(replica), http.MethodGet, "/api/orderbook?symbol=CACHE", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var book models.OrderBookResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &book))
+	assert.Equal(t, "CACHE", book.Symbol)
+	assert.Equal(t, []models.OrderBookEntry{{Price: 9.5, Quantity: qty(4)}}, book.Bids)
+	assert.Positive(t, book.Sequence)
+
+	// Storage is still the source of truth where the cache is off
+	book2, err := replica.Service.LoadOrderBook(context.Background(), "CACHE")
+	require.NoError(t, err)
+	assert.Empty(t, book2.Bids)
+}
+
+func TestStreamOverWebSocket(t *testing.T) {
+	mr := miniredis.RunT(t)
+	owner := newMarketDataDeps(t, mr)
+	replica := newMarketDataDeps(t, mr)
+	server := httptest.NewServer(newTestRouter(replica))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/stream"
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?symbol=WS", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	var event models.MarketDataEvent
+	require.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, models.MarketDataSnapshot, event.Type)
+
+	// Orders placed on one instance stream from every other
+	_, err = owner.Service.PlaceOrder(context.Background(), &models.PlaceOrderRequest{Symbol: "WS", Side: "sell", Type: "limit", Price: 7, Quantity: qty(3)})
+	require.NoError(t, err)
+	require.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, models.MarketDataBook, event.Type)
+	assert.Equal(t, "WS", event.Symbol)
+	assert.Equal(t, []models.OrderBookEntry{{Price: 7, Quantity: qty(3)}}, event.Asks)
+}
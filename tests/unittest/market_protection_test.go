@@ -0,0 +1,92 @@
+package unittest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tests/mockdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarketOrderProtection(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	deps.Service.Engine.Symbols = map[string]config.SymbolConfig{"PRT": {MarketProtectionPct: 1}}
+	ctx := context.Background()
+
+	rest := func(symbol, side string, prices ...float64) {
+		t.Helper()
+		for _, price := range prices {
+			_, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: symbol, Side: side, Type: "limit", Price: price, Quantity: qty(1)})
+			require.NoError(t, err)
+		}
+	}
+
+	t.Run("Max Slippage", func(t *testing.T) {
+		rest("SLP", "sell", 100, 101, 103)
+
+		// 2% above the best ask at arrival is 102
+		resp, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "SLP", Side: "buy", Type: "market", Quantity: qty(3), MaxSlippagePct: 2})
+		require.NoError(t, err)
+		assert.Equal(t, "canceled", resp.Status)
+		assertQty(t, 1, resp.RemainingQuantity)
+		assert.Equal(t, 100.5, resp.AveragePrice)
+		assert.Equal(t, models.ReasonSlippage, resp.CancelReason)
+		requireCanceled(t, deps, resp.OrderID, models.ReasonSlippage)
+
+		book, err := deps.Service.GetOrderBook(ctx, "SLP")
+		require.NoError(t, err)
+		require.Len(t, book.Asks, 1)
+		assert.Equal(t, 103.0, book.Asks[0].Price)
+	})
+
+	t.Run("Protection Price", func(t *testing.T) {
+		rest("SLP", "buy", 50, 49)
+
+		resp, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "SLP", Side: "sell", Type: "market", Quantity: qty(2), ProtectionPrice: 49.5})
+		require.NoError(t, err)
+		assert.Equal(t, "canceled", resp.Status)
+		assert.Equal(t, 50.0, resp.AveragePrice)
+		assert.Equal(t, models.ReasonSlippage, resp.CancelReason)
+	})
+
+	t.Run("Symbol Default", func(t *testing.T) {
+		rest("PRT", "sell", 10, 10.2, 10.2)
+
+		resp, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "PRT", Side: "buy", Type: "market", Quantity: qty(2)})
+		require.NoError(t, err)
+		assert.Equal(t, "canceled", resp.Status)
+		assert.Equal(t, 10.0, resp.AveragePrice)
+
+		// The order's own max slippage overrides it
+		resp, err = deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "PRT", Side: "buy", Type: "market", Quantity: qty(2), MaxSlippagePct: 5})
+		require.NoError(t, err)
+		assert.Equal(t, "filled", resp.Status)
+		assert.Equal(t, 10.2, resp.AveragePrice)
+		assert.Empty(t, resp.CancelReason)
+	})
+
+	t.Run("No Liquidity", func(t *testing.T) {
+		resp, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "EMPTY", Side: "buy", Type: "market", Quantity: qty(1), MaxSlippagePct: 1})
+		require.NoError(t, err)
+		assert.Equal(t, "canceled", resp.Status)
+		assert.Equal(t, models.ReasonNoLiquidity, resp.CancelReason)
+		assert.Zero(t, resp.AveragePrice)
+	})
+
+	t.Run("Market Orders Only", func(t *testing.T) {
+		err := service.ValidateOrder(&models.PlaceOrderRequest{Symbol: "SLP", Side: "buy", Type: "limit", Price: 10, Quantity: qty(1), MaxSlippagePct: 1})
+		assert.Equal(t, apperr.CodeValidationFailed, apperr.CodeOf(err))
+
+		router := newTestRouter(deps)
+		w := doRequest(router, http.MethodPost, "/api/orders", models.PlaceOrderRequest{Symbol: "SLP", Side: "buy", Type: "limit", Price: 10, Quantity: qty(1), ProtectionPrice: 11}, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = doRequest(router, http.MethodPost, "/api/orders", models.PlaceOrderRequest{Symbol: "SLP", Side: "buy", Type: "market", Quantity: qty(1), MaxSlippagePct: 100}, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
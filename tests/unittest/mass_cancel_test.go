@@ -0,0 +1,107 @@
+package unittest
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tests/mockdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMassCancel(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	router := newAuthRouter(deps)
+	alice := newTestKey(t, deps, "alice", models.RoleTrader, models.ScopeRead, models.ScopeTrade, models.ScopeCancel)
+	bob := newTestKey(t, deps, "bob", models.RoleTrader, models.ScopeRead, models.ScopeTrade, models.ScopeCancel)
+	operator := newTestKey(t, deps, "night-shift", models.RoleOperator, models.ScopeAdmin)
+	now := time.Now()
+
+	place := func(key *models.IssuedAPIKeyResponse, symbol, side string, price float64) int64 {
+		w := doSignedRequest(router, key, http.MethodPost, "/api/orders", models.PlaceOrderRequest{Symbol: symbol, Side: side, Type: "limit", Price: price, Quantity: qty(2)}, now)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		return decode[models.PlaceOrderResponse](t, w).OrderID
+	}
+	aliceBid := place(alice, "MCX", "buy", 9)
+	aliceAsk := place(alice, "MCX", "sell", 11)
+	aliceOther := place(alice, "MCY", "sell", 11)
+	bobAsk := place(bob, "MCX", "sell", 12)
+	// Partially filled orders rest too
+	doSignedRequest(router, bob, http.MethodPost, "/api/orders", models.PlaceOrderRequest{Symbol: "MCX", Side: "buy", Type: "market", Quantity: qty(1)}, now)
+
+	t.Run("Own Orders By Symbol And Side", func(t *testing.T) {
+		w := doSignedRequest(router, alice, http.MethodDelete, "/api/orders?symbol=MCX&side=sell", nil, now)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		resp := decode[models.MassCancelResponse](t, w)
+		assert.Equal(t, []int64{aliceAsk}, resp.OrderIDs)
+
+		w = doSignedRequest(router, alice, http.MethodGet, "/api/orders/"+strconv.FormatInt(aliceAsk, 10)+"/events", nil, now)
+		events := decode[models.OrderEventsResponse](t, w).Events
+		require.NotEmpty(t, events)
+		last := events[len(events)-1]
+		assert.Equal(t, models.OrderEventCanceled, last.Type)
+		assert.Equal(t, models.ReasonMassCancel, last.Reason)
+		assert.Equal(t, "partial", last.PrevStatus)
+	})
+
+	t.Run("Every Own Order", func(t *testing.T) {
+		w := doSignedRequest(router, alice, http.MethodDelete, "/api/orders", nil, now)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		resp := decode[models.MassCancelResponse](t, w)
+		assert.Equal(t, 2, resp.Canceled)
+		assert.Equal(t, []int64{aliceBid, aliceOther}, resp.OrderIDs)
+
+		// Nothing left to cancel
+		w = doSignedRequest(router, alice, http.MethodDelete, "/api/orders", nil, now.Add(time.Second))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Empty(t, decode[models.MassCancelResponse](t, w).OrderIDs)
+	})
+
+	t.Run("Invalid Side", func(t *testing.T) {
+		w := doSignedRequest(router, bob, http.MethodDelete, "/api/orders?side=hold", nil, now)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "invalid_order_side", errorCode(t, w))
+	})
+
+	t.Run("Operator Pulls A Book", func(t *testing.T) {
+		w := doSignedRequest(router, operator, http.MethodDelete, "/admin/orders", nil, now)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "symbol_required", errorCode(t, w))
+
+		w = doSignedRequest(router, bob, http.MethodDelete, "/admin/orders?symbol=MCX", nil, now)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = doSignedRequest(router, operator, http.MethodDelete, "/admin/orders?symbol=MCX", nil, now)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, []int64{bobAsk}, decode[models.MassCancelResponse](t, w).OrderIDs)
+
+		book := doSignedRequest(router, bob, http.MethodGet, "/api/orderbook?symbol=MCX", nil, now)
+		resp := decode[models.OrderBookResponse](t, book)
+		assert.Empty(t, resp.Bids)
+		assert.Empty(t, resp.Asks)
+	})
+}
+
+func TestMassCancelWithoutAuthNeedsAFilter(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	router := newTestRouter(deps)
+	for _, symbol := range []string{"MCA", "MCB"} {
+		w := doRequest(router, http.MethodPost, "/api/orders", models.PlaceOrderRequest{Symbol: symbol, Side: "buy", Type: "limit", Price: 10, Quantity: qty(1)}, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	w := doRequest(router, http.MethodDelete, "/api/orders", nil, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "filter_required", errorCode(t, w))
+
+	w = doRequest(router, http.MethodDelete, "/api/orders?symbol=MCA", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 1, decode[models.MassCancelResponse](t, w).Canceled)
+
+	// The other book is untouched
+	book := decode[models.OrderBookResponse](t, doRequest(router, http.MethodGet, "/api/orderbook?symbol=MCB", nil, nil))
+	assert.Len(t, book.Bids, 1)
+}
@@ -0,0 +1,130 @@
+package unittest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository/memory"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMemoryOrder(symbol, side string, price, quantity float64) *models.Order {
+	return &models.Order{
+		Symbol:       symbol,
+		Side:         side,
+		Type:         "limit",
+		Price:        price,
+		Quantity:     qty(quantity),
+		RemainingQty: qty(quantity),
+		Status:       "open",
+		CreatedAt:    time.Now(),
+	}
+}
+
+func TestMemoryStoreTransactions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Rollback Discards Writes", func(t *testing.T) {
+		store := memory.NewStore()
+		orders := memory.NewOrderRepository(store)
+
+		tx, err := store.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		id, err := orders.CreateOrder(ctx, tx, newMemoryOrder("MEM", "buy", 10, 5))
+		require.NoError(t, err)
+
+		order, err := orders.GetOrderByID(ctx, tx, id)
+		require.NoError(t, err)
+		order.Status = "partial"
+		order.RemainingQty = qty(2)
+		require.NoError(t, orders.UpdateOrder(ctx, tx, order))
+		require.NoError(t, tx.Rollback())
+
+		_, err = orders.GetOrderByID(ctx, nil, id)
+		assert.ErrorContains(t, err, "not found")
+	})
+
+	t.Run("Commit Persists Writes", func(t *testing.T) {
+		store := memory.NewStore()
+		orders := memory.NewOrderRepository(store)
+
+		tx, err := store.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		id, err := orders.CreateOrder(ctx, tx, newMemoryOrder("MEM", "sell", 10, 5))
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+		assert.ErrorIs(t, tx.Rollback(), sql.ErrTxDone)
+
+		order, err := orders.GetOrderByID(ctx, nil, id)
+		require.NoError(t, err)
+		assert.Equal(t, "open", order.Status)
+	})
+
+	t.Run("Rollback Restores Updated Order", func(t *testing.T) {
+		store := memory.NewStore()
+		orders := memory.NewOrderRepository(store)
+
+		id, err := orders.CreateOrder(ctx, nil, newMemoryOrder("MEM", "sell", 10, 5))
+		require.NoError(t, err)
+
+		tx, err := store.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		order, err := orders.GetOrderByID(ctx, tx, id)
+		require.NoError(t, err)
+		order.Status = "canceled"
+		order.RemainingQty = decimal.Zero
+		require.NoError(t, orders.UpdateOrder(ctx, tx, order))
+		require.NoError(t, tx.Rollback())
+
+		order, err = orders.GetOrderByID(ctx, nil, id)
+		require.NoError(t, err)
+		assert.Equal(t, "open", order.Status)
+		assertQty(t, 5, order.RemainingQty)
+	})
+
+	t.Run("Read Only Transaction Rejects Writes", func(t *testing.T) {
+		store := memory.NewStore()
+		orders := memory.NewOrderRepository(store)
+
+		tx, err := store.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		_, err = orders.CreateOrder(ctx, tx, newMemoryOrder("MEM", "buy", 10, 5))
+		assert.ErrorContains(t, err, "read-only")
+	})
+
+	t.Run("Transactions Are Serialized", func(t *testing.T) {
+		store := memory.NewStore()
+
+		tx, err := store.BeginTx(ctx, nil)
+		require.NoError(t, err)
+
+		waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err = store.BeginTx(waitCtx, nil)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		require.NoError(t, tx.Commit())
+		tx, err = store.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+	})
+
+	t.Run("Foreign Transaction Rejected", func(t *testing.T) {
+		storeA, storeB := memory.NewStore(), memory.NewStore()
+		orders := memory.NewOrderRepository(storeB)
+
+		tx, err := storeA.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		_, err = orders.CreateOrder(ctx, tx, newMemoryOrder("MEM", "buy", 10, 5))
+		assert.Error(t, err)
+	})
+}
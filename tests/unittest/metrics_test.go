@@ -0,0 +1,97 @@
+package unittest
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+	"github.com/Puneet-Vishnoi/order-matching-engine/metrics"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tests/mockdb"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/lib/pq"
+)
+
+func TestEngineMetrics(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	deps.Service.Engine.Symbols = map[string]config.SymbolConfig{"MET": {}}
+	m := deps.Service.Metrics
+	ctx := context.Background()
+
+	for _, req := range []models.PlaceOrderRequest{
+		{Symbol: "MET", Side: "sell", Type: "limit", Price: 10, Quantity: qty(5)},
+		{Symbol: "MET", Side: "sell", Type: "limit", Price: 11, Quantity: qty(5)},
+		{Symbol: "MET", Side: "buy", Type: "limit", Price: 9, Quantity: qty(2)},
+		{Symbol: "MET", Side: "buy", Type: "market", Quantity: qty(7)},
+		// Unlisted symbols share one series
+		{Symbol: "UNL1", Side: "sell", Type: "limit", Price: 10, Quantity: qty(1)},
+		{Symbol: "UNL2", Side: "sell", Type: "limit", Price: 20, Quantity: qty(4)},
+		{Symbol: "UNL2", Side: "buy", Type: "limit", Price: 20, Quantity: qty(1)},
+	} {
+		_, err := deps.Service.PlaceOrder(ctx, &req)
+		require.NoError(t, err)
+	}
+	_, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "MET", Side: "hold", Type: "limit", Price: 1, Quantity: qty(1)})
+	require.Error(t, err)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.OrdersPlaced.WithLabelValues("MET", "sell", "limit")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.OrdersPlaced.WithLabelValues("MET", "buy", "market")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.Trades.WithLabelValues("MET")))
+	assert.Equal(t, 7.0, testutil.ToFloat64(m.TradedQuantity.WithLabelValues("MET")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.OrdersPlaced.WithLabelValues("other", "sell", "limit")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Trades.WithLabelValues("other")))
+	assert.Equal(t, 5, testutil.CollectAndCount(m.OrdersPlaced), "MET and other only")
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Rejections.WithLabelValues("constraint_violation")))
+	assert.Equal(t, 5, testutil.CollectAndCount(m.PlaceOrderStageSeconds), "db_insert, match, trade_insert, commit and total")
+
+	expected := `
+# HELP order_engine_book_depth_quantity Resting quantity, by symbol and side.
+# TYPE order_engine_book_depth_quantity gauge
+order_engine_book_depth_quantity{side="buy",symbol="MET"} 2
+order_engine_book_depth_quantity{side="sell",symbol="MET"} 3
+order_engine_book_depth_quantity{side="sell",symbol="other"} 4
+`
+	require.NoError(t, testutil.GatherAndCompare(m.Registry, strings.NewReader(expected), "order_engine_book_depth_quantity"))
+}
+
+func TestHTTPMetrics(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	router := newTestRouter(deps)
+
+	doRequest(router, http.MethodGet, "/api/orders/1", nil, nil)
+	doRequest(router, http.MethodGet, "/api/orders/abc", nil, nil)
+	doRequest(router, http.MethodGet, "/api/orderbook?symbol=MET", nil, nil)
+
+	m := deps.Service.Metrics
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.HTTPRequests.WithLabelValues("/api/orders/:id", "GET", "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.HTTPRequests.WithLabelValues("/api/orders/:id", "GET", "400")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.HTTPRequests.WithLabelValues("/api/orderbook", "GET", "200")))
+
+	w := doRequest(router, http.MethodGet, "/metrics", nil, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "order_engine_transactions_total")
+}
+
+func TestDBStatsMetrics(t *testing.T) {
+	// sql.Open does not connect, so the pool reports its configured limits only
+	db, err := sql.Open("postgres", "host=localhost dbname=orders")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(7)
+
+	m := metrics.New()
+	m.RegisterDBStats(db, "orders")
+
+	expected := `
+# HELP go_sql_max_open_connections Maximum number of open connections to the database.
+# TYPE go_sql_max_open_connections gauge
+go_sql_max_open_connections{db_name="orders"} 7
+`
+	require.NoError(t, testutil.GatherAndCompare(m.Registry, strings.NewReader(expected), "go_sql_max_open_connections"))
+}
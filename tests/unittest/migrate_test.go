@@ -0,0 +1,75 @@
+package unittest
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/db/postgres/migrate"
+	"github.com/Puneet-Vishnoi/order-matching-engine/db/postgres/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        fstest.MapFS
+		wantVersions []int64
+		wantErr      string
+	}{
+		{
+			name: "Sorted By Version",
+			files: fstest.MapFS{
+				"0002_add_index.up.sql":      {Data: []byte("CREATE INDEX a ON t (c);")},
+				"0002_add_index.down.sql":    {Data: []byte("DROP INDEX a;")},
+				"0001_create_table.up.sql":   {Data: []byte("CREATE TABLE t (c INT);")},
+				"0001_create_table.down.sql": {Data: []byte("DROP TABLE t;")},
+				"README.md":                  {Data: []byte("ignored")},
+			},
+			wantVersions: []int64{1, 2},
+		},
+		{
+			name: "Missing Down File",
+			files: fstest.MapFS{
+				"0001_create_table.up.sql": {Data: []byte("CREATE TABLE t (c INT);")},
+			},
+			wantErr: "must have both up and down files",
+		},
+		{
+			name: "Conflicting Names",
+			files: fstest.MapFS{
+				"0001_create_table.up.sql": {Data: []byte("CREATE TABLE t (c INT);")},
+				"0001_other.down.sql":      {Data: []byte("DROP TABLE t;")},
+			},
+			wantErr: "conflicting names",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			list, err := migrate.Load(tc.files)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var versions []int64
+			for _, m := range list {
+				versions = append(versions, m.Version)
+			}
+			assert.Equal(t, tc.wantVersions, versions)
+		})
+	}
+}
+
+func TestEmbeddedMigrationsAreComplete(t *testing.T) {
+	list, err := migrate.Load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, list)
+
+	for i, m := range list {
+		assert.Equal(t, int64(i+1), m.Version, "migration versions must be contiguous")
+	}
+}
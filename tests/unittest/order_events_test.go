@@ -0,0 +1,86 @@
+package unittest
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tests/mockdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type wantEvent struct {
+	Type          string
+	PrevStatus    string
+	Status        string
+	PrevRemaining float64
+	Remaining     float64
+	Reason        string
+	HasTrade      bool
+}
+
+func eventsOf(t *testing.T, svc *service.OrderService, orderID int64) []wantEvent {
+	resp, err := svc.GetOrderEvents(context.Background(), strconv.FormatInt(orderID, 10))
+	require.NoError(t, err)
+
+	var got []wantEvent
+	for _, e := range resp.Events {
+		got = append(got, wantEvent{e.Type, e.PrevStatus, e.Status, e.PrevRemainingQty.InexactFloat64(), e.RemainingQty.InexactFloat64(), e.Reason, e.TradeID != nil})
+	}
+	return got
+}
+
+func TestOrderEvents(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	ctx := service.WithActor(context.Background(), "desk-7")
+
+	sell, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "EVT", Side: "sell", Type: "limit", Price: 50, Quantity: qty(10)})
+	require.NoError(t, err)
+	buy, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "EVT", Side: "buy", Type: "market", Quantity: qty(4)})
+	require.NoError(t, err)
+	_, err = deps.Service.CancelOrder(ctx, strconv.FormatInt(sell.OrderID, 10))
+	require.NoError(t, err)
+	unfilled, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "EVT", Side: "buy", Type: "market", Quantity: qty(3)})
+	require.NoError(t, err)
+
+	t.Run("Resting Order Partially Filled Then Canceled", func(t *testing.T) {
+		assert.Equal(t, []wantEvent{
+			{"created", "", "open", 0, 10, "new_order", false},
+			{"fill", "open", "partial", 10, 6, "matched", true},
+			{"canceled", "partial", "canceled", 6, 0, "user_request", false},
+		}, eventsOf(t, deps.Service, sell.OrderID))
+	})
+
+	t.Run("Incoming Order Filled", func(t *testing.T) {
+		assert.Equal(t, []wantEvent{
+			{"created", "", "open", 0, 4, "new_order", false},
+			{"fill", "open", "filled", 4, 0, "matched", true},
+		}, eventsOf(t, deps.Service, buy.OrderID))
+	})
+
+	t.Run("Market Order Without Liquidity", func(t *testing.T) {
+		assert.Equal(t, []wantEvent{
+			{"created", "", "open", 0, 3, "new_order", false},
+			{"canceled", "open", "canceled", 3, 3, "no_liquidity", false},
+		}, eventsOf(t, deps.Service, unfilled.OrderID))
+	})
+
+	t.Run("Fill Events Share The Trade", func(t *testing.T) {
+		sellEvents, err := deps.Service.GetOrderEvents(ctx, strconv.FormatInt(sell.OrderID, 10))
+		require.NoError(t, err)
+		buyEvents, err := deps.Service.GetOrderEvents(ctx, strconv.FormatInt(buy.OrderID, 10))
+		require.NoError(t, err)
+
+		assert.Equal(t, *sellEvents.Events[1].TradeID, *buyEvents.Events[1].TradeID)
+		assert.Equal(t, "desk-7", sellEvents.Events[1].Actor)
+	})
+
+	t.Run("Unknown Order", func(t *testing.T) {
+		_, err := deps.Service.GetOrderEvents(ctx, "99999")
+		assert.Equal(t, apperr.CodeOrderNotFound, apperr.CodeOf(err))
+	})
+}
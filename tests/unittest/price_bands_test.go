@@ -0,0 +1,173 @@
+package unittest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tests/mockdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceBands(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	deps.Service.Engine.Symbols = map[string]config.SymbolConfig{
+		"BND": {ReferencePrice: 100, StaticBandPct: 5},
+		"DYN": {DynamicBandPct: 10},
+	}
+	ctx := service.WithActor(context.Background(), "operator")
+
+	place := func(symbol, side, typ string, price, quantity float64) (*models.PlaceOrderResponse, error) {
+		return deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: symbol, Side: side, Type: typ, Price: price, Quantity: qty(quantity)})
+	}
+	asks := func(symbol string) []models.OrderBookEntry {
+		book, err := deps.Service.GetOrderBook(ctx, symbol)
+		require.NoError(t, err)
+		return book.Asks
+	}
+
+	for _, price := range []float64{104, 106} {
+		_, err := place("BND", "sell", "limit", price, 1)
+		require.NoError(t, err)
+	}
+
+	t.Run("Reject", func(t *testing.T) {
+		// The second ask lies beyond the band [95, 105]: nothing trades
+		_, err := place("BND", "buy", "market", 0, 2)
+		assert.Equal(t, apperr.ReasonPriceOutOfBand, apperr.CodeOf(err))
+		_, err = place("BND", "buy", "limit", 106, 2)
+		assert.Equal(t, apperr.ReasonPriceOutOfBand, apperr.CodeOf(err))
+		assert.Len(t, asks("BND"), 2)
+
+		// Selling down is not limited by the top of the band
+		resp, err := place("BND", "sell", "limit", 101, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "open", resp.Status)
+	})
+
+	t.Run("Cap", func(t *testing.T) {
+		deps.Service.Engine.BandPolicy = config.BandCap
+		defer func() { deps.Service.Engine.BandPolicy = config.BandReject }()
+
+		// The market order fills up to the band; the rest is canceled
+		resp, err := place("BND", "buy", "market", 0, 3)
+		require.NoError(t, err)
+		assert.Equal(t, "canceled", resp.Status)
+		assertQty(t, 1, resp.RemainingQuantity)
+		events, err := deps.EventRepo.ListEventsByOrder(ctx, resp.OrderID)
+		require.NoError(t, err)
+		last := events[len(events)-1]
+		assert.Equal(t, models.OrderEventCanceled, last.Type)
+		assert.Equal(t, models.ReasonPriceBand, last.Reason)
+
+		// Limit prices beyond the band move to its edge
+		resp, err = place("BND", "buy", "limit", 110, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "open", resp.Status)
+		order, err := deps.OrderRepo.GetOrderByID(ctx, nil, resp.OrderID)
+		require.NoError(t, err)
+		assert.Equal(t, 105.0, order.Price)
+	})
+
+	t.Run("Reference Price", func(t *testing.T) {
+		// Moving the reference to 110 opens the band to [104.5, 115.5]
+		resp, err := deps.Service.SetTradingPhase(ctx, "BND", &models.SetTradingPhaseRequest{Phase: models.PhaseContinuous, ReferencePrice: 110})
+		require.NoError(t, err)
+		assert.Equal(t, 110.0, resp.ReferencePrice)
+
+		// and it carries over
+		_, err = deps.Service.SetTradingPhase(ctx, "BND", &models.SetTradingPhaseRequest{Phase: models.PhaseContinuous})
+		require.NoError(t, err)
+		phase, err := deps.Service.GetTradingPhase(ctx, "BND")
+		require.NoError(t, err)
+		assert.Equal(t, 110.0, phase.ReferencePrice)
+
+		filled, err := place("BND", "buy", "limit", 106, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "filled", filled.Status)
+	})
+
+	t.Run("Dynamic", func(t *testing.T) {
+		// Nothing traded yet: no band
+		_, err := place("DYN", "sell", "limit", 50, 1)
+		require.NoError(t, err)
+		_, err = place("DYN", "buy", "market", 0, 1)
+		require.NoError(t, err)
+
+		// The band follows the last trade, 50: [45, 55]
+		for _, price := range []float64{54, 56} {
+			_, err := place("DYN", "sell", "limit", price, 1)
+			require.NoError(t, err)
+		}
+		_, err = place("DYN", "buy", "market", 0, 2)
+		assert.Equal(t, apperr.ReasonPriceOutOfBand, apperr.CodeOf(err))
+		_, err = place("DYN", "buy", "market", 0, 1)
+		require.NoError(t, err)
+
+		// and moves with it to [48.6, 59.4]
+		resp, err := place("DYN", "buy", "market", 0, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "filled", resp.Status)
+		assert.Empty(t, asks("DYN"))
+	})
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	deps.Service.Engine.Symbols = map[string]config.SymbolConfig{
+		"CBK": {CircuitBreakerPct: 5, CircuitBreakerWindow: time.Minute},
+	}
+	ctx := context.Background()
+
+	trade := func(price float64) error {
+		if _, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "CBK", Side: "sell", Type: "limit", Price: price, Quantity: qty(1)}); err != nil {
+			return err
+		}
+		_, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "CBK", Side: "buy", Type: "market", Quantity: qty(1)})
+		return err
+	}
+
+	// Within 5% of the lowest trade
+	require.NoError(t, trade(100))
+	require.NoError(t, trade(105))
+	phase, err := deps.Service.GetTradingPhase(ctx, "CBK")
+	require.NoError(t, err)
+	assert.Equal(t, models.PhaseContinuous, phase.Phase)
+
+	// The trade that moves prices further goes through, then trading halts
+	require.NoError(t, trade(106))
+	phase, err = deps.Service.GetTradingPhase(ctx, "CBK")
+	require.NoError(t, err)
+	assert.Equal(t, models.PhaseHalted, phase.Phase)
+	assert.Equal(t, service.CircuitBreakerActor, phase.UpdatedBy)
+	assert.Contains(t, phase.Reason, "circuit breaker")
+
+	err = trade(106)
+	assert.Equal(t, apperr.ReasonTradingHalted, apperr.CodeOf(err))
+	trades, err := deps.Service.ListTrades(ctx, "CBK")
+	require.NoError(t, err)
+	assert.Len(t, trades, 3)
+
+	t.Run("Resumed", func(t *testing.T) {
+		_, err := deps.Service.SetTradingPhase(ctx, "CBK", &models.SetTradingPhaseRequest{Phase: models.PhaseContinuous})
+		require.NoError(t, err)
+
+		// The trades before the halt are still within the window but no
+		// longer count
+		require.NoError(t, trade(106))
+		phase, err := deps.Service.GetTradingPhase(ctx, "CBK")
+		require.NoError(t, err)
+		assert.Equal(t, models.PhaseContinuous, phase.Phase)
+
+		// Those since the resume do
+		require.NoError(t, trade(112))
+		phase, err = deps.Service.GetTradingPhase(ctx, "CBK")
+		require.NoError(t, err)
+		assert.Equal(t, models.PhaseHalted, phase.Phase)
+	})
+}
@@ -0,0 +1,102 @@
+package unittest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tests/mockdb"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// qty returns v as a decimal with no more digits than it is written with.
+func qty(v float64) decimal.Decimal {
+	return decimal.RequireFromString(strconv.FormatFloat(v, 'f', -1, 64))
+}
+
+// decimals returns a quantity precision to configure.
+func decimals(n int) *int {
+	return &n
+}
+
+// assertQty compares quantities by value: equal decimals may differ in
+// exponent.
+func assertQty(t *testing.T, want float64, got decimal.Decimal, msgAndArgs ...any) {
+	t.Helper()
+	assert.Equal(t, qty(want).String(), got.String(), msgAndArgs...)
+}
+
+func TestFractionalQuantities(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	deps.Service.Engine.Symbols = map[string]config.SymbolConfig{
+		"BTC":  {QuantityPrecision: decimals(8)},
+		"LOTS": {QuantityPrecision: decimals(2), LotSize: qty(0.25), MinQuantity: qty(0.5)},
+	}
+	ctx := context.Background()
+	router := newTestRouter(deps)
+
+	t.Run("Trade", func(t *testing.T) {
+		for _, q := range []float64{0.5, 0.25} {
+			_, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "BTC", Side: "sell", Type: "limit", Price: 100, Quantity: qty(q)})
+			require.NoError(t, err)
+		}
+
+		book, err := deps.Service.GetOrderBook(ctx, "BTC")
+		require.NoError(t, err)
+		require.Len(t, book.Asks, 1)
+		assertQty(t, 0.75, book.Asks[0].Quantity)
+
+		resp, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "BTC", Side: "buy", Type: "limit", Price: 100, Quantity: qty(0.6)})
+		require.NoError(t, err)
+		assert.Equal(t, "filled", resp.Status)
+		assertQty(t, 0.6, resp.ExecutedQuantity)
+		assertQty(t, 0, resp.RemainingQuantity)
+
+		book, err = deps.Service.GetOrderBook(ctx, "BTC")
+		require.NoError(t, err)
+		assertQty(t, 0.15, book.Asks[0].Quantity)
+
+		trades, err := deps.Service.ListTrades(ctx, "BTC")
+		require.NoError(t, err)
+		require.Len(t, trades, 2)
+		assertQty(t, 0.5, trades[0].Quantity)
+		assertQty(t, 0.1, trades[1].Quantity)
+	})
+
+	t.Run("JSON Strings", func(t *testing.T) {
+		// Requests may send numbers or strings; responses send strings
+		for _, quantity := range []any{0.125, "0.00000015"} {
+			w := doRequest(router, http.MethodPost, "/api/orders", map[string]any{"symbol": "BTC", "side": "sell", "type": "limit", "price": 101, "quantity": quantity}, nil)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			assert.Contains(t, w.Body.String(), fmt.Sprintf(`"remaining_quantity":"%v"`, quantity))
+		}
+	})
+
+	t.Run("Precision", func(t *testing.T) {
+		// Whole quantities by default
+		_, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "WHOLE", Side: "buy", Type: "limit", Price: 10, Quantity: qty(1.5)})
+		assert.Equal(t, apperr.ReasonInvalidPrecision, apperr.CodeOf(err))
+		_, err = deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "BTC", Side: "buy", Type: "limit", Price: 10, Quantity: qty(0.000000001)})
+		assert.Equal(t, apperr.ReasonInvalidPrecision, apperr.CodeOf(err))
+
+		resp, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "WHOLE", Side: "buy", Type: "limit", Price: 10, Quantity: qty(2)})
+		require.NoError(t, err)
+		assert.Equal(t, "open", resp.Status)
+	})
+
+	t.Run("Fractional Lots", func(t *testing.T) {
+		_, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "LOTS", Side: "buy", Type: "limit", Price: 10, Quantity: qty(0.6)})
+		assert.Equal(t, apperr.ReasonInvalidLotSize, apperr.CodeOf(err))
+		_, err = deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "LOTS", Side: "buy", Type: "limit", Price: 10, Quantity: qty(0.25)})
+		assert.Equal(t, apperr.ReasonQuantityOutOfRange, apperr.CodeOf(err))
+		_, err = deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "LOTS", Side: "buy", Type: "limit", Price: 10, Quantity: qty(0.75)})
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,115 @@
+package unittest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tests/mockdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteQuantityOrders(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	deps.Service.Engine.Symbols = map[string]config.SymbolConfig{"LOT": {LotSize: qty(3)}, "FRAC": {QuantityPrecision: decimals(8)}}
+	ctx := context.Background()
+	router := newTestRouter(deps)
+
+	ask := func(symbol string, price, quantity float64) {
+		t.Helper()
+		_, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: symbol, Side: "sell", Type: "limit", Price: price, Quantity: qty(quantity)})
+		require.NoError(t, err)
+	}
+
+	t.Run("Spends Across Levels", func(t *testing.T) {
+		ask("QTE", 100, 2)
+		ask("QTE", 101, 3)
+		ask("QTE", 105, 5)
+
+		// 2 at 100, then 300 left buys 2 at 101
+		w := doRequest(router, http.MethodPost, "/api/orders", models.PlaceOrderRequest{Symbol: "QTE", Side: "buy", Type: "market", QuoteQuantity: qty(500)}, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		resp := decode[models.PlaceOrderResponse](t, w)
+		assert.Equal(t, "filled", resp.Status)
+		assertQty(t, 4, resp.ExecutedQuantity)
+		assertQty(t, 402, resp.QuoteSpent)
+		assertQty(t, 98, resp.QuoteLeftover)
+		assert.Equal(t, 100.5, resp.AveragePrice)
+
+		order, err := deps.OrderRepo.GetOrderByID(ctx, nil, resp.OrderID)
+		require.NoError(t, err)
+		assertQty(t, 4, order.Quantity)
+
+		book, err := deps.Service.GetOrderBook(ctx, "QTE")
+		require.NoError(t, err)
+		require.Len(t, book.Asks, 2)
+		assert.Equal(t, models.OrderBookEntry{Price: 101, Quantity: qty(1)}, book.Asks[0])
+	})
+
+	t.Run("Whole Lots", func(t *testing.T) {
+		ask("LOT", 10, 9)
+
+		resp, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "LOT", Side: "buy", Type: "market", QuoteQuantity: qty(75)})
+		require.NoError(t, err)
+		assertQty(t, 6, resp.ExecutedQuantity)
+		assertQty(t, 60, resp.QuoteSpent)
+		assertQty(t, 15, resp.QuoteLeftover)
+
+		_, err = deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "LOT", Side: "buy", Type: "market", QuoteQuantity: qty(25)})
+		assert.Equal(t, apperr.ReasonQuoteTooSmall, apperr.CodeOf(err))
+	})
+
+	t.Run("Within Protection", func(t *testing.T) {
+		ask("QTP", 10, 1)
+		ask("QTP", 12, 5)
+
+		// Sized to 6, of which the 5 beyond the protection are canceled
+		resp, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "QTP", Side: "buy", Type: "market", QuoteQuantity: qty(100), MaxSlippagePct: 10})
+		require.NoError(t, err)
+		assert.Equal(t, "canceled", resp.Status)
+		assert.Equal(t, models.ReasonSlippage, resp.CancelReason)
+		assertQty(t, 1, resp.ExecutedQuantity)
+		assertQty(t, 10, resp.QuoteSpent)
+		assertQty(t, 90, resp.QuoteLeftover)
+
+		book, err := deps.Service.GetOrderBook(ctx, "QTP")
+		require.NoError(t, err)
+		require.Len(t, book.Asks, 1)
+		assertQty(t, 5, book.Asks[0].Quantity)
+	})
+
+	t.Run("Exact Amounts", func(t *testing.T) {
+		ask("FRAC", 3, 10)
+
+		// 10 / 3 to eight decimals; the leftover is less than a cent
+		resp, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "FRAC", Side: "buy", Type: "market", QuoteQuantity: qty(10)})
+		require.NoError(t, err)
+		assertQty(t, 3.33333333, resp.ExecutedQuantity)
+		assertQty(t, 9.99999999, resp.QuoteSpent)
+		assertQty(t, 0.00000001, resp.QuoteLeftover)
+	})
+
+	t.Run("No Asks", func(t *testing.T) {
+		_, err := deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "NONE", Side: "buy", Type: "market", QuoteQuantity: qty(100)})
+		assert.Equal(t, apperr.ReasonQuoteTooSmall, apperr.CodeOf(err))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for name, req := range map[string]models.PlaceOrderRequest{
+			"Sell":         {Symbol: "QTE", Side: "sell", Type: "market", QuoteQuantity: qty(100)},
+			"Limit":        {Symbol: "QTE", Side: "buy", Type: "limit", Price: 10, QuoteQuantity: qty(100)},
+			"Both":         {Symbol: "QTE", Side: "buy", Type: "market", Quantity: qty(1), QuoteQuantity: qty(100)},
+			"Neither":      {Symbol: "QTE", Side: "buy", Type: "market"},
+			"Negative":     {Symbol: "QTE", Side: "buy", Type: "market", QuoteQuantity: qty(-1)},
+			"Negative Qty": {Symbol: "QTE", Side: "buy", Type: "market", Quantity: qty(-1)},
+		} {
+			err := service.ValidateOrder(&req)
+			assert.Equal(t, apperr.CodeValidationFailed, apperr.CodeOf(err), name)
+		}
+	})
+}
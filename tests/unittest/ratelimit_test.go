@@ -0,0 +1,245 @@
+package unittest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/ratelimit"
+	"github.com/Puneet-Vishnoi/order-matching-engine/routes"
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tests/mockdb"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRedisStore(t *testing.T, mr *miniredis.Miniredis) *ratelimit.RedisStore {
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return ratelimit.NewRedisStore(client, "ratelimit:")
+}
+
+func TestTokenBucketStores(t *testing.T) {
+	mr := miniredis.RunT(t)
+	stores := map[string]ratelimit.Store{
+		"Memory": ratelimit.NewMemoryStore(),
+		"Redis":  newRedisStore(t, mr),
+	}
+	limit := ratelimit.Limit{Rate: 2, Burst: 3}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Unix(1_700_000_000, 0)
+
+			// The burst is available at once
+			for i := 2; i >= 0; i-- {
+				res, err := store.Take(ctx, "k", limit, now)
+				require.NoError(t, err)
+				assert.True(t, res.Allowed)
+				assert.Equal(t, i, res.Remaining)
+			}
+			res, err := store.Take(ctx, "k", limit, now)
+			require.NoError(t, err)
+			assert.False(t, res.Allowed)
+			assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+
+			// Other keys have their own bucket
+			res, err = store.Take(ctx, "other", limit, now)
+			require.NoError(t, err)
+			assert.True(t, res.Allowed)
+
+			// Tokens refill at the rate, up to the burst
+			res, err = store.Take(ctx, "k", limit, now.Add(500*time.Millisecond))
+			require.NoError(t, err)
+			assert.True(t, res.Allowed)
+			res, err = store.Take(ctx, "k", limit, now.Add(time.Hour))
+			require.NoError(t, err)
+			assert.True(t, res.Allowed)
+			assert.Equal(t, 2, res.Remaining)
+
+			// Counters accumulate per field
+			_, err = store.Count(ctx, "c", "messages", 3, time.Minute)
+			require.NoError(t, err)
+			counts, err := store.Count(ctx, "c", "fills", 1, time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]int64{"messages": 3, "fills": 1}, counts)
+		})
+	}
+}
+
+func TestRedisLimitsAreSharedAcrossInstances(t *testing.T) {
+	mr := miniredis.RunT(t)
+	a, b := newRedisStore(t, mr), newRedisStore(t, mr)
+	limit := ratelimit.Limit{Rate: 1, Burst: 2}
+	now := time.Now()
+
+	for _, store := range []*ratelimit.RedisStore{a, b} {
+		res, err := store.Take(context.Background(), "shared", limit, now)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+	}
+	res, err := a.Take(context.Background(), "shared", limit, now)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+
+	// Idle buckets expire instead of piling up
+	assert.True(t, mr.TTL("ratelimit:shared") > 0)
+}
+
+// newLimitedDeps returns services whose rate limiter uses cfg and a clock
+// the test controls.
+func newLimitedDeps(cfg config.RateLimitConfig, now *time.Time) *mockdb.TestDeps {
+	deps := mockdb.GetMemoryTestInstance()
+	limiter := service.NewRateLimiter(ratelimit.NewMemoryStore(), cfg, deps.Service.Metrics)
+	limiter.Now = func() time.Time { return *now }
+	deps.Service.RateLimiter = limiter
+	return deps
+}
+
+func TestRateLimitedRequestsGet429(t *testing.T) {
+	now := time.Now()
+	deps := newLimitedDeps(config.RateLimitConfig{Place: ratelimit.Limit{Rate: 0.5, Burst: 2}}, &now)
+	router := newAuthRouter(deps)
+	alice := newTestKey(t, deps, "alice", models.RoleTrader, models.ScopeTrade)
+	bob := newTestKey(t, deps, "bob", models.RoleTrader, models.ScopeTrade)
+	order := func(quantity float64) models.PlaceOrderRequest {
+		return models.PlaceOrderRequest{Symbol: "RATE", Side: "buy", Type: "limit", Price: 10, Quantity: qty(quantity)}
+	}
+
+	for _, quantity := range []float64{1, 2} {
+		w := doSignedRequest(router, alice, http.MethodPost, "/api/orders", order(quantity), now)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	w := doSignedRequest(router, alice, http.MethodPost, "/api/orders", order(3), now)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "rate_limited", errorCode(t, w))
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+
+	// Accounts do not share buckets
+	w = doSignedRequest(router, bob, http.MethodPost, "/api/orders", order(4), now)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doRequest(router, http.MethodGet, "/metrics", nil, nil)
+	assert.Contains(t, w.Body.String(), `order_engine_rate_limited_total{class="place"} 1`)
+}
+
+func TestUnauthenticatedRequestsAreLimitedByIP(t *testing.T) {
+	now := time.Now()
+	deps := newLimitedDeps(config.RateLimitConfig{Read: ratelimit.Limit{Rate: 1, Burst: 1}}, &now)
+	router := newTestRouter(deps)
+
+	assert.Equal(t, http.StatusOK, doRequest(router, http.MethodGet, "/api/trades?symbol=IP", nil, nil).Code)
+	w := doRequest(router, http.MethodGet, "/api/trades?symbol=IP", nil, nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
+
+func TestForwardedForIsTrustedOnlyFromProxies(t *testing.T) {
+	now := time.Now()
+	limits := config.RateLimitConfig{Read: ratelimit.Limit{Rate: 1, Burst: 1}}
+	get := func(router http.Handler, clientIP string) int {
+		return doRequest(router, http.MethodGet, "/api/trades?symbol=XFF", nil, map[string]string{"X-Forwarded-For": clientIP}).Code
+	}
+
+	t.Run("No Trusted Proxies", func(t *testing.T) {
+		router, err := routes.NewRouter(nil)
+		require.NoError(t, err)
+		routes.RegisterRoutes(router, newLimitedDeps(limits, &now).Service, nil, nil, nil)
+
+		assert.Equal(t, http.StatusOK, get(router, "203.0.113.1"))
+		assert.Equal(t, http.StatusTooManyRequests, get(router, "203.0.113.2"), "rotating X-Forwarded-For must not evade the limit")
+	})
+
+	t.Run("Trusted Proxy", func(t *testing.T) {
+		// httptest requests come from 192.0.2.1
+		router, err := routes.NewRouter([]string{"192.0.2.0/24"})
+		require.NoError(t, err)
+		routes.RegisterRoutes(router, newLimitedDeps(limits, &now).Service, nil, nil, nil)
+
+		assert.Equal(t, http.StatusOK, get(router, "203.0.113.1"))
+		assert.Equal(t, http.StatusOK, get(router, "203.0.113.2"))
+		assert.Equal(t, http.StatusTooManyRequests, get(router, "203.0.113.1"))
+	})
+}
+
+func TestOrderToTradeRatioGuard(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	deps := newLimitedDeps(config.RateLimitConfig{
+		OrderToTrade: config.OrderToTradeConfig{MaxRatio: 2, MinMessages: 4, Window: time.Minute},
+	}, &now)
+	alice := service.WithAccount(context.Background(), 1)
+	bob := service.WithAccount(context.Background(), 2)
+	sell := func(price float64) *models.PlaceOrderRequest {
+		return &models.PlaceOrderRequest{Symbol: "OTR", Side: "sell", Type: "limit", Price: price, Quantity: qty(1)}
+	}
+
+	// Two resting orders, both filled: 2 messages, 2 fills
+	for _, price := range []float64{10, 11} {
+		_, err := deps.Service.PlaceOrder(alice, sell(price))
+		require.NoError(t, err)
+	}
+	_, err := deps.Service.PlaceOrder(bob, &models.PlaceOrderRequest{Symbol: "OTR", Side: "buy", Type: "market", Quantity: qty(2)})
+	require.NoError(t, err)
+
+	// Up to MaxRatio messages per fill are allowed, cancels included
+	placed, err := deps.Service.PlaceOrder(alice, sell(20))
+	require.NoError(t, err)
+	_, err = deps.Service.CancelOrder(alice, strconv.FormatInt(placed.OrderID, 10))
+	require.NoError(t, err)
+
+	_, err = deps.Service.PlaceOrder(alice, sell(21))
+	appErr, ok := apperr.As(err)
+	require.True(t, ok, "got %v", err)
+	assert.Equal(t, "order_to_trade_ratio_exceeded", appErr.Code)
+	assert.Equal(t, time.Minute, appErr.RetryAfter)
+
+	// Bob's ratio is his own
+	_, err = deps.Service.PlaceOrder(bob, sell(30))
+	assert.NoError(t, err)
+
+	// The next window starts clean
+	now = now.Add(time.Minute)
+	_, err = deps.Service.PlaceOrder(alice, sell(21))
+	assert.NoError(t, err)
+}
+
+func TestReplacesAreLimitedAsNewOrders(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	deps := newLimitedDeps(config.RateLimitConfig{
+		PlacePerSymbol: ratelimit.Limit{Rate: 1, Burst: 2},
+		OrderToTrade:   config.OrderToTradeConfig{MaxRatio: 1, MinMessages: 3, Window: time.Minute},
+	}, &now)
+	alice := service.WithAccount(context.Background(), 1)
+	placed, err := deps.Service.PlaceOrder(alice, &models.PlaceOrderRequest{Symbol: "RPL", Side: "sell", Type: "limit", Price: 10, Quantity: qty(1)})
+	require.NoError(t, err)
+	replace := func(price float64) error {
+		resp, err := deps.Service.ReplaceOrder(alice, strconv.FormatInt(placed.OrderID, 10), &models.ReplaceOrderRequest{Price: price, Quantity: qty(1)})
+		if err == nil {
+			placed = resp
+		}
+		return err
+	}
+	require.NoError(t, replace(11))
+
+	// The symbol's bucket is empty after an order and a replace
+	err = replace(12)
+	appErr, ok := apperr.As(err)
+	require.True(t, ok, "got %v", err)
+	assert.Equal(t, "rate_limited", appErr.Code)
+
+	// A refill lets the next replace through to the ratio guard, which
+	// refuses a third message without fills
+	now = now.Add(time.Second)
+	err = replace(12)
+	appErr, ok = apperr.As(err)
+	require.True(t, ok, "got %v", err)
+	assert.Equal(t, "order_to_trade_ratio_exceeded", appErr.Code)
+}
@@ -0,0 +1,153 @@
+package unittest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tests/mockdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleHierarchy(t *testing.T) {
+	trader := &models.Account{Role: models.RoleTrader}
+	assert.True(t, trader.HasRole(models.RoleViewer))
+	assert.True(t, trader.HasRole(models.RoleTrader))
+	assert.False(t, trader.HasRole(models.RoleMarketMaker))
+	assert.False(t, trader.HasRole(models.RoleAdmin))
+	assert.False(t, trader.HasRole("superuser"))
+
+	unknown := &models.Account{Role: "superuser"}
+	assert.False(t, unknown.HasRole(models.RoleViewer))
+}
+
+func TestRoleEnforcement(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	router := newAuthRouter(deps)
+	all := []string{models.ScopeRead, models.ScopeTrade, models.ScopeCancel, models.ScopeAdmin}
+	keys := map[string]*models.IssuedAPIKeyResponse{}
+	for _, role := range models.Roles {
+		keys[role] = newTestKey(t, deps, role, role, all...)
+	}
+	now := time.Now()
+
+	tests := []struct {
+		role       string
+		method     string
+		path       string
+		body       any
+		wantStatus int
+	}{
+		{models.RoleViewer, http.MethodGet, "/api/orderbook?symbol=RBAC", nil, http.StatusOK},
+		{models.RoleViewer, http.MethodPost, "/api/orders", models.PlaceOrderRequest{Symbol: "RBAC", Side: "buy", Type: "limit", Price: 10, Quantity: qty(1)}, http.StatusForbidden},
+		{models.RoleTrader, http.MethodPost, "/api/orders", models.PlaceOrderRequest{Symbol: "RBAC", Side: "buy", Type: "limit", Price: 10, Quantity: qty(2)}, http.StatusOK},
+		{models.RoleTrader, http.MethodGet, "/admin/audit", nil, http.StatusForbidden},
+		{models.RoleMarketMaker, http.MethodPost, "/api/orders", models.PlaceOrderRequest{Symbol: "RBAC", Side: "sell", Type: "limit", Price: 11, Quantity: qty(1)}, http.StatusOK},
+		{models.RoleMarketMaker, http.MethodGet, "/admin/audit", nil, http.StatusForbidden},
+		{models.RoleOperator, http.MethodGet, "/admin/audit", nil, http.StatusOK},
+		{models.RoleOperator, http.MethodPost, "/admin/accounts", models.CreateAccountRequest{Name: "eve"}, http.StatusForbidden},
+		{models.RoleAdmin, http.MethodGet, "/admin/audit", nil, http.StatusOK},
+		{models.RoleAdmin, http.MethodPost, "/admin/accounts", models.CreateAccountRequest{Name: "dave"}, http.StatusCreated},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.role+" "+tc.method+" "+tc.path, func(t *testing.T) {
+			w := doSignedRequest(router, keys[tc.role], tc.method, tc.path, tc.body, now)
+			assert.Equal(t, tc.wantStatus, w.Code, w.Body.String())
+			if tc.wantStatus == http.StatusForbidden {
+				assert.Equal(t, "insufficient_role", errorCode(t, w))
+			}
+		})
+	}
+}
+
+func TestSetRoleAppliesToExistingKeys(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	router := newAuthRouter(deps)
+	admin := newTestKey(t, deps, "ops", models.RoleAdmin, models.ScopeAdmin)
+	viewer := newTestKey(t, deps, "frank", models.RoleViewer, models.ScopeRead, models.ScopeTrade)
+	order := models.PlaceOrderRequest{Symbol: "ROLE", Side: "buy", Type: "limit", Price: 10, Quantity: qty(1)}
+	now := time.Now()
+
+	w := doSignedRequest(router, viewer, http.MethodPost, "/api/orders", order, now)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	rolePath := "/admin/accounts/" + strconv.FormatInt(viewer.AccountID, 10) + "/role"
+	w = doSignedRequest(router, admin, http.MethodPut, rolePath, models.UpdateAccountRoleRequest{Role: "root"}, now)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doSignedRequest(router, admin, http.MethodPut, rolePath, models.UpdateAccountRoleRequest{Role: models.RoleTrader}, now)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var account models.Account
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &account))
+	assert.Equal(t, models.RoleTrader, account.Role)
+
+	w = doSignedRequest(router, viewer, http.MethodPost, "/api/orders", order, now.Add(-time.Second))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestAdminAuditLog(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	router := newAuthRouter(deps)
+	admin := newTestKey(t, deps, "ops", models.RoleAdmin, models.ScopeAdmin)
+	operator := newTestKey(t, deps, "night-shift", models.RoleOperator, models.ScopeAdmin)
+	now := time.Now()
+
+	w := doSignedRequest(router, admin, http.MethodPost, "/admin/accounts", models.CreateAccountRequest{Name: "grace", Role: models.RoleMarketMaker}, now)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var grace models.Account
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &grace))
+	assert.Equal(t, models.RoleMarketMaker, grace.Role)
+
+	// Rejected attempts are recorded with their error code
+	w = doSignedRequest(router, operator, http.MethodDelete, "/admin/keys/"+admin.KeyID, nil, now)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	// Reads are not actions and are not recorded
+	w = doSignedRequest(router, admin, http.MethodGet, "/admin/audit?limit=0", nil, now)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doSignedRequest(router, operator, http.MethodGet, "/admin/audit", nil, now)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var log models.AuditLogResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &log))
+	require.Len(t, log.Entries, 2)
+
+	denied, created := log.Entries[0], log.Entries[1]
+	assert.Equal(t, "DELETE /admin/keys/:key_id", denied.Action)
+	assert.Equal(t, "account:"+strconv.FormatInt(operator.AccountID, 10)+"/"+operator.KeyID, denied.Actor)
+	assert.Equal(t, "insufficient_role", denied.Result)
+	assert.JSONEq(t, `{"path":{"key_id":"`+admin.KeyID+`"}}`, string(denied.Params))
+
+	assert.Equal(t, "POST /admin/accounts", created.Action)
+	assert.Equal(t, models.AuditResultOK, created.Result)
+	assert.JSONEq(t, `{"body":{"name":"grace","role":"market_maker"}}`, string(created.Params))
+
+	// The key is still valid: the revocation never ran
+	key, err := deps.AccountRepo.GetAPIKey(context.Background(), nil, admin.KeyID)
+	require.NoError(t, err)
+	assert.Nil(t, key.RevokedAt)
+}
+
+func TestAuditRunRecordsFailures(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	ctx := service.WithActor(context.Background(), "cli")
+
+	err := deps.Audit.Run(ctx, "cli accounts set-role", map[string]any{"args": []string{"42", "admin"}}, func() error {
+		_, err := deps.Auth.SetRole(ctx, 42, models.RoleAdmin)
+		return err
+	})
+	require.Error(t, err)
+
+	resp, err := deps.Audit.List(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, resp.Entries, 1)
+	assert.Equal(t, "cli", resp.Entries[0].Actor)
+	assert.Equal(t, "account_not_found", resp.Entries[0].Result)
+}
@@ -0,0 +1,75 @@
+package unittest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository/memory"
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestPlaceOrderSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	store := memory.NewStore()
+	svc := service.NewOrderService(store,
+		repository.NewTracedOrderRepository(memory.NewOrderRepository(store)),
+		repository.NewTracedTradeRepository(memory.NewTradeRepository(store)),
+		repository.NewTracedOrderEventRepository(memory.NewOrderEventRepository(store)),
+	)
+
+	ctx := context.Background()
+	_, err := svc.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "TRC", Side: "sell", Type: "limit", Price: 5, Quantity: qty(2)})
+	require.NoError(t, err)
+
+	resp, err := svc.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "TRC", Side: "buy", Type: "limit", Price: 5, Quantity: qty(2)})
+	require.NoError(t, err)
+
+	// Spans of the second (matching) order only
+	var root sdktrace.ReadOnlySpan
+	for _, s := range recorder.Ended() {
+		if s.Name() == "OrderService.PlaceOrder" {
+			root = s
+		}
+	}
+	require.NotNil(t, root)
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range recorder.Ended() {
+		if s.SpanContext().TraceID() == root.SpanContext().TraceID() {
+			spans[s.Name()] = s
+		}
+	}
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range root.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	assert.Equal(t, "TRC", attrs["order.symbol"].AsString())
+	assert.Equal(t, "buy", attrs["order.side"].AsString())
+	assert.Equal(t, resp.OrderID, attrs["order.id"].AsInt64())
+	assert.Equal(t, int64(1), attrs["order.trades_generated"].AsInt64())
+
+	for _, name := range []string{
+		"MatchingEngine.Match",
+		"OrderRepository.CreateOrder",
+		"OrderRepository.FetchOpenSellOrders",
+		"TradeRepository.CreateTrade",
+		"OrderEventRepository.CreateEvent",
+		"Tx.Commit",
+	} {
+		assert.Contains(t, spans, name)
+	}
+	assert.Equal(t, spans["MatchingEngine.Match"].SpanContext().SpanID(), spans["OrderRepository.FetchOpenSellOrders"].Parent().SpanID())
+}
@@ -0,0 +1,185 @@
+package unittest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/apperr"
+	"github.com/Puneet-Vishnoi/order-matching-engine/config"
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tests/mockdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTradingHalt(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	router := newAuthRouter(deps)
+	trader := newTestKey(t, deps, "trader", models.RoleTrader, models.ScopeRead, models.ScopeTrade, models.ScopeCancel)
+	operator := newTestKey(t, deps, "night-shift", models.RoleOperator, models.ScopeAdmin)
+	now := time.Now()
+
+	setPhase := func(req models.SetTradingPhaseRequest, ts time.Time) (int, string) {
+		w := doSignedRequest(router, operator, http.MethodPut, "/admin/phases/HLT", req, ts)
+		if w.Code != http.StatusOK {
+			return w.Code, errorCode(t, w)
+		}
+		return w.Code, decode[models.TradingPhaseResponse](t, w).Phase
+	}
+	place := func(ts time.Time) (int, string) {
+		w := doSignedRequest(router, trader, http.MethodPost, "/api/orders", models.PlaceOrderRequest{Symbol: "HLT", Side: "sell", Type: "limit", Price: 10, Quantity: qty(1)}, ts)
+		if w.Code != http.StatusOK {
+			return w.Code, errorCode(t, w)
+		}
+		return w.Code, strconv.FormatInt(decode[models.PlaceOrderResponse](t, w).OrderID, 10)
+	}
+
+	w := doSignedRequest(router, trader, http.MethodGet, "/api/phases/HLT", nil, now)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, models.PhaseContinuous, decode[models.TradingPhase](t, w).Phase)
+
+	code, resting := place(now)
+	require.Equal(t, http.StatusOK, code, resting)
+
+	code, phase := setPhase(models.SetTradingPhaseRequest{Phase: models.PhaseHalted, Reason: "bad print"}, now)
+	require.Equal(t, http.StatusOK, code, phase)
+	w = doSignedRequest(router, trader, http.MethodGet, "/api/phases/HLT", nil, now.Add(time.Second))
+	halted := decode[models.TradingPhase](t, w)
+	assert.Equal(t, models.PhaseHalted, halted.Phase)
+	assert.Equal(t, "bad print", halted.Reason)
+	assert.NotEmpty(t, halted.UpdatedBy)
+
+	// New orders are rejected; cancels go through
+	code, reason := place(now.Add(time.Second))
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.Equal(t, apperr.ReasonTradingHalted, reason)
+	w = doSignedRequest(router, trader, http.MethodDelete, "/api/orders/"+resting, nil, now)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Other symbols keep trading
+	w = doSignedRequest(router, trader, http.MethodPost, "/api/orders", models.PlaceOrderRequest{Symbol: "HLU", Side: "sell", Type: "limit", Price: 10, Quantity: qty(1)}, now)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	code, phase = setPhase(models.SetTradingPhaseRequest{Phase: models.PhaseClosed}, now.Add(time.Second))
+	require.Equal(t, http.StatusOK, code, phase)
+	code, reason = place(now.Add(2 * time.Second))
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.Equal(t, apperr.ReasonMarketClosed, reason)
+
+	code, phase = setPhase(models.SetTradingPhaseRequest{Phase: models.PhaseContinuous}, now.Add(2*time.Second))
+	require.Equal(t, http.StatusOK, code, phase)
+	code, _ = place(now.Add(3 * time.Second))
+	assert.Equal(t, http.StatusOK, code)
+
+	t.Run("Invalid", func(t *testing.T) {
+		code, reason := setPhase(models.SetTradingPhaseRequest{Phase: "paused"}, now)
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, apperr.CodeValidationFailed, reason)
+
+		code, reason = setPhase(models.SetTradingPhaseRequest{Phase: models.PhaseHalted, Auction: true}, now)
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, apperr.CodeValidationFailed, reason)
+	})
+
+	t.Run("Operators Only", func(t *testing.T) {
+		w := doSignedRequest(router, trader, http.MethodPut, "/admin/phases/HLT", models.SetTradingPhaseRequest{Phase: models.PhaseHalted}, now)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestHaltQueueAndReopeningAuction(t *testing.T) {
+	deps := mockdb.GetMemoryTestInstance()
+	deps.Service.Engine.HaltPolicy = config.HaltQueue
+	ctx := service.WithActor(context.Background(), "operator")
+
+	place := func(side, typ string, price, quantity float64) (*models.PlaceOrderResponse, error) {
+		return deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "AUC", Side: side, Type: typ, Price: price, Quantity: qty(quantity)})
+	}
+	_, err := deps.Service.SetTradingPhase(ctx, "AUC", &models.SetTradingPhaseRequest{Phase: models.PhaseHalted})
+	require.NoError(t, err)
+
+	// Queued limit orders rest unmatched, crossed or not
+	var ids []int64
+	for _, o := range []struct {
+		side     string
+		price    float64
+		quantity float64
+	}{{"buy", 10, 3}, {"buy", 12, 2}, {"sell", 11, 4}, {"sell", 9, 1}} {
+		resp, err := place(o.side, "limit", o.price, o.quantity)
+		require.NoError(t, err)
+		assert.Equal(t, "open", resp.Status)
+		ids = append(ids, resp.OrderID)
+	}
+	_, err = place("buy", "market", 0, 1)
+	assert.Equal(t, apperr.ReasonNotContinuous, apperr.CodeOf(err))
+
+	// A crossed book only reopens through an auction
+	_, err = deps.Service.SetTradingPhase(ctx, "AUC", &models.SetTradingPhaseRequest{Phase: models.PhaseContinuous})
+	assert.Equal(t, apperr.CodeBookCrossed, apperr.CodeOf(err))
+
+	resp, err := deps.Service.SetTradingPhase(ctx, "AUC", &models.SetTradingPhaseRequest{Phase: models.PhaseContinuous, Auction: true})
+	require.NoError(t, err)
+	assert.Equal(t, models.PhaseContinuous, resp.Phase)
+	require.NotNil(t, resp.Auction)
+	assert.Equal(t, models.AuctionResult{Price: 11, Quantity: qty(2), Trades: 2}, *resp.Auction)
+
+	trades, err := deps.Service.ListTrades(ctx, "AUC")
+	require.NoError(t, err)
+	require.Len(t, trades, 2)
+	for _, trade := range trades {
+		assert.Equal(t, 11.0, trade.Price)
+		assert.Equal(t, ids[1], trade.BuyOrderID)
+	}
+
+	book, err := deps.Service.GetOrderBook(ctx, "AUC")
+	require.NoError(t, err)
+	require.Len(t, book.Bids, 1)
+	assert.Equal(t, 10.0, book.Bids[0].Price)
+	require.Len(t, book.Asks, 1)
+	assert.Equal(t, 11.0, book.Asks[0].Price)
+	assertQty(t, 3, book.Asks[0].Quantity)
+
+	events, err := deps.EventRepo.ListEventsByOrder(ctx, ids[1])
+	require.NoError(t, err)
+	last := events[len(events)-1]
+	assert.Equal(t, models.OrderEventFill, last.Type)
+	assert.Equal(t, models.ReasonAuction, last.Reason)
+	assert.Equal(t, "filled", last.Status)
+
+	// Matching resumes
+	placed, err := place("buy", "market", 0, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "filled", placed.Status)
+
+	t.Run("Pre-Open Balanced", func(t *testing.T) {
+		_, err := deps.Service.SetTradingPhase(ctx, "OPN", &models.SetTradingPhaseRequest{Phase: models.PhasePreOpen})
+		require.NoError(t, err)
+		for _, o := range []models.PlaceOrderRequest{
+			{Symbol: "OPN", Side: "buy", Type: "limit", Price: 12, Quantity: qty(5)},
+			{Symbol: "OPN", Side: "sell", Type: "limit", Price: 9, Quantity: qty(5)},
+			{Symbol: "OPN", Side: "buy", Type: "limit", Price: 10, Quantity: qty(1)},
+			{Symbol: "OPN", Side: "sell", Type: "limit", Price: 11, Quantity: qty(1)},
+		} {
+			_, err := deps.Service.PlaceOrder(ctx, &o)
+			require.NoError(t, err)
+		}
+
+		// As much executes at 9 to 12; the surplus flips side between 10
+		// and 11, so the lower of the two middle prices wins
+		resp, err := deps.Service.SetTradingPhase(ctx, "OPN", &models.SetTradingPhaseRequest{Phase: models.PhaseContinuous, Auction: true})
+		require.NoError(t, err)
+		assert.Equal(t, models.AuctionResult{Price: 10, Quantity: qty(5), Trades: 1}, *resp.Auction)
+	})
+
+	t.Run("Uncrossed Book", func(t *testing.T) {
+		_, err := deps.Service.SetTradingPhase(ctx, "AUC", &models.SetTradingPhaseRequest{Phase: models.PhaseHalted})
+		require.NoError(t, err)
+		resp, err := deps.Service.SetTradingPhase(ctx, "AUC", &models.SetTradingPhaseRequest{Phase: models.PhaseContinuous, Auction: true})
+		require.NoError(t, err)
+		assert.Equal(t, models.AuctionResult{}, *resp.Auction)
+	})
+}
@@ -0,0 +1,114 @@
+package unittest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository"
+	"github.com/Puneet-Vishnoi/order-matching-engine/repository/memory"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxRunner(t *testing.T) {
+	serializationFailure := &pq.Error{Code: "40001", Message: "could not serialize access"}
+	deadlock := &pq.Error{Code: "40P01", Message: "deadlock detected"}
+
+	tests := []struct {
+		name          string
+		failures      []error // returned by the first attempts, in order
+		wantErr       string
+		wantCalls     int
+		wantRetries   uint64
+		wantExhausted uint64
+	}{
+		{
+			name:      "Succeeds First Time",
+			wantCalls: 1,
+		},
+		{
+			name:        "Retries Serialization Failure",
+			failures:    []error{serializationFailure, serializationFailure},
+			wantCalls:   3,
+			wantRetries: 2,
+		},
+		{
+			name:        "Retries Deadlock",
+			failures:    []error{deadlock},
+			wantCalls:   2,
+			wantRetries: 1,
+		},
+		{
+			name:      "Does Not Retry Other Errors",
+			failures:  []error{errors.New("boom")},
+			wantErr:   "boom",
+			wantCalls: 1,
+		},
+		{
+			name:          "Gives Up After Max Attempts",
+			failures:      []error{serializationFailure, serializationFailure, serializationFailure},
+			wantErr:       "transaction failed after 3 attempts",
+			wantCalls:     3,
+			wantRetries:   2,
+			wantExhausted: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			runner := repository.NewTxRunner(memory.NewStore())
+			runner.MaxAttempts = 3
+			runner.BaseDelay = time.Millisecond
+			runner.MaxDelay = 2 * time.Millisecond
+
+			calls := 0
+			err := runner.Run(context.Background(), nil, func(tx repository.Tx) error {
+				calls++
+				if calls <= len(tc.failures) {
+					return tc.failures[calls-1]
+				}
+				return nil
+			})
+
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantCalls, calls)
+
+			stats := runner.Stats()
+			assert.Equal(t, uint64(1), stats.Transactions)
+			assert.Equal(t, tc.wantRetries, stats.Retries)
+			assert.Equal(t, tc.wantExhausted, stats.Exhausted)
+		})
+	}
+}
+
+func TestTxRunnerRollsBackFailedAttempts(t *testing.T) {
+	store := memory.NewStore()
+	orders := memory.NewOrderRepository(store)
+	runner := repository.NewTxRunner(store)
+	runner.BaseDelay = time.Millisecond
+
+	calls := 0
+	err := runner.Run(context.Background(), nil, func(tx repository.Tx) error {
+		calls++
+		if _, err := orders.CreateOrder(context.Background(), tx, newMemoryOrder("RETRY", "buy", 10, 1)); err != nil {
+			return err
+		}
+		if calls == 1 {
+			return &pq.Error{Code: "40001"}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	book, err := orders.FetchOpenBuyOrders(context.Background(), nil, "RETRY")
+	require.NoError(t, err)
+	assert.Len(t, book, 1, "the first attempt's insert must have been rolled back")
+}
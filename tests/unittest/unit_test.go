@@ -11,7 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-var test = mockdb.GetTestInstance()
+var test = mockdb.GetMemoryTestInstance()
 
 func TestPlaceOrder(t *testing.T) {
 	tests := []struct {
@@ -19,7 +19,7 @@ func TestPlaceOrder(t *testing.T) {
 		setup       func() []int64 // Returns order IDs for cleanup
 		request     models.PlaceOrderRequest
 		wantStatus  string
-		wantRemQty  int
+		wantRemQty  float64
 		wantErr     string
 		checkTrades bool
 		tradeCount  int
@@ -34,7 +34,7 @@ func TestPlaceOrder(t *testing.T) {
 				Side:     "buy",
 				Type:     "limit",
 				Price:    100.0,
-				Quantity: 10,
+				Quantity: qty(10),
 			},
 			wantStatus:  "open",
 			wantRemQty:  10,
@@ -52,7 +52,7 @@ func TestPlaceOrder(t *testing.T) {
 				Side:     "sell",
 				Type:     "limit",
 				Price:    200.0,
-				Quantity: 5,
+				Quantity: qty(5),
 			},
 			wantStatus:  "open",
 			wantRemQty:  5,
@@ -69,7 +69,7 @@ func TestPlaceOrder(t *testing.T) {
 					Side:     "sell",
 					Type:     "limit",
 					Price:    150.0,
-					Quantity: 10,
+					Quantity: qty(10),
 				}
 				resp, err := test.Service.PlaceOrder(context.Background(), &sellReq)
 				require.NoError(t, err)
@@ -80,7 +80,7 @@ func TestPlaceOrder(t *testing.T) {
 				Side:     "buy",
 				Type:     "limit",
 				Price:    150.0,
-				Quantity: 10,
+				Quantity: qty(10),
 			},
 			wantStatus:  "filled",
 			wantRemQty:  0,
@@ -96,7 +96,7 @@ func TestPlaceOrder(t *testing.T) {
 					Side:     "sell",
 					Type:     "limit",
 					Price:    140.0,
-					Quantity: 5,
+					Quantity: qty(5),
 				}
 				resp, err := test.Service.PlaceOrder(context.Background(), &sellReq)
 				require.NoError(t, err)
@@ -107,7 +107,7 @@ func TestPlaceOrder(t *testing.T) {
 				Side:     "buy",
 				Type:     "limit",
 				Price:    140.0,
-				Quantity: 10,
+				Quantity: qty(10),
 			},
 			wantStatus:  "partial",
 			wantRemQty:  5,
@@ -122,9 +122,9 @@ func TestPlaceOrder(t *testing.T) {
 
 				// Create multiple sell orders at different prices
 				sellOrders := []models.PlaceOrderRequest{
-					{Symbol: "MSFT", Side: "sell", Type: "limit", Price: 100.0, Quantity: 5},
-					{Symbol: "MSFT", Side: "sell", Type: "limit", Price: 101.0, Quantity: 3},
-					{Symbol: "MSFT", Side: "sell", Type: "limit", Price: 102.0, Quantity: 2},
+					{Symbol: "MSFT", Side: "sell", Type: "limit", Price: 100.0, Quantity: qty(5)},
+					{Symbol: "MSFT", Side: "sell", Type: "limit", Price: 101.0, Quantity: qty(3)},
+					{Symbol: "MSFT", Side: "sell", Type: "limit", Price: 102.0, Quantity: qty(2)},
 				}
 
 				for _, order := range sellOrders {
@@ -138,7 +138,7 @@ func TestPlaceOrder(t *testing.T) {
 				Symbol:   "MSFT",
 				Side:     "buy",
 				Type:     "market",
-				Quantity: 8,
+				Quantity: qty(8),
 			},
 			wantStatus:  "filled",
 			wantRemQty:  0,
@@ -156,11 +156,11 @@ func TestPlaceOrder(t *testing.T) {
 				Side:     "invalid",
 				Type:     "limit",
 				Price:    100.0,
-				Quantity: 5,
+				Quantity: qty(5),
 			},
 			wantStatus: "",
 			wantRemQty: 0,
-			wantErr: "pq: new row for relation \"orders\" violates check constraint \"orders_side_check\"",
+			wantErr:    "new row for relation \"orders\" violates check constraint \"orders_side_check\"",
 		},
 		{
 			name: "Buy Order Higher Price Than Sell",
@@ -170,7 +170,7 @@ func TestPlaceOrder(t *testing.T) {
 					Side:     "sell",
 					Type:     "limit",
 					Price:    200.0,
-					Quantity: 8,
+					Quantity: qty(8),
 				}
 				resp, err := test.Service.PlaceOrder(context.Background(), &sellReq)
 				require.NoError(t, err)
@@ -181,7 +181,7 @@ func TestPlaceOrder(t *testing.T) {
 				Side:     "buy",
 				Type:     "limit",
 				Price:    210.0, // Higher than sell price, should match
-				Quantity: 8,
+				Quantity: qty(8),
 			},
 			wantStatus:  "filled",
 			wantRemQty:  0,
@@ -197,7 +197,7 @@ func TestPlaceOrder(t *testing.T) {
 					Side:     "buy",
 					Type:     "limit",
 					Price:    300.0,
-					Quantity: 6,
+					Quantity: qty(6),
 				}
 				resp, err := test.Service.PlaceOrder(context.Background(), &buyReq)
 				require.NoError(t, err)
@@ -208,7 +208,7 @@ func TestPlaceOrder(t *testing.T) {
 				Side:     "sell",
 				Type:     "limit",
 				Price:    290.0, // Lower than buy price, should match
-				Quantity: 6,
+				Quantity: qty(6),
 			},
 			wantStatus:  "filled",
 			wantRemQty:  0,
@@ -233,7 +233,7 @@ func TestPlaceOrder(t *testing.T) {
 
 			require.NoError(t, err)
 			assert.Equal(t, tc.wantStatus, resp.Status)
-			assert.Equal(t, tc.wantRemQty, resp.RemainingQuantity)
+			assertQty(t, tc.wantRemQty, resp.RemainingQuantity)
 
 			if tc.checkTrades {
 				trades, err := test.Service.ListTrades(context.Background(), tc.request.Symbol)
@@ -266,7 +266,7 @@ func TestCancelOrder(t *testing.T) {
 					Side:     "buy",
 					Type:     "limit",
 					Price:    100.0,
-					Quantity: 10,
+					Quantity: qty(10),
 				}
 				resp, err := test.Service.PlaceOrder(context.Background(), &req)
 				require.NoError(t, err)
@@ -291,7 +291,7 @@ func TestCancelOrder(t *testing.T) {
 					Side:     "sell",
 					Type:     "limit",
 					Price:    150.0,
-					Quantity: 5,
+					Quantity: qty(5),
 				}
 				test.Service.PlaceOrder(context.Background(), &sellReq)
 
@@ -300,7 +300,7 @@ func TestCancelOrder(t *testing.T) {
 					Side:     "buy",
 					Type:     "limit",
 					Price:    150.0,
-					Quantity: 5,
+					Quantity: qty(5),
 				}
 				resp, err := test.Service.PlaceOrder(context.Background(), &buyReq)
 				require.NoError(t, err)
@@ -348,8 +348,8 @@ func TestGetOrderStatus(t *testing.T) {
 		setup       func() string
 		orderID     string
 		wantStatus  string
-		wantExecQty int
-		wantRemQty  int
+		wantExecQty float64
+		wantRemQty  float64
 		wantErr     string
 	}{
 		{
@@ -360,7 +360,7 @@ func TestGetOrderStatus(t *testing.T) {
 					Side:     "buy",
 					Type:     "limit",
 					Price:    100.0,
-					Quantity: 10,
+					Quantity: qty(10),
 				}
 				resp, err := test.Service.PlaceOrder(context.Background(), &req)
 				require.NoError(t, err)
@@ -380,7 +380,7 @@ func TestGetOrderStatus(t *testing.T) {
 					Side:     "sell",
 					Type:     "limit",
 					Price:    200.0,
-					Quantity: 5,
+					Quantity: qty(5),
 				}
 				test.Service.PlaceOrder(context.Background(), &sellReq)
 
@@ -390,7 +390,7 @@ func TestGetOrderStatus(t *testing.T) {
 					Side:     "buy",
 					Type:     "limit",
 					Price:    200.0,
-					Quantity: 5,
+					Quantity: qty(5),
 				}
 				resp, err := test.Service.PlaceOrder(context.Background(), &buyReq)
 				require.NoError(t, err)
@@ -410,7 +410,7 @@ func TestGetOrderStatus(t *testing.T) {
 					Side:     "sell",
 					Type:     "limit",
 					Price:    300.0,
-					Quantity: 3,
+					Quantity: qty(3),
 				}
 				test.Service.PlaceOrder(context.Background(), &sellReq)
 
@@ -420,7 +420,7 @@ func TestGetOrderStatus(t *testing.T) {
 					Side:     "buy",
 					Type:     "limit",
 					Price:    300.0,
-					Quantity: 10,
+					Quantity: qty(10),
 				}
 				resp, err := test.Service.PlaceOrder(context.Background(), &buyReq)
 				require.NoError(t, err)
@@ -462,8 +462,8 @@ func TestGetOrderStatus(t *testing.T) {
 
 			require.NoError(t, err)
 			assert.Equal(t, tc.wantStatus, resp.Status)
-			assert.Equal(t, tc.wantExecQty, resp.ExecutedQuantity)
-			assert.Equal(t, tc.wantRemQty, resp.RemainingQuantity)
+			assertQty(t, tc.wantExecQty, resp.ExecutedQuantity)
+			assertQty(t, tc.wantRemQty, resp.RemainingQuantity)
 		})
 	}
 }
@@ -485,7 +485,7 @@ func TestListTrades(t *testing.T) {
 					Side:     "sell",
 					Type:     "limit",
 					Price:    100.0,
-					Quantity: 5,
+					Quantity: qty(5),
 				}
 				test.Service.PlaceOrder(context.Background(), &sellReq)
 
@@ -494,7 +494,7 @@ func TestListTrades(t *testing.T) {
 					Side:     "buy",
 					Type:     "limit",
 					Price:    100.0,
-					Quantity: 5,
+					Quantity: qty(5),
 				}
 				test.Service.PlaceOrder(context.Background(), &buyReq)
 			},
@@ -554,14 +554,14 @@ func TestGetOrderBook(t *testing.T) {
 			setup: func() {
 				// Create buy orders (bids)
 				buyOrders := []models.PlaceOrderRequest{
-					{Symbol: "BOOK_TEST", Side: "buy", Type: "limit", Price: 95.0, Quantity: 10},
-					{Symbol: "BOOK_TEST", Side: "buy", Type: "limit", Price: 94.0, Quantity: 5},
+					{Symbol: "BOOK_TEST", Side: "buy", Type: "limit", Price: 95.0, Quantity: qty(10)},
+					{Symbol: "BOOK_TEST", Side: "buy", Type: "limit", Price: 94.0, Quantity: qty(5)},
 				}
 
 				// Create sell orders (asks)
 				sellOrders := []models.PlaceOrderRequest{
-					{Symbol: "BOOK_TEST", Side: "sell", Type: "limit", Price: 105.0, Quantity: 8},
-					{Symbol: "BOOK_TEST", Side: "sell", Type: "limit", Price: 106.0, Quantity: 3},
+					{Symbol: "BOOK_TEST", Side: "sell", Type: "limit", Price: 105.0, Quantity: qty(8)},
+					{Symbol: "BOOK_TEST", Side: "sell", Type: "limit", Price: 106.0, Quantity: qty(3)},
 				}
 
 				for _, order := range buyOrders {
@@ -594,7 +594,7 @@ func TestGetOrderBook(t *testing.T) {
 					Side:     "buy",
 					Type:     "limit",
 					Price:    100.0,
-					Quantity: 10,
+					Quantity: qty(10),
 				}
 				test.Service.PlaceOrder(context.Background(), &buyReq)
 			},
@@ -0,0 +1,238 @@
+package unittest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Puneet-Vishnoi/order-matching-engine/models"
+	"github.com/Puneet-Vishnoi/order-matching-engine/service"
+	"github.com/Puneet-Vishnoi/order-matching-engine/tests/mockdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookReceiver records the requests it gets and answers with status.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newWebhookReceiver(t *testing.T, status int) (*webhookReceiver, string) {
+	r := &webhookReceiver{status: status}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, body)
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(srv.Close)
+	return r, srv.URL
+}
+
+func (r *webhookReceiver) setStatus(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+func (r *webhookReceiver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+// newWebhookDeps returns services recording fills in the outbox, with a
+// webhook clock the test controls.
+func newWebhookDeps(now *time.Time) *mockdb.TestDeps {
+	deps := mockdb.GetMemoryTestInstance()
+	deps.Service.Outbox = deps.OutboxRepo
+	deps.Webhooks.Now = func() time.Time { return *now }
+	return deps
+}
+
+// cross places a resting sell and a buy that fills it.
+func cross(t *testing.T, deps *mockdb.TestDeps, symbol string) {
+	seller := service.WithAccount(context.Background(), 1)
+	buyer := service.WithAccount(context.Background(), 2)
+	_, err := deps.Service.PlaceOrder(seller, &models.PlaceOrderRequest{Symbol: symbol, Side: "sell", Type: "limit", Price: 10, Quantity: qty(3)})
+	require.NoError(t, err)
+	_, err = deps.Service.PlaceOrder(buyer, &models.PlaceOrderRequest{Symbol: symbol, Side: "buy", Type: "limit", Price: 10, Quantity: qty(3)})
+	require.NoError(t, err)
+}
+
+func TestFillsAreDeliveredToSignedWebhooks(t *testing.T) {
+	now := time.Now()
+	deps := newWebhookDeps(&now)
+	receiver, url := newWebhookReceiver(t, http.StatusNoContent)
+	ctx := context.Background()
+
+	hook, err := deps.Webhooks.Register(ctx, url)
+	require.NoError(t, err)
+	require.NotEmpty(t, hook.Secret)
+	// A deactivated webhook gets nothing
+	_, other := newWebhookReceiver(t, http.StatusOK)
+	inactive, err := deps.Webhooks.Register(ctx, other)
+	require.NoError(t, err)
+	_, err = deps.Webhooks.Deactivate(ctx, inactive.ID)
+	require.NoError(t, err)
+
+	// Resting orders are not fills
+	_, err = deps.Service.PlaceOrder(ctx, &models.PlaceOrderRequest{Symbol: "HOOK", Side: "buy", Type: "limit", Price: 1, Quantity: qty(1)})
+	require.NoError(t, err)
+	cross(t, deps, "HOOK")
+
+	require.NoError(t, deps.Webhooks.DispatchOnce(ctx))
+	require.Equal(t, 1, receiver.count())
+
+	req, body := receiver.requests[0], receiver.bodies[0]
+	ts := req.Header.Get(service.HeaderWebhookTimestamp)
+	assert.Equal(t, strconv.FormatInt(now.Unix(), 10), ts)
+	assert.Equal(t, service.SignWebhook(hook.Secret, ts, body), req.Header.Get(service.HeaderWebhookSignature))
+	assert.NotEqual(t, service.SignWebhook("wrong", ts, body), req.Header.Get(service.HeaderWebhookSignature))
+	assert.Equal(t, models.EventTradeExecuted, req.Header.Get(service.HeaderWebhookEvent))
+	assert.Equal(t, strconv.FormatInt(hook.ID, 10), req.Header.Get(service.HeaderWebhookID))
+
+	var event models.OutboxEvent
+	require.NoError(t, json.Unmarshal(body, &event))
+	assert.Equal(t, req.Header.Get(service.HeaderWebhookEventID), strconv.FormatInt(event.ID, 10))
+	var fill models.TradeExecutedEvent
+	require.NoError(t, json.Unmarshal(event.Data, &fill))
+	assert.Equal(t, "HOOK", fill.Symbol)
+	assertQty(t, 3, fill.Trade.Quantity)
+	assert.Equal(t, int64(2), fill.BuyAccountID)
+	assert.Equal(t, int64(1), fill.SellAccountID)
+
+	// Delivered once: the event is dispatched and the delivery settled
+	require.NoError(t, deps.Webhooks.DispatchOnce(ctx))
+	assert.Equal(t, 1, receiver.count())
+	resp, err := deps.Webhooks.ListDeliveries(ctx, models.DeliveryFilter{}, 10)
+	require.NoError(t, err)
+	require.Len(t, resp.Deliveries, 1)
+	assert.Equal(t, models.DeliveryDelivered, resp.Deliveries[0].Status)
+	assert.Equal(t, 1, resp.Deliveries[0].Attempts)
+}
+
+func TestFailedDeliveriesBackOffUntilDead(t *testing.T) {
+	now := time.Now()
+	deps := newWebhookDeps(&now)
+	deps.Webhooks.Config.MaxAttempts = 3
+	deps.Webhooks.Config.InitialBackoff = time.Second
+	deps.Webhooks.Config.MaxBackoff = time.Minute
+	receiver, url := newWebhookReceiver(t, http.StatusInternalServerError)
+	ctx := context.Background()
+
+	hook, err := deps.Webhooks.Register(ctx, url)
+	require.NoError(t, err)
+	cross(t, deps, "RETRY")
+
+	delivery := func() models.WebhookDelivery {
+		resp, err := deps.Webhooks.ListDeliveries(ctx, models.DeliveryFilter{WebhookID: hook.ID}, 10)
+		require.NoError(t, err)
+		require.Len(t, resp.Deliveries, 1)
+		return resp.Deliveries[0]
+	}
+
+	require.NoError(t, deps.Webhooks.DispatchOnce(ctx))
+	d := delivery()
+	assert.Equal(t, models.DeliveryPending, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+	assert.Contains(t, d.LastError, "500")
+	assert.WithinDuration(t, now.Add(time.Second), d.NextAttemptAt, 0)
+
+	// Not due yet
+	require.NoError(t, deps.Webhooks.DispatchOnce(ctx))
+	assert.Equal(t, 1, receiver.count())
+
+	// The wait doubles
+	now = now.Add(time.Second)
+	require.NoError(t, deps.Webhooks.DispatchOnce(ctx))
+	assert.WithinDuration(t, now.Add(2*time.Second), delivery().NextAttemptAt, 0)
+
+	now = now.Add(2 * time.Second)
+	require.NoError(t, deps.Webhooks.DispatchOnce(ctx))
+	assert.Equal(t, 3, receiver.count())
+	assert.Equal(t, models.DeliveryDead, delivery().Status)
+
+	// Dead deliveries are left alone until replayed
+	now = now.Add(time.Hour)
+	require.NoError(t, deps.Webhooks.DispatchOnce(ctx))
+	assert.Equal(t, 3, receiver.count())
+
+	w := doRequest(newTestRouter(deps), http.MethodGet, "/metrics", nil, nil)
+	assert.Contains(t, w.Body.String(), `order_engine_webhook_delivery_attempts_total{result="failed"} 2`)
+	assert.Contains(t, w.Body.String(), `order_engine_webhook_delivery_attempts_total{result="dead"} 1`)
+}
+
+func TestReplayDeadDeliveriesOverAdminAPI(t *testing.T) {
+	now := time.Now()
+	deps := newWebhookDeps(&now)
+	deps.Webhooks.Config.MaxAttempts = 1
+	router := newAuthRouter(deps)
+	admin := newTestKey(t, deps, "ops", models.RoleAdmin, models.ScopeAdmin)
+	operator := newTestKey(t, deps, "night-shift", models.RoleOperator, models.ScopeAdmin)
+	receiver, url := newWebhookReceiver(t, http.StatusServiceUnavailable)
+	ctx := context.Background()
+
+	// Registering is for admins
+	w := doSignedRequest(router, operator, http.MethodPost, "/admin/webhooks", models.CreateWebhookRequest{URL: url}, now)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doSignedRequest(router, admin, http.MethodPost, "/admin/webhooks", models.CreateWebhookRequest{URL: "ftp://example.com"}, now)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doSignedRequest(router, admin, http.MethodPost, "/admin/webhooks", models.CreateWebhookRequest{URL: url}, now)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var hook models.CreatedWebhookResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &hook))
+	assert.NotEmpty(t, hook.Secret)
+
+	cross(t, deps, "REPLAY")
+	cross(t, deps, "REPLAY")
+	require.NoError(t, deps.Webhooks.DispatchOnce(ctx))
+	assert.Equal(t, 2, receiver.count())
+
+	w = doSignedRequest(router, operator, http.MethodGet, "/admin/deliveries?status=dead", nil, now)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var dead models.DeliveryListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &dead))
+	require.Len(t, dead.Deliveries, 2)
+	w = doSignedRequest(router, operator, http.MethodGet, "/admin/deliveries?status=lost", nil, now)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// One at a time...
+	receiver.setStatus(http.StatusOK)
+	replayPath := "/admin/deliveries/" + strconv.FormatInt(dead.Deliveries[0].ID, 10) + "/replay"
+	w = doSignedRequest(router, operator, http.MethodPost, replayPath, nil, now)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, deps.Webhooks.DispatchOnce(ctx))
+	assert.Equal(t, 3, receiver.count())
+
+	// ...or every dead delivery of a webhook
+	w = doSignedRequest(router, operator, http.MethodPost, "/admin/webhooks/"+strconv.FormatInt(hook.ID, 10)+"/replay", nil, now)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"replayed":1}`, w.Body.String())
+	require.NoError(t, deps.Webhooks.DispatchOnce(ctx))
+	assert.Equal(t, 4, receiver.count())
+
+	resp, err := deps.Webhooks.ListDeliveries(ctx, models.DeliveryFilter{Status: models.DeliveryDelivered}, 10)
+	require.NoError(t, err)
+	assert.Len(t, resp.Deliveries, 2)
+
+	w = doSignedRequest(router, operator, http.MethodPost, "/admin/deliveries/999/replay", nil, now)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "delivery_not_found", errorCode(t, w))
+
+	w = doSignedRequest(router, admin, http.MethodDelete, "/admin/webhooks/"+strconv.FormatInt(hook.ID, 10), nil, now)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"active":false`)
+	assert.NotContains(t, w.Body.String(), "secret")
+}
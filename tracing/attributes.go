@@ -0,0 +1,32 @@
+package tracing
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys shared by the handler, service and repository spans.
+const (
+	AttrSymbol      = attribute.Key("order.symbol")
+	AttrSide        = attribute.Key("order.side")
+	AttrType        = attribute.Key("order.type")
+	AttrOrderID     = attribute.Key("order.id")
+	AttrOrderStatus = attribute.Key("order.status")
+	AttrTrades      = attribute.Key("order.trades_generated")
+	AttrRows        = attribute.Key("db.rows_returned")
+	AttrAccountID   = attribute.Key("account.id")
+	AttrAPIKeyID    = attribute.Key("api_key.id")
+	AttrWebhookID   = attribute.Key("webhook.id")
+	AttrDeliveryID  = attribute.Key("webhook.delivery_id")
+	AttrFIXSession  = attribute.Key("fix.session_id")
+)
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
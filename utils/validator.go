@@ -1,8 +1,11 @@
 package utils
 
 import (
-	"github.com/go-playground/validator/v10"
+	"reflect"
 	"sync"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
 )
 
 var (
@@ -13,6 +16,11 @@ var (
 func GetValidator() *validator.Validate {
 	onceValidate.Do(func() {
 		validate = validator.New()
+		// Decimal quantities are checked like numbers: gt=0, required...
+		validate.RegisterCustomTypeFunc(func(field reflect.Value) any {
+			f, _ := field.Interface().(decimal.Decimal).Float64()
+			return f
+		}, decimal.Decimal{})
 	})
 	return validate
 }